@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/config"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/database"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/kmhfl"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+)
+
+// facility-import loads a Kenya Master Health Facility List export (CSV or XLSX)
+//
+//	go run ./cmd/facility-import -file kmhfl.csv -dry-run
+func main() {
+	file := flag.String("file", "", "path to the KMHFL export (.csv or .xlsx)")
+	dryRun := flag.Bool("dry-run", false, "print the changes without writing them")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	cfg := config.Load()
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *file, err)
+	}
+
+	rows, err := kmhfl.ReadFile(*file, data)
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", *file, err)
+	}
+
+	db, err := database.NewDB(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	geoBackend, err := repository.DetectGeoBackend(ctx, db.Pool, cfg.GeoBackend)
+	if err != nil {
+		log.Fatalf("Failed to select geo backend: %v", err)
+	}
+
+	importer := kmhfl.NewImporter(repository.NewFacilityRepository(db.Pool, geoBackend))
+
+	report, err := importer.Import(ctx, rows, *dryRun)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	report.WriteText(os.Stdout)
+}
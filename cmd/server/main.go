@@ -1,18 +1,49 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/config"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/database"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/handlers"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/jobs"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/kmhfl"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/middleware"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/rulebook"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/services"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/geo"
 )
 
+// Kinds of background job run by the server
+const (
+	jobReferralReminders    = "referral_reminders"
+	jobAppointmentReminders = "appointment_reminders"
+	jobSessionCleanup       = "session_cleanup"
+	jobPruneJobs            = "prune_jobs"
+	jobPruneEvents          = "prune_events"
+)
+
+// schedulerLockKey is the advisory lock held by the instance that
+// enqueues scheduled jobs
+const schedulerLockKey = 4902017
+
+// finishedJobRetention is how long succeeded jobs stay inspectable
+const finishedJobRetention = 7 * 24 * time.Hour
+
+// publishedEventRetention is how long published events stay in the outbox
+const publishedEventRetention = 7 * 24 * time.Hour
+
+// eventPublishInterval is how often the outbox is checked for new events
+const eventPublishInterval = time.Second
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
@@ -36,21 +67,145 @@ func main() {
 	}
 	defer redis.Close()
 
+	// Select geo backend (PostGIS when available, Haversine otherwise)
+	geoBackend, err := repository.DetectGeoBackend(context.Background(), db.Pool, cfg.GeoBackend)
+	if err != nil {
+		log.Fatalf("Failed to select geo backend: %v", err)
+	}
+
+	// Load the key that signs offline rule bundles
+	var bundleSigner *rulebook.Signer
+	switch {
+	case cfg.RulebookSigningKey != "":
+		bundleSigner, err = rulebook.NewSigner(cfg.RulebookSigningKey)
+	case cfg.Environment != "development":
+		// Devices hold the public key, so a key that changes on restart would
+		// make them reject every bundle
+		log.Fatal("RULEBOOK_SIGNING_KEY must be set outside development")
+	default:
+		log.Println("Warning: RULEBOOK_SIGNING_KEY not set, signing rule bundles with a throwaway key")
+		bundleSigner, err = rulebook.GenerateSigner()
+	}
+	if err != nil {
+		log.Fatalf("Failed to load rule bundle signing key: %v", err)
+	}
+
 	// Initialize repositories
 	patientRepo := repository.NewPatientRepository(db.Pool)
-	facilityRepo := repository.NewFacilityRepository(db.Pool)
+	facilityRepo := repository.NewFacilityRepository(db.Pool, geoBackend)
 	userRepo := repository.NewUserRepository(db.Pool)
 	triageRepo := repository.NewTriageRepository(db.Pool)
+	apiKeyRepo := repository.NewAPIKeyRepository(db.Pool)
+	clinicianRepo := repository.NewClinicianRepository(db.Pool)
+	closureRepo := repository.NewFacilityClosureRepository(db.Pool)
+	facilityStatusRepo := repository.NewFacilityStatusRepository(db.Pool)
+	referralRepo := repository.NewReferralRepository(db.Pool)
+	patientMergeRepo := repository.NewPatientMergeRepository(db.Pool)
+	phoneIssueRepo := repository.NewPhoneIssueRepository(db.Pool)
+	householdRepo := repository.NewHouseholdRepository(db.Pool)
+	syncRepo := repository.NewSyncRepository(db.Pool)
+	notificationRepo := repository.NewNotificationRepository(db.Pool)
+	reminderRepo := repository.NewReferralReminderRepository(db.Pool)
+	appointmentRepo := repository.NewAppointmentRepository(db.Pool)
+	transportRepo := repository.NewTransportRepository(db.Pool)
+	outboxRepo := repository.NewOutboxRepository(db.Pool)
 
 	// Initialize services
 	authService := services.NewAuthService(redis, userRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	facilityRecommender := services.NewFacilityRecommender(facilityRepo, closureRepo, facilityStatusRepo)
+	patientService := services.NewPatientService(patientRepo)
+	var smsSender services.SMSSender
+	switch {
+	case cfg.AfricasTalkingAPIKey != "":
+		smsSender = services.NewAfricasTalkingSender(cfg.AfricasTalkingUsername, cfg.AfricasTalkingAPIKey)
+	case cfg.Environment != "development":
+		log.Fatal("AFRICASTALKING_API_KEY must be set outside development")
+	default:
+		log.Println("AFRICASTALKING_API_KEY not set; SMS will be logged, not sent")
+		smsSender = services.LogSMSSender{}
+	}
+	notificationService := services.NewNotificationService(notificationRepo, userRepo, patientRepo, smsSender)
+	var transportProvider services.TransportProvider
+	var simulator *services.SimulatedTransportProvider
+	switch cfg.TransportProvider {
+	case "simulator":
+		simulator = &services.SimulatedTransportProvider{
+			AssignAfter: cfg.TransportSimulatorAssignAfter,
+			ArriveAfter: cfg.TransportSimulatorArriveAfter,
+		}
+		transportProvider = simulator
+	case "none", "":
+		log.Println("Transport dispatch disabled")
+	default:
+		log.Fatalf("Unknown TRANSPORT_PROVIDER %q", cfg.TransportProvider)
+	}
+	transportService := services.NewTransportService(transportRepo, transportProvider)
+	if simulator != nil {
+		simulator.Report = transportService.HandleUpdate
+	}
+	referralService := services.NewReferralService(referralRepo, facilityRepo, facilityStatusRepo, notificationService)
+	reminderService := services.NewReferralReminderService(reminderRepo, notificationService, smsSender)
+	appointmentService := services.NewAppointmentService(appointmentRepo, smsSender, cfg.AppointmentNoShowAfter)
+	syncService := services.NewSyncService(syncRepo, facilityRepo, facilityStatusRepo)
+
+	// Initialize the job queue; only the Postgres queue can be inspected
+	postgresQueue := jobs.NewPostgresQueue(db.Pool)
+	var jobQueue jobs.Queue = postgresQueue
+	var jobInspector jobs.Inspector = postgresQueue
+	switch cfg.JobBackend {
+	case "postgres":
+	case "amqp":
+		amqpQueue, err := jobs.DialAMQP(cfg.RabbitMQURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+		}
+		defer amqpQueue.Close()
+		jobQueue, jobInspector = amqpQueue, nil
+	default:
+		log.Fatalf("Unknown JOB_BACKEND %q", cfg.JobBackend)
+	}
+
+	// Deliver domain events from the outbox through the job queue
+	eventDispatcher := services.NewEventDispatcher(outboxRepo, jobQueue)
+	if transportProvider != nil {
+		eventDispatcher.Subscribe("transport", transportService.HandleReferralCreated, models.EventReferralCreated)
+	}
+	if cfg.OutboxWebhookURL != "" {
+		if cfg.OutboxWebhookSecret == "" {
+			log.Fatal("OUTBOX_WEBHOOK_SECRET must be set with OUTBOX_WEBHOOK_URL")
+		}
+		eventDispatcher.Subscribe("webhook", services.NewWebhookSink(cfg.OutboxWebhookURL, cfg.OutboxWebhookSecret).Handle)
+	}
 
 	// Initialize handlers
 	healthHandler := handlers.HealthCheck
 	authHandler := handlers.NewAuthHandler(authService)
-	patientHandler := handlers.NewPatientHandler(patientRepo)
-	facilityHandler := handlers.NewFacilityHandler(facilityRepo)
+	patientHandler := handlers.NewPatientHandler(patientRepo, patientService)
+	patientMergeHandler := handlers.NewPatientMergeHandler(patientMergeRepo)
+	phoneIssueHandler := handlers.NewPhoneIssueHandler(phoneIssueRepo)
+	householdHandler := handlers.NewHouseholdHandler(householdRepo)
+	travelSpeeds := geo.TravelSpeeds{
+		WalkKMH:   cfg.TravelSpeedWalkKMH,
+		BodaKMH:   cfg.TravelSpeedBodaKMH,
+		MatatuKMH: cfg.TravelSpeedMatatuKMH,
+	}
+	facilityHandler := handlers.NewFacilityHandler(facilityRepo, closureRepo, facilityStatusRepo, travelSpeeds)
 	triageHandler := handlers.NewTriageHandler(triageRepo)
+	recommendationHandler := handlers.NewRecommendationHandler(triageRepo, facilityRecommender, travelSpeeds)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	clinicianHandler := handlers.NewClinicianHandler(clinicianRepo)
+	facilityImportHandler := handlers.NewFacilityImportHandler(kmhfl.NewImporter(facilityRepo))
+	closureHandler := handlers.NewFacilityClosureHandler(closureRepo)
+	facilityStatusHandler := handlers.NewFacilityStatusHandler(facilityRepo, facilityStatusRepo)
+	referralHandler := handlers.NewReferralHandler(referralService, referralRepo, clinicianRepo)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo)
+	reminderHandler := handlers.NewReferralReminderHandler(reminderRepo, referralRepo)
+	appointmentHandler := handlers.NewAppointmentHandler(appointmentRepo, appointmentService, cfg.SMSWebhookToken)
+	transportHandler := handlers.NewTransportHandler(transportRepo, referralRepo, transportService, cfg.TransportWebhookToken)
+	syncHandler := handlers.NewSyncHandler(syncService)
+	rulebookHandler := handlers.NewRulebookHandler(facilityRepo, bundleSigner)
+	jobHandler := handlers.NewJobHandler(jobInspector)
 
 	// Set Gin mode
 	if cfg.Environment == "production" {
@@ -79,45 +234,240 @@ func main() {
 			})
 		})
 
+		// SMS gateway and transport provider callbacks authenticate with their webhook tokens
+		v1.POST("/sms/inbound", appointmentHandler.InboundSMS)
+		v1.POST("/transport/webhook", transportHandler.TransportWebhook)
+
 		// Auth routes (public)
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/logout", authHandler.Logout)
-			auth.GET("/me", middleware.AuthMiddleware(authService), authHandler.Me)
+			auth.GET("/me", middleware.AuthMiddleware(authService, nil), authHandler.Me)
 		}
 
-		// Protected routes (require authentication)
+		// Protected routes (require a user session or an API key)
 		protected := v1.Group("")
-		protected.Use(middleware.AuthMiddleware(authService))
+		protected.Use(middleware.AuthMiddleware(authService, apiKeyService))
 		{
 			// Patient routes
 			patients := protected.Group("/patients")
 			{
-				patients.POST("", patientHandler.CreatePatient)
-				patients.GET("/:id", patientHandler.GetPatient)
-				patients.PUT("/:id", patientHandler.UpdatePatient)
+				// Merging duplicates rewrites patient history, so it is for
+				// clinicians and admins. CHVs search only the patients they look
+				// after; API keys search with patients:read.
+				clinicalStaff := middleware.RoleMiddleware(string(models.UserRoleAdmin), string(models.UserRoleClinician))
+				patientSearchers := middleware.SessionRoleMiddleware(string(models.UserRoleAdmin), string(models.UserRoleClinician), string(models.UserRoleCHV))
+
+				patients.POST("", middleware.SessionOnlyMiddleware(), patientHandler.CreatePatient)
+				patients.GET("", middleware.ScopeMiddleware(models.APIKeyScopePatientsRead), patientSearchers, patientHandler.SearchPatients)
+				patients.GET("/:id", middleware.ScopeMiddleware(models.APIKeyScopePatientsRead), patientHandler.GetPatient)
+				patients.GET("/:id/summary", middleware.ScopeMiddleware(models.APIKeyScopePatientsRead), patientHandler.GetPatientSummary)
+				patients.PUT("/:id", middleware.SessionOnlyMiddleware(), patientHandler.UpdatePatient)
+				patients.PATCH("/:id", middleware.SessionOnlyMiddleware(), patientHandler.PatchPatient)
+				patients.POST("/:id/merge", middleware.SessionOnlyMiddleware(), clinicalStaff, patientMergeHandler.MergePatient)
+				patients.GET("/:id/merges", middleware.SessionOnlyMiddleware(), clinicalStaff, patientMergeHandler.ListMerges)
+			}
+
+			patientMerges := protected.Group("/patient-merges")
+			patientMerges.Use(middleware.SessionOnlyMiddleware(), middleware.RoleMiddleware(string(models.UserRoleAdmin), string(models.UserRoleClinician)))
+			{
+				patientMerges.POST("/:id/undo", patientMergeHandler.UndoMerge)
+			}
+
+			// Household registry; CHVs only reach households on their caseload
+			households := protected.Group("/households")
+			households.Use(middleware.SessionOnlyMiddleware(), middleware.RoleMiddleware(string(models.UserRoleAdmin), string(models.UserRoleClinician), string(models.UserRoleCHV)))
+			{
+				households.POST("", householdHandler.CreateHousehold)
+				households.GET("/:id", householdHandler.GetHousehold)
+				households.POST("/:id/members", householdHandler.AddMember)
+				households.DELETE("/:id/members/:patient_id", householdHandler.RemoveMember)
+				households.PUT("/:id/chv", middleware.RoleMiddleware(string(models.UserRoleAdmin)), householdHandler.AssignCHV)
+			}
+
+			chv := protected.Group("/chv")
+			chv.Use(middleware.SessionOnlyMiddleware(), middleware.RoleMiddleware(string(models.UserRoleCHV)))
+			{
+				chv.GET("/me/caseload", householdHandler.GetMyCaseload)
 			}
 
+			// Offline sync for the CHV app
+			protected.POST("/sync", middleware.SessionOnlyMiddleware(), middleware.RoleMiddleware(string(models.UserRoleCHV)), syncHandler.Sync)
+
 			// Facility routes
 			facilities := protected.Group("/facilities")
+			facilities.Use(middleware.ScopeMiddleware(models.APIKeyScopeFacilitiesRead))
 			{
 				facilities.GET("", facilityHandler.ListFacilities)
 				facilities.GET("/nearby", facilityHandler.GetNearbyFacilities)
 				facilities.GET("/:id", facilityHandler.GetFacility)
+				facilities.GET("/:id/clinicians", clinicianHandler.ListFacilityClinicians)
+				facilities.GET("/:id/closures", closureHandler.ListClosures)
+				facilities.GET("/:id/status", facilityStatusHandler.GetStatus)
+
+				// Capacity reporting is limited to staff of the facility
+				facilityMember := middleware.FacilityMemberMiddleware(clinicianRepo, "id")
+				facilities.PUT("/:id/status", middleware.ScopeMiddleware(models.APIKeyScopeFacilitiesWrite), facilityMember, facilityStatusHandler.ReportStatus)
+				facilities.GET("/:id/status/history", facilityMember, facilityStatusHandler.GetStatusHistory)
+
+				adminOnly := middleware.RoleMiddleware(string(models.UserRoleAdmin))
+				facilities.POST("", adminOnly, facilityHandler.CreateFacility)
+				facilities.POST("/bulk", adminOnly, facilityHandler.BulkCreateFacilities)
+				facilities.POST("/import", adminOnly, facilityImportHandler.ImportKMHFL)
+				facilities.PUT("/:id", adminOnly, facilityHandler.UpdateFacility)
+				facilities.DELETE("/:id", adminOnly, facilityHandler.DeactivateFacility)
+			}
+
+			// Clinician routes (writes are admin-only)
+			clinicians := protected.Group("/clinicians")
+			clinicians.Use(middleware.SessionOnlyMiddleware())
+			{
+				adminOnly := middleware.RoleMiddleware(string(models.UserRoleAdmin))
+
+				clinicians.GET("", clinicianHandler.ListClinicians)
+				clinicians.GET("/:id", clinicianHandler.GetClinician)
+				clinicians.POST("", adminOnly, clinicianHandler.CreateClinician)
+				clinicians.PUT("/:id", adminOnly, clinicianHandler.UpdateClinician)
+				clinicians.DELETE("/:id", adminOnly, clinicianHandler.DeactivateClinician)
+				clinicians.POST("/:id/reactivate", adminOnly, clinicianHandler.ReactivateClinician)
+				clinicians.POST("/:id/user", adminOnly, clinicianHandler.LinkUser)
+			}
+
+			// Referral routes
+			referrals := protected.Group("/referrals")
+			{
+				// Patient sessions neither make nor read referrals. CHVs only read
+				// the referrals they made; the handlers enforce that.
+				referralStaff := middleware.SessionRoleMiddleware(string(models.UserRoleAdmin), string(models.UserRoleClinician), string(models.UserRoleCHV))
+				referrals.POST("", middleware.ScopeMiddleware(models.APIKeyScopeReferralsWrite), referralStaff, referralHandler.CreateReferral)
+				referrals.GET("", middleware.ScopeMiddleware(models.APIKeyScopeReferralsRead), referralStaff, referralHandler.ListReferrals)
+				referrals.GET("/:id", middleware.ScopeMiddleware(models.APIKeyScopeReferralsRead), referralStaff, referralHandler.GetReferral)
+				referrals.GET("/:id/outcome", middleware.ScopeMiddleware(models.APIKeyScopeReferralsRead), referralStaff, referralHandler.GetOutcome)
+				referrals.GET("/:id/reminders", middleware.ScopeMiddleware(models.APIKeyScopeReferralsRead), referralStaff, reminderHandler.ListReferralReminders)
+				referrals.GET("/:id/transfers", middleware.ScopeMiddleware(models.APIKeyScopeReferralsRead), referralStaff, referralHandler.GetTransferChain)
+				referrals.GET("/:id/dispatch", middleware.ScopeMiddleware(models.APIKeyScopeReferralsRead), referralStaff, transportHandler.GetDispatch)
+
+				// Progress and outcomes are reported by the receiving facility
+				referrals.PUT("/:id/status", middleware.ScopeMiddleware(models.APIKeyScopeReferralsWrite), referralHandler.UpdateStatus)
+				referrals.PUT("/:id/outcome", middleware.ScopeMiddleware(models.APIKeyScopeOutcomesWrite), middleware.SessionRoleMiddleware(string(models.UserRoleAdmin), string(models.UserRoleClinician)), referralHandler.RecordOutcome)
+				referrals.POST("/:id/transfer", middleware.SessionOnlyMiddleware(), middleware.RoleMiddleware(string(models.UserRoleAdmin), string(models.UserRoleClinician)), referralHandler.TransferReferral)
+				referrals.POST("/:id/dispatch", middleware.SessionOnlyMiddleware(), middleware.RoleMiddleware(string(models.UserRoleAdmin), string(models.UserRoleClinician)), transportHandler.Redispatch)
+			}
+
+			// Appointment routes
+			appointments := protected.Group("/appointments")
+			appointments.Use(middleware.SessionOnlyMiddleware(), middleware.RoleMiddleware(string(models.UserRoleAdmin), string(models.UserRoleClinician), string(models.UserRoleCHV)))
+			{
+				appointments.POST("", appointmentHandler.CreateAppointment)
+				appointments.GET("/:id", appointmentHandler.GetAppointment)
+				appointments.POST("/:id/cancel", appointmentHandler.CancelAppointment)
+			}
+
+			// In-app inbox
+			notifications := protected.Group("/notifications")
+			notifications.Use(middleware.SessionOnlyMiddleware())
+			{
+				notifications.GET("", notificationHandler.ListNotifications)
+				notifications.POST("/:id/read", notificationHandler.MarkRead)
 			}
 
 			// Triage routes
-		    triage := protected.Group("/triage")
+			triage := protected.Group("/triage")
+			triage.Use(middleware.SessionOnlyMiddleware())
 			{
 				triage.POST("", triageHandler.CreateTriage)
+				triage.GET("/rulebook", rulebookHandler.GetRulebook)
+				triage.GET("/rulebook/key", rulebookHandler.GetSigningKey)
 				triage.GET("/:id", triageHandler.GetTriage)
+				triage.GET("/:id/recommended-facilities", recommendationHandler.RecommendFacilities)
 				triage.GET("/patient/:patient_id", triageHandler.GetPatientTriages)
 			}
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(middleware.SessionOnlyMiddleware(), middleware.RoleMiddleware(string(models.UserRoleAdmin)))
+			{
+				admin.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+				admin.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+				admin.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+
+				admin.POST("/closures", closureHandler.CreateClosure)
+				admin.GET("/closures", closureHandler.ListClosures)
+				admin.DELETE("/closures/:id", closureHandler.DeleteClosure)
+
+				admin.GET("/phone-issues", phoneIssueHandler.ListIssues)
+				admin.POST("/phone-issues/:id/resolve", phoneIssueHandler.ResolveIssue)
+
+				admin.GET("/reminder-schedules", reminderHandler.ListSchedules)
+				admin.PUT("/reminder-schedules", reminderHandler.PutSchedule)
+
+				admin.GET("/analytics/transfers", referralHandler.TransferStats)
+
+				admin.GET("/jobs", jobHandler.ListJobs)
+				admin.GET("/jobs/stats", jobHandler.JobStats)
+				admin.GET("/jobs/:id", jobHandler.GetJob)
+				admin.POST("/jobs/:id/retry", jobHandler.RetryJob)
+			}
 		}
 	}
 
+	// Run background jobs on this instance
+	hostname, _ := os.Hostname()
+	worker := jobs.NewWorker(jobQueue, fmt.Sprintf("%s-%d", hostname, os.Getpid()))
+	worker.Handle(jobReferralReminders, func(ctx context.Context, job *jobs.Job) error {
+		return reminderService.Tick(ctx)
+	})
+	worker.Handle(jobAppointmentReminders, func(ctx context.Context, job *jobs.Job) error {
+		return appointmentService.Tick(ctx)
+	})
+	worker.Handle(jobSessionCleanup, func(ctx context.Context, job *jobs.Job) error {
+		return userRepo.DeleteExpiredSessions(ctx)
+	})
+	worker.Handle(jobPruneJobs, func(ctx context.Context, job *jobs.Job) error {
+		pruned, err := postgresQueue.Prune(ctx, time.Now().Add(-finishedJobRetention))
+		if pruned > 0 {
+			log.Printf("Pruned %d finished jobs", pruned)
+		}
+		return err
+	})
+	worker.Handle(jobPruneEvents, func(ctx context.Context, job *jobs.Job) error {
+		pruned, err := outboxRepo.Prune(ctx, time.Now().Add(-publishedEventRetention))
+		if pruned > 0 {
+			log.Printf("Pruned %d published events", pruned)
+		}
+		return err
+	})
+	eventDispatcher.Register(worker)
+	go worker.Run(context.Background(), cfg.JobWorkers, cfg.JobPollInterval)
+	go eventDispatcher.Run(context.Background(), eventPublishInterval)
+
+	// One elected instance enqueues the schedules. A tick that fails is not
+	// retried; the next one catches up.
+	scheduler := jobs.NewScheduler(jobQueue, jobs.NewPostgresElector(db.Pool, schedulerLockKey), models.FacilityTimezone)
+	schedule := func(name, spec, kind string) {
+		if err := scheduler.Add(name, spec, jobs.NewJob{Kind: kind, MaxAttempts: 1}); err != nil {
+			log.Fatalf("Failed to schedule %s: %v", name, err)
+		}
+	}
+	schedule("session-cleanup", "@hourly", jobSessionCleanup)
+	if cfg.ReferralReminderInterval > 0 {
+		schedule("referral-reminders", "@every "+cfg.ReferralReminderInterval.String(), jobReferralReminders)
+	} else {
+		log.Println("Referral reminders disabled")
+	}
+	if cfg.AppointmentReminderInterval > 0 {
+		schedule("appointment-reminders", "@every "+cfg.AppointmentReminderInterval.String(), jobAppointmentReminders)
+	} else {
+		log.Println("Appointment reminders disabled")
+	}
+	if jobInspector != nil {
+		schedule("prune-jobs", "0 3 * * *", jobPruneJobs)
+	}
+	schedule("prune-events", "30 3 * * *", jobPruneEvents)
+	go scheduler.Run(context.Background(), 15*time.Second)
+
 	// Start server
 	port := cfg.Port
 	if port == "" {
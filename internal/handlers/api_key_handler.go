@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/services"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// CreateAPIKey handles POST /v1/admin/api-keys
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		createdBy = &id
+	}
+
+	result, err := h.apiKeyService.Create(c.Request.Context(), &req, createdBy)
+	switch {
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "CREATE_FAILED", "Failed to create API key")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, result)
+}
+
+// ListAPIKeys handles GET /v1/admin/api-keys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	var facilityID *uuid.UUID
+	if facilityIDStr := c.Query("facility_id"); facilityIDStr != "" {
+		id, err := uuid.Parse(facilityIDStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+			return
+		}
+		facilityID = &id
+	}
+
+	keys, err := h.apiKeyService.List(c.Request.Context(), facilityID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to list API keys")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"api_keys": keys,
+		"count":    len(keys),
+	})
+}
+
+// RevokeAPIKey handles DELETE /v1/admin/api-keys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid API key ID")
+		return
+	}
+
+	err = h.apiKeyService.Revoke(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "API key not found")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "REVOKE_FAILED", "Failed to revoke API key")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "API key revoked"})
+}
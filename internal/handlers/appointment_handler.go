@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/services"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type AppointmentHandler struct {
+	appointmentRepo    *repository.AppointmentRepository
+	appointmentService *services.AppointmentService
+	webhookToken       string
+}
+
+func NewAppointmentHandler(appointmentRepo *repository.AppointmentRepository, appointmentService *services.AppointmentService, webhookToken string) *AppointmentHandler {
+	return &AppointmentHandler{appointmentRepo: appointmentRepo, appointmentService: appointmentService, webhookToken: webhookToken}
+}
+
+// CreateAppointment handles POST /v1/appointments
+func (h *AppointmentHandler) CreateAppointment(c *gin.Context) {
+	var req models.CreateAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(time.Now()); err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	appointment, err := h.appointmentRepo.Create(c.Request.Context(), &req)
+	switch {
+	case errors.Is(err, repository.ErrDuplicate):
+		response.Error(c, http.StatusConflict, "SLOT_TAKEN", "That slot is already booked")
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Patient, facility, clinician or referral not found")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "APPOINTMENT_CREATE_FAILED", "Failed to create appointment")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, appointment)
+}
+
+// GetAppointment handles GET /v1/appointments/:id
+func (h *AppointmentHandler) GetAppointment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid appointment ID")
+		return
+	}
+
+	appointment, err := h.appointmentRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Appointment not found")
+		return
+	}
+
+	response.Success(c, http.StatusOK, appointment)
+}
+
+// CancelAppointment handles POST /v1/appointments/:id/cancel. The slot is
+// free to book again straight away.
+func (h *AppointmentHandler) CancelAppointment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid appointment ID")
+		return
+	}
+
+	appointment, err := h.appointmentRepo.UpdateStatus(c.Request.Context(), id, models.AppointmentStatusCancelled)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Appointment not found")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusConflict, "INVALID_TRANSITION", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to cancel appointment")
+		return
+	}
+
+	response.Success(c, http.StatusOK, appointment)
+}
+
+// InboundSMS handles POST /v1/sms/inbound, the SMS gateway's callback for
+// texts sent to the short code. It answers 200 to anything it has read, so
+// the gateway does not retry replies that matched no appointment.
+func (h *AppointmentHandler) InboundSMS(c *gin.Context) {
+	if h.webhookToken == "" {
+		response.Error(c, http.StatusNotFound, "NOT_CONFIGURED", "SMS webhook is not configured")
+		return
+	}
+	if !smsWebhookAuthorized(c, h.webhookToken) {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid webhook token")
+		return
+	}
+
+	var msg models.InboundSMS
+	if err := c.ShouldBind(&msg); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid callback: "+err.Error())
+		return
+	}
+
+	action, err := h.appointmentService.HandleReply(c.Request.Context(), &msg)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "SMS_HANDLING_FAILED", "Failed to handle SMS")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"action": action})
+}
@@ -18,12 +18,12 @@ func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 }
 
 type RegisterRequest struct {
-	Phone string `json:"phone" binding:"required"`
+	Phone string `json:"phone" binding:"required,kephone"`
 	Role  string `json:"role" binding:"required,oneof=patient chv clinician admin"`
 }
 
 type LoginRequest struct {
-	Phone string `json:"phone" binding:"required"`
+	Phone string `json:"phone" binding:"required,kephone"`
 }
 
 type AuthResponse struct {
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type ClinicianHandler struct {
+	clinicianRepo repository.ClinicianRepositoryInterface
+}
+
+func NewClinicianHandler(clinicianRepo repository.ClinicianRepositoryInterface) *ClinicianHandler {
+	return &ClinicianHandler{clinicianRepo: clinicianRepo}
+}
+
+// CreateClinician handles POST /v1/clinicians
+func (h *ClinicianHandler) CreateClinician(c *gin.Context) {
+	var req models.CreateClinicianRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	clinician, err := h.clinicianRepo.Create(c.Request.Context(), &req)
+	switch {
+	case errors.Is(err, repository.ErrDuplicate):
+		response.Error(c, http.StatusConflict, "CLINICIAN_EXISTS", "A clinician with this phone number already exists")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "CREATE_FAILED", "Failed to create clinician")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, clinician)
+}
+
+// GetClinician handles GET /v1/clinicians/:id
+func (h *ClinicianHandler) GetClinician(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid clinician ID")
+		return
+	}
+
+	clinician, err := h.clinicianRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Clinician not found")
+		return
+	}
+
+	response.Success(c, http.StatusOK, clinician)
+}
+
+// ListClinicians handles GET /v1/clinicians
+func (h *ClinicianHandler) ListClinicians(c *gin.Context) {
+	var facilityID *uuid.UUID
+	if facilityIDStr := c.Query("facility_id"); facilityIDStr != "" {
+		id, err := uuid.Parse(facilityIDStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+			return
+		}
+		facilityID = &id
+	}
+
+	h.list(c, facilityID)
+}
+
+// ListFacilityClinicians handles GET /v1/facilities/:id/clinicians
+func (h *ClinicianHandler) ListFacilityClinicians(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+		return
+	}
+
+	h.list(c, &facilityID)
+}
+
+func (h *ClinicianHandler) list(c *gin.Context, facilityID *uuid.UUID) {
+	// Inactive clinicians are hidden unless explicitly requested
+	activeOnly := c.Query("include_inactive") != "true"
+
+	clinicians, err := h.clinicianRepo.List(c.Request.Context(), facilityID, activeOnly)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to list clinicians")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"clinicians": clinicians,
+		"count":      len(clinicians),
+	})
+}
+
+// UpdateClinician handles PUT /v1/clinicians/:id
+func (h *ClinicianHandler) UpdateClinician(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid clinician ID")
+		return
+	}
+
+	var req models.UpdateClinicianRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	clinician, err := h.clinicianRepo.Update(c.Request.Context(), id, &req)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Clinician not found")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to update clinician")
+		return
+	}
+
+	response.Success(c, http.StatusOK, clinician)
+}
+
+// DeactivateClinician handles DELETE /v1/clinicians/:id
+func (h *ClinicianHandler) DeactivateClinician(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid clinician ID")
+		return
+	}
+
+	revoked, err := h.clinicianRepo.Deactivate(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Clinician not found")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"message":          "Clinician deactivated",
+		"sessions_revoked": revoked,
+	})
+}
+
+// ReactivateClinician handles POST /v1/clinicians/:id/reactivate
+func (h *ClinicianHandler) ReactivateClinician(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid clinician ID")
+		return
+	}
+
+	err = h.clinicianRepo.Reactivate(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Clinician not found")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "REACTIVATE_FAILED", "Failed to reactivate clinician")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Clinician reactivated"})
+}
+
+// LinkUser handles POST /v1/clinicians/:id/user
+func (h *ClinicianHandler) LinkUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid clinician ID")
+		return
+	}
+
+	var req models.LinkClinicianUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	err = h.clinicianRepo.LinkUser(c.Request.Context(), id, req.UserID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Clinician, or a user with the clinician role, not found")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "LINK_FAILED", "Failed to link user to clinician")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "User linked to clinician"})
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+)
+
+// Mock ClinicianRepository
+type MockClinicianRepository struct {
+	mock.Mock
+}
+
+func (m *MockClinicianRepository) Create(ctx context.Context, req *models.CreateClinicianRequest) (*models.Clinician, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Clinician), args.Error(1)
+}
+
+func (m *MockClinicianRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Clinician, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Clinician), args.Error(1)
+}
+
+func (m *MockClinicianRepository) List(ctx context.Context, facilityID *uuid.UUID, activeOnly bool) ([]*models.Clinician, error) {
+	args := m.Called(ctx, facilityID, activeOnly)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Clinician), args.Error(1)
+}
+
+func (m *MockClinicianRepository) Update(ctx context.Context, id uuid.UUID, req *models.UpdateClinicianRequest) (*models.Clinician, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Clinician), args.Error(1)
+}
+
+func (m *MockClinicianRepository) Deactivate(ctx context.Context, id uuid.UUID) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockClinicianRepository) Reactivate(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockClinicianRepository) LinkUser(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func TestCreateClinician(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success - Create clinician", func(t *testing.T) {
+		mockRepo := new(MockClinicianRepository)
+		handler := NewClinicianHandler(mockRepo)
+
+		clinician := &models.Clinician{
+			ID:        uuid.New(),
+			Name:      "Dr. Jane Wambui",
+			Phone:     "+254733345678",
+			IsActive:  true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.CreateClinicianRequest")).
+			Return(clinician, nil)
+
+		router := gin.New()
+		router.POST("/clinicians", handler.CreateClinician)
+
+		jsonBody, _ := json.Marshal(map[string]interface{}{
+			"name":  "Dr. Jane Wambui",
+			"phone": "+254733345678",
+		})
+
+		req, _ := http.NewRequest("POST", "/clinicians", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fail - Duplicate phone", func(t *testing.T) {
+		mockRepo := new(MockClinicianRepository)
+		handler := NewClinicianHandler(mockRepo)
+
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.CreateClinicianRequest")).
+			Return(nil, repository.ErrDuplicate)
+
+		router := gin.New()
+		router.POST("/clinicians", handler.CreateClinician)
+
+		jsonBody, _ := json.Marshal(map[string]interface{}{
+			"name":  "Dr. Jane Wambui",
+			"phone": "+254733345678",
+		})
+
+		req, _ := http.NewRequest("POST", "/clinicians", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Fail - Unknown facility", func(t *testing.T) {
+		mockRepo := new(MockClinicianRepository)
+		handler := NewClinicianHandler(mockRepo)
+
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.CreateClinicianRequest")).
+			Return(nil, fmt.Errorf("%w: facility does not exist", repository.ErrValidation))
+
+		router := gin.New()
+		router.POST("/clinicians", handler.CreateClinician)
+
+		jsonBody, _ := json.Marshal(map[string]interface{}{
+			"name":        "Dr. Jane Wambui",
+			"phone":       "+254733345678",
+			"facility_id": uuid.New(),
+		})
+
+		req, _ := http.NewRequest("POST", "/clinicians", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fail - Missing name", func(t *testing.T) {
+		mockRepo := new(MockClinicianRepository)
+		handler := NewClinicianHandler(mockRepo)
+
+		router := gin.New()
+		router.POST("/clinicians", handler.CreateClinician)
+
+		jsonBody, _ := json.Marshal(map[string]interface{}{"phone": "+254733345678"})
+
+		req, _ := http.NewRequest("POST", "/clinicians", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestListFacilityClinicians(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockClinicianRepository)
+	handler := NewClinicianHandler(mockRepo)
+
+	facilityID := uuid.New()
+	clinicians := []*models.Clinician{
+		{ID: uuid.New(), Name: "Dr. Jane Wambui", FacilityID: &facilityID, IsActive: true},
+	}
+
+	mockRepo.On("List", mock.Anything, &facilityID, true).Return(clinicians, nil)
+
+	router := gin.New()
+	router.GET("/facilities/:id/clinicians", handler.ListFacilityClinicians)
+
+	req, _ := http.NewRequest("GET", "/facilities/"+facilityID.String()+"/clinicians", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["count"])
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeactivateClinician(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success - Sessions revoked", func(t *testing.T) {
+		mockRepo := new(MockClinicianRepository)
+		handler := NewClinicianHandler(mockRepo)
+
+		clinicianID := uuid.New()
+		mockRepo.On("Deactivate", mock.Anything, clinicianID).Return(int64(2), nil)
+
+		router := gin.New()
+		router.DELETE("/clinicians/:id", handler.DeactivateClinician)
+
+		req, _ := http.NewRequest("DELETE", "/clinicians/"+clinicianID.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(2), data["sessions_revoked"])
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fail - Not found", func(t *testing.T) {
+		mockRepo := new(MockClinicianRepository)
+		handler := NewClinicianHandler(mockRepo)
+
+		clinicianID := uuid.New()
+		mockRepo.On("Deactivate", mock.Anything, clinicianID).Return(int64(0), assert.AnError)
+
+		router := gin.New()
+		router.DELETE("/clinicians/:id", handler.DeactivateClinician)
+
+		req, _ := http.NewRequest("DELETE", "/clinicians/"+clinicianID.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestReactivateClinician(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{"Success", nil, http.StatusOK},
+		{"Fail - Not found", repository.ErrNotFound, http.StatusNotFound},
+		{"Fail - Database error", assert.AnError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockClinicianRepository)
+			handler := NewClinicianHandler(mockRepo)
+
+			clinicianID := uuid.New()
+			mockRepo.On("Reactivate", mock.Anything, clinicianID).Return(tt.err)
+
+			router := gin.New()
+			router.POST("/clinicians/:id/reactivate", handler.ReactivateClinician)
+
+			req, _ := http.NewRequest("POST", "/clinicians/"+clinicianID.String()+"/reactivate", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateClinician(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{"Success", nil, http.StatusOK},
+		{"Fail - Not found", repository.ErrNotFound, http.StatusNotFound},
+		{"Fail - Unknown facility", fmt.Errorf("%w: facility does not exist", repository.ErrValidation), http.StatusBadRequest},
+		{"Fail - Database error", assert.AnError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockClinicianRepository)
+			handler := NewClinicianHandler(mockRepo)
+
+			clinicianID := uuid.New()
+			var clinician *models.Clinician
+			if tt.err == nil {
+				clinician = &models.Clinician{ID: clinicianID, Name: "Dr. Jane Wambui", IsActive: true}
+			}
+			mockRepo.On("Update", mock.Anything, clinicianID, mock.AnythingOfType("*models.UpdateClinicianRequest")).Return(clinician, tt.err)
+
+			router := gin.New()
+			router.PUT("/clinicians/:id", handler.UpdateClinician)
+
+			jsonBody, _ := json.Marshal(map[string]interface{}{"facility_id": uuid.New()})
+			req, _ := http.NewRequest("PUT", "/clinicians/"+clinicianID.String(), bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLinkClinicianUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{"Success", nil, http.StatusOK},
+		{"Fail - Not found", repository.ErrNotFound, http.StatusNotFound},
+		{"Fail - Database error", assert.AnError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockClinicianRepository)
+			handler := NewClinicianHandler(mockRepo)
+
+			clinicianID, userID := uuid.New(), uuid.New()
+			mockRepo.On("LinkUser", mock.Anything, clinicianID, userID).Return(tt.err)
+
+			router := gin.New()
+			router.POST("/clinicians/:id/user", handler.LinkUser)
+
+			jsonBody, _ := json.Marshal(map[string]interface{}{"user_id": userID})
+			req, _ := http.NewRequest("POST", "/clinicians/"+clinicianID.String()+"/user", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.NotContains(t, w.Body.String(), assert.AnError.Error())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+// defaultClosureListDays is how far ahead ListClosures looks when no range is given
+const defaultClosureListDays = 90
+
+type FacilityClosureHandler struct {
+	closureRepo *repository.FacilityClosureRepository
+}
+
+func NewFacilityClosureHandler(closureRepo *repository.FacilityClosureRepository) *FacilityClosureHandler {
+	return &FacilityClosureHandler{closureRepo: closureRepo}
+}
+
+// CreateClosure handles POST /v1/admin/closures
+func (h *FacilityClosureHandler) CreateClosure(c *gin.Context) {
+	var req models.CreateFacilityClosureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	startsOn, endsOn, err := req.Dates()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		createdBy = &id
+	}
+
+	closure, err := h.closureRepo.Create(c.Request.Context(), &req, startsOn, endsOn, createdBy)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "CREATE_FAILED", "Failed to create closure")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, closure)
+}
+
+// ListClosures handles GET /v1/admin/closures and GET /v1/facilities/:id/closures
+func (h *FacilityClosureHandler) ListClosures(c *gin.Context) {
+	var facilityID *uuid.UUID
+	idStr := c.Param("id")
+	if idStr == "" {
+		idStr = c.Query("facility_id")
+	}
+	if idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+			return
+		}
+		facilityID = &id
+	}
+
+	from := time.Now().In(models.FacilityTimezone)
+	to := from.AddDate(0, 0, defaultClosureListDays)
+	for param, dest := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := c.Query(param); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", param+" must be a date (YYYY-MM-DD)")
+				return
+			}
+			*dest = date
+		}
+	}
+
+	closures, err := h.closureRepo.List(c.Request.Context(), from, to, facilityID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to list closures")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"closures": closures,
+		"count":    len(closures),
+	})
+}
+
+// DeleteClosure handles DELETE /v1/admin/closures/:id
+func (h *FacilityClosureHandler) DeleteClosure(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid closure ID")
+		return
+	}
+
+	if err := h.closureRepo.Delete(c.Request.Context(), id); err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Closure not found")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Closure deleted"})
+}
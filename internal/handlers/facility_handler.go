@@ -1,21 +1,79 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/geo"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/pagination"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
 )
 
+// Page sizes used when the request does not set a limit
+const (
+	defaultNearbyLimit = 20
+	defaultListLimit   = 50
+)
+
 type FacilityHandler struct {
 	facilityRepo *repository.FacilityRepository
+	closureRepo  *repository.FacilityClosureRepository
+	statusRepo   *repository.FacilityStatusRepository
+	travelSpeeds geo.TravelSpeeds
+}
+
+func NewFacilityHandler(facilityRepo *repository.FacilityRepository, closureRepo *repository.FacilityClosureRepository, statusRepo *repository.FacilityStatusRepository, travelSpeeds geo.TravelSpeeds) *FacilityHandler {
+	return &FacilityHandler{facilityRepo: facilityRepo, closureRepo: closureRepo, statusRepo: statusRepo, travelSpeeds: travelSpeeds}
 }
 
-func NewFacilityHandler(facilityRepo *repository.FacilityRepository) *FacilityHandler {
-	return &FacilityHandler{facilityRepo: facilityRepo}
+// parseOpenAt reads the optional open_at query parameter (RFC 3339)
+func parseOpenAt(c *gin.Context) (*time.Time, bool) {
+	value := c.Query("open_at")
+	if value == "" {
+		return nil, true
+	}
+
+	openAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_OPEN_AT", "open_at must be an RFC 3339 timestamp")
+		return nil, false
+	}
+	return &openAt, true
+}
+
+// loadClosures fetches the closures needed to evaluate open status now and at openAt
+func (h *FacilityHandler) loadClosures(ctx context.Context, now time.Time, openAt *time.Time, facilityID *uuid.UUID) ([]*models.FacilityClosure, error) {
+	from, to := now, now
+	if openAt != nil {
+		if openAt.Before(from) {
+			from = *openAt
+		}
+		if openAt.After(to) {
+			to = *openAt
+		}
+	}
+
+	from, to = models.ClosureWindow(from, to)
+	return h.closureRepo.List(ctx, from, to, facilityID)
+}
+
+// openAtFilter keeps the facilities open at openAt, or returns nil when no
+// open_at was asked for. Facilities without recorded hours are excluded
+// because they cannot be shown to be open.
+func openAtFilter(openAt *time.Time, closures []*models.FacilityClosure) repository.FacilityFilter {
+	if openAt == nil {
+		return nil
+	}
+	return func(facility *models.Facility) bool {
+		open, known := facility.IsOpenAt(*openAt, closures)
+		return known && open
+	}
 }
 
 // GetFacility handles GET /v1/facilities/:id
@@ -33,6 +91,14 @@ func (h *FacilityHandler) GetFacility(c *gin.Context) {
 		return
 	}
 
+	now := time.Now()
+	closures, err := h.loadClosures(c.Request.Context(), now, nil, &id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to load facility closures")
+		return
+	}
+	facility.SetOpenStatus(now, closures)
+
 	response.Success(c, http.StatusOK, facility)
 }
 
@@ -44,43 +110,255 @@ func (h *FacilityHandler) GetNearbyFacilities(c *gin.Context) {
 		return
 	}
 
-	facilities, err := h.facilityRepo.GetNearby(c.Request.Context(), req.Latitude, req.Longitude, req.RadiusKM)
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultNearbyLimit
+	}
+
+	var after *pagination.Cursor
+	if req.Cursor != "" {
+		cursor, err := pagination.Decode(req.Cursor)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "INVALID_CURSOR", "Invalid pagination cursor")
+			return
+		}
+		after = cursor
+	}
+
+	openAt, ok := parseOpenAt(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	closures, err := h.loadClosures(c.Request.Context(), now, openAt, nil)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to load facility closures")
+		return
+	}
+
+	facilities, next, err := h.facilityRepo.GetNearby(c.Request.Context(), req.Latitude, req.Longitude, req.RadiusKM, limit, after, openAtFilter(openAt, closures))
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		response.Error(c, http.StatusBadRequest, "INVALID_CURSOR", "Invalid pagination cursor")
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to query facilities")
 		return
 	}
 
+	for _, facility := range facilities {
+		facility.SetOpenStatus(now, closures)
+	}
+
+	ids := make([]uuid.UUID, len(facilities))
+	for i, facility := range facilities {
+		ids[i] = facility.ID
+	}
+	statuses, err := h.statusRepo.GetMany(c.Request.Context(), ids)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to load facility capacity")
+		return
+	}
+
+	for _, facility := range facilities {
+		if status, ok := statuses[facility.ID]; ok {
+			facility.Capacity = status
+			facility.Warnings = status.Warnings(now)
+		}
+		if facility.Latitude != nil && facility.Longitude != nil {
+			facility.BearingDegrees = geo.BearingDegrees(req.Latitude, req.Longitude, *facility.Latitude, *facility.Longitude)
+			facility.Direction = geo.CompassPoint(facility.BearingDegrees)
+		}
+		facility.TravelEstimates = h.travelSpeeds.Estimate(facility.DistanceKM)
+	}
+
+	var nextCursor *string
+	if next != nil {
+		token := pagination.Encode(*next)
+		nextCursor = &token
+	}
+
 	response.Success(c, http.StatusOK, gin.H{
-		"facilities": facilities,
-		"count":      len(facilities),
+		"facilities":  facilities,
+		"count":       len(facilities),
+		"next_cursor": nextCursor,
 	})
 }
 
 // ListFacilities handles GET /v1/facilities
 func (h *FacilityHandler) ListFacilities(c *gin.Context) {
-	county := c.Query("county")
-	facilityTypeStr := c.Query("type")
+	var req models.FacilitySearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters: "+err.Error())
+		return
+	}
+	if err := req.Normalize(); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
 
-	var county_ *string
-	var facilityType_ *models.FacilityType
+	var after *pagination.Cursor
+	if req.Cursor != "" {
+		cursor, err := pagination.Decode(req.Cursor)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "INVALID_CURSOR", "Invalid pagination cursor")
+			return
+		}
+		after = cursor
+	}
 
-	if county != "" {
-		county_ = &county
+	openAt, ok := parseOpenAt(c)
+	if !ok {
+		return
 	}
 
-	if facilityTypeStr != "" {
-		ft := models.FacilityType(facilityTypeStr)
-		facilityType_ = &ft
+	now := time.Now()
+	closures, err := h.loadClosures(c.Request.Context(), now, openAt, nil)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to load facility closures")
+		return
 	}
 
-	facilities, err := h.facilityRepo.List(c.Request.Context(), county_, facilityType_)
+	facilities, next, total, err := h.facilityRepo.Search(c.Request.Context(), &req, limit, after, openAtFilter(openAt, closures))
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		response.Error(c, http.StatusBadRequest, "INVALID_CURSOR", "Cursor does not match the requested sort")
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to list facilities")
 		return
 	}
 
+	for _, facility := range facilities {
+		facility.SetOpenStatus(now, closures)
+	}
+
+	var nextCursor *string
+	if next != nil {
+		token := pagination.Encode(*next)
+		nextCursor = &token
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"facilities":  facilities,
+		"count":       len(facilities),
+		"total":       total,
+		"next_cursor": nextCursor,
+	})
+}
+
+// CreateFacility handles POST /v1/facilities
+func (h *FacilityHandler) CreateFacility(c *gin.Context) {
+	var req models.CreateFacilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	facility, err := h.facilityRepo.Create(c.Request.Context(), &req)
+	switch {
+	case errors.Is(err, repository.ErrDuplicate):
+		response.Error(c, http.StatusConflict, "DUPLICATE_FACILITY", "A facility with this MFL code already exists")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "CREATE_FAILED", "Failed to create facility")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, facility)
+}
+
+// UpdateFacility handles PUT /v1/facilities/:id
+func (h *FacilityHandler) UpdateFacility(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+		return
+	}
+
+	var req models.UpdateFacilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	facility, err := h.facilityRepo.Update(c.Request.Context(), id, &req)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Facility not found")
+		return
+	case errors.Is(err, repository.ErrDuplicate):
+		response.Error(c, http.StatusConflict, "DUPLICATE_FACILITY", "A facility with these details already exists")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to update facility")
+		return
+	}
+
+	response.Success(c, http.StatusOK, facility)
+}
+
+// DeactivateFacility handles DELETE /v1/facilities/:id
+func (h *FacilityHandler) DeactivateFacility(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+		return
+	}
+
+	if err := h.facilityRepo.Deactivate(c.Request.Context(), id); err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Facility not found")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Facility deactivated"})
+}
+
+// BulkCreateFacilities handles POST /v1/facilities/bulk
+func (h *FacilityHandler) BulkCreateFacilities(c *gin.Context) {
+	var req models.BulkCreateFacilitiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	// Rows are created independently so one bad row does not block the rest
+	results := make([]models.BulkFacilityResult, 0, len(req.Facilities))
+	created := 0
+	for i := range req.Facilities {
+		result := models.BulkFacilityResult{Index: i}
+
+		if err := req.Facilities[i].Validate(); err != nil {
+			result.Error = err.Error()
+		} else if facility, err := h.facilityRepo.Create(c.Request.Context(), &req.Facilities[i]); err != nil {
+			result.Error = "failed to create facility"
+		} else {
+			result.ID = &facility.ID
+			created++
+		}
+
+		results = append(results, result)
+	}
+
 	response.Success(c, http.StatusOK, gin.H{
-		"facilities": facilities,
-		"count":      len(facilities),
+		"results": results,
+		"created": created,
+		"failed":  len(results) - created,
 	})
-}
\ No newline at end of file
+}
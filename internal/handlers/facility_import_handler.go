@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/kmhfl"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+// maxImportFileSize caps uploads; a full national KMHFL export is around 5 MB
+const maxImportFileSize = 20 << 20
+
+type FacilityImportHandler struct {
+	importer *kmhfl.Importer
+}
+
+func NewFacilityImportHandler(importer *kmhfl.Importer) *FacilityImportHandler {
+	return &FacilityImportHandler{importer: importer}
+}
+
+// ImportKMHFL handles POST /v1/facilities/import
+func (h *FacilityImportHandler) ImportKMHFL(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "A KMHFL export must be uploaded in the 'file' field")
+		return
+	}
+
+	if fileHeader.Size > maxImportFileSize {
+		response.Error(c, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "Import file exceeds 20 MB")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_FILE", "Failed to read uploaded file")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_FILE", "Failed to read uploaded file")
+		return
+	}
+
+	rows, err := kmhfl.ReadFile(fileHeader.Filename, data)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_FILE", err.Error())
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+
+	report, err := h.importer.Import(c.Request.Context(), rows, dryRun)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "IMPORT_FAILED", "Failed to import facilities")
+		return
+	}
+
+	response.Success(c, http.StatusOK, report)
+}
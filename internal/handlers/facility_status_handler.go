@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type FacilityStatusHandler struct {
+	facilityRepo *repository.FacilityRepository
+	statusRepo   *repository.FacilityStatusRepository
+}
+
+func NewFacilityStatusHandler(facilityRepo *repository.FacilityRepository, statusRepo *repository.FacilityStatusRepository) *FacilityStatusHandler {
+	return &FacilityStatusHandler{facilityRepo: facilityRepo, statusRepo: statusRepo}
+}
+
+// ReportStatus handles PUT /v1/facilities/:id/status
+func (h *FacilityStatusHandler) ReportStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+		return
+	}
+
+	var req models.ReportFacilityStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+
+	now := time.Now()
+	if err := req.Validate(now); err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	_, err = h.facilityRepo.GetByID(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Facility not found")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to get facility")
+		return
+	}
+
+	var reportedBy, apiKeyID *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		uid := userID.(uuid.UUID)
+		reportedBy = &uid
+	}
+	if value, exists := c.Get("api_key"); exists {
+		keyID := value.(*models.APIKey).ID
+		apiKeyID = &keyID
+	}
+
+	status, err := h.statusRepo.Report(c.Request.Context(), id, &req, reportedBy, apiKeyID)
+	if errors.Is(err, repository.ErrValidation) {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "REPORT_FAILED", "Failed to report facility status")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"status":         status,
+		"beds_available": status.BedsAvailable(),
+		"warnings":       status.Warnings(now),
+	})
+}
+
+// GetStatus handles GET /v1/facilities/:id/status
+func (h *FacilityStatusHandler) GetStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+		return
+	}
+
+	status, err := h.statusRepo.Get(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to get facility status")
+		return
+	}
+	if status == nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Facility has not reported its status")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"status":         status,
+		"beds_available": status.BedsAvailable(),
+		"warnings":       status.Warnings(time.Now()),
+	})
+}
+
+// GetStatusHistory handles GET /v1/facilities/:id/status/history
+func (h *FacilityStatusHandler) GetStatusHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 || limit > 500 {
+			response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "limit must be between 1 and 500")
+			return
+		}
+	}
+
+	reports, err := h.statusRepo.History(c.Request.Context(), id, limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to get facility status history")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"reports": reports,
+		"count":   len(reports),
+	})
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type HouseholdHandler struct {
+	householdRepo *repository.HouseholdRepository
+}
+
+func NewHouseholdHandler(householdRepo *repository.HouseholdRepository) *HouseholdHandler {
+	return &HouseholdHandler{householdRepo: householdRepo}
+}
+
+// canAccessHousehold lets admins and clinicians reach any household, and
+// CHVs only the households on their own caseload
+func (h *HouseholdHandler) canAccessHousehold(c *gin.Context, householdID uuid.UUID) (bool, error) {
+	role, _ := c.Get("user_role")
+	if role != models.UserRoleCHV {
+		return true, nil
+	}
+
+	assignment, err := h.householdRepo.CurrentCHV(c.Request.Context(), householdID)
+	if err != nil || assignment == nil {
+		return false, err
+	}
+	userID, _ := c.Get("user_id")
+	return assignment.CHVUserID == userID, nil
+}
+
+// householdFromParam parses the :id parameter and checks the caller may access
+// the household. It writes the error response and returns false otherwise.
+func (h *HouseholdHandler) householdFromParam(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid household ID")
+		return uuid.Nil, false
+	}
+
+	allowed, err := h.canAccessHousehold(c, id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to check household access")
+		return uuid.Nil, false
+	}
+	if !allowed {
+		response.Error(c, http.StatusForbidden, "HOUSEHOLD_ACCESS_DENIED", "This household is not on your caseload")
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// CreateHousehold handles POST /v1/households. A CHV registering a household
+// takes it onto their caseload.
+func (h *HouseholdHandler) CreateHousehold(c *gin.Context) {
+	var req models.CreateHouseholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	var createdBy, chvUserID *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		createdBy = &id
+		if role, _ := c.Get("user_role"); role == models.UserRoleCHV {
+			chvUserID = &id
+		}
+	}
+
+	household, err := h.householdRepo.Create(c.Request.Context(), &req, createdBy, chvUserID)
+	switch {
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "CREATE_FAILED", "Failed to create household")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, household)
+}
+
+// GetHousehold handles GET /v1/households/:id
+func (h *HouseholdHandler) GetHousehold(c *gin.Context) {
+	id, ok := h.householdFromParam(c)
+	if !ok {
+		return
+	}
+
+	household, err := h.householdRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to get household")
+		return
+	}
+	if household == nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Household not found")
+		return
+	}
+
+	response.Success(c, http.StatusOK, household)
+}
+
+// AddMember handles POST /v1/households/:id/members
+func (h *HouseholdHandler) AddMember(c *gin.Context) {
+	id, ok := h.householdFromParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddHouseholdMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+
+	var addedBy *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		addedBy = &id
+	}
+
+	member, err := h.householdRepo.AddMember(c.Request.Context(), id, &req, addedBy)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Household or patient not found")
+		return
+	case errors.Is(err, repository.ErrDuplicate):
+		response.Error(c, http.StatusConflict, "ALREADY_IN_HOUSEHOLD", "Patient already belongs to a household; remove them from it first")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "CREATE_FAILED", "Failed to add household member")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, member)
+}
+
+// RemoveMember handles DELETE /v1/households/:id/members/:patient_id
+func (h *HouseholdHandler) RemoveMember(c *gin.Context) {
+	id, ok := h.householdFromParam(c)
+	if !ok {
+		return
+	}
+
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid patient ID")
+		return
+	}
+
+	err = h.householdRepo.RemoveMember(c.Request.Context(), id, patientID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Patient is not a member of this household")
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "DELETE_FAILED", "Failed to remove household member")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Patient removed from household"})
+}
+
+// AssignCHV handles PUT /v1/households/:id/chv
+func (h *HouseholdHandler) AssignCHV(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid household ID")
+		return
+	}
+
+	var req models.AssignCHVRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+
+	var assignedBy *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		assignedBy = &id
+	}
+
+	assignment, err := h.householdRepo.AssignCHV(c.Request.Context(), id, req.CHVUserID, assignedBy)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Household not found")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to assign CHV")
+		return
+	}
+
+	response.Success(c, http.StatusOK, assignment)
+}
+
+// GetMyCaseload handles GET /v1/chv/me/caseload
+func (h *HouseholdHandler) GetMyCaseload(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	caseload, err := h.householdRepo.Caseload(c.Request.Context(), userID.(uuid.UUID), time.Now())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to load caseload")
+		return
+	}
+
+	caseload.Redact(models.SummaryAccessCareTeam)
+	response.Success(c, http.StatusOK, caseload)
+}
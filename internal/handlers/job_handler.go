@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/jobs"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type JobHandler struct {
+	inspector jobs.Inspector
+}
+
+// NewJobHandler takes a nil inspector when the queue cannot be inspected,
+// as with AMQP; the endpoints then answer 404
+func NewJobHandler(inspector jobs.Inspector) *JobHandler {
+	return &JobHandler{inspector: inspector}
+}
+
+func (h *JobHandler) available(c *gin.Context) bool {
+	if h.inspector == nil {
+		response.Error(c, http.StatusNotFound, "NOT_CONFIGURED", "This job queue cannot be inspected")
+		return false
+	}
+	return true
+}
+
+// ListJobs handles GET /v1/admin/jobs?status=&kind=&limit=
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	var req jobs.ListJobsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	list, err := h.inspector.List(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "LIST_FAILED", "Failed to list jobs")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"jobs":  list,
+		"count": len(list),
+	})
+}
+
+// JobStats handles GET /v1/admin/jobs/stats
+func (h *JobHandler) JobStats(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	stats, err := h.inspector.Stats(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to count jobs")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"kinds": stats})
+}
+
+// GetJob handles GET /v1/admin/jobs/:id
+func (h *JobHandler) GetJob(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid job ID")
+		return
+	}
+
+	job, err := h.inspector.Get(c.Request.Context(), id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Job not found")
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to get job")
+		return
+	}
+
+	response.Success(c, http.StatusOK, job)
+}
+
+// RetryJob handles POST /v1/admin/jobs/:id/retry, which runs a dead job
+// again with a fresh set of attempts
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid job ID")
+		return
+	}
+
+	job, err := h.inspector.Retry(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Job not found")
+		return
+	case errors.Is(err, jobs.ErrNotDead):
+		response.Error(c, http.StatusConflict, "NOT_DEAD", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "RETRY_FAILED", "Failed to retry job")
+		return
+	}
+
+	response.Success(c, http.StatusOK, job)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type NotificationHandler struct {
+	notificationRepo *repository.NotificationRepository
+}
+
+func NewNotificationHandler(notificationRepo *repository.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{notificationRepo: notificationRepo}
+}
+
+// ListNotifications handles GET /v1/notifications, the caller's inbox
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	var req models.ListNotificationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	notifications, err := h.notificationRepo.ListForUser(c.Request.Context(), userID.(uuid.UUID), &req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to list notifications")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"notifications": notifications,
+		"count":         len(notifications),
+	})
+}
+
+// MarkRead handles POST /v1/notifications/:id/read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid notification ID")
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	notification, err := h.notificationRepo.MarkRead(c.Request.Context(), id, userID.(uuid.UUID))
+	if errors.Is(err, repository.ErrNotFound) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Notification not found")
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to mark notification read")
+		return
+	}
+
+	response.Success(c, http.StatusOK, notification)
+}
@@ -1,21 +1,28 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/middleware"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/services"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
 )
 
 type PatientHandler struct {
-	patientRepo *repository.PatientRepository
+	patientRepo    *repository.PatientRepository
+	patientService *services.PatientService
 }
 
-func NewPatientHandler(patientRepo *repository.PatientRepository) *PatientHandler {
-	return &PatientHandler{patientRepo: patientRepo}
+func NewPatientHandler(patientRepo *repository.PatientRepository, patientService *services.PatientService) *PatientHandler {
+	return &PatientHandler{patientRepo: patientRepo, patientService: patientService}
 }
 
 // CreatePatient handles POST /v1/patients
@@ -25,16 +32,103 @@ func (h *PatientHandler) CreatePatient(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
 		return
 	}
+	userID := c.MustGet("user_id").(uuid.UUID)
+	req.RegisteredBy = &userID
 
-	patient, err := h.patientRepo.Create(c.Request.Context(), &req)
-	if err != nil {
+	patient, err := h.patientService.Create(c.Request.Context(), &req)
+	var duplicateErr *services.DuplicatePatientError
+	switch {
+	case errors.Is(err, services.ErrInvalidPhone):
+		response.Error(c, http.StatusBadRequest, "INVALID_PHONE", "Phone must be a Kenyan mobile number, e.g. 0712345678")
+		return
+	case errors.Is(err, services.ErrFutureDateOfBirth):
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	case errors.As(err, &duplicateErr) && duplicateErr.PhoneTaken:
+		response.ErrorWithData(c, http.StatusConflict, "PHONE_ALREADY_REGISTERED",
+			"A patient with this phone number is already registered", gin.H{"candidates": duplicateErr.Matches})
+		return
+	case duplicateErr != nil:
+		response.ErrorWithData(c, http.StatusConflict, "POSSIBLE_DUPLICATE",
+			"Similar patients are already registered; resend with confirm_new if this is a different person", gin.H{"candidates": duplicateErr.Matches})
+		return
+	case err != nil:
 		response.Error(c, http.StatusInternalServerError, "CREATE_FAILED", "Failed to create patient")
 		return
 	}
 
+	c.Header("ETag", patient.ETag())
 	response.Success(c, http.StatusCreated, patient)
 }
 
+// SearchPatients handles GET /v1/patients
+func (h *PatientHandler) SearchPatients(c *gin.Context) {
+	var req models.SearchPatientsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	if boundTo, bound := c.Get("facility_id"); bound {
+		facilityID := boundTo.(uuid.UUID)
+		req.FacilityID = &facilityID
+	}
+	if role, _ := c.Get("user_role"); role == models.UserRoleCHV {
+		userID := c.MustGet("user_id").(uuid.UUID)
+		req.CHVUserID = &userID
+	}
+
+	patients, err := h.patientService.Search(c.Request.Context(), &req)
+	switch {
+	case errors.Is(err, services.ErrInvalidPhone):
+		response.Error(c, http.StatusBadRequest, "INVALID_PHONE", "Phone must be a Kenyan mobile number, e.g. 0712345678")
+		return
+	case errors.Is(err, services.ErrSearchFilterRequired):
+		response.Error(c, http.StatusBadRequest, "SEARCH_FILTER_REQUIRED", err.Error())
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "SEARCH_FAILED", "Failed to search patients")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"patients": patients,
+		"count":    len(patients),
+	})
+}
+
+// loadPatient fetches a patient, following a merged record to its survivor
+func (h *PatientHandler) loadPatient(ctx context.Context, id uuid.UUID) (*models.Patient, error) {
+	patient, err := h.patientRepo.GetByID(ctx, id)
+	if err != nil || patient.MergedInto == nil {
+		return patient, err
+	}
+	return h.patientRepo.GetByID(ctx, *patient.MergedInto)
+}
+
+// visibleToCaller reports whether the caller may read a patient. Facility-bound
+// API keys only see patients with a referral or appointment at their facility.
+func (h *PatientHandler) visibleToCaller(c *gin.Context, patientID uuid.UUID) (bool, error) {
+	boundTo, bound := c.Get("facility_id")
+	if !bound {
+		return true, nil
+	}
+	return h.patientRepo.SeenAtFacility(c.Request.Context(), patientID, boundTo.(uuid.UUID))
+}
+
+// respondWithPatient sends a patient with its ETag. When the record was
+// reached through a merged ID, Content-Location names the record returned.
+func respondWithPatient(c *gin.Context, status int, requestedID uuid.UUID, patient *models.Patient) {
+	c.Header("ETag", patient.ETag())
+	if patient.ID != requestedID {
+		c.Header("Content-Location", "/v1/patients/"+patient.ID.String())
+	}
+	response.Success(c, status, patient)
+}
+
 // GetPatient handles GET /v1/patients/:id
 func (h *PatientHandler) GetPatient(c *gin.Context) {
 	idStr := c.Param("id")
@@ -44,16 +138,137 @@ func (h *PatientHandler) GetPatient(c *gin.Context) {
 		return
 	}
 
-	patient, err := h.patientRepo.GetByID(c.Request.Context(), id)
+	patient, err := h.loadPatient(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Patient not found")
+		return
+	}
+
+	visible, err := h.visibleToCaller(c, patient.ID)
 	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to get patient")
+		return
+	}
+	if !visible {
 		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Patient not found")
 		return
 	}
 
-	response.Success(c, http.StatusOK, patient)
+	respondWithPatient(c, http.StatusOK, id, patient)
 }
 
-// UpdatePatient handles PUT /v1/patients/:id
+// GetPatientSummary handles GET /v1/patients/:id/summary. Clinical notes
+// and contact details are redacted according to the caller's role.
+func (h *PatientHandler) GetPatientSummary(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid patient ID")
+		return
+	}
+
+	access := models.SummaryAccessPartner
+	if c.GetString("auth_type") == middleware.AuthTypeSession {
+		role, _ := c.Get("user_role")
+		var ok bool
+		access, ok = models.SummaryAccessForRole(role.(models.UserRole))
+		if !ok {
+			response.Error(c, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions")
+			return
+		}
+	}
+
+	summary, err := h.patientRepo.GetSummary(c.Request.Context(), id, time.Now())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to build patient summary")
+		return
+	}
+	if summary == nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Patient not found")
+		return
+	}
+
+	visible, err := h.visibleToCaller(c, summary.Patient.ID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to build patient summary")
+		return
+	}
+	if !visible {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Patient not found")
+		return
+	}
+
+	summary.Redact(access)
+	if summary.Patient.ID != id {
+		c.Header("Content-Location", "/v1/patients/"+summary.Patient.ID.String()+"/summary")
+	}
+	response.Success(c, http.StatusOK, summary)
+}
+
+// PatchPatient handles PATCH /v1/patients/:id. The body is a JSON Merge
+// Patch and If-Match must carry the ETag of the version being edited.
+func (h *PatientHandler) PatchPatient(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid patient ID")
+		return
+	}
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		response.Error(c, http.StatusPreconditionRequired, "PRECONDITION_REQUIRED", "If-Match header with the patient's ETag is required")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+		return
+	}
+	req, err := models.DecodePatchPatientRequest(body)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid merge patch: "+err.Error())
+		return
+	}
+	if err := req.Validate(time.Now()); err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	patient, err := h.patientRepo.Patch(c.Request.Context(), id, req, ifMatch)
+	if err != nil {
+		h.respondToUpdateError(c, id, err)
+		return
+	}
+
+	respondWithPatient(c, http.StatusOK, id, patient)
+}
+
+// respondToUpdateError writes the response for a failed PUT or PATCH. A
+// version conflict returns the current record so the client can redo its edit.
+func (h *PatientHandler) respondToUpdateError(c *gin.Context, id uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		current, err := h.loadPatient(c.Request.Context(), id)
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to update patient")
+			return
+		}
+		c.Header("ETag", current.ETag())
+		response.ErrorWithData(c, http.StatusConflict, "VERSION_CONFLICT",
+			"Patient was changed by someone else; review the current record and retry with its ETag", current)
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Patient not found")
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+	case errors.Is(err, repository.ErrDuplicate):
+		response.Error(c, http.StatusConflict, "PHONE_ALREADY_REGISTERED", "A patient with this phone number is already registered")
+	default:
+		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to update patient")
+	}
+}
+
+// UpdatePatient handles PUT /v1/patients/:id. Like PATCH, If-Match must
+// carry the ETag of the version being replaced.
 func (h *PatientHandler) UpdatePatient(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -62,17 +277,23 @@ func (h *PatientHandler) UpdatePatient(c *gin.Context) {
 		return
 	}
 
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		response.Error(c, http.StatusPreconditionRequired, "PRECONDITION_REQUIRED", "If-Match header with the patient's ETag is required")
+		return
+	}
+
 	var req models.CreatePatientRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
 		return
 	}
 
-	patient, err := h.patientRepo.Update(c.Request.Context(), id, &req)
+	patient, err := h.patientRepo.Update(c.Request.Context(), id, &req, ifMatch)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to update patient")
+		h.respondToUpdateError(c, id, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, patient)
+	respondWithPatient(c, http.StatusOK, id, patient)
 }
\ No newline at end of file
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type PatientMergeHandler struct {
+	mergeRepo *repository.PatientMergeRepository
+}
+
+func NewPatientMergeHandler(mergeRepo *repository.PatientMergeRepository) *PatientMergeHandler {
+	return &PatientMergeHandler{mergeRepo: mergeRepo}
+}
+
+// MergePatient handles POST /v1/patients/:id/merge
+func (h *PatientMergeHandler) MergePatient(c *gin.Context) {
+	survivorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid patient ID")
+		return
+	}
+
+	var req models.MergePatientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+
+	var mergedBy *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		mergedBy = &id
+	}
+
+	merge, err := h.mergeRepo.Merge(c.Request.Context(), survivorID, req.DuplicateID, req.Reason, mergedBy)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Patient not found")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusConflict, "MERGE_CONFLICT", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "MERGE_FAILED", "Failed to merge patients")
+		return
+	}
+
+	response.Success(c, http.StatusOK, merge)
+}
+
+// ListMerges handles GET /v1/patients/:id/merges
+func (h *PatientMergeHandler) ListMerges(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid patient ID")
+		return
+	}
+
+	merges, err := h.mergeRepo.ListForPatient(c.Request.Context(), patientID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "LIST_FAILED", "Failed to list patient merges")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"merges": merges,
+		"count":  len(merges),
+	})
+}
+
+// UndoMerge handles POST /v1/patient-merges/:id/undo
+func (h *PatientMergeHandler) UndoMerge(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid merge ID")
+		return
+	}
+
+	var undoneBy *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		userUUID := userID.(uuid.UUID)
+		undoneBy = &userUUID
+	}
+
+	merge, err := h.mergeRepo.Undo(c.Request.Context(), id, undoneBy)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Patient merge not found")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusConflict, "UNDO_CONFLICT", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "UNDO_FAILED", "Failed to undo patient merge")
+		return
+	}
+
+	response.Success(c, http.StatusOK, merge)
+}
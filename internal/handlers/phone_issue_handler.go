@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type PhoneIssueHandler struct {
+	issueRepo *repository.PhoneIssueRepository
+}
+
+func NewPhoneIssueHandler(issueRepo *repository.PhoneIssueRepository) *PhoneIssueHandler {
+	return &PhoneIssueHandler{issueRepo: issueRepo}
+}
+
+// ListIssues handles GET /v1/admin/phone-issues
+func (h *PhoneIssueHandler) ListIssues(c *gin.Context) {
+	var req models.ListPhoneIssuesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	issues, err := h.issueRepo.List(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "LIST_FAILED", "Failed to list phone issues")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"issues": issues,
+		"count":  len(issues),
+	})
+}
+
+// ResolveIssue handles POST /v1/admin/phone-issues/:id/resolve
+func (h *PhoneIssueHandler) ResolveIssue(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid issue ID")
+		return
+	}
+
+	issue, err := h.issueRepo.Resolve(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Phone issue not found")
+		return
+	}
+
+	response.Success(c, http.StatusOK, issue)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/services"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/geo"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type RecommendationHandler struct {
+	triageRepo   repository.TriageRepositoryInterface
+	recommender  *services.FacilityRecommender
+	travelSpeeds geo.TravelSpeeds
+}
+
+func NewRecommendationHandler(triageRepo repository.TriageRepositoryInterface, recommender *services.FacilityRecommender, travelSpeeds geo.TravelSpeeds) *RecommendationHandler {
+	return &RecommendationHandler{triageRepo: triageRepo, recommender: recommender, travelSpeeds: travelSpeeds}
+}
+
+// RecommendFacilities handles GET /v1/triage/:id/recommended-facilities
+func (h *RecommendationHandler) RecommendFacilities(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid triage session ID")
+		return
+	}
+
+	var req models.RecommendFacilitiesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	session, err := h.triageRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Triage session not found")
+		return
+	}
+
+	if session.TriageLevel == nil {
+		response.Error(c, http.StatusConflict, "TRIAGE_INCOMPLETE", "Triage session has not been assigned a triage level yet")
+		return
+	}
+
+	recommendations, criteria, err := h.recommender.Recommend(c.Request.Context(), session, &req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to recommend facilities")
+		return
+	}
+
+	for _, rec := range recommendations {
+		facility := rec.Facility
+		if facility.Latitude != nil && facility.Longitude != nil {
+			facility.BearingDegrees = geo.BearingDegrees(req.Latitude, req.Longitude, *facility.Latitude, *facility.Longitude)
+			facility.Direction = geo.CompassPoint(facility.BearingDegrees)
+		}
+		facility.TravelEstimates = h.travelSpeeds.Estimate(facility.DistanceKM)
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"triage_session_id": session.ID,
+		"criteria":          criteria,
+		"recommendations":   recommendations,
+		"count":             len(recommendations),
+	})
+}
@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/middleware"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/services"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type ReferralHandler struct {
+	referralService *services.ReferralService
+	referralRepo    repository.ReferralRepositoryInterface
+	clinicians      middleware.ClinicianLookup
+}
+
+func NewReferralHandler(referralService *services.ReferralService, referralRepo repository.ReferralRepositoryInterface, clinicians middleware.ClinicianLookup) *ReferralHandler {
+	return &ReferralHandler{referralService: referralService, referralRepo: referralRepo, clinicians: clinicians}
+}
+
+// CreateReferral handles POST /v1/referrals
+func (h *ReferralHandler) CreateReferral(c *gin.Context) {
+	var req models.CreateReferralRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		createdBy = &id
+	}
+
+	referral, warnings, err := h.referralService.Create(c.Request.Context(), &req, createdBy)
+	var capacityErr *services.CapacityWarningError
+	switch {
+	case errors.As(err, &capacityErr):
+		response.ErrorWithDetails(c, http.StatusConflict, "CAPACITY_WARNING",
+			"Facility has reported capacity problems; resend with acknowledge_warnings to refer anyway",
+			strings.Join(capacityErr.Warnings, "; "))
+		return
+	case errors.Is(err, services.ErrFacilityNotFound):
+		response.Error(c, http.StatusNotFound, "FACILITY_NOT_FOUND", "Facility not found")
+		return
+	case errors.Is(err, services.ErrFacilityNotAccepting):
+		response.Error(c, http.StatusConflict, "FACILITY_NOT_ACCEPTING", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "REFERRAL_CREATE_FAILED", "Failed to create referral")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{
+		"referral": referral,
+		"warnings": warnings,
+	})
+}
+
+// GetReferral handles GET /v1/referrals/:id
+func (h *ReferralHandler) GetReferral(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid referral ID")
+		return
+	}
+
+	referral, err := h.referralRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+
+	if !canReadReferral(c, referral) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+
+	response.Success(c, http.StatusOK, referral)
+}
+
+// ListReferrals handles GET /v1/referrals
+func (h *ReferralHandler) ListReferrals(c *gin.Context) {
+	var req models.ListReferralsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	if c.GetString("auth_type") == middleware.AuthTypeAPIKey {
+		if boundTo, bound := c.Get("facility_id"); bound {
+			facilityID := boundTo.(uuid.UUID)
+			req.FacilityID = &facilityID
+		}
+	}
+	if role, _ := c.Get("user_role"); role == models.UserRoleCHV {
+		userID := c.MustGet("user_id").(uuid.UUID)
+		req.CreatedByCHV = &userID
+	}
+
+	referrals, err := h.referralRepo.List(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to list referrals")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"referrals": referrals,
+		"count":     len(referrals),
+	})
+}
+
+// canReadReferral reports whether the caller may see a referral.
+// Facility-bound API keys only see their own facility's referrals, and CHVs
+// only the referrals they made.
+func canReadReferral(c *gin.Context, referral *models.Referral) bool {
+	if boundTo, bound := c.Get("facility_id"); bound && (referral.FacilityID == nil || *referral.FacilityID != boundTo.(uuid.UUID)) {
+		return false
+	}
+	if role, _ := c.Get("user_role"); role == models.UserRoleCHV {
+		return referral.CreatedByCHV != nil && *referral.CreatedByCHV == c.MustGet("user_id").(uuid.UUID)
+	}
+	return true
+}
+
+// loadForFacility loads the referral named in the URL and checks the caller
+// can act for the facility it was sent to. On failure the error response has
+// been written and nil is returned.
+func (h *ReferralHandler) loadForFacility(c *gin.Context) *models.Referral {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid referral ID")
+		return nil
+	}
+
+	referral, err := h.referralRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return nil
+	}
+
+	if referral.FacilityID == nil || !middleware.CanActForFacility(c, h.clinicians, *referral.FacilityID) {
+		response.Error(c, http.StatusForbidden, "FACILITY_ACCESS_DENIED", "Only the receiving facility can update this referral")
+		return nil
+	}
+
+	return referral
+}
+
+// UpdateStatus handles PUT /v1/referrals/:id/status. The receiving facility
+// marks the referral accepted, completed or no_show, and the referring CHV
+// is notified.
+func (h *ReferralHandler) UpdateStatus(c *gin.Context) {
+	var req models.UpdateReferralStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+
+	referral := h.loadForFacility(c)
+	if referral == nil {
+		return
+	}
+
+	var clinicianID *uuid.UUID
+	if value, exists := c.Get("user"); exists {
+		clinicianID = value.(*models.User).ClinicianID
+	}
+
+	referral, err := h.referralService.UpdateStatus(c.Request.Context(), referral.ID, req.Status, clinicianID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusConflict, "INVALID_TRANSITION", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to update referral status")
+		return
+	}
+
+	response.Success(c, http.StatusOK, referral)
+}
+
+// RecordOutcome handles PUT /v1/referrals/:id/outcome. Recording again
+// replaces the earlier outcome.
+func (h *ReferralHandler) RecordOutcome(c *gin.Context) {
+	var req models.RecordReferralOutcomeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	referral := h.loadForFacility(c)
+	if referral == nil {
+		return
+	}
+
+	// An API key (such as a facility EMR) records the outcome without a user
+	var recordedBy *uuid.UUID
+	if userID, exists := c.Get("user_id"); exists {
+		id := userID.(uuid.UUID)
+		recordedBy = &id
+	}
+
+	outcome, err := h.referralRepo.RecordOutcome(c.Request.Context(), referral.ID, &req, recordedBy)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusConflict, "REFERRAL_NOT_COMPLETED", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "OUTCOME_FAILED", "Failed to record referral outcome")
+		return
+	}
+
+	response.Success(c, http.StatusOK, outcome)
+}
+
+// GetOutcome handles GET /v1/referrals/:id/outcome
+func (h *ReferralHandler) GetOutcome(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid referral ID")
+		return
+	}
+
+	referral, err := h.referralRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+	if !canReadReferral(c, referral) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+
+	outcome, err := h.referralRepo.GetOutcome(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "No outcome has been recorded for this referral")
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to get referral outcome")
+		return
+	}
+
+	response.Success(c, http.StatusOK, outcome)
+}
+
+// TransferReferral handles POST /v1/referrals/:id/transfer. A clinician at
+// the receiving facility forwards a referral it cannot handle; the new
+// referral is returned and the patient and referring CHV are told.
+func (h *ReferralHandler) TransferReferral(c *gin.Context) {
+	var req models.TransferReferralRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	referral := h.loadForFacility(c)
+	if referral == nil {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	transferredBy := userID.(uuid.UUID)
+
+	transferred, transfer, warnings, err := h.referralService.Transfer(c.Request.Context(), referral.ID, &req, &transferredBy)
+	var capacityErr *services.CapacityWarningError
+	switch {
+	case errors.As(err, &capacityErr):
+		response.ErrorWithDetails(c, http.StatusConflict, "CAPACITY_WARNING",
+			"Facility has reported capacity problems; resend with acknowledge_warnings to transfer anyway",
+			strings.Join(capacityErr.Warnings, "; "))
+		return
+	case errors.Is(err, services.ErrFacilityNotFound):
+		response.Error(c, http.StatusNotFound, "FACILITY_NOT_FOUND", "Facility not found")
+		return
+	case errors.Is(err, services.ErrFacilityNotAccepting):
+		response.Error(c, http.StatusConflict, "FACILITY_NOT_ACCEPTING", err.Error())
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusConflict, "INVALID_TRANSFER", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "TRANSFER_FAILED", "Failed to transfer referral")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{
+		"referral": transferred,
+		"transfer": transfer,
+		"warnings": warnings,
+	})
+}
+
+// GetTransferChain handles GET /v1/referrals/:id/transfers: every referral
+// in the chain, original first, and the transfers between them
+func (h *ReferralHandler) GetTransferChain(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid referral ID")
+		return
+	}
+
+	chain, transfers, err := h.referralRepo.GetTransferChain(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to get referral transfers")
+		return
+	}
+
+	// Callers see the whole chain if they can see any referral in it
+	visible := false
+	for _, referral := range chain {
+		visible = visible || canReadReferral(c, referral)
+	}
+	if !visible {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"referrals": chain,
+		"transfers": transfers,
+	})
+}
+
+// TransferStats handles GET /v1/admin/analytics/transfers?from=&to= with
+// dates as YYYY-MM-DD, the last 30 days by default
+func (h *ReferralHandler) TransferStats(c *gin.Context) {
+	var req models.TransferStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters: "+err.Error())
+		return
+	}
+	from, to, err := req.Period(time.Now())
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	stats, err := h.referralRepo.TransferStats(c.Request.Context(), from, to)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to compute transfer stats")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"from":       from,
+		"to":         to,
+		"facilities": stats,
+	})
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/middleware"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestCanReadReferral(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	chvID, facilityID := uuid.New(), uuid.New()
+	referral := &models.Referral{ID: uuid.New(), FacilityID: &facilityID, CreatedByCHV: &chvID}
+
+	session := func(role models.UserRole, userID uuid.UUID) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("auth_type", middleware.AuthTypeSession)
+		c.Set("user_id", userID)
+		c.Set("user_role", role)
+		return c
+	}
+	apiKey := func(boundTo *uuid.UUID) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("auth_type", middleware.AuthTypeAPIKey)
+		if boundTo != nil {
+			c.Set("facility_id", *boundTo)
+		}
+		return c
+	}
+	otherFacility := uuid.New()
+
+	assert.True(t, canReadReferral(session(models.UserRoleCHV, chvID), referral), "the CHV who made it")
+	assert.False(t, canReadReferral(session(models.UserRoleCHV, uuid.New()), referral), "another CHV")
+	assert.True(t, canReadReferral(session(models.UserRoleClinician, uuid.New()), referral))
+	assert.True(t, canReadReferral(apiKey(nil), referral))
+	assert.True(t, canReadReferral(apiKey(&facilityID), referral))
+	assert.False(t, canReadReferral(apiKey(&otherFacility), referral))
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type ReferralReminderHandler struct {
+	reminderRepo *repository.ReferralReminderRepository
+	referralRepo repository.ReferralRepositoryInterface
+}
+
+func NewReferralReminderHandler(reminderRepo *repository.ReferralReminderRepository, referralRepo repository.ReferralRepositoryInterface) *ReferralReminderHandler {
+	return &ReferralReminderHandler{reminderRepo: reminderRepo, referralRepo: referralRepo}
+}
+
+// ListSchedules handles GET /v1/admin/reminder-schedules
+func (h *ReferralReminderHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.reminderRepo.ListSchedules(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to list reminder schedules")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"schedules": schedules,
+		"count":     len(schedules),
+	})
+}
+
+// PutSchedule handles PUT /v1/admin/reminder-schedules. Omit county to set
+// the default for the priority.
+func (h *ReferralReminderHandler) PutSchedule(c *gin.Context) {
+	var req models.PutReminderScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	updatedBy := userID.(uuid.UUID)
+
+	schedule, err := h.reminderRepo.PutSchedule(c.Request.Context(), &req, &updatedBy)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to save reminder schedule")
+		return
+	}
+
+	response.Success(c, http.StatusOK, schedule)
+}
+
+// ListReferralReminders handles GET /v1/referrals/:id/reminders
+func (h *ReferralReminderHandler) ListReferralReminders(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid referral ID")
+		return
+	}
+
+	referral, err := h.referralRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+	if !canReadReferral(c, referral) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+
+	reminders, err := h.reminderRepo.ListForReferral(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to list referral reminders")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"reminders": reminders,
+		"count":     len(reminders),
+	})
+}
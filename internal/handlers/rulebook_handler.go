@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/rulebook"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type RulebookHandler struct {
+	facilityRepo *repository.FacilityRepository
+	signer       *rulebook.Signer
+}
+
+func NewRulebookHandler(facilityRepo *repository.FacilityRepository, signer *rulebook.Signer) *RulebookHandler {
+	return &RulebookHandler{facilityRepo: facilityRepo, signer: signer}
+}
+
+// GetRulebook handles GET /v1/triage/rulebook?county=. The body is the gzipped
+// bundle JSON; X-Bundle-Signature is an Ed25519 signature of those bytes.
+// Devices send the ETag back in If-None-Match and get 304 until it changes.
+func (h *RulebookHandler) GetRulebook(c *gin.Context) {
+	county := strings.TrimSpace(c.Query("county"))
+	if county == "" {
+		response.Error(c, http.StatusBadRequest, "COUNTY_REQUIRED", "county is required")
+		return
+	}
+
+	facilities, err := h.facilityRepo.ListByCounty(c.Request.Context(), county)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to load county facilities")
+		return
+	}
+
+	bundle, err := rulebook.NewBundle(rulebook.Default(), rulebook.DefaultTemplates(), county, facilities)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "BUNDLE_FAILED", "Failed to build rule bundle")
+		return
+	}
+
+	etag := `"` + bundle.Version + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	body, signature, err := h.signer.Pack(bundle)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "BUNDLE_FAILED", "Failed to build rule bundle")
+		return
+	}
+
+	c.Header("X-Bundle-Version", bundle.Version)
+	c.Header("X-Bundle-Signature", signature)
+	c.Header("X-Bundle-Key-ID", h.signer.KeyID)
+	c.Data(http.StatusOK, "application/gzip", body)
+}
+
+// GetSigningKey handles GET /v1/triage/rulebook/key
+func (h *RulebookHandler) GetSigningKey(c *gin.Context) {
+	response.Success(c, http.StatusOK, gin.H{
+		"algorithm":  "ed25519",
+		"key_id":     h.signer.KeyID,
+		"public_key": h.signer.PublicKey(),
+	})
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. Weak
+// validators match, since the bundle is compared by content version.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/services"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type SyncHandler struct {
+	syncService *services.SyncService
+}
+
+func NewSyncHandler(syncService *services.SyncService) *SyncHandler {
+	return &SyncHandler{syncService: syncService}
+}
+
+// Sync handles POST /v1/sync. Each mutation gets its own result, so the
+// response is 200 even when some were rejected or lost a conflict.
+func (h *SyncHandler) Sync(c *gin.Context) {
+	var req models.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body: "+err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	resp, err := h.syncService.Sync(c.Request.Context(), userID.(uuid.UUID), &req)
+	switch {
+	case errors.Is(err, repository.ErrInvalidCursor):
+		response.Error(c, http.StatusBadRequest, "INVALID_CURSOR", "Invalid sync cursor")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "SYNC_FAILED", "Failed to sync; retry the batch")
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
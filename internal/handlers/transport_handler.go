@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/services"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
+)
+
+type TransportHandler struct {
+	transportRepo    *repository.TransportRepository
+	referralRepo     *repository.ReferralRepository
+	transportService *services.TransportService
+	webhookToken     string
+}
+
+func NewTransportHandler(transportRepo *repository.TransportRepository, referralRepo *repository.ReferralRepository, transportService *services.TransportService, webhookToken string) *TransportHandler {
+	return &TransportHandler{transportRepo: transportRepo, referralRepo: referralRepo, transportService: transportService, webhookToken: webhookToken}
+}
+
+// GetDispatch handles GET /v1/referrals/:id/dispatch
+func (h *TransportHandler) GetDispatch(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid referral ID")
+		return
+	}
+
+	referral, err := h.referralRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+
+	if !canReadReferral(c, referral) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+
+	dispatch, err := h.transportRepo.GetByReferral(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "No transport was requested for this referral")
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "QUERY_FAILED", "Failed to get transport dispatch")
+		return
+	}
+
+	response.Success(c, http.StatusOK, dispatch)
+}
+
+// Redispatch handles POST /v1/referrals/:id/dispatch, requesting transport
+// again after the provider refused it
+func (h *TransportHandler) Redispatch(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid referral ID")
+		return
+	}
+
+	referral, err := h.referralRepo.GetByID(c.Request.Context(), id)
+	if err != nil || !canReadReferral(c, referral) {
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	}
+
+	dispatch, err := h.transportService.Redispatch(c.Request.Context(), referral)
+	switch {
+	case errors.Is(err, services.ErrTransportDisabled):
+		response.Error(c, http.StatusNotFound, "NOT_CONFIGURED", "Transport dispatch is not configured")
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Referral not found")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusConflict, "DISPATCH_IN_PROGRESS", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "DISPATCH_FAILED", "Failed to request transport")
+		return
+	}
+
+	response.Success(c, http.StatusOK, dispatch)
+}
+
+// TransportWebhook handles POST /v1/transport/webhook, the transport
+// provider's callback as a rider is assigned and arrives
+func (h *TransportHandler) TransportWebhook(c *gin.Context) {
+	if h.webhookToken == "" {
+		response.Error(c, http.StatusNotFound, "NOT_CONFIGURED", "Transport webhook is not configured")
+		return
+	}
+	if !webhookAuthorized(c, h.webhookToken) {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid webhook token")
+		return
+	}
+
+	var update models.TransportUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid callback: "+err.Error())
+		return
+	}
+
+	dispatch, err := h.transportService.HandleUpdate(c.Request.Context(), &update)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error(c, http.StatusNotFound, "NOT_FOUND", "Unknown dispatch reference")
+		return
+	case errors.Is(err, repository.ErrValidation):
+		response.Error(c, http.StatusConflict, "INVALID_TRANSITION", err.Error())
+		return
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to record transport update")
+		return
+	}
+
+	response.Success(c, http.StatusOK, dispatch)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/rulebook"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
 )
 
@@ -32,8 +33,21 @@ func (h *TriageHandler) CreateTriage(c *gin.Context) {
 		return
 	}
 
-	// Create triage session
-	session, err := h.triageRepo.Create(c.Request.Context(), &req)
+	// Red flags are settled by the rulebook straight away, the same way the
+	// CHV app triages offline, and saved with the session itself
+	book := rulebook.Default()
+	verdict := book.Evaluate(req.Symptoms)
+
+	var session *models.TriageSession
+	var err error
+	message := "Triage session created and queued for processing"
+	if verdict != nil {
+		result := map[string]interface{}{"source": "rulebook", "rulebook_version": book.Version}
+		session, err = h.triageRepo.CreateWithVerdict(c.Request.Context(), &req, verdict, result)
+		message = "Red flag found: " + verdict.TriageCode
+	} else {
+		session, err = h.triageRepo.Create(c.Request.Context(), &req)
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "TRIAGE_CREATE_FAILED", "Failed to create triage session")
 		return
@@ -42,10 +56,10 @@ func (h *TriageHandler) CreateTriage(c *gin.Context) {
 	// Build response
 	triageResponse := models.TriageResponse{
 		SessionID:         session.ID,
-		Status:            models.TriageStatusQueued,
+		Status:            session.Status,
 		TriageLevel:       session.TriageLevel,
 		RecommendedAction: session.RecommendedAction,
-		Message:           "Triage session created and queued for processing",
+		Message:           message,
 		CreatedAt:         session.CreatedAt,
 	}
 
@@ -29,6 +29,14 @@ func (m *MockTriageRepository) Create(ctx context.Context, req *models.CreateTri
 	return args.Get(0).(*models.TriageSession), args.Error(1)
 }
 
+func (m *MockTriageRepository) CreateWithVerdict(ctx context.Context, req *models.CreateTriageRequest, verdict *models.TriageVerdict, llmResponse map[string]interface{}) (*models.TriageSession, error) {
+	args := m.Called(ctx, req, verdict, llmResponse)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TriageSession), args.Error(1)
+}
+
 func (m *MockTriageRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.TriageSession, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -104,6 +112,51 @@ func TestCreateTriage(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Success - Red flag settled by rulebook", func(t *testing.T) {
+		mockRepo := new(MockTriageRepository)
+		handler := NewTriageHandler(mockRepo)
+
+		symptoms := map[string]interface{}{"fever": true, "convulsions": true}
+		sessionID := uuid.New()
+		level := models.TriageLevelRed
+		action := "Go to the nearest hospital now"
+		mockRepo.On("CreateWithVerdict", mock.Anything, mock.AnythingOfType("*models.CreateTriageRequest"),
+			mock.MatchedBy(func(v *models.TriageVerdict) bool { return v.TriageCode == "FEVER_DANGER_SIGNS" }), mock.Anything).
+			Return(&models.TriageSession{
+				ID:                sessionID,
+				Symptoms:          symptoms,
+				Channel:           "web",
+				Status:            models.TriageStatusCompleted,
+				TriageLevel:       &level,
+				RecommendedAction: &action,
+				CreatedAt:         time.Now(),
+				UpdatedAt:         time.Now(),
+			}, nil)
+
+		router := gin.New()
+		router.POST("/triage", handler.CreateTriage)
+
+		jsonBody, _ := json.Marshal(models.CreateTriageRequest{Symptoms: symptoms, Channel: "web"})
+		req, _ := http.NewRequest("POST", "/triage", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "completed", data["status"])
+		assert.Equal(t, "red", data["triage_level"])
+		assert.NotEmpty(t, data["recommended_action"])
+
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("Fail - Invalid request body", func(t *testing.T) {
 		mockRepo := new(MockTriageRepository)
 		handler := NewTriageHandler(mockRepo)
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/phone"
+)
+
+// Custom binding tags used by request models. They are registered when the
+// handlers package loads so every ShouldBind call, including in tests, knows them.
+//
+//	kephone: a Kenyan mobile number in any common format, see phone.Normalize
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		panic("handlers: gin binding does not use go-playground/validator")
+	}
+	if err := v.RegisterValidation("kephone", validateKenyanPhone); err != nil {
+		panic("handlers: " + err.Error())
+	}
+}
+
+func validateKenyanPhone(fl validator.FieldLevel) bool {
+	return phone.IsValid(fl.Field().String())
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestKenyanPhoneBinding(t *testing.T) {
+	for _, phone := range []string{"0712345678", "+254 712 345 678", "254110123456"} {
+		req := RegisterRequest{Phone: phone, Role: "chv"}
+		assert.NoError(t, binding.Validator.ValidateStruct(&req), phone)
+	}
+
+	for _, phone := range []string{"12345", "+255712345678", "0201234567"} {
+		req := RegisterRequest{Phone: phone, Role: "chv"}
+		assert.Error(t, binding.Validator.ValidateStruct(&req), phone)
+	}
+
+	// Optional on search
+	assert.NoError(t, binding.Validator.ValidateStruct(&models.SearchPatientsRequest{Name: "Wanjiku"}))
+	assert.Error(t, binding.Validator.ValidateStruct(&models.SearchPatientsRequest{Phone: "not a phone"}))
+}
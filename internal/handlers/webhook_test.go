@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookAuthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	request := func(target, header string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, target, nil)
+		if header != "" {
+			c.Request.Header.Set("Authorization", header)
+		}
+		return c
+	}
+
+	t.Run("Bearer only", func(t *testing.T) {
+		authorized := func(target, header string) bool {
+			return webhookAuthorized(request(target, header), "s3cret")
+		}
+
+		assert.True(t, authorized("/v1/transport/webhook", "Bearer s3cret"))
+		assert.False(t, authorized("/v1/transport/webhook", "Bearer wrong"))
+		assert.False(t, authorized("/v1/transport/webhook", "s3cret"))
+		assert.False(t, authorized("/v1/transport/webhook?token=s3cret", ""), "the query string is not accepted")
+	})
+
+	t.Run("SMS gateway", func(t *testing.T) {
+		authorized := func(target, header string) bool {
+			return smsWebhookAuthorized(request(target, header), "s3cret")
+		}
+
+		assert.True(t, authorized("/v1/sms/inbound", "Bearer s3cret"))
+		assert.True(t, authorized("/v1/sms/inbound?token=s3cret", ""))
+		assert.True(t, authorized("/v1/sms/inbound", "Basic YXQ6czNjcmV0"), "at:s3cret")
+		assert.False(t, authorized("/v1/sms/inbound?token=wrong", ""))
+		assert.False(t, authorized("/v1/sms/inbound?token=", ""))
+		assert.False(t, authorized("/v1/sms/inbound", "Basic YXQ6d3Jvbmc="), "at:wrong")
+		assert.False(t, authorized("/v1/sms/inbound", ""))
+	})
+}
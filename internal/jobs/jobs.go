@@ -0,0 +1,163 @@
+// Package jobs runs work in the background: jobs queued for as soon as
+// possible or for later, retried with backoff and dead-lettered when they
+// keep failing, and cron-style schedules enqueued by a single elected
+// instance. Jobs are kept in Postgres, or in RabbitMQ when AMQP is chosen.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	// StatusDead means the job used up its attempts, or failed permanently
+	StatusDead Status = "dead"
+)
+
+// DefaultMaxAttempts is how many times a job runs unless it asks otherwise
+const DefaultMaxAttempts = 5
+
+var (
+	ErrNotFound = errors.New("job not found")
+	// ErrNotDead is returned when retrying a job that has not been dead-lettered
+	ErrNotDead = errors.New("only dead jobs can be retried")
+	// ErrLeaseLost is returned when a worker reports on a job whose lease
+	// ran out and was taken by another worker; the report is discarded
+	ErrLeaseLost = errors.New("job lease was taken over by another worker")
+)
+
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      Status          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	UniqueKey   *string         `json:"unique_key,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedBy    *string         `json:"locked_by,omitempty"`
+	LastError   *string         `json:"last_error,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v interface{}) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("invalid %s payload: %w", j.Kind, err))
+	}
+	return nil
+}
+
+// NewJob describes a job to enqueue. A zero RunAt runs it as soon as a
+// worker is free. A job with the UniqueKey of one already enqueued is
+// dropped; only the Postgres queue honours it.
+type NewJob struct {
+	Kind        string
+	Payload     interface{}
+	RunAt       time.Time
+	MaxAttempts int
+	UniqueKey   string
+}
+
+func (n *NewJob) payload() (json.RawMessage, error) {
+	if n.Payload == nil {
+		return json.RawMessage(`{}`), nil
+	}
+	raw, err := json.Marshal(n.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", n.Kind, err)
+	}
+	return raw, nil
+}
+
+func (n *NewJob) maxAttempts() int {
+	if n.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return n.MaxAttempts
+}
+
+// Queue holds jobs until a worker takes them. Reserve counts an attempt and
+// hands the job to one worker only; the worker then reports the outcome
+// with Complete, RetryLater or DeadLetter.
+type Queue interface {
+	// Enqueue returns nil if the job's unique key was already enqueued
+	Enqueue(ctx context.Context, job *NewJob) (*Job, error)
+	// Reserve returns the next due job of one of the kinds, or nil if
+	// none is due
+	Reserve(ctx context.Context, worker string, kinds []string) (*Job, error)
+	Complete(ctx context.Context, job *Job) error
+	RetryLater(ctx context.Context, job *Job, cause error, at time.Time) error
+	DeadLetter(ctx context.Context, job *Job, cause error) error
+}
+
+// Inspector lets admins look at jobs and retry dead ones; PostgresQueue
+// satisfies it
+type Inspector interface {
+	List(ctx context.Context, req *ListJobsRequest) ([]*Job, error)
+	Get(ctx context.Context, id uuid.UUID) (*Job, error)
+	Stats(ctx context.Context) ([]*KindStats, error)
+	Retry(ctx context.Context, id uuid.UUID) (*Job, error)
+}
+
+type ListJobsRequest struct {
+	Status *Status `form:"status" binding:"omitempty,oneof=queued running succeeded dead"`
+	Kind   string  `form:"kind"`
+	Limit  int     `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// KindStats counts the jobs of one kind by status
+type KindStats struct {
+	Kind      string `json:"kind"`
+	Queued    int    `json:"queued"`
+	Running   int    `json:"running"`
+	Succeeded int    `json:"succeeded"`
+	Dead      int    `json:"dead"`
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error so the job is dead-lettered without retries,
+// e.g. for a payload that cannot be decoded
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped by Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Backoff is how long to wait before the next attempt after the given
+// number of failed attempts: 30s, 1m, 2m and so on, capped at an hour
+func Backoff(attempts int) time.Duration {
+	const base, limit = 30 * time.Second, time.Hour
+
+	delay := base
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		return limit
+	}
+	return delay
+}
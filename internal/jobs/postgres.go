@@ -0,0 +1,339 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultLease is how long a running job may go without finishing before
+// another worker assumes its worker died and takes it again. Leases are not
+// renewed, so handlers must finish well within it.
+const DefaultLease = 10 * time.Minute
+
+// PostgresQueue keeps jobs in the jobs table. Workers on any number of
+// instances share it; SKIP LOCKED stops them waiting on each other.
+type PostgresQueue struct {
+	db    *pgxpool.Pool
+	lease time.Duration
+}
+
+func NewPostgresQueue(db *pgxpool.Pool) *PostgresQueue {
+	return &PostgresQueue{db: db, lease: DefaultLease}
+}
+
+const jobColumns = `
+			id, kind, payload, status, attempts, max_attempts, unique_key, run_at,
+			locked_by, last_error, completed_at, created_at, updated_at`
+
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.UniqueKey,
+		&job.RunAt,
+		&job.LockedBy,
+		&job.LastError,
+		&job.CompletedAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (q *PostgresQueue) Enqueue(ctx context.Context, job *NewJob) (*Job, error) {
+	payload, err := job.payload()
+	if err != nil {
+		return nil, err
+	}
+
+	var runAt *time.Time
+	if !job.RunAt.IsZero() {
+		runAt = &job.RunAt
+	}
+	var uniqueKey *string
+	if job.UniqueKey != "" {
+		uniqueKey = &job.UniqueKey
+	}
+
+	query := `
+		INSERT INTO jobs (kind, payload, max_attempts, unique_key, run_at)
+		VALUES ($1, $2, $3, $4, COALESCE($5, CURRENT_TIMESTAMP))
+		ON CONFLICT (unique_key) WHERE unique_key IS NOT NULL DO NOTHING
+		RETURNING ` + jobColumns
+
+	created, err := scanJob(q.db.QueryRow(ctx, query, job.Kind, payload, job.maxAttempts(), uniqueKey, runAt))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error enqueueing %s job: %v", job.Kind, err)
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return created, nil
+}
+
+// Reserve takes the job that has been due longest, including one whose
+// worker let its lease run out
+func (q *PostgresQueue) Reserve(ctx context.Context, worker string, kinds []string) (*Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_by = $1, locked_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE kind = ANY($2)
+				AND ((status = 'queued' AND run_at <= CURRENT_TIMESTAMP)
+					OR (status = 'running' AND locked_at < CURRENT_TIMESTAMP - make_interval(secs => $3)))
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(q.db.QueryRow(ctx, query, worker, kinds, q.lease.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (q *PostgresQueue) Complete(ctx context.Context, job *Job) error {
+	err := q.finish(ctx, job, `status = 'succeeded', completed_at = CURRENT_TIMESTAMP`)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+func (q *PostgresQueue) RetryLater(ctx context.Context, job *Job, cause error, at time.Time) error {
+	err := q.finish(ctx, job, `status = 'queued', run_at = $4, last_error = $5`, at, cause.Error())
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	return nil
+}
+
+func (q *PostgresQueue) DeadLetter(ctx context.Context, job *Job, cause error) error {
+	err := q.finish(ctx, job, `status = 'dead', last_error = $4, completed_at = CURRENT_TIMESTAMP`, cause.Error())
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+	return nil
+}
+
+// finish releases a job with the given SET clause, whose placeholders start
+// at $4. It only applies while the job is still held by the reservation
+// that returned it: a worker whose lease ran out and was taken over gets
+// ErrLeaseLost instead of overwriting the new holder's outcome.
+func (q *PostgresQueue) finish(ctx context.Context, job *Job, set string, args ...interface{}) error {
+	tag, err := q.db.Exec(ctx, `
+		UPDATE jobs
+		SET `+set+`, locked_by = NULL, locked_at = NULL
+		WHERE id = $1 AND status = 'running' AND locked_by = $2 AND attempts = $3`,
+		append([]interface{}{job.ID, job.LockedBy, job.Attempts}, args...)...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// List returns the most recently created jobs first
+func (q *PostgresQueue) List(ctx context.Context, req *ListJobsRequest) ([]*Job, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE ($1::varchar IS NULL OR status = $1)
+			AND ($2 = '' OR kind = $2)
+		ORDER BY created_at DESC
+		LIMIT $3`
+
+	rows, err := q.db.Query(ctx, query, req.Status, req.Kind, limit)
+	if err != nil {
+		log.Printf("Error listing jobs: %v", err)
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (q *PostgresQueue) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	job, err := scanJob(q.db.QueryRow(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+func (q *PostgresQueue) Stats(ctx context.Context) ([]*KindStats, error) {
+	rows, err := q.db.Query(ctx, `
+		SELECT kind,
+			COUNT(*) FILTER (WHERE status = 'queued'),
+			COUNT(*) FILTER (WHERE status = 'running'),
+			COUNT(*) FILTER (WHERE status = 'succeeded'),
+			COUNT(*) FILTER (WHERE status = 'dead')
+		FROM jobs
+		GROUP BY kind
+		ORDER BY kind`)
+	if err != nil {
+		log.Printf("Error counting jobs: %v", err)
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []*KindStats{}
+	for rows.Next() {
+		var s KindStats
+		if err := rows.Scan(&s.Kind, &s.Queued, &s.Running, &s.Succeeded, &s.Dead); err != nil {
+			return nil, fmt.Errorf("failed to scan job stats: %w", err)
+		}
+		stats = append(stats, &s)
+	}
+
+	return stats, rows.Err()
+}
+
+// Retry gives a dead job a fresh set of attempts, starting now. The last
+// error is kept until the job next fails or succeeds.
+func (q *PostgresQueue) Retry(ctx context.Context, id uuid.UUID) (*Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'queued', attempts = 0, run_at = CURRENT_TIMESTAMP, completed_at = NULL
+		WHERE id = $1 AND status = 'dead'
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(q.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := q.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotDead
+	}
+	if err != nil {
+		log.Printf("Error retrying job %s: %v", id, err)
+		return nil, fmt.Errorf("failed to retry job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Prune deletes jobs that succeeded before the given time. Dead jobs are
+// kept until an admin retries them.
+func (q *PostgresQueue) Prune(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := q.db.Exec(ctx, `DELETE FROM jobs WHERE status = 'succeeded' AND completed_at < $1`, before)
+	if err != nil {
+		log.Printf("Error pruning jobs: %v", err)
+		return 0, fmt.Errorf("failed to prune jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// PostgresElector elects a leader among instances sharing a database by
+// holding a session advisory lock on a connection kept for the purpose.
+// The lock goes with the connection, so a leader that dies or loses the
+// database hands over without waiting for a timeout.
+type PostgresElector struct {
+	db  *pgxpool.Pool
+	key int64
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+}
+
+func NewPostgresElector(db *pgxpool.Pool, key int64) *PostgresElector {
+	return &PostgresElector{db: db, key: key}
+}
+
+// IsLeader reports whether this instance holds the lock, trying to take
+// it if nobody does
+func (e *PostgresElector) IsLeader(ctx context.Context) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		if err := e.conn.Ping(ctx); err == nil {
+			return true
+		}
+		log.Printf("Lost leadership: connection holding lock %d failed", e.key)
+		e.conn.Conn().Close(context.Background())
+		e.conn.Release()
+		e.conn = nil
+	}
+
+	conn, err := e.db.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error acquiring connection for leader election: %v", err)
+		return false
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, e.key).Scan(&acquired); err != nil || !acquired {
+		if err != nil {
+			log.Printf("Error taking leader lock %d: %v", e.key, err)
+		}
+		conn.Release()
+		return false
+	}
+
+	log.Printf("Took leadership with lock %d", e.key)
+	e.conn = conn
+	return true
+}
+
+// Resign gives up leadership, if held
+func (e *PostgresElector) Resign(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return
+	}
+	if _, err := e.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, e.key); err != nil {
+		// Closing the session releases the lock anyway
+		e.conn.Conn().Close(context.Background())
+	}
+	e.conn.Release()
+	e.conn = nil
+}
@@ -0,0 +1,203 @@
+//go:build integration
+
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+func TestPostgresQueue(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	queue := NewPostgresQueue(pool)
+
+	// A kind of its own keeps the test clear of other jobs in the database
+	kind := "test_" + uuid.NewString()[:8]
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM jobs WHERE kind = $1`, kind)
+	})
+
+	t.Run("Delayed jobs wait", func(t *testing.T) {
+		later, err := queue.Enqueue(ctx, &NewJob{Kind: kind, RunAt: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		assert.Equal(t, StatusQueued, later.Status)
+		assert.Equal(t, DefaultMaxAttempts, later.MaxAttempts)
+
+		job, err := queue.Reserve(ctx, "w1", []string{kind})
+		require.NoError(t, err)
+		assert.Nil(t, job)
+
+		_, err = pool.Exec(ctx, `DELETE FROM jobs WHERE id = $1`, later.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Unique keys are enqueued once", func(t *testing.T) {
+		key := kind + ":slot"
+		first, err := queue.Enqueue(ctx, &NewJob{Kind: kind, UniqueKey: key, RunAt: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		require.NotNil(t, first)
+
+		again, err := queue.Enqueue(ctx, &NewJob{Kind: kind, UniqueKey: key})
+		require.NoError(t, err)
+		assert.Nil(t, again)
+
+		_, err = pool.Exec(ctx, `DELETE FROM jobs WHERE id = $1`, first.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Concurrent workers never share a job", func(t *testing.T) {
+		const count = 20
+		for i := 0; i < count; i++ {
+			_, err := queue.Enqueue(ctx, &NewJob{Kind: kind, Payload: map[string]int{"n": i}})
+			require.NoError(t, err)
+		}
+
+		var mu sync.Mutex
+		seen := map[uuid.UUID]int{}
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					job, err := queue.Reserve(ctx, "w", []string{kind})
+					if err != nil || job == nil {
+						return
+					}
+					mu.Lock()
+					seen[job.ID]++
+					mu.Unlock()
+					queue.Complete(ctx, job)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, seen, count)
+		for id, n := range seen {
+			assert.Equal(t, 1, n, id)
+		}
+	})
+
+	t.Run("Retry, dead-letter and admin retry", func(t *testing.T) {
+		enqueued, err := queue.Enqueue(ctx, &NewJob{Kind: kind, MaxAttempts: 2})
+		require.NoError(t, err)
+
+		job, err := queue.Reserve(ctx, "w1", []string{kind})
+		require.NoError(t, err)
+		require.Equal(t, enqueued.ID, job.ID)
+		assert.Equal(t, 1, job.Attempts)
+		assert.Equal(t, "w1", *job.LockedBy)
+
+		require.NoError(t, queue.RetryLater(ctx, job, errors.New("gateway timeout"), time.Now().Add(-time.Second)))
+		job, err = queue.Reserve(ctx, "w2", []string{kind})
+		require.NoError(t, err)
+		assert.Equal(t, 2, job.Attempts)
+		assert.Equal(t, "gateway timeout", *job.LastError)
+
+		_, err = queue.Retry(ctx, job.ID)
+		assert.ErrorIs(t, err, ErrNotDead)
+
+		require.NoError(t, queue.DeadLetter(ctx, job, errors.New("gateway timeout")))
+		dead, err := queue.Get(ctx, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusDead, dead.Status)
+		assert.Nil(t, dead.LockedBy)
+
+		deadStatus := StatusDead
+		listed, err := queue.List(ctx, &ListJobsRequest{Status: &deadStatus, Kind: kind})
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+
+		retried, err := queue.Retry(ctx, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusQueued, retried.Status)
+		assert.Equal(t, 0, retried.Attempts)
+
+		_, err = queue.Retry(ctx, uuid.New())
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("An expired lease is taken over", func(t *testing.T) {
+		job, err := queue.Reserve(ctx, "crashed", []string{kind})
+		require.NoError(t, err)
+		require.NotNil(t, job)
+
+		_, err = pool.Exec(ctx, `UPDATE jobs SET locked_at = CURRENT_TIMESTAMP - interval '1 hour' WHERE id = $1`, job.ID)
+		require.NoError(t, err)
+
+		taken, err := queue.Reserve(ctx, "w1", []string{kind})
+		require.NoError(t, err)
+		require.NotNil(t, taken)
+		assert.Equal(t, job.ID, taken.ID)
+		assert.Equal(t, "w1", *taken.LockedBy)
+
+		// The crashed worker coming back cannot overwrite the new holder
+		assert.ErrorIs(t, queue.DeadLetter(ctx, job, errors.New("late failure")), ErrLeaseLost)
+		require.NoError(t, queue.Complete(ctx, taken))
+		assert.ErrorIs(t, queue.Complete(ctx, taken), ErrLeaseLost, "a job is finished once")
+
+		stored, err := queue.Get(ctx, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusSucceeded, stored.Status)
+	})
+
+	t.Run("Stats and prune", func(t *testing.T) {
+		stats, err := queue.Stats(ctx)
+		require.NoError(t, err)
+		var mine *KindStats
+		for _, s := range stats {
+			if s.Kind == kind {
+				mine = s
+			}
+		}
+		require.NotNil(t, mine)
+		assert.Equal(t, 21, mine.Succeeded)
+
+		_, err = queue.Prune(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		remaining, err := queue.List(ctx, &ListJobsRequest{Kind: kind})
+		require.NoError(t, err)
+		assert.Empty(t, remaining)
+	})
+}
+
+func TestPostgresElector(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	key := time.Now().UnixNano()
+
+	first, second := NewPostgresElector(pool, key), NewPostgresElector(pool, key)
+	assert.True(t, first.IsLeader(ctx))
+	assert.True(t, first.IsLeader(ctx), "leadership is kept")
+	assert.False(t, second.IsLeader(ctx))
+
+	first.Resign(ctx)
+	assert.True(t, second.IsLeader(ctx))
+	second.Resign(ctx)
+}
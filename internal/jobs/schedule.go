@@ -0,0 +1,226 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec says when a schedule fires
+type Spec interface {
+	// Next returns the first firing strictly after t
+	Next(t time.Time) time.Time
+}
+
+// ParseSpec reads a five-field cron expression (minute hour day-of-month
+// month day-of-week, with *, lists, ranges and steps), @hourly, @daily, or
+// "@every 5m". Cron times are read in loc; @every fires on multiples of
+// the interval, so every instance agrees on the slots.
+func ParseSpec(spec string, loc *time.Location) (Spec, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "@hourly":
+		spec = "0 * * * *"
+	case spec == "@daily":
+		spec = "0 0 * * *"
+	case strings.HasPrefix(spec, "@every "):
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || interval < time.Minute {
+			return nil, fmt.Errorf("invalid schedule %q: @every needs a duration of at least 1m", spec)
+		}
+		return everySpec(interval), nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: want 5 fields", spec)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	var c cronSpec
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		c.fields[i] = set
+	}
+	c.loc = loc
+	return &c, nil
+}
+
+type everySpec time.Duration
+
+func (e everySpec) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(e)).Add(time.Duration(e))
+}
+
+type cronSpec struct {
+	fields [5]map[int]bool
+	loc    *time.Location
+}
+
+// cronSearchLimit stops Next looking forever for a date that never comes,
+// such as 30 February
+const cronSearchLimit = 366 * 24 * 60
+
+func (c *cronSpec) Next(t time.Time) time.Time {
+	next := t.In(c.loc).Truncate(time.Minute).Add(time.Minute)
+	for i := 0; i < cronSearchLimit; i++ {
+		if c.fields[0][next.Minute()] && c.fields[1][next.Hour()] && c.fields[2][next.Day()] &&
+			c.fields[3][int(next.Month())] && c.fields[4][int(next.Weekday())] {
+			return next
+		}
+		next = next.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// parseCronField expands one cron field, such as "*/15" or "1-5,0", into
+// the values it matches
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, s, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad step in %q", part)
+			}
+			part, step = base, n
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			from, to, isRange := strings.Cut(part, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("bad value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return nil, fmt.Errorf("bad range %q", part)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// Elector decides which instance enqueues scheduled jobs; PostgresElector
+// satisfies it
+type Elector interface {
+	IsLeader(ctx context.Context) bool
+}
+
+// scheduleCatchUp is how far back a new leader looks for a firing the last
+// leader may have missed while handing over
+const scheduleCatchUp = time.Minute
+
+type schedule struct {
+	name string
+	spec Spec
+	job  NewJob
+	next time.Time
+}
+
+// Scheduler enqueues a job each time a schedule fires. Only the elected
+// leader enqueues; each firing carries a unique key, so a firing seen by
+// an outgoing and an incoming leader is still enqueued once.
+type Scheduler struct {
+	queue     Queue
+	elector   Elector
+	loc       *time.Location
+	schedules []*schedule
+	leading   bool
+	now       func() time.Time
+}
+
+// NewScheduler reads cron expressions in loc
+func NewScheduler(queue Queue, elector Elector, loc *time.Location) *Scheduler {
+	return &Scheduler{queue: queue, elector: elector, loc: loc, now: time.Now}
+}
+
+// Add registers a schedule under a name that must stay the same between
+// releases, since it keys the firings
+func (s *Scheduler) Add(name, spec string, job NewJob) error {
+	parsed, err := ParseSpec(spec, s.loc)
+	if err != nil {
+		return err
+	}
+	s.schedules = append(s.schedules, &schedule{name: name, spec: parsed, job: job})
+	return nil
+}
+
+// Run calls Tick every interval until the context is cancelled
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil {
+			log.Printf("Error enqueueing scheduled jobs: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick enqueues the schedules that have fired since the last tick, if this
+// instance leads. Firings missed in a long gap are collapsed into one.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	if !s.elector.IsLeader(ctx) {
+		s.leading = false
+		return nil
+	}
+
+	now := s.now()
+	if !s.leading {
+		for _, sched := range s.schedules {
+			sched.next = sched.spec.Next(now.Add(-scheduleCatchUp))
+		}
+		s.leading = true
+	}
+
+	for _, sched := range s.schedules {
+		if sched.next.IsZero() || sched.next.After(now) {
+			continue
+		}
+
+		due := sched.next
+		for {
+			following := sched.spec.Next(due)
+			if following.IsZero() || following.After(now) {
+				break
+			}
+			due = following
+		}
+
+		job := sched.job
+		job.RunAt = due
+		job.UniqueKey = fmt.Sprintf("schedule:%s:%s", sched.name, due.UTC().Format(time.RFC3339))
+		if _, err := s.queue.Enqueue(ctx, &job); err != nil {
+			return fmt.Errorf("failed to enqueue %s: %w", sched.name, err)
+		}
+		sched.next = sched.spec.Next(due)
+	}
+
+	return nil
+}
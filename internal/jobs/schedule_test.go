@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var nairobi = time.FixedZone("EAT", 3*60*60)
+
+func TestParseSpec(t *testing.T) {
+	from := time.Date(2026, 3, 2, 9, 7, 30, 0, nairobi) // a Monday
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 3, 2, 9, 15, 0, 0, nairobi)},
+		{"0 3 * * *", time.Date(2026, 3, 3, 3, 0, 0, 0, nairobi)},
+		{"30 8-17 * * 1-5", time.Date(2026, 3, 2, 9, 30, 0, 0, nairobi)},
+		{"0 9 * * 0,6", time.Date(2026, 3, 7, 9, 0, 0, 0, nairobi)},
+		{"0 0 1 */3 *", time.Date(2026, 4, 1, 0, 0, 0, 0, nairobi)},
+		{"@hourly", time.Date(2026, 3, 2, 10, 0, 0, 0, nairobi)},
+		{"@daily", time.Date(2026, 3, 3, 0, 0, 0, 0, nairobi)},
+		{"@every 5m", time.Date(2026, 3, 2, 9, 10, 0, 0, nairobi)},
+	}
+	for _, c := range cases {
+		spec, err := ParseSpec(c.spec, nairobi)
+		require.NoError(t, err, c.spec)
+		assert.True(t, c.want.Equal(spec.Next(from)), "%s: got %s", c.spec, spec.Next(from))
+	}
+
+	never, err := ParseSpec("0 0 30 2 *", nairobi)
+	require.NoError(t, err)
+	assert.True(t, never.Next(from).IsZero(), "30 February never comes")
+
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@every 10s", "@weekly"} {
+		_, err := ParseSpec(bad, nairobi)
+		assert.Error(t, err, bad)
+	}
+}
+
+type fakeElector struct {
+	leader bool
+}
+
+func (f *fakeElector) IsLeader(ctx context.Context) bool {
+	return f.leader
+}
+
+func TestScheduler(t *testing.T) {
+	queue := newFakeQueue()
+	elector := &fakeElector{}
+	scheduler := NewScheduler(queue, elector, nairobi)
+	require.NoError(t, scheduler.Add("reminders", "@every 5m", NewJob{Kind: "reminders", MaxAttempts: 1}))
+
+	now := time.Date(2026, 3, 2, 9, 0, 20, 0, nairobi)
+	scheduler.now = func() time.Time { return now }
+
+	t.Run("Followers enqueue nothing", func(t *testing.T) {
+		require.NoError(t, scheduler.Tick(context.Background()))
+		assert.Empty(t, queue.enqueued)
+	})
+
+	t.Run("A new leader catches up on a firing it may have missed", func(t *testing.T) {
+		elector.leader = true
+		require.NoError(t, scheduler.Tick(context.Background()))
+		require.Len(t, queue.enqueued, 1)
+		assert.Equal(t, "schedule:reminders:2026-03-02T06:00:00Z", queue.enqueued[0].UniqueKey)
+		assert.True(t, time.Date(2026, 3, 2, 9, 0, 0, 0, nairobi).Equal(queue.enqueued[0].RunAt))
+		assert.Equal(t, 1, queue.enqueued[0].MaxAttempts)
+
+		require.NoError(t, scheduler.Tick(context.Background()))
+		assert.Len(t, queue.enqueued, 1, "not due again yet")
+	})
+
+	t.Run("Missed firings are collapsed", func(t *testing.T) {
+		now = now.Add(17 * time.Minute)
+		require.NoError(t, scheduler.Tick(context.Background()))
+		require.Len(t, queue.enqueued, 2)
+		assert.True(t, time.Date(2026, 3, 2, 9, 15, 0, 0, nairobi).Equal(queue.enqueued[1].RunAt))
+	})
+}
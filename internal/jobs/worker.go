@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// jobTimeout bounds a single attempt. It is kept well inside DefaultLease
+// so a slow job is abandoned before another worker takes it again.
+const jobTimeout = 5 * time.Minute
+
+// Handler does the work for one kind of job. Returning an error retries the
+// job with backoff; wrap it with Permanent to dead-letter the job at once.
+type Handler func(ctx context.Context, job *Job) error
+
+// Worker takes jobs of the kinds it has handlers for and runs them
+type Worker struct {
+	queue    Queue
+	name     string
+	handlers map[string]Handler
+	kinds    []string
+	backoff  func(attempts int) time.Duration
+	now      func() time.Time
+}
+
+// NewWorker names the worker in the jobs it locks, so an admin can see
+// which instance ran a job
+func NewWorker(queue Queue, name string) *Worker {
+	return &Worker{queue: queue, name: name, handlers: map[string]Handler{}, backoff: Backoff, now: time.Now}
+}
+
+// Handle registers the handler for a kind of job. Call it before Run.
+func (w *Worker) Handle(kind string, handler Handler) {
+	if _, ok := w.handlers[kind]; !ok {
+		w.kinds = append(w.kinds, kind)
+	}
+	w.handlers[kind] = handler
+}
+
+// Run works through jobs on the given number of goroutines until the
+// context is cancelled, checking for new jobs every pollInterval when the
+// queue is empty
+func (w *Worker) Run(ctx context.Context, concurrency int, pollInterval time.Duration) {
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, pollInterval)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context, pollInterval time.Duration) {
+	for {
+		worked, err := w.Work(ctx)
+		if err != nil {
+			log.Printf("Error working jobs: %v", err)
+		}
+		if worked && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Work runs the next due job, if there is one, and records how it went. It
+// reports whether a job was run.
+func (w *Worker) Work(ctx context.Context) (bool, error) {
+	if ctx.Err() != nil {
+		return false, nil
+	}
+
+	job, err := w.queue.Reserve(ctx, w.name, w.kinds)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	runErr := w.run(ctx, job)
+	switch {
+	case runErr == nil:
+		err = w.queue.Complete(ctx, job)
+	case IsPermanent(runErr) || job.Attempts >= job.MaxAttempts:
+		log.Printf("Job %s (%s) dead after %d attempts: %v", job.ID, job.Kind, job.Attempts, runErr)
+		err = w.queue.DeadLetter(ctx, job, runErr)
+	default:
+		log.Printf("Job %s (%s) failed attempt %d: %v", job.ID, job.Kind, job.Attempts, runErr)
+		err = w.queue.RetryLater(ctx, job, runErr, w.now().Add(w.backoff(job.Attempts)))
+	}
+
+	return true, err
+}
+
+// run calls the job's handler, turning a panic into an error so one bad
+// job cannot stop the worker
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	handler, ok := w.handlers[job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for %s jobs", job.Kind))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	return handler(jobCtx, job)
+}
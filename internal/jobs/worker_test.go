@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueue struct {
+	enqueued []*NewJob
+	ready    []*Job
+	outcome  map[uuid.UUID]Status
+	retryAt  map[uuid.UUID]time.Time
+	errors   map[uuid.UUID]string
+}
+
+func newFakeQueue() *fakeQueue {
+	return &fakeQueue{outcome: map[uuid.UUID]Status{}, retryAt: map[uuid.UUID]time.Time{}, errors: map[uuid.UUID]string{}}
+}
+
+func (f *fakeQueue) Enqueue(ctx context.Context, job *NewJob) (*Job, error) {
+	f.enqueued = append(f.enqueued, job)
+	return &Job{ID: uuid.New(), Kind: job.Kind, Status: StatusQueued, MaxAttempts: job.maxAttempts()}, nil
+}
+
+func (f *fakeQueue) Reserve(ctx context.Context, worker string, kinds []string) (*Job, error) {
+	for i, job := range f.ready {
+		for _, kind := range kinds {
+			if job.Kind == kind {
+				f.ready = append(f.ready[:i], f.ready[i+1:]...)
+				job.Attempts++
+				job.Status = StatusRunning
+				return job, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeQueue) Complete(ctx context.Context, job *Job) error {
+	f.outcome[job.ID] = StatusSucceeded
+	return nil
+}
+
+func (f *fakeQueue) RetryLater(ctx context.Context, job *Job, cause error, at time.Time) error {
+	f.outcome[job.ID] = StatusQueued
+	f.retryAt[job.ID] = at
+	f.errors[job.ID] = cause.Error()
+	return nil
+}
+
+func (f *fakeQueue) DeadLetter(ctx context.Context, job *Job, cause error) error {
+	f.outcome[job.ID] = StatusDead
+	f.errors[job.ID] = cause.Error()
+	return nil
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 4*time.Minute, Backoff(4))
+	assert.Equal(t, time.Hour, Backoff(20))
+}
+
+func TestWorker(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	newWorker := func(queue *fakeQueue) *Worker {
+		worker := NewWorker(queue, "test-1")
+		worker.now = func() time.Time { return now }
+		worker.Handle("ok", func(ctx context.Context, job *Job) error { return nil })
+		worker.Handle("flaky", func(ctx context.Context, job *Job) error { return errors.New("gateway timeout") })
+		worker.Handle("bad_payload", func(ctx context.Context, job *Job) error {
+			var payload struct{ ID uuid.UUID }
+			return job.Decode(&payload)
+		})
+		worker.Handle("panics", func(ctx context.Context, job *Job) error { panic("nil map") })
+		return worker
+	}
+	job := func(kind string, attempts int) *Job {
+		return &Job{ID: uuid.New(), Kind: kind, Payload: []byte(`{"id": 7}`), Status: StatusQueued, Attempts: attempts, MaxAttempts: 3}
+	}
+
+	t.Run("Empty queue", func(t *testing.T) {
+		worked, err := newWorker(newFakeQueue()).Work(context.Background())
+		require.NoError(t, err)
+		assert.False(t, worked)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		queue := newFakeQueue()
+		ok := job("ok", 0)
+		queue.ready = []*Job{ok}
+
+		worked, err := newWorker(queue).Work(context.Background())
+		require.NoError(t, err)
+		assert.True(t, worked)
+		assert.Equal(t, StatusSucceeded, queue.outcome[ok.ID])
+	})
+
+	t.Run("Failure is retried with backoff", func(t *testing.T) {
+		queue := newFakeQueue()
+		flaky := job("flaky", 1)
+		queue.ready = []*Job{flaky}
+
+		_, err := newWorker(queue).Work(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, StatusQueued, queue.outcome[flaky.ID])
+		assert.Equal(t, now.Add(time.Minute), queue.retryAt[flaky.ID])
+		assert.Equal(t, "gateway timeout", queue.errors[flaky.ID])
+	})
+
+	t.Run("Last attempt is dead-lettered", func(t *testing.T) {
+		queue := newFakeQueue()
+		flaky := job("flaky", 2)
+		queue.ready = []*Job{flaky}
+
+		_, err := newWorker(queue).Work(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, StatusDead, queue.outcome[flaky.ID])
+	})
+
+	t.Run("Permanent failures and panics", func(t *testing.T) {
+		queue := newFakeQueue()
+		bad, panics := job("bad_payload", 0), job("panics", 0)
+		queue.ready = []*Job{bad, panics}
+		worker := newWorker(queue)
+
+		for i := 0; i < 2; i++ {
+			_, err := worker.Work(context.Background())
+			require.NoError(t, err)
+		}
+		assert.Equal(t, StatusDead, queue.outcome[bad.ID], "a bad payload is not retried")
+		assert.Contains(t, queue.errors[bad.ID], "invalid bad_payload payload")
+		assert.Equal(t, StatusQueued, queue.outcome[panics.ID])
+		assert.Equal(t, "panic: nil map", queue.errors[panics.ID])
+	})
+
+	t.Run("Only kinds with handlers are taken", func(t *testing.T) {
+		queue := newFakeQueue()
+		queue.ready = []*Job{job("unknown", 0)}
+
+		worked, err := newWorker(queue).Work(context.Background())
+		require.NoError(t, err)
+		assert.False(t, worked)
+	})
+}
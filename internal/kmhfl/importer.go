@@ -0,0 +1,206 @@
+package kmhfl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// Store is the persistence the importer needs; FacilityRepository satisfies it
+type Store interface {
+	GetByMFLCode(ctx context.Context, mflCode string) (*models.Facility, error)
+	UpsertByMFLCode(ctx context.Context, req *models.CreateFacilityRequest) (bool, error)
+}
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+)
+
+type Change struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type Diff struct {
+	Line    int      `json:"line"`
+	MFLCode string   `json:"mfl_code"`
+	Name    string   `json:"name"`
+	Action  string   `json:"action"`
+	Changes []Change `json:"changes,omitempty"`
+}
+
+type RowError struct {
+	Line    int    `json:"line"`
+	MFLCode string `json:"mfl_code,omitempty"`
+	Error   string `json:"error"`
+}
+
+type Report struct {
+	DryRun  bool       `json:"dry_run"`
+	Total   int        `json:"total"`
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	Skipped int        `json:"skipped"`
+	Invalid int        `json:"invalid"`
+	Diffs   []Diff     `json:"diffs"`
+	Errors  []RowError `json:"errors"`
+}
+
+type Importer struct {
+	store Store
+}
+
+func NewImporter(store Store) *Importer {
+	return &Importer{store: store}
+}
+
+// Import upserts rows by MFL code. In dry-run mode the report (including
+// diffs) is computed against the database but nothing is written.
+func (i *Importer) Import(ctx context.Context, rows []Row, dryRun bool) (*Report, error) {
+	report := &Report{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Diffs:  []Diff{},
+		Errors: []RowError{},
+	}
+	seen := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		req, err := MapRow(row)
+		if errors.Is(err, errSkip) {
+			report.Skipped++
+			continue
+		}
+		if err != nil {
+			report.Invalid++
+			report.Errors = append(report.Errors, RowError{Line: row.Line, MFLCode: row.Fields["code"], Error: err.Error()})
+			continue
+		}
+
+		code := *req.MFLCode
+		if firstLine, ok := seen[code]; ok {
+			report.Invalid++
+			report.Errors = append(report.Errors, RowError{
+				Line:    row.Line,
+				MFLCode: code,
+				Error:   fmt.Sprintf("duplicate MFL code, first seen on line %d", firstLine),
+			})
+			continue
+		}
+		seen[code] = row.Line
+
+		existing, err := i.store.GetByMFLCode(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+
+		diff := Diff{Line: row.Line, MFLCode: code, Name: req.Name, Action: ActionCreate}
+		if existing != nil {
+			diff.Action = ActionUpdate
+			diff.Changes = compareFacility(existing, req)
+			if len(diff.Changes) == 0 {
+				report.Skipped++
+				continue
+			}
+		}
+
+		if !dryRun {
+			if _, err := i.store.UpsertByMFLCode(ctx, req); err != nil {
+				report.Invalid++
+				report.Errors = append(report.Errors, RowError{Line: row.Line, MFLCode: code, Error: err.Error()})
+				continue
+			}
+		}
+
+		if diff.Action == ActionCreate {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+		report.Diffs = append(report.Diffs, diff)
+	}
+
+	return report, nil
+}
+
+// compareFacility lists the registry-owned fields that an import would change.
+// Like UpsertByMFLCode, it ignores fields the row leaves empty, and the
+// coordinates unless the row has both.
+func compareFacility(existing *models.Facility, req *models.CreateFacilityRequest) []Change {
+	var changes []Change
+	add := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, Change{Field: field, From: from, To: to})
+		}
+	}
+
+	add("name", existing.Name, req.Name)
+	add("type", string(existing.Type), string(req.Type))
+	if req.Level != nil {
+		add("level", formatInt(existing.Level), formatInt(req.Level))
+	}
+	if req.County != nil {
+		add("county", formatString(existing.County), formatString(req.County))
+	}
+	if req.SubCounty != nil {
+		add("sub_county", formatString(existing.SubCounty), formatString(req.SubCounty))
+	}
+	if req.Latitude != nil && req.Longitude != nil {
+		add("latitude", formatCoordinate(existing.Latitude), formatCoordinate(req.Latitude))
+		add("longitude", formatCoordinate(existing.Longitude), formatCoordinate(req.Longitude))
+	}
+
+	return changes
+}
+
+func formatString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func formatInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *v)
+}
+
+func formatCoordinate(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.6f", *v)
+}
+
+// WriteText prints a human-readable report, used by the facility-import command
+func (r *Report) WriteText(w io.Writer) {
+	for _, diff := range r.Diffs {
+		switch diff.Action {
+		case ActionCreate:
+			fmt.Fprintf(w, "+ [%s] %s\n", diff.MFLCode, diff.Name)
+		case ActionUpdate:
+			fmt.Fprintf(w, "~ [%s] %s\n", diff.MFLCode, diff.Name)
+			for _, change := range diff.Changes {
+				fmt.Fprintf(w, "    %s: %q -> %q\n", change.Field, change.From, change.To)
+			}
+		}
+	}
+
+	for _, rowErr := range r.Errors {
+		fmt.Fprintf(w, "! line %d [%s]: %s\n", rowErr.Line, rowErr.MFLCode, rowErr.Error)
+	}
+
+	mode := "applied"
+	if r.DryRun {
+		mode = "dry run, nothing written"
+	}
+	fmt.Fprintf(w, "\n%d rows (%s): %d created, %d updated, %d skipped, %d invalid\n",
+		r.Total, mode, r.Created, r.Updated, r.Skipped, r.Invalid)
+}
@@ -0,0 +1,283 @@
+package kmhfl
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+const sampleCSV = `Code,Officialname,Facility type,Keph level,County,Sub county,Latitude,Longitude,Operation status
+12345,Kamulu Health Centre,Health Centre,Level 3,MACHAKOS,KANGUNDO,-1.2345,37.1234,Operational
+12346,Makueni County Referral Hospital,County Referral Hospital,Level 5,MAKUENI,MAKUENI,-1.8345,37.6234,Operational
+12347,Old Dispensary,Dispensary,Level 2,MAKUENI,KIBWEZI,-2.4,37.9,Closed
+12348,Bad Coordinates Clinic,Medical Clinic,Level 2,NAIROBI,WESTLANDS,36.8,-1.26,Operational
+,No Code,Dispensary,Level 2,NAIROBI,WESTLANDS,,,Operational
+`
+
+func TestReadCSV(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader(sampleCSV))
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, "12345", rows[0].Fields["code"])
+	assert.Equal(t, "Kamulu Health Centre", rows[0].Fields["name"])
+	assert.Equal(t, "Level 3", rows[0].Fields["keph_level"])
+	assert.Equal(t, "KANGUNDO", rows[0].Fields["sub_county"])
+}
+
+func TestReadXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, _ := zw.Create("xl/sharedStrings.xml")
+	w.Write([]byte(`<sst><si><t>Code</t></si><si><t>Name</t></si><si><r><t>Kamulu </t></r><r><t>Health Centre</t></r></si></sst>`))
+
+	// The second data row leaves column A empty, so B2 must land in column 1
+	w, _ = zw.Create("xl/worksheets/sheet1.xml")
+	w.Write([]byte(`<worksheet><sheetData>
+		<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+		<row r="2"><c r="A2"><v>12345</v></c><c r="B2" t="s"><v>2</v></c></row>
+		<row r="3"><c r="B3" t="inlineStr"><is><t>No Code</t></is></c></row>
+	</sheetData></worksheet>`))
+	require.NoError(t, zw.Close())
+
+	rows, err := ReadXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "12345", rows[0].Fields["code"])
+	assert.Equal(t, "Kamulu Health Centre", rows[0].Fields["name"])
+	assert.Equal(t, "", rows[1].Fields["code"])
+	assert.Equal(t, "No Code", rows[1].Fields["name"])
+}
+
+func TestReadXLSXFirstSheet(t *testing.T) {
+	build := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range files {
+			w, _ := zw.Create(name)
+			w.Write([]byte(content))
+		}
+		require.NoError(t, zw.Close())
+		return buf.Bytes()
+	}
+
+	// After reordering, the first sheet is stored as sheet2.xml
+	workbook := `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>
+		<sheet name="Facilities" sheetId="2" r:id="rId2"/>
+		<sheet name="Notes" sheetId="1" r:id="rId1"/>
+	</sheets></workbook>`
+	rels := `<Relationships>
+		<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>
+		<Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/>
+	</Relationships>`
+
+	t.Run("Follows the workbook", func(t *testing.T) {
+		data := build(map[string]string{
+			"xl/workbook.xml":            workbook,
+			"xl/_rels/workbook.xml.rels": rels,
+			"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Notes</t></is></c></row></sheetData></worksheet>`,
+			"xl/worksheets/sheet2.xml": `<worksheet><sheetData>
+				<row r="1"><c r="A1" t="inlineStr"><is><t>Code</t></is></c></row>
+				<row r="2"><c r="A2"><v>12345</v></c></row>
+			</sheetData></worksheet>`,
+		})
+
+		rows, err := ReadXLSX(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, "12345", rows[0].Fields["code"])
+	})
+
+	t.Run("Missing worksheet", func(t *testing.T) {
+		data := build(map[string]string{
+			"xl/workbook.xml":            workbook,
+			"xl/_rels/workbook.xml.rels": rels,
+			"xl/worksheets/sheet1.xml":   `<worksheet><sheetData/></worksheet>`,
+		})
+
+		_, err := ReadXLSX(bytes.NewReader(data), int64(len(data)))
+		assert.ErrorContains(t, err, "xl/worksheets/sheet2.xml")
+	})
+}
+
+func TestReadFileUnsupported(t *testing.T) {
+	_, err := ReadFile("facilities.json", []byte("{}"))
+	assert.Error(t, err)
+}
+
+func TestMapFacilityType(t *testing.T) {
+	tests := []struct {
+		kmhflType string
+		level     int
+		want      models.FacilityType
+	}{
+		{"Dispensary", 2, models.FacilityTypeDispensary},
+		{"Health Centre", 3, models.FacilityTypeHealthCenter},
+		{"Sub-District Hospital", 4, models.FacilityTypeSubCountyHospital},
+		{"Primary care hospitals", 4, models.FacilityTypeSubCountyHospital},
+		{"District Hospital", 4, models.FacilityTypeCountyHospital},
+		{"Comprehensive Teaching & Tertiary Referral Hospital", 6, models.FacilityTypeCountyHospital},
+		{"Medical Clinic", 2, models.FacilityTypePrivateClinic},
+		{"Nursing Home", 3, models.FacilityTypePrivateClinic},
+		{"Hospitals", 5, models.FacilityTypeCountyHospital},
+		{"", 3, models.FacilityTypeHealthCenter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kmhflType, func(t *testing.T) {
+			got, ok := MapFacilityType(tt.kmhflType, tt.level)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, ok := MapFacilityType("Unknown", 0)
+	assert.False(t, ok)
+}
+
+func TestParseKEPHLevel(t *testing.T) {
+	level, ok := ParseKEPHLevel("Level 4")
+	assert.True(t, ok)
+	assert.Equal(t, 4, level)
+
+	_, ok = ParseKEPHLevel("")
+	assert.False(t, ok)
+}
+
+func TestMapRow(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader(sampleCSV))
+	require.NoError(t, err)
+
+	req, err := MapRow(rows[0])
+	require.NoError(t, err)
+	assert.Equal(t, "12345", *req.MFLCode)
+	assert.Equal(t, models.FacilityTypeHealthCenter, req.Type)
+	assert.Equal(t, 3, *req.Level)
+	assert.Equal(t, "Machakos", *req.County)
+	assert.Equal(t, "Kangundo", *req.SubCounty)
+	assert.InDelta(t, -1.2345, *req.Latitude, 1e-9)
+
+	_, err = MapRow(rows[2])
+	assert.ErrorIs(t, err, errSkip)
+
+	_, err = MapRow(rows[3])
+	assert.Error(t, err)
+
+	_, err = MapRow(rows[4])
+	assert.Error(t, err)
+}
+
+type fakeStore struct {
+	facilities map[string]*models.Facility
+	upserts    []string
+}
+
+func (s *fakeStore) GetByMFLCode(ctx context.Context, mflCode string) (*models.Facility, error) {
+	return s.facilities[mflCode], nil
+}
+
+func (s *fakeStore) UpsertByMFLCode(ctx context.Context, req *models.CreateFacilityRequest) (bool, error) {
+	s.upserts = append(s.upserts, *req.MFLCode)
+	_, exists := s.facilities[*req.MFLCode]
+	return !exists, nil
+}
+
+func TestImport(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader(sampleCSV + "12345,Kamulu Health Centre,Health Centre,Level 3,MACHAKOS,KANGUNDO,-1.2345,37.1234,Operational\n"))
+	require.NoError(t, err)
+
+	county := "Makueni"
+	subCounty := "Makueni"
+	level := 4
+	lat, lng := -1.8345, 37.6234
+
+	newStore := func() *fakeStore {
+		return &fakeStore{facilities: map[string]*models.Facility{
+			"12346": {
+				Name:      "Makueni County Hospital",
+				Type:      models.FacilityTypeCountyHospital,
+				Level:     &level,
+				County:    &county,
+				SubCounty: &subCounty,
+				Latitude:  &lat,
+				Longitude: &lng,
+			},
+		}}
+	}
+
+	t.Run("Dry run writes nothing", func(t *testing.T) {
+		store := newStore()
+		report, err := NewImporter(store).Import(context.Background(), rows, true)
+		require.NoError(t, err)
+
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 6, report.Total)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		assert.Equal(t, 1, report.Skipped)
+		assert.Equal(t, 3, report.Invalid)
+		assert.Empty(t, store.upserts)
+
+		require.Len(t, report.Diffs, 2)
+		update := report.Diffs[1]
+		assert.Equal(t, ActionUpdate, update.Action)
+		assert.ElementsMatch(t, []Change{
+			{Field: "name", From: "Makueni County Hospital", To: "Makueni County Referral Hospital"},
+			{Field: "level", From: "4", To: "5"},
+		}, update.Changes)
+
+		var out bytes.Buffer
+		report.WriteText(&out)
+		assert.Contains(t, out.String(), "+ [12345] Kamulu Health Centre")
+		assert.Contains(t, out.String(), "dry run, nothing written")
+	})
+
+	t.Run("Apply upserts changed rows", func(t *testing.T) {
+		store := newStore()
+		report, err := NewImporter(store).Import(context.Background(), rows, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"12345", "12346"}, store.upserts)
+		assert.Equal(t, 3, report.Invalid)
+	})
+}
+
+func TestCompareFacilityIgnoresMissingFields(t *testing.T) {
+	county := "Machakos"
+	level := 3
+	lat, lng := -1.2345, 37.1234
+	existing := &models.Facility{
+		Name:      "Kamulu Health Centre",
+		Type:      models.FacilityTypeHealthCenter,
+		Level:     &level,
+		County:    &county,
+		Latitude:  &lat,
+		Longitude: &lng,
+	}
+
+	// The row has no county and only a latitude, which the upsert would keep
+	otherLat := -1.3
+	req := &models.CreateFacilityRequest{
+		Name:     "Kamulu Health Centre",
+		Type:     models.FacilityTypeHealthCenter,
+		Level:    &level,
+		Latitude: &otherLat,
+	}
+	assert.Empty(t, compareFacility(existing, req))
+
+	otherLng := 37.2
+	req.Longitude = &otherLng
+	assert.ElementsMatch(t, []Change{
+		{Field: "latitude", From: "-1.234500", To: "-1.300000"},
+		{Field: "longitude", From: "37.123400", To: "37.200000"},
+	}, compareFacility(existing, req))
+}
@@ -0,0 +1,199 @@
+package kmhfl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// errSkip marks rows that are valid KMHFL data but not importable as referral
+// facilities (closed facilities, community units)
+var errSkip = errors.New("skipped")
+
+// headerAliases maps the column names used across KMHFL export versions onto
+// the keys MapRow understands
+var headerAliases = map[string]string{
+	"code":                   "code",
+	"mfl_code":               "code",
+	"facility_code":          "code",
+	"name":                   "name",
+	"officialname":           "name",
+	"official_name":          "name",
+	"facility_name":          "name",
+	"facility_type":          "facility_type",
+	"facility_type_name":     "facility_type",
+	"facility_type_category": "facility_type",
+	"type":                   "facility_type",
+	"keph_level":             "keph_level",
+	"keph_level_name":        "keph_level",
+	"level":                  "keph_level",
+	"county":                 "county",
+	"county_name":            "county",
+	"sub_county":             "sub_county",
+	"subcounty":              "sub_county",
+	"sub_county_name":        "sub_county",
+	"latitude":               "latitude",
+	"lat":                    "latitude",
+	"longitude":              "longitude",
+	"long":                   "longitude",
+	"lng":                    "longitude",
+	"operation_status":       "operation_status",
+	"operation_status_name":  "operation_status",
+	"status":                 "operation_status",
+}
+
+var nonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+func normalizeHeader(name string) string {
+	key := strings.Trim(nonAlnum.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "_"), "_")
+	if alias, ok := headerAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+var kephLevelPattern = regexp.MustCompile(`(\d)`)
+
+// ParseKEPHLevel extracts the numeric level from values like "Level 3"
+func ParseKEPHLevel(value string) (int, bool) {
+	match := kephLevelPattern.FindString(value)
+	if match == "" {
+		return 0, false
+	}
+	level, _ := strconv.Atoi(match)
+	return level, true
+}
+
+// MapFacilityType maps a KMHFL facility type name onto models.FacilityType,
+// falling back to the KEPH level when the name is not recognised
+func MapFacilityType(kmhflType string, level int) (models.FacilityType, bool) {
+	t := strings.ToLower(kmhflType)
+
+	switch {
+	case strings.Contains(t, "dispensar"):
+		return models.FacilityTypeDispensary, true
+	case strings.Contains(t, "health cent"):
+		return models.FacilityTypeHealthCenter, true
+	// Check sub-district names before the broader "district" match
+	case strings.Contains(t, "sub-district"), strings.Contains(t, "sub district"),
+		strings.Contains(t, "sub-county"), strings.Contains(t, "sub county"),
+		strings.Contains(t, "primary care hospital"):
+		return models.FacilityTypeSubCountyHospital, true
+	case strings.Contains(t, "district hospital"), strings.Contains(t, "county referral"),
+		strings.Contains(t, "county hospital"), strings.Contains(t, "secondary care"),
+		strings.Contains(t, "provincial"), strings.Contains(t, "teaching"),
+		strings.Contains(t, "tertiary"), strings.Contains(t, "national referral"):
+		return models.FacilityTypeCountyHospital, true
+	case strings.Contains(t, "clinic"), strings.Contains(t, "medical cent"),
+		strings.Contains(t, "nursing home"), strings.Contains(t, "maternity home"):
+		return models.FacilityTypePrivateClinic, true
+	}
+
+	switch {
+	case level >= 5:
+		return models.FacilityTypeCountyHospital, true
+	case level == 4:
+		return models.FacilityTypeSubCountyHospital, true
+	case level == 3:
+		return models.FacilityTypeHealthCenter, true
+	case level == 2:
+		return models.FacilityTypeDispensary, true
+	}
+
+	return "", false
+}
+
+// MapRow converts a KMHFL row into a facility create request. It returns
+// errSkip for rows that should be ignored rather than reported as invalid.
+func MapRow(row Row) (*models.CreateFacilityRequest, error) {
+	code := row.Fields["code"]
+	if code == "" {
+		return nil, fmt.Errorf("missing MFL code")
+	}
+	if _, err := strconv.Atoi(code); err != nil {
+		return nil, fmt.Errorf("MFL code %q is not numeric", code)
+	}
+
+	name := row.Fields["name"]
+	if name == "" {
+		return nil, fmt.Errorf("missing facility name")
+	}
+
+	if status := strings.ToLower(row.Fields["operation_status"]); strings.Contains(status, "closed") {
+		return nil, errSkip
+	}
+
+	req := &models.CreateFacilityRequest{
+		MFLCode: &code,
+		Name:    name,
+	}
+
+	level, hasLevel := ParseKEPHLevel(row.Fields["keph_level"])
+	if hasLevel {
+		// Level 1 is the community tier, which has no physical facility to refer to
+		if level == 1 {
+			return nil, errSkip
+		}
+		req.Level = &level
+	}
+
+	facilityType, ok := MapFacilityType(row.Fields["facility_type"], level)
+	if !ok {
+		return nil, fmt.Errorf("unrecognised facility type %q", row.Fields["facility_type"])
+	}
+	req.Type = facilityType
+
+	if county := titleCase(row.Fields["county"]); county != "" {
+		req.County = &county
+	}
+	if subCounty := titleCase(row.Fields["sub_county"]); subCounty != "" {
+		req.SubCounty = &subCounty
+	}
+
+	lat, hasLat, err := parseCoordinate(row.Fields["latitude"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude: %w", err)
+	}
+	lng, hasLng, err := parseCoordinate(row.Fields["longitude"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude: %w", err)
+	}
+	if hasLat && hasLng {
+		req.Latitude = &lat
+		req.Longitude = &lng
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// parseCoordinate treats blanks and the 0 placeholder used in KMHFL as missing
+func parseCoordinate(value string) (float64, bool, error) {
+	if value == "" {
+		return 0, false, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	if f == 0 {
+		return 0, false, nil
+	}
+	return f, true, nil
+}
+
+// titleCase turns registry values like "MACHAKOS" or "kangundo east" into "Machakos" / "Kangundo East"
+func titleCase(value string) string {
+	words := strings.Fields(strings.ToLower(value))
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
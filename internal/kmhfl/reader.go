@@ -0,0 +1,285 @@
+package kmhfl
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Row is one line of a KMHFL export keyed by normalized header name
+type Row struct {
+	Line   int
+	Fields map[string]string
+}
+
+// ReadFile parses a KMHFL export, choosing the format from the file name
+func ReadFile(name string, data []byte) ([]Row, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return ReadCSV(bytes.NewReader(data))
+	case ".xlsx":
+		return ReadXLSX(bytes.NewReader(data), int64(len(data)))
+	default:
+		return nil, fmt.Errorf("unsupported file type %q: expected .csv or .xlsx", filepath.Ext(name))
+	}
+}
+
+// ReadCSV parses a KMHFL CSV export. The first record must be the header.
+func ReadCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+
+	return buildRows(records)
+}
+
+// ReadXLSX parses the first worksheet of a KMHFL Excel export
+func ReadXLSX(r io.ReaderAt, size int64) ([]Row, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var sharedStrings []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if sharedStrings, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	sheet, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("xlsx first worksheet %s is missing", sheetPath)
+	}
+
+	records, err := readSheet(sheet, sharedStrings)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildRows(records)
+}
+
+func buildRows(records [][]string) ([]Row, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+
+	header := make([]string, len(records[0]))
+	for i, name := range records[0] {
+		header[i] = normalizeHeader(name)
+	}
+
+	rows := make([]Row, 0, len(records)-1)
+	for i, record := range records[1:] {
+		fields := make(map[string]string, len(header))
+		empty := true
+		for j, value := range record {
+			if j >= len(header) || header[j] == "" {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			if value != "" {
+				empty = false
+			}
+			fields[header[j]] = value
+		}
+		if empty {
+			continue
+		}
+		// Line numbers are 1-based and count the header
+		rows = append(rows, Row{Line: i + 2, Fields: fields})
+	}
+
+	return rows, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name  string `xml:"name,attr"`
+		RelID string `xml:"id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// firstSheetPath finds the archive path of the workbook's first sheet. Sheets
+// are not always stored as sheet1.xml (Excel keeps the original file when
+// sheets are reordered or deleted), so the workbook and its relationships
+// are followed. Archives without a workbook fall back to sheet1.xml.
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	workbookFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeXML(workbookFile, &workbook); err != nil {
+		return "", fmt.Errorf("failed to parse workbook: %w", err)
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("xlsx workbook has no sheets")
+	}
+	first := workbook.Sheets[0]
+
+	relsFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return "", fmt.Errorf("xlsx has no workbook relationships")
+	}
+	var rels xlsxRelationships
+	if err := decodeXML(relsFile, &rels); err != nil {
+		return "", fmt.Errorf("failed to parse workbook relationships: %w", err)
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != first.RelID {
+			continue
+		}
+		// Targets are relative to xl/ unless they start with a slash
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("xlsx sheet %q has no worksheet relationship", first.Name)
+}
+
+func decodeXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shared strings: %w", err)
+	}
+	defer rc.Close()
+
+	var sst xlsxSharedStrings
+	if err := xml.NewDecoder(rc).Decode(&sst); err != nil {
+		return nil, fmt.Errorf("failed to parse shared strings: %w", err)
+	}
+
+	strs := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		if len(item.Runs) == 0 {
+			strs[i] = item.Text
+			continue
+		}
+		// Rich text is split into runs
+		var sb strings.Builder
+		for _, run := range item.Runs {
+			sb.WriteString(run.Text)
+		}
+		strs[i] = sb.String()
+	}
+
+	return strs, nil
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref       string `xml:"r,attr"`
+			Type      string `xml:"t,attr"`
+			Value     string `xml:"v"`
+			InlineStr string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readSheet(f *zip.File, sharedStrings []string) ([][]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worksheet: %w", err)
+	}
+	defer rc.Close()
+
+	var sheet xlsxSheet
+	if err := xml.NewDecoder(rc).Decode(&sheet); err != nil {
+		return nil, fmt.Errorf("failed to parse worksheet: %w", err)
+	}
+
+	records := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var record []string
+		for i, cell := range row.Cells {
+			// Empty cells are omitted from the XML, so place values by reference
+			col := i
+			if cell.Ref != "" {
+				col = columnIndex(cell.Ref)
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err != nil || idx < 0 || idx >= len(sharedStrings) {
+					return nil, fmt.Errorf("invalid shared string reference in cell %s", cell.Ref)
+				}
+				record[col] = sharedStrings[idx]
+			case "inlineStr":
+				record[col] = cell.InlineStr
+			default:
+				record[col] = cell.Value
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// columnIndex converts a cell reference such as "AB12" to a zero-based column
+func columnIndex(ref string) int {
+	col := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+	}
+	return col - 1
+}
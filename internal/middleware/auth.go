@@ -1,15 +1,26 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/services"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/response"
 )
 
-func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
+const (
+	AuthTypeSession = "session"
+	AuthTypeAPIKey  = "api_key"
+)
+
+// AuthMiddleware accepts either a user session ("Bearer <token>") or a
+// machine credential ("ApiKey <key>")
+func AuthMiddleware(authService *services.AuthService, apiKeyService *services.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -18,14 +29,39 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		// Extract token from "Bearer <token>" format
+		// Extract credential from "<scheme> <credential>" format
 		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			response.Error(c, http.StatusUnauthorized, "INVALID_TOKEN_FORMAT", "Authorization header must be in format: Bearer <token>")
+		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "ApiKey") {
+			response.Error(c, http.StatusUnauthorized, "INVALID_TOKEN_FORMAT", "Authorization header must be in format: Bearer <token> or ApiKey <key>")
 			c.Abort()
 			return
 		}
 
+		if parts[0] == "ApiKey" {
+			if apiKeyService == nil {
+				response.Error(c, http.StatusUnauthorized, "INVALID_TOKEN_FORMAT", "API keys are not accepted on this route")
+				c.Abort()
+				return
+			}
+
+			apiKey, err := apiKeyService.Validate(c.Request.Context(), parts[1])
+			if err != nil {
+				response.Error(c, http.StatusUnauthorized, "INVALID_API_KEY", err.Error())
+				c.Abort()
+				return
+			}
+
+			// Store API key in context
+			c.Set("auth_type", AuthTypeAPIKey)
+			c.Set("api_key", apiKey)
+			if apiKey.FacilityID != nil {
+				c.Set("facility_id", *apiKey.FacilityID)
+			}
+
+			c.Next()
+			return
+		}
+
 		sessionToken := parts[1]
 
 		// Validate session
@@ -37,6 +73,7 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 		}
 
 		// Store user in context
+		c.Set("auth_type", AuthTypeSession)
 		c.Set("user", user)
 		c.Set("user_id", user.ID)
 		c.Set("user_role", user.Role)
@@ -55,7 +92,7 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 			return
 		}
 
-		role := string(userRole.(string))
+		role := fmt.Sprint(userRole)
 		for _, allowedRole := range allowedRoles {
 			if role == allowedRole {
 				c.Next()
@@ -66,4 +103,109 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		response.Error(c, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions")
 		c.Abort()
 	}
-}
\ No newline at end of file
+}
+
+// SessionRoleMiddleware restricts user sessions to the given roles. API key
+// callers are passed through; their access is governed by ScopeMiddleware.
+func SessionRoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
+	requireRole := RoleMiddleware(allowedRoles...)
+	return func(c *gin.Context) {
+		if c.GetString("auth_type") == AuthTypeAPIKey {
+			c.Next()
+			return
+		}
+		requireRole(c)
+	}
+}
+
+// ScopeMiddleware requires API key callers to hold the given scope. User
+// sessions are passed through; their access is governed by RoleMiddleware.
+func ScopeMiddleware(scope models.APIKeyScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("api_key")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		apiKey, ok := value.(*models.APIKey)
+		if !ok || !apiKey.HasScope(scope) {
+			response.Error(c, http.StatusForbidden, "INSUFFICIENT_SCOPE", "API key lacks required scope: "+string(scope))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SessionOnlyMiddleware rejects API key callers on routes that act on behalf of a person
+func SessionOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_type") != AuthTypeSession {
+			response.Error(c, http.StatusForbidden, "SESSION_REQUIRED", "This endpoint requires a user session")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ClinicianLookup resolves the clinician record linked to a user account
+type ClinicianLookup interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Clinician, error)
+}
+
+// CanActForFacility reports whether the caller may act on behalf of a facility:
+// admins, clinicians assigned to it, and API keys that are either bound to it
+// or not bound to any facility
+func CanActForFacility(c *gin.Context, clinicians ClinicianLookup, facilityID uuid.UUID) bool {
+	if c.GetString("auth_type") == AuthTypeAPIKey {
+		boundTo, bound := c.Get("facility_id")
+		return !bound || boundTo.(uuid.UUID) == facilityID
+	}
+
+	value, exists := c.Get("user")
+	if !exists {
+		return false
+	}
+	user, ok := value.(*models.User)
+	if !ok {
+		return false
+	}
+
+	switch user.Role {
+	case models.UserRoleAdmin:
+		return true
+	case models.UserRoleClinician:
+		if user.ClinicianID == nil {
+			return false
+		}
+		clinician, err := clinicians.GetByID(c.Request.Context(), *user.ClinicianID)
+		return err == nil && clinician.IsActive && clinician.FacilityID != nil && *clinician.FacilityID == facilityID
+	}
+
+	return false
+}
+
+// FacilityMemberMiddleware restricts a route to callers who can act for the
+// facility named by the given URL parameter
+func FacilityMemberMiddleware(clinicians ClinicianLookup, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		facilityID, err := uuid.Parse(c.Param(param))
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "INVALID_ID", "Invalid facility ID")
+			c.Abort()
+			return
+		}
+
+		if !CanActForFacility(c, clinicians, facilityID) {
+			response.Error(c, http.StatusForbidden, "FACILITY_ACCESS_DENIED", "You cannot act on behalf of this facility")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
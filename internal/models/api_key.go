@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyScope string
+
+const (
+	APIKeyScopeReferralsRead   APIKeyScope = "referrals:read"
+	APIKeyScopeReferralsWrite  APIKeyScope = "referrals:write"
+	APIKeyScopeOutcomesWrite   APIKeyScope = "outcomes:write"
+	APIKeyScopeFacilitiesRead  APIKeyScope = "facilities:read"
+	APIKeyScopeFacilitiesWrite APIKeyScope = "facilities:write"
+	APIKeyScopePatientsRead    APIKeyScope = "patients:read"
+)
+
+// ValidAPIKeyScopes lists every scope an API key can be granted
+var ValidAPIKeyScopes = []APIKeyScope{
+	APIKeyScopeReferralsRead,
+	APIKeyScopeReferralsWrite,
+	APIKeyScopeOutcomesWrite,
+	APIKeyScopeFacilitiesRead,
+	APIKeyScopeFacilitiesWrite,
+	APIKeyScopePatientsRead,
+}
+
+func (s APIKeyScope) IsValid() bool {
+	for _, valid := range ValidAPIKeyScopes {
+		if s == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a machine credential. Only the prefix is ever shown again after
+// creation; the secret part is stored as a SHA-256 hash.
+type APIKey struct {
+	ID         uuid.UUID     `json:"id"`
+	Name       string        `json:"name"`
+	KeyPrefix  string        `json:"key_prefix"`
+	KeyHash    string        `json:"-"`
+	Scopes     []APIKeyScope `json:"scopes"`
+	FacilityID *uuid.UUID    `json:"facility_id,omitempty"`
+	CreatedBy  *uuid.UUID    `json:"created_by,omitempty"`
+	ExpiresAt  *time.Time    `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateAPIKeyRequest struct {
+	Name       string        `json:"name" binding:"required"`
+	Scopes     []APIKeyScope `json:"scopes" binding:"required,min=1"`
+	FacilityID *uuid.UUID    `json:"facility_id"`
+	ExpiresAt  *time.Time    `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse carries the plaintext key, which is returned exactly once
+type CreateAPIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyScopeIsValid(t *testing.T) {
+	assert.True(t, APIKeyScopeReferralsRead.IsValid())
+	assert.True(t, APIKeyScopeFacilitiesWrite.IsValid())
+	assert.False(t, APIKeyScope("admin:*").IsValid())
+}
+
+func TestAPIKeyState(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		key         APIKey
+		wantExpired bool
+		wantRevoked bool
+	}{
+		{name: "No expiry", key: APIKey{}, wantExpired: false, wantRevoked: false},
+		{name: "Expires in future", key: APIKey{ExpiresAt: &future}, wantExpired: false, wantRevoked: false},
+		{name: "Expired", key: APIKey{ExpiresAt: &past}, wantExpired: true, wantRevoked: false},
+		{name: "Revoked", key: APIKey{RevokedAt: &past}, wantExpired: false, wantRevoked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantExpired, tt.key.IsExpired())
+			assert.Equal(t, tt.wantRevoked, tt.key.IsRevoked())
+		})
+	}
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	key := APIKey{Scopes: []APIKeyScope{APIKeyScopeReferralsRead, APIKeyScopeOutcomesWrite}}
+
+	assert.True(t, key.HasScope(APIKeyScopeReferralsRead))
+	assert.True(t, key.HasScope(APIKeyScopeOutcomesWrite))
+	assert.False(t, key.HasScope(APIKeyScopeReferralsWrite))
+}
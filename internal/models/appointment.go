@@ -0,0 +1,163 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AppointmentStatus string
+
+const (
+	AppointmentStatusScheduled AppointmentStatus = "scheduled"
+	AppointmentStatusConfirmed AppointmentStatus = "confirmed"
+	AppointmentStatusCompleted AppointmentStatus = "completed"
+	AppointmentStatusCancelled AppointmentStatus = "cancelled"
+	AppointmentStatusNoShow    AppointmentStatus = "no_show"
+)
+
+// IsLive reports whether the appointment still holds its slot
+func (s AppointmentStatus) IsLive() bool {
+	return s == AppointmentStatusScheduled || s == AppointmentStatusConfirmed
+}
+
+// DefaultNoShowAfter is how long after the slot a live appointment is
+// marked no_show when not configured
+const DefaultNoShowAfter = 2 * time.Hour
+
+type Appointment struct {
+	ID            uuid.UUID         `json:"id"`
+	ReferralID    *uuid.UUID        `json:"referral_id,omitempty"`
+	PatientID     *uuid.UUID        `json:"patient_id,omitempty"`
+	FacilityID    *uuid.UUID        `json:"facility_id,omitempty"`
+	ClinicianID   *uuid.UUID        `json:"clinician_id,omitempty"`
+	ScheduledTime time.Time         `json:"scheduled_time"`
+	Status        AppointmentStatus `json:"status"`
+	Notes         *string           `json:"notes,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+type CreateAppointmentRequest struct {
+	PatientID     uuid.UUID  `json:"patient_id" binding:"required"`
+	ReferralID    *uuid.UUID `json:"referral_id"`
+	FacilityID    uuid.UUID  `json:"facility_id" binding:"required"`
+	ClinicianID   *uuid.UUID `json:"clinician_id"`
+	ScheduledTime time.Time  `json:"scheduled_time" binding:"required"`
+	Notes         *string    `json:"notes"`
+}
+
+func (r *CreateAppointmentRequest) Validate(now time.Time) error {
+	if !r.ScheduledTime.After(now) {
+		return fmt.Errorf("scheduled_time must be in the future")
+	}
+	return nil
+}
+
+// AppointmentReminderKind names a reminder by how long before the slot it
+// is sent
+type AppointmentReminderKind string
+
+const (
+	AppointmentReminder24h AppointmentReminderKind = "reminder_24h"
+	AppointmentReminder2h  AppointmentReminderKind = "reminder_2h"
+)
+
+// AppointmentReminderLeads lists the reminders, earliest first
+var AppointmentReminderLeads = []struct {
+	Kind AppointmentReminderKind
+	Lead time.Duration
+}{
+	{AppointmentReminder24h, 24 * time.Hour},
+	{AppointmentReminder2h, 2 * time.Hour},
+}
+
+// AppointmentHorizon is how far ahead the reminder job looks
+const AppointmentHorizon = 24 * time.Hour
+
+// UpcomingAppointment is a live appointment with what the reminder job
+// needs to text the patient
+type UpcomingAppointment struct {
+	Appointment       *Appointment
+	FacilityName      string
+	PatientPhone      string
+	PatientLanguage   string
+	SMSConsentRefused bool
+	Sent              []AppointmentReminderKind
+}
+
+// DueReminder returns the reminder to send now, if any. Only the latest due
+// reminder is sent, so an appointment booked two hours ahead gets one text.
+func (u *UpcomingAppointment) DueReminder(now time.Time) (AppointmentReminderKind, bool) {
+	until := u.Appointment.ScheduledTime.Sub(now)
+	if until <= 0 {
+		return "", false
+	}
+
+	sent := map[AppointmentReminderKind]bool{}
+	for _, kind := range u.Sent {
+		sent[kind] = true
+	}
+
+	due := -1
+	for i, reminder := range AppointmentReminderLeads {
+		if until <= reminder.Lead {
+			due = i
+		}
+	}
+	if due < 0 {
+		return "", false
+	}
+	for _, reminder := range AppointmentReminderLeads[due:] {
+		if sent[reminder.Kind] {
+			return "", false
+		}
+	}
+	return AppointmentReminderLeads[due].Kind, true
+}
+
+// AppointmentReply is what a patient asked for in an SMS reply
+type AppointmentReply string
+
+const (
+	AppointmentReplyConfirm AppointmentReply = "confirm"
+	AppointmentReplyCancel  AppointmentReply = "cancel"
+	// AppointmentReplyUnknown is any other text, or a reply with no
+	// upcoming appointment to apply to
+	AppointmentReplyUnknown AppointmentReply = "unknown"
+)
+
+var appointmentReplyKeywords = map[string]AppointmentReply{
+	"YES":     AppointmentReplyConfirm,
+	"Y":       AppointmentReplyConfirm,
+	"CONFIRM": AppointmentReplyConfirm,
+	"NDIYO":   AppointmentReplyConfirm,
+	"NO":      AppointmentReplyCancel,
+	"N":       AppointmentReplyCancel,
+	"CANCEL":  AppointmentReplyCancel,
+	"HAPANA":  AppointmentReplyCancel,
+	"GHAIRI":  AppointmentReplyCancel,
+}
+
+// ParseAppointmentReply reads the keyword at the start of an SMS reply, so
+// "Yes thank you" confirms
+func ParseAppointmentReply(text string) AppointmentReply {
+	fields := strings.Fields(strings.ToUpper(text))
+	if len(fields) == 0 {
+		return AppointmentReplyUnknown
+	}
+	keyword := strings.Trim(fields[0], ".,!")
+	if reply, ok := appointmentReplyKeywords[keyword]; ok {
+		return reply
+	}
+	return AppointmentReplyUnknown
+}
+
+// InboundSMS is a text received from the SMS gateway
+type InboundSMS struct {
+	ProviderID string `form:"id"`
+	From       string `form:"from" binding:"required"`
+	Text       string `form:"text"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppointmentDueReminder(t *testing.T) {
+	slot := time.Date(2026, 3, 3, 9, 30, 0, 0, FacilityTimezone)
+	appointment := func(sent ...AppointmentReminderKind) *UpcomingAppointment {
+		return &UpcomingAppointment{Appointment: &Appointment{ScheduledTime: slot}, Sent: sent}
+	}
+	due := func(u *UpcomingAppointment, now time.Time) AppointmentReminderKind {
+		kind, _ := u.DueReminder(now)
+		return kind
+	}
+
+	assert.Equal(t, AppointmentReminderKind(""), due(appointment(), slot.Add(-30*time.Hour)), "nothing before the first lead")
+	assert.Equal(t, AppointmentReminder24h, due(appointment(), slot.Add(-20*time.Hour)))
+	assert.Equal(t, AppointmentReminderKind(""), due(appointment(AppointmentReminder24h), slot.Add(-20*time.Hour)))
+	assert.Equal(t, AppointmentReminder2h, due(appointment(AppointmentReminder24h), slot.Add(-time.Hour)))
+	assert.Equal(t, AppointmentReminder2h, due(appointment(), slot.Add(-time.Hour)), "booked late, only the latest reminder")
+	assert.Equal(t, AppointmentReminderKind(""), due(appointment(AppointmentReminder2h), slot.Add(-time.Hour)))
+	assert.Equal(t, AppointmentReminderKind(""), due(appointment(), slot.Add(time.Minute)), "nothing once the slot has started")
+}
+
+func TestParseAppointmentReply(t *testing.T) {
+	assert.Equal(t, AppointmentReplyConfirm, ParseAppointmentReply("Yes thank you"))
+	assert.Equal(t, AppointmentReplyConfirm, ParseAppointmentReply(" ndiyo"))
+	assert.Equal(t, AppointmentReplyCancel, ParseAppointmentReply("NO."))
+	assert.Equal(t, AppointmentReplyCancel, ParseAppointmentReply("hapana"))
+	assert.Equal(t, AppointmentReplyUnknown, ParseAppointmentReply("nothing today"))
+	assert.Equal(t, AppointmentReplyUnknown, ParseAppointmentReply(""))
+}
+
+func TestCreateAppointmentRequestValidate(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, FacilityTimezone)
+	assert.NoError(t, (&CreateAppointmentRequest{ScheduledTime: now.Add(time.Hour)}).Validate(now))
+	assert.Error(t, (&CreateAppointmentRequest{ScheduledTime: now.Add(-time.Hour)}).Validate(now))
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How long after a referral is made the patient should have reached the
+// facility, by priority. Past this the CHV should follow up.
+const (
+	FollowUpAfterRed    = 24 * time.Hour
+	FollowUpAfterYellow = 72 * time.Hour
+	FollowUpAfterGreen  = 7 * 24 * time.Hour
+	// Missed appointments stay on the caseload this long
+	MissedAppointmentWindow = 30 * 24 * time.Hour
+	// Completed, no-show and expired referrals stay on the caseload this
+	// long, so the CHV sees what happened to the patient
+	RecentOutcomeWindow = 30 * 24 * time.Hour
+)
+
+type FollowUpKind string
+
+const (
+	FollowUpKindReferral    FollowUpKind = "referral_not_completed"
+	FollowUpKindAppointment FollowUpKind = "missed_appointment"
+)
+
+// FollowUp is a visit the CHV owes a patient
+type FollowUp struct {
+	Kind          FollowUpKind `json:"kind"`
+	ReferralID    *uuid.UUID   `json:"referral_id,omitempty"`
+	AppointmentID *uuid.UUID   `json:"appointment_id,omitempty"`
+	DueAt         time.Time    `json:"due_at"`
+	DaysOverdue   int          `json:"days_overdue"`
+}
+
+// Caseload is the digital version of a CHV's household register
+type Caseload struct {
+	Households   []*CaseloadHousehold `json:"households"`
+	PatientCount int                  `json:"patient_count"`
+	OverdueCount int                  `json:"overdue_count"`
+	GeneratedAt  time.Time            `json:"generated_at"`
+}
+
+type CaseloadHousehold struct {
+	Household *Household         `json:"household"`
+	Patients  []*CaseloadPatient `json:"patients"`
+}
+
+type CaseloadPatient struct {
+	Patient          *Patient           `json:"patient"`
+	Relationship     *string            `json:"relationship,omitempty"`
+	IsHead           bool               `json:"is_head"`
+	LastTriage       *TriageSummary     `json:"last_triage,omitempty"`
+	OpenReferrals    []*ReferralSummary `json:"open_referrals"`
+	RecentOutcomes   []*ReferralSummary `json:"recent_outcomes"`
+	OverdueFollowUps []*FollowUp        `json:"overdue_follow_ups"`
+}
+
+// FollowUpDue is when a referral should have been completed by
+func FollowUpDue(referral *ReferralSummary) time.Time {
+	after := FollowUpAfterGreen
+	if referral.Priority != nil {
+		switch *referral.Priority {
+		case TriageLevelRed:
+			after = FollowUpAfterRed
+		case TriageLevelYellow:
+			after = FollowUpAfterYellow
+		}
+	}
+	return referral.CreatedAt.Add(after)
+}
+
+// SetOverdueFollowUps lists the patient's open referrals past their follow-up
+// time and the given missed appointments, most overdue first
+func (p *CaseloadPatient) SetOverdueFollowUps(missed []*AppointmentSummary, now time.Time) {
+	p.OverdueFollowUps = []*FollowUp{}
+	for _, referral := range p.OpenReferrals {
+		if due := FollowUpDue(referral); now.After(due) {
+			id := referral.ID
+			p.OverdueFollowUps = append(p.OverdueFollowUps, &FollowUp{
+				Kind:        FollowUpKindReferral,
+				ReferralID:  &id,
+				DueAt:       due,
+				DaysOverdue: int(now.Sub(due).Hours() / 24),
+			})
+		}
+	}
+	for _, appointment := range missed {
+		if !now.After(appointment.ScheduledTime) {
+			continue
+		}
+		id := appointment.ID
+		p.OverdueFollowUps = append(p.OverdueFollowUps, &FollowUp{
+			Kind:          FollowUpKindAppointment,
+			ReferralID:    appointment.ReferralID,
+			AppointmentID: &id,
+			DueAt:         appointment.ScheduledTime,
+			DaysOverdue:   int(now.Sub(appointment.ScheduledTime).Hours() / 24),
+		})
+	}
+
+	sort.SliceStable(p.OverdueFollowUps, func(i, j int) bool {
+		return p.OverdueFollowUps[i].DueAt.Before(p.OverdueFollowUps[j].DueAt)
+	})
+}
+
+// Tally counts the patients and overdue follow-ups on the caseload
+func (c *Caseload) Tally() {
+	c.PatientCount, c.OverdueCount = 0, 0
+	for _, household := range c.Households {
+		c.PatientCount += len(household.Patients)
+		for _, patient := range household.Patients {
+			c.OverdueCount += len(patient.OverdueFollowUps)
+		}
+	}
+}
+
+// Redact applies summary redaction to every patient on the caseload
+func (c *Caseload) Redact(access SummaryAccess) {
+	if access == SummaryAccessFull {
+		return
+	}
+	for _, household := range c.Households {
+		for _, patient := range household.Patients {
+			if patient.LastTriage != nil {
+				redactTriage([]*TriageSummary{patient.LastTriage})
+			}
+			redactReferrals(patient.OpenReferrals)
+			redactReferrals(patient.RecentOutcomes)
+		}
+	}
+}
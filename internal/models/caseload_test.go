@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowUpDue(t *testing.T) {
+	created := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	red, yellow := TriageLevelRed, TriageLevelYellow
+
+	assert.Equal(t, created.Add(24*time.Hour), FollowUpDue(&ReferralSummary{Priority: &red, CreatedAt: created}))
+	assert.Equal(t, created.Add(72*time.Hour), FollowUpDue(&ReferralSummary{Priority: &yellow, CreatedAt: created}))
+	assert.Equal(t, created.Add(7*24*time.Hour), FollowUpDue(&ReferralSummary{CreatedAt: created}), "unprioritised referrals are treated as green")
+}
+
+func TestSetOverdueFollowUps(t *testing.T) {
+	now := time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC)
+	red, yellow := TriageLevelRed, TriageLevelYellow
+
+	overdue := &ReferralSummary{ID: uuid.New(), Priority: &red, CreatedAt: now.Add(-3 * 24 * time.Hour)}
+	recent := &ReferralSummary{ID: uuid.New(), Priority: &yellow, CreatedAt: now.Add(-24 * time.Hour)}
+	missed := &AppointmentSummary{ID: uuid.New(), ScheduledTime: now.Add(-24 * time.Hour)}
+
+	patient := &CaseloadPatient{OpenReferrals: []*ReferralSummary{recent, overdue}}
+	patient.SetOverdueFollowUps([]*AppointmentSummary{missed}, now)
+
+	require.Len(t, patient.OverdueFollowUps, 2)
+	assert.Equal(t, FollowUpKindReferral, patient.OverdueFollowUps[0].Kind, "most overdue first")
+	assert.Equal(t, overdue.ID, *patient.OverdueFollowUps[0].ReferralID)
+	assert.Equal(t, 2, patient.OverdueFollowUps[0].DaysOverdue)
+	assert.Equal(t, FollowUpKindAppointment, patient.OverdueFollowUps[1].Kind)
+	assert.Equal(t, missed.ID, *patient.OverdueFollowUps[1].AppointmentID)
+
+	caseload := &Caseload{Households: []*CaseloadHousehold{{Patients: []*CaseloadPatient{patient, {}}}}}
+	caseload.Tally()
+	assert.Equal(t, 2, caseload.PatientCount)
+	assert.Equal(t, 2, caseload.OverdueCount)
+}
+
+func TestCaseloadRedact(t *testing.T) {
+	note := "Query TB"
+	patient := &CaseloadPatient{
+		LastTriage:     &TriageSummary{SummaryText: &note, Symptoms: map[string]interface{}{"cough": "2 weeks"}},
+		OpenReferrals:  []*ReferralSummary{{Notes: &note}},
+		RecentOutcomes: []*ReferralSummary{{Outcome: &ReferralOutcome{DiagnosisCategory: DiagnosisRespiratory, Notes: &note}}},
+	}
+	caseload := &Caseload{Households: []*CaseloadHousehold{{Patients: []*CaseloadPatient{patient}}}}
+
+	caseload.Redact(SummaryAccessCareTeam)
+	assert.Nil(t, patient.LastTriage.SummaryText)
+	assert.Nil(t, patient.LastTriage.Symptoms)
+	assert.Nil(t, patient.OpenReferrals[0].Notes)
+	assert.Nil(t, patient.RecentOutcomes[0].Outcome.Notes)
+	assert.Equal(t, DiagnosisRespiratory, patient.RecentOutcomes[0].Outcome.DiagnosisCategory)
+}
+
+func TestCreateHouseholdRequestValidate(t *testing.T) {
+	lat, lng := -1.2921, 36.8219
+	assert.NoError(t, (&CreateHouseholdRequest{Name: "Kamau"}).Validate())
+	assert.NoError(t, (&CreateHouseholdRequest{Name: "Kamau", Latitude: &lat, Longitude: &lng}).Validate())
+	assert.Error(t, (&CreateHouseholdRequest{Name: "Kamau", Latitude: &lat}).Validate())
+
+	london := 51.5
+	assert.Error(t, (&CreateHouseholdRequest{Name: "Kamau", Latitude: &london, Longitude: &lng}).Validate())
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Clinician struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	Phone          string     `json:"phone"`
+	Email          *string    `json:"email,omitempty"`
+	FacilityID     *uuid.UUID `json:"facility_id,omitempty"`
+	Specialization *string    `json:"specialization,omitempty"`
+	LicenseNumber  *string    `json:"license_number,omitempty"`
+	IsActive       bool       `json:"is_active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type CreateClinicianRequest struct {
+	Name           string     `json:"name" binding:"required"`
+	Phone          string     `json:"phone" binding:"required,kephone"`
+	Email          *string    `json:"email" binding:"omitempty,email"`
+	FacilityID     *uuid.UUID `json:"facility_id"`
+	Specialization *string    `json:"specialization"`
+	LicenseNumber  *string    `json:"license_number"`
+}
+
+type UpdateClinicianRequest struct {
+	Name           *string    `json:"name"`
+	Email          *string    `json:"email" binding:"omitempty,email"`
+	FacilityID     *uuid.UUID `json:"facility_id"`
+	Specialization *string    `json:"specialization"`
+	LicenseNumber  *string    `json:"license_number"`
+}
+
+type LinkClinicianUserRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
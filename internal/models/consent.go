@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ConsentType string
+
+const (
+	ConsentTypeDataCollection   ConsentType = "data_collection"
+	ConsentTypeDataSharing      ConsentType = "data_sharing"
+	ConsentTypeSMSNotifications ConsentType = "sms_notifications"
+	ConsentTypeResearch         ConsentType = "research"
+)
+
+// ValidConsentTypes mirrors the consent_type enum
+var ValidConsentTypes = []ConsentType{
+	ConsentTypeDataCollection,
+	ConsentTypeDataSharing,
+	ConsentTypeSMSNotifications,
+	ConsentTypeResearch,
+}
+
+func (t ConsentType) IsValid() bool {
+	for _, valid := range ValidConsentTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// Consent is one consent decision from consent_logs
+type Consent struct {
+	ID          uuid.UUID              `json:"id"`
+	PatientID   uuid.UUID              `json:"patient_id"`
+	ConsentType ConsentType            `json:"consent_type"`
+	Granted     bool                   `json:"granted"`
+	Details     map[string]interface{} `json:"details"`
+	GrantedAt   time.Time              `json:"granted_at"`
+}
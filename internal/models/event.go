@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType names a domain event. Subscribers and external sinks see these
+// names, so they must not change once published.
+type EventType string
+
+const (
+	// EventTriageCompleted carries the TriageSession once it has a verdict,
+	// from the server or, for sessions synced from the CHV app, the device
+	EventTriageCompleted EventType = "triage.completed"
+	// EventReferralCreated carries the new Referral, including one created
+	// by a transfer
+	EventReferralCreated EventType = "referral.created"
+	// EventReferralAccepted carries the Referral once the facility accepts it
+	EventReferralAccepted EventType = "referral.accepted"
+	// EventAppointmentBooked carries the new Appointment
+	EventAppointmentBooked EventType = "appointment.booked"
+	// EventConsentChanged carries a ConsentChange
+	EventConsentChanged EventType = "consent.changed"
+)
+
+// ValidEventTypes lists the events the outbox carries
+var ValidEventTypes = []EventType{
+	EventTriageCompleted,
+	EventReferralCreated,
+	EventReferralAccepted,
+	EventAppointmentBooked,
+	EventConsentChanged,
+}
+
+func (t EventType) IsValid() bool {
+	for _, valid := range ValidEventTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// DomainEvent is a change recorded in the outbox alongside the write that
+// made it. Delivery is at least once and unordered, so subscribers must
+// tolerate duplicates and use OccurredAt rather than arrival order.
+type DomainEvent struct {
+	ID   uuid.UUID `json:"id"`
+	Type EventType `json:"type"`
+	// AggregateID is the triage session, referral, appointment or patient
+	// the event is about
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"`
+}
+
+// DecodePayload unmarshals the payload into v, which should match the type:
+// *TriageSession, *Referral, *Appointment or *ConsentChange
+func (e *DomainEvent) DecodePayload(v interface{}) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("invalid %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// ConsentChange is the payload of consent.changed. Consents maps each
+// consent that changed to whether it is now given; a flag removed from the
+// patient record counts as withdrawn.
+type ConsentChange struct {
+	PatientID uuid.UUID       `json:"patient_id"`
+	Consents  map[string]bool `json:"consents"`
+}
+
+// ChangedConsents returns the consents that differ between two sets of
+// patient consent flags, with their new values. It returns nil if nothing
+// changed.
+func ChangedConsents(before, after map[string]bool) map[string]bool {
+	var changed map[string]bool
+	mark := func(flag string, value bool) {
+		if changed == nil {
+			changed = map[string]bool{}
+		}
+		changed[flag] = value
+	}
+
+	for flag, value := range after {
+		if old, ok := before[flag]; !ok || old != value {
+			mark(flag, value)
+		}
+	}
+	for flag, old := range before {
+		if _, ok := after[flag]; !ok && old {
+			mark(flag, false)
+		}
+	}
+	return changed
+}
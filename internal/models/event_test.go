@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedConsents(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]bool
+		after  map[string]bool
+		want   map[string]bool
+	}{
+		{"No change", map[string]bool{"research": true}, map[string]bool{"research": true}, nil},
+		{"Nothing given", nil, map[string]bool{}, nil},
+		{"Given on registration", nil, map[string]bool{"data_collection": true}, map[string]bool{"data_collection": true}},
+		{"Withdrawn", map[string]bool{"research": true}, map[string]bool{"research": false}, map[string]bool{"research": false}},
+		{"Refused explicitly", map[string]bool{}, map[string]bool{"research": false}, map[string]bool{"research": false}},
+		{"Removed counts as withdrawn", map[string]bool{"research": true, "data_sharing": false}, map[string]bool{}, map[string]bool{"research": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ChangedConsents(tt.before, tt.after))
+		})
+	}
+}
+
+func TestEventTypeIsValid(t *testing.T) {
+	assert.True(t, EventReferralAccepted.IsValid())
+	assert.False(t, EventType("referral.deleted").IsValid())
+}
@@ -1,9 +1,12 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/geo"
 )
 
 type FacilityType string
@@ -16,8 +19,32 @@ const (
 	FacilityTypePrivateClinic     FacilityType = "private_clinic"
 )
 
+func (t FacilityType) IsValid() bool {
+	switch t {
+	case FacilityTypeDispensary, FacilityTypeHealthCenter, FacilityTypeSubCountyHospital,
+		FacilityTypeCountyHospital, FacilityTypePrivateClinic:
+		return true
+	}
+	return false
+}
+
+// KEPH facility levels accepted for referral facilities
+const (
+	MinFacilityLevel = 2
+	MaxFacilityLevel = 6
+)
+
+// Approximate bounding box of Kenya, used to reject swapped or mistyped coordinates
+const (
+	KenyaMinLatitude  = -4.72
+	KenyaMaxLatitude  = 5.03
+	KenyaMinLongitude = 33.90
+	KenyaMaxLongitude = 41.91
+)
+
 type Facility struct {
 	ID               uuid.UUID              `json:"id"`
+	MFLCode          *string                `json:"mfl_code,omitempty"`
 	Name             string                 `json:"name"`
 	Type             FacilityType           `json:"type"`
 	Level            *int                   `json:"level,omitempty"`
@@ -29,18 +56,249 @@ type Facility struct {
 	Phone            *string                `json:"phone,omitempty"`
 	Email            *string                `json:"email,omitempty"`
 	Services         []string               `json:"services"`
-	OperatingHours   map[string]string      `json:"operating_hours"`
+	OperatingHours   OperatingHours         `json:"operating_hours"`
 	AcceptsReferrals bool                   `json:"accepts_referrals"`
 	AcceptsMpesa     bool                   `json:"accepts_mpesa"`
 	BedCapacity      *int                   `json:"bed_capacity,omitempty"`
 	StaffCount       *int                   `json:"staff_count,omitempty"`
 	AvailableSlots   []map[string]interface{} `json:"available_slots"`
+	IsActive         bool                   `json:"is_active"`
 	CreatedAt        time.Time              `json:"created_at"`
 	UpdatedAt        time.Time              `json:"updated_at"`
+
+	// Computed per request from OperatingHours and closures
+	OpenNow  *bool      `json:"open_now,omitempty"`
+	NextOpen *time.Time `json:"next_open,omitempty"`
 }
 
 type NearbyFacilitiesRequest struct {
-	Latitude  float64 `json:"latitude" binding:"required"`
-	Longitude float64 `json:"longitude" binding:"required"`
-	RadiusKM  float64 `json:"radius_km" binding:"required,min=1,max=50"`
-}
\ No newline at end of file
+	Latitude  float64 `json:"latitude" form:"latitude" binding:"required"`
+	Longitude float64 `json:"longitude" form:"longitude" binding:"required"`
+	RadiusKM  float64 `json:"radius_km" form:"radius_km" binding:"required,min=1,max=50"`
+	Limit     int     `json:"limit" form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor    string  `json:"cursor" form:"cursor"`
+}
+
+// NearbyFacility is a search result: the facility plus where it is relative to the caller
+type NearbyFacility struct {
+	Facility
+	DistanceKM      float64              `json:"distance_km"`
+	BearingDegrees  float64              `json:"bearing_degrees"`
+	Direction       string               `json:"direction"`
+	TravelEstimates []geo.TravelEstimate `json:"travel_estimates"`
+	Capacity        *FacilityStatus      `json:"capacity,omitempty"`
+	Warnings        []string             `json:"warnings,omitempty"`
+}
+
+type CreateFacilityRequest struct {
+	MFLCode          *string           `json:"mfl_code"`
+	Name             string            `json:"name" binding:"required"`
+	Type             FacilityType      `json:"type" binding:"required"`
+	Level            *int              `json:"level"`
+	County           *string           `json:"county"`
+	SubCounty        *string           `json:"sub_county"`
+	Latitude         *float64          `json:"latitude"`
+	Longitude        *float64          `json:"longitude"`
+	Address          *string           `json:"address"`
+	Phone            *string           `json:"phone"`
+	Email            *string           `json:"email" binding:"omitempty,email"`
+	Services         []string          `json:"services"`
+	OperatingHours   *OperatingHours   `json:"operating_hours"`
+	AcceptsReferrals *bool             `json:"accepts_referrals"`
+	AcceptsMpesa     *bool             `json:"accepts_mpesa"`
+	BedCapacity      *int              `json:"bed_capacity" binding:"omitempty,min=0"`
+	StaffCount       *int              `json:"staff_count" binding:"omitempty,min=0"`
+}
+
+// Validate checks the fields that the database cannot enforce on its own
+func (r *CreateFacilityRequest) Validate() error {
+	if !r.Type.IsValid() {
+		return fmt.Errorf("invalid facility type: %s", r.Type)
+	}
+	if err := ValidateFacilityLevel(r.Level); err != nil {
+		return err
+	}
+	if r.OperatingHours != nil {
+		if err := r.OperatingHours.Validate(); err != nil {
+			return err
+		}
+	}
+	if (r.Latitude == nil) != (r.Longitude == nil) {
+		return fmt.Errorf("latitude and longitude must be provided together")
+	}
+	if r.Latitude != nil {
+		return ValidateKenyaCoordinates(*r.Latitude, *r.Longitude)
+	}
+	return nil
+}
+
+// UpdateFacilityRequest holds a partial update; nil fields are left unchanged
+type UpdateFacilityRequest struct {
+	Name             *string           `json:"name"`
+	Type             *FacilityType     `json:"type"`
+	Level            *int              `json:"level"`
+	County           *string           `json:"county"`
+	SubCounty        *string           `json:"sub_county"`
+	Latitude         *float64          `json:"latitude"`
+	Longitude        *float64          `json:"longitude"`
+	Address          *string           `json:"address"`
+	Phone            *string           `json:"phone"`
+	Email            *string           `json:"email" binding:"omitempty,email"`
+	Services         []string          `json:"services"`
+	OperatingHours   *OperatingHours   `json:"operating_hours"`
+	AcceptsReferrals *bool             `json:"accepts_referrals"`
+	AcceptsMpesa     *bool             `json:"accepts_mpesa"`
+	BedCapacity      *int              `json:"bed_capacity" binding:"omitempty,min=0"`
+	StaffCount       *int              `json:"staff_count" binding:"omitempty,min=0"`
+	IsActive         *bool             `json:"is_active"`
+}
+
+func (r *UpdateFacilityRequest) Validate() error {
+	if r.Type != nil && !r.Type.IsValid() {
+		return fmt.Errorf("invalid facility type: %s", *r.Type)
+	}
+	if err := ValidateFacilityLevel(r.Level); err != nil {
+		return err
+	}
+	if r.OperatingHours != nil {
+		if err := r.OperatingHours.Validate(); err != nil {
+			return err
+		}
+	}
+	if (r.Latitude == nil) != (r.Longitude == nil) {
+		return fmt.Errorf("latitude and longitude must be updated together")
+	}
+	if r.Latitude != nil {
+		return ValidateKenyaCoordinates(*r.Latitude, *r.Longitude)
+	}
+	return nil
+}
+
+type BulkCreateFacilitiesRequest struct {
+	Facilities []CreateFacilityRequest `json:"facilities" binding:"required,min=1,max=500"`
+}
+
+// BulkFacilityResult reports the outcome of one row of a bulk import
+type BulkFacilityResult struct {
+	Index int        `json:"index"`
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+func ValidateFacilityLevel(level *int) error {
+	if level != nil && (*level < MinFacilityLevel || *level > MaxFacilityLevel) {
+		return fmt.Errorf("level must be between %d and %d", MinFacilityLevel, MaxFacilityLevel)
+	}
+	return nil
+}
+
+func ValidateKenyaCoordinates(lat, lng float64) error {
+	if lat < KenyaMinLatitude || lat > KenyaMaxLatitude || lng < KenyaMinLongitude || lng > KenyaMaxLongitude {
+		return fmt.Errorf("coordinates (%f, %f) are outside Kenya", lat, lng)
+	}
+	return nil
+}
+
+// closedOn returns a predicate for the days on which closures shut the facility
+func (f *Facility) closedOn(closures []*FacilityClosure) func(day time.Time) bool {
+	return func(day time.Time) bool {
+		for _, closure := range closures {
+			if closure.Applies(f, day) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// IsOpenAt reports whether the facility is open at t, taking closures into
+// account. known is false when no operating hours are recorded.
+func (f *Facility) IsOpenAt(t time.Time, closures []*FacilityClosure) (open, known bool) {
+	if f.OperatingHours.IsZero() {
+		return false, false
+	}
+	return f.OperatingHours.OpenAt(t, f.closedOn(closures)), true
+}
+
+// SetOpenStatus fills OpenNow and NextOpen for the response. Both stay nil
+// when the facility has no recorded hours.
+func (f *Facility) SetOpenStatus(now time.Time, closures []*FacilityClosure) {
+	open, known := f.IsOpenAt(now, closures)
+	if !known {
+		return
+	}
+	f.OpenNow = &open
+	if !open {
+		if next, ok := f.OperatingHours.NextOpen(now, f.closedOn(closures)); ok {
+			f.NextOpen = &next
+		}
+	}
+}
+
+// Sort orders accepted by facility search. A leading "-" sorts descending.
+const (
+	FacilitySortName      = "name"
+	FacilitySortLevel     = "level"
+	FacilitySortCreatedAt = "created_at"
+	// FacilitySortRelevance ranks by name similarity and requires Query
+	FacilitySortRelevance = "relevance"
+)
+
+type FacilitySearchRequest struct {
+	Query            string   `form:"q"`
+	County           string   `form:"county"`
+	SubCounty        string   `form:"sub_county"`
+	Type             string   `form:"type"`
+	Services         []string `form:"services"`
+	ServicesMatch    string   `form:"services_match" binding:"omitempty,oneof=any all"`
+	MinLevel         *int     `form:"min_level" binding:"omitempty,min=2,max=6"`
+	MaxLevel         *int     `form:"max_level" binding:"omitempty,min=2,max=6"`
+	AcceptsReferrals *bool    `form:"accepts_referrals"`
+	AcceptsMpesa     *bool    `form:"accepts_mpesa"`
+	Sort             string   `form:"sort"`
+	Limit            int      `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor           string   `form:"cursor"`
+}
+
+// Normalize splits comma-separated services, applies defaults and checks the
+// combinations binding tags cannot express
+func (r *FacilitySearchRequest) Normalize() error {
+	var services []string
+	for _, value := range r.Services {
+		for _, service := range strings.Split(value, ",") {
+			if service = strings.ToLower(strings.TrimSpace(service)); service != "" {
+				services = append(services, service)
+			}
+		}
+	}
+	r.Services = services
+	r.Query = strings.TrimSpace(r.Query)
+
+	if r.ServicesMatch == "" {
+		r.ServicesMatch = "any"
+	}
+	if r.Type != "" && !FacilityType(r.Type).IsValid() {
+		return fmt.Errorf("invalid facility type: %s", r.Type)
+	}
+	if r.MinLevel != nil && r.MaxLevel != nil && *r.MinLevel > *r.MaxLevel {
+		return fmt.Errorf("min_level must not exceed max_level")
+	}
+
+	if r.Sort == "" {
+		r.Sort = FacilitySortName
+		if r.Query != "" {
+			r.Sort = FacilitySortRelevance
+		}
+	}
+	switch strings.TrimPrefix(r.Sort, "-") {
+	case FacilitySortName, FacilitySortLevel, FacilitySortCreatedAt:
+	case FacilitySortRelevance:
+		if r.Query == "" {
+			return fmt.Errorf("sort=relevance requires q")
+		}
+	default:
+		return fmt.Errorf("invalid sort: %s", r.Sort)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Stockout string
+
+// Stockouts facilities can report; each one knocks out a service referrals may rely on
+const (
+	StockoutLabReagents Stockout = "lab_reagents"
+	StockoutAmbulance   Stockout = "ambulance"
+	StockoutOxygen      Stockout = "oxygen"
+	StockoutBlood       Stockout = "blood"
+	StockoutMedicines   Stockout = "essential_medicines"
+	StockoutPower       Stockout = "power"
+)
+
+var ValidStockouts = []Stockout{
+	StockoutLabReagents,
+	StockoutAmbulance,
+	StockoutOxygen,
+	StockoutBlood,
+	StockoutMedicines,
+	StockoutPower,
+}
+
+func (s Stockout) IsValid() bool {
+	for _, valid := range ValidStockouts {
+		if s == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxReferralPause bounds how long a facility can stop accepting referrals in one report
+const MaxReferralPause = 7 * 24 * time.Hour
+
+// FacilityStatus is the latest capacity report for a facility
+type FacilityStatus struct {
+	FacilityID       uuid.UUID  `json:"facility_id"`
+	BedsTotal        *int       `json:"beds_total,omitempty"`
+	BedsOccupied     *int       `json:"beds_occupied,omitempty"`
+	Stockouts        []Stockout `json:"stockouts"`
+	ReferralsPaused  bool       `json:"referrals_paused"`
+	PauseReason      *string    `json:"pause_reason,omitempty"`
+	PausedUntil      *time.Time `json:"paused_until,omitempty"`
+	ReportedBy       *uuid.UUID `json:"reported_by,omitempty"`
+	ReportedByAPIKey *uuid.UUID `json:"reported_by_api_key,omitempty"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// FacilityStatusReport is one entry in a facility's capacity history
+type FacilityStatusReport struct {
+	ID uuid.UUID `json:"id"`
+	FacilityStatus
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsPaused reports whether the facility has stopped accepting referrals at now
+func (s *FacilityStatus) IsPaused(now time.Time) bool {
+	return s.ReferralsPaused && s.PausedUntil != nil && s.PausedUntil.After(now)
+}
+
+// BedsAvailable returns free beds, or nil when occupancy has not been reported
+func (s *FacilityStatus) BedsAvailable() *int {
+	if s.BedsTotal == nil || s.BedsOccupied == nil {
+		return nil
+	}
+	available := *s.BedsTotal - *s.BedsOccupied
+	if available < 0 {
+		available = 0
+	}
+	return &available
+}
+
+// IsFull reports whether the facility has reported no free beds
+func (s *FacilityStatus) IsFull() bool {
+	available := s.BedsAvailable()
+	return available != nil && *available == 0
+}
+
+// Warnings lists the reasons a referral to this facility may fail
+func (s *FacilityStatus) Warnings(now time.Time) []string {
+	warnings := []string{}
+	if s.IsPaused(now) {
+		reason := ""
+		if s.PauseReason != nil {
+			reason = ": " + *s.PauseReason
+		}
+		warnings = append(warnings, fmt.Sprintf("not accepting referrals until %s%s", s.PausedUntil.In(FacilityTimezone).Format("2 Jan 15:04"), reason))
+	}
+	if s.IsFull() {
+		warnings = append(warnings, "no free beds")
+	}
+	for _, stockout := range s.Stockouts {
+		warnings = append(warnings, "stock-out: "+string(stockout))
+	}
+	return warnings
+}
+
+// ReportFacilityStatusRequest updates the live status; nil fields keep their
+// current value. Resuming referrals clears the pause reason and expiry.
+type ReportFacilityStatusRequest struct {
+	BedsTotal       *int       `json:"beds_total" binding:"omitempty,min=0"`
+	BedsOccupied    *int       `json:"beds_occupied" binding:"omitempty,min=0"`
+	Stockouts       []Stockout `json:"stockouts"`
+	ReferralsPaused *bool      `json:"referrals_paused"`
+	PauseReason     *string    `json:"pause_reason"`
+	PausedUntil     *time.Time `json:"paused_until"`
+}
+
+func (r *ReportFacilityStatusRequest) Validate(now time.Time) error {
+	if r.BedsTotal != nil && r.BedsOccupied != nil && *r.BedsOccupied > *r.BedsTotal {
+		return fmt.Errorf("beds_occupied cannot exceed beds_total")
+	}
+	for _, stockout := range r.Stockouts {
+		if !stockout.IsValid() {
+			return fmt.Errorf("invalid stockout: %s", stockout)
+		}
+	}
+
+	if r.ReferralsPaused != nil && *r.ReferralsPaused {
+		if r.PauseReason == nil || *r.PauseReason == "" {
+			return fmt.Errorf("pause_reason is required when pausing referrals")
+		}
+		if r.PausedUntil == nil {
+			return fmt.Errorf("paused_until is required when pausing referrals")
+		}
+		if !r.PausedUntil.After(now) {
+			return fmt.Errorf("paused_until must be in the future")
+		}
+		if r.PausedUntil.Sub(now) > MaxReferralPause {
+			return fmt.Errorf("referrals can be paused for at most %d days", int(MaxReferralPause.Hours()/24))
+		}
+	}
+
+	return nil
+}
+
+// Apply merges a report into the current status. The status is left
+// unchanged if the merged bed counts are inconsistent.
+func (s *FacilityStatus) Apply(req *ReportFacilityStatusRequest) error {
+	total, occupied := s.BedsTotal, s.BedsOccupied
+	if req.BedsTotal != nil {
+		total = req.BedsTotal
+	}
+	if req.BedsOccupied != nil {
+		occupied = req.BedsOccupied
+	}
+	if total != nil && occupied != nil && *occupied > *total {
+		return fmt.Errorf("beds_occupied cannot exceed beds_total")
+	}
+	s.BedsTotal, s.BedsOccupied = total, occupied
+
+	if req.Stockouts != nil {
+		s.Stockouts = req.Stockouts
+	}
+	if s.Stockouts == nil {
+		s.Stockouts = []Stockout{}
+	}
+
+	if req.ReferralsPaused != nil {
+		s.ReferralsPaused = *req.ReferralsPaused
+		if s.ReferralsPaused {
+			s.PauseReason = req.PauseReason
+			s.PausedUntil = req.PausedUntil
+		} else {
+			s.PauseReason = nil
+			s.PausedUntil = nil
+		}
+	}
+
+	return nil
+}
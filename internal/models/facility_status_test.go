@@ -0,0 +1,85 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportFacilityStatusRequestValidate(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, FacilityTimezone)
+	paused := true
+	reason := "Theatre under repair"
+	tomorrow := now.Add(24 * time.Hour)
+	nextMonth := now.AddDate(0, 1, 0)
+	yesterday := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		req     ReportFacilityStatusRequest
+		wantErr bool
+	}{
+		{"Beds only", ReportFacilityStatusRequest{BedsTotal: intPtr(40), BedsOccupied: intPtr(32)}, false},
+		{"Occupied exceeds total", ReportFacilityStatusRequest{BedsTotal: intPtr(10), BedsOccupied: intPtr(11)}, true},
+		{"Known stockouts", ReportFacilityStatusRequest{Stockouts: []Stockout{StockoutOxygen, StockoutBlood}}, false},
+		{"Unknown stockout", ReportFacilityStatusRequest{Stockouts: []Stockout{"morphine"}}, true},
+		{"Pause", ReportFacilityStatusRequest{ReferralsPaused: &paused, PauseReason: &reason, PausedUntil: &tomorrow}, false},
+		{"Pause without reason", ReportFacilityStatusRequest{ReferralsPaused: &paused, PausedUntil: &tomorrow}, true},
+		{"Pause without expiry", ReportFacilityStatusRequest{ReferralsPaused: &paused, PauseReason: &reason}, true},
+		{"Pause in the past", ReportFacilityStatusRequest{ReferralsPaused: &paused, PauseReason: &reason, PausedUntil: &yesterday}, true},
+		{"Pause longer than a week", ReportFacilityStatusRequest{ReferralsPaused: &paused, PauseReason: &reason, PausedUntil: &nextMonth}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate(now)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFacilityStatusApply(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, FacilityTimezone)
+	until := now.Add(6 * time.Hour)
+	reason := "Power outage"
+	paused, resumed := true, false
+
+	status := &FacilityStatus{BedsTotal: intPtr(20), BedsOccupied: intPtr(5)}
+
+	// Partial reports keep the fields they leave out
+	assert.NoError(t, status.Apply(&ReportFacilityStatusRequest{BedsOccupied: intPtr(20)}))
+	assert.Equal(t, 20, *status.BedsTotal)
+	assert.True(t, status.IsFull())
+	assert.Equal(t, []Stockout{}, status.Stockouts)
+
+	// Lowering the total below current occupancy is rejected
+	assert.Error(t, status.Apply(&ReportFacilityStatusRequest{BedsTotal: intPtr(10)}))
+
+	assert.NoError(t, status.Apply(&ReportFacilityStatusRequest{ReferralsPaused: &paused, PauseReason: &reason, PausedUntil: &until}))
+	assert.True(t, status.IsPaused(now))
+	assert.False(t, status.IsPaused(until.Add(time.Minute)), "pause lapses at paused_until")
+
+	assert.NoError(t, status.Apply(&ReportFacilityStatusRequest{ReferralsPaused: &resumed}))
+	assert.False(t, status.IsPaused(now))
+	assert.Nil(t, status.PauseReason)
+	assert.Nil(t, status.PausedUntil)
+}
+
+func TestFacilityStatusWarnings(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, FacilityTimezone)
+
+	assert.Empty(t, (&FacilityStatus{BedsTotal: intPtr(20), BedsOccupied: intPtr(12)}).Warnings(now))
+	assert.Empty(t, (&FacilityStatus{}).Warnings(now), "unreported occupancy is not a warning")
+
+	status := &FacilityStatus{
+		BedsTotal:    intPtr(20),
+		BedsOccupied: intPtr(20),
+		Stockouts:    []Stockout{StockoutOxygen},
+	}
+	assert.Equal(t, []string{"no free beds", "stock-out: oxygen"}, status.Warnings(now))
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int           { return &v }
+func floatPtr(v float64) *float64 { return &v }
+
+func TestFacilityTypeIsValid(t *testing.T) {
+	assert.True(t, FacilityTypeDispensary.IsValid())
+	assert.True(t, FacilityTypeCountyHospital.IsValid())
+	assert.False(t, FacilityType("referral_hospital").IsValid())
+	assert.False(t, FacilityType("").IsValid())
+}
+
+func TestCreateFacilityRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateFacilityRequest
+		wantErr bool
+	}{
+		{
+			name: "Valid facility in Machakos",
+			req: CreateFacilityRequest{
+				Name:      "Kamulu Health Center",
+				Type:      FacilityTypeHealthCenter,
+				Level:     intPtr(3),
+				Latitude:  floatPtr(-1.2345),
+				Longitude: floatPtr(37.1234),
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Valid facility without coordinates",
+			req:     CreateFacilityRequest{Name: "Clinic", Type: FacilityTypePrivateClinic},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid type",
+			req:     CreateFacilityRequest{Name: "Clinic", Type: "hospital"},
+			wantErr: true,
+		},
+		{
+			name:    "Level below range",
+			req:     CreateFacilityRequest{Name: "Clinic", Type: FacilityTypeDispensary, Level: intPtr(1)},
+			wantErr: true,
+		},
+		{
+			name:    "Level above range",
+			req:     CreateFacilityRequest{Name: "Clinic", Type: FacilityTypeCountyHospital, Level: intPtr(7)},
+			wantErr: true,
+		},
+		{
+			name:    "Swapped coordinates",
+			req:     CreateFacilityRequest{Name: "Clinic", Type: FacilityTypeDispensary, Latitude: floatPtr(37.1234), Longitude: floatPtr(-1.2345)},
+			wantErr: true,
+		},
+		{
+			name:    "Latitude without longitude",
+			req:     CreateFacilityRequest{Name: "Clinic", Type: FacilityTypeDispensary, Latitude: floatPtr(-1.2345)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUpdateFacilityRequestValidate(t *testing.T) {
+	assert.NoError(t, (&UpdateFacilityRequest{}).Validate())
+	assert.NoError(t, (&UpdateFacilityRequest{Level: intPtr(6)}).Validate())
+
+	invalidType := FacilityType("hospital")
+	assert.Error(t, (&UpdateFacilityRequest{Type: &invalidType}).Validate())
+	assert.Error(t, (&UpdateFacilityRequest{Latitude: floatPtr(0.5), Longitude: floatPtr(30.0)}).Validate())
+}
+
+func TestFacilitySearchRequestNormalize(t *testing.T) {
+	t.Run("Defaults and comma-separated services", func(t *testing.T) {
+		req := &FacilitySearchRequest{Services: []string{"Maternity, lab", "icu"}}
+		assert.NoError(t, req.Normalize())
+		assert.Equal(t, []string{"maternity", "lab", "icu"}, req.Services)
+		assert.Equal(t, "any", req.ServicesMatch)
+		assert.Equal(t, FacilitySortName, req.Sort)
+	})
+
+	t.Run("Text search defaults to relevance", func(t *testing.T) {
+		req := &FacilitySearchRequest{Query: " kamulu "}
+		assert.NoError(t, req.Normalize())
+		assert.Equal(t, "kamulu", req.Query)
+		assert.Equal(t, FacilitySortRelevance, req.Sort)
+	})
+
+	t.Run("Descending sort", func(t *testing.T) {
+		req := &FacilitySearchRequest{Sort: "-level"}
+		assert.NoError(t, req.Normalize())
+	})
+
+	for name, req := range map[string]*FacilitySearchRequest{
+		"Relevance without q":  {Sort: FacilitySortRelevance},
+		"Unknown sort":         {Sort: "distance"},
+		"Inverted level range": {MinLevel: intPtr(5), MaxLevel: intPtr(3)},
+		"Unknown type":         {Type: "pharmacy"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, req.Normalize())
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Household is the unit a CHV serves. The location is approximate, usually
+// captured on the CHV's phone at the first visit.
+type Household struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Village   *string    `json:"village,omitempty"`
+	Landmark  *string    `json:"landmark,omitempty"`
+	Latitude  *float64   `json:"latitude,omitempty"`
+	Longitude *float64   `json:"longitude,omitempty"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	// Members and CHV are only loaded for a single household
+	Members []*HouseholdMember `json:"members,omitempty"`
+	CHV     *CHVAssignment     `json:"chv,omitempty"`
+}
+
+type HouseholdMember struct {
+	ID           uuid.UUID  `json:"id"`
+	HouseholdID  uuid.UUID  `json:"household_id"`
+	PatientID    uuid.UUID  `json:"patient_id"`
+	PatientName  *string    `json:"patient_name,omitempty"`
+	Relationship *string    `json:"relationship,omitempty"`
+	IsHead       bool       `json:"is_head"`
+	AddedBy      *uuid.UUID `json:"added_by,omitempty"`
+	AddedAt      time.Time  `json:"added_at"`
+}
+
+// CHVAssignment puts a household on a CHV's caseload
+type CHVAssignment struct {
+	ID          uuid.UUID  `json:"id"`
+	HouseholdID uuid.UUID  `json:"household_id"`
+	CHVUserID   uuid.UUID  `json:"chv_user_id"`
+	CHVName     *string    `json:"chv_name,omitempty"`
+	AssignedBy  *uuid.UUID `json:"assigned_by,omitempty"`
+	AssignedAt  time.Time  `json:"assigned_at"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"`
+}
+
+type CreateHouseholdRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Village   *string  `json:"village"`
+	Landmark  *string  `json:"landmark"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+func (r *CreateHouseholdRequest) Validate() error {
+	if (r.Latitude == nil) != (r.Longitude == nil) {
+		return fmt.Errorf("latitude and longitude must be provided together")
+	}
+	if r.Latitude != nil {
+		return ValidateKenyaCoordinates(*r.Latitude, *r.Longitude)
+	}
+	return nil
+}
+
+type AddHouseholdMemberRequest struct {
+	PatientID    uuid.UUID `json:"patient_id" binding:"required"`
+	Relationship *string   `json:"relationship"`
+	IsHead       bool      `json:"is_head"`
+}
+
+type AssignCHVRequest struct {
+	CHVUserID uuid.UUID `json:"chv_user_id" binding:"required"`
+}
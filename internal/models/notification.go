@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationKind string
+
+const (
+	NotificationReferralAccepted  NotificationKind = "referral_accepted"
+	NotificationReferralCompleted NotificationKind = "referral_completed"
+	NotificationReferralNoShow    NotificationKind = "referral_no_show"
+	// NotificationReferralUnattended asks the CHV to chase a referral the
+	// patient has not acted on
+	NotificationReferralUnattended NotificationKind = "referral_unattended"
+	// NotificationReferralTransferred gives the CHV the new facility and
+	// token after the receiving facility forwarded a referral
+	NotificationReferralTransferred NotificationKind = "referral_transferred"
+)
+
+// NotificationKindForStatus is the notification sent to the referring CHV
+// when a referral reaches a status, if any
+func NotificationKindForStatus(status ReferralStatus) (NotificationKind, bool) {
+	switch status {
+	case ReferralStatusAccepted:
+		return NotificationReferralAccepted, true
+	case ReferralStatusCompleted:
+		return NotificationReferralCompleted, true
+	case ReferralStatusNoShow:
+		return NotificationReferralNoShow, true
+	}
+	return "", false
+}
+
+type SMSStatus string
+
+const (
+	SMSStatusPending SMSStatus = "pending"
+	SMSStatusSent    SMSStatus = "sent"
+	SMSStatusFailed  SMSStatus = "failed"
+	// SMSStatusSkipped is for users without a phone number to text
+	SMSStatusSkipped SMSStatus = "skipped"
+	// SMSStatusLogged is for messages written to the log in development
+	// instead of being sent
+	SMSStatusLogged SMSStatus = "logged"
+)
+
+// Notification is an entry in a user's in-app inbox. The same text is sent
+// by SMS; SMSStatus records how that went.
+type Notification struct {
+	ID         uuid.UUID        `json:"id"`
+	UserID     uuid.UUID        `json:"user_id"`
+	Kind       NotificationKind `json:"kind"`
+	ReferralID *uuid.UUID       `json:"referral_id,omitempty"`
+	Body       string           `json:"body"`
+	SMSStatus  SMSStatus        `json:"sms_status"`
+	ReadAt     *time.Time       `json:"read_at,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+type ListNotificationsRequest struct {
+	UnreadOnly bool `form:"unread_only"`
+	Limit      int  `form:"limit" binding:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,273 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FacilityTimezone is the zone facility operating hours are written in
+var FacilityTimezone = loadFacilityTimezone()
+
+func loadFacilityTimezone() *time.Location {
+	loc, err := time.LoadLocation("Africa/Nairobi")
+	if err != nil {
+		// No tzdata on the host; Kenya has been UTC+3 without DST since 1960
+		return time.FixedZone("EAT", 3*60*60)
+	}
+	return loc
+}
+
+// Weekdays lists the keys of OperatingHours.Days in week order
+var Weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// nextOpenHorizonDays bounds how far ahead NextOpen searches
+const nextOpenHorizonDays = 14
+
+// TimeInterval is an opening interval in HH:MM local time. Closes may be
+// "24:00" for end of day; a close earlier than the open time runs overnight
+// into the next day.
+type TimeInterval struct {
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// OperatingHours is a weekly schedule keyed by lowercase weekday. A day that
+// is missing or has no intervals is closed; AlwaysOpen overrides Days.
+type OperatingHours struct {
+	AlwaysOpen bool                      `json:"always_open"`
+	Days       map[string][]TimeInterval `json:"days"`
+}
+
+// IsZero reports whether no schedule has been recorded
+func (h OperatingHours) IsZero() bool {
+	return !h.AlwaysOpen && len(h.Days) == 0
+}
+
+func (h OperatingHours) MarshalJSON() ([]byte, error) {
+	days := h.Days
+	if days == nil {
+		days = map[string][]TimeInterval{}
+	}
+	return json.Marshal(struct {
+		AlwaysOpen bool                      `json:"always_open"`
+		Days       map[string][]TimeInterval `json:"days"`
+	}{h.AlwaysOpen, days})
+}
+
+// UnmarshalJSON accepts the structured schedule and the legacy free-text
+// form, e.g. {"monday": "08:00-17:00", "saturday": "24/7"}
+func (h *OperatingHours) UnmarshalJSON(data []byte) error {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+	_, hasDays := probe["days"]
+	_, hasAlwaysOpen := probe["always_open"]
+
+	if hasDays || hasAlwaysOpen || len(probe) == 0 {
+		var structured struct {
+			AlwaysOpen bool                      `json:"always_open"`
+			Days       map[string][]TimeInterval `json:"days"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&structured); err != nil {
+			return err
+		}
+		h.AlwaysOpen = structured.AlwaysOpen
+		h.Days = structured.Days
+		return nil
+	}
+
+	var legacy map[string]string
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("operating hours: %w", err)
+	}
+	parsed, err := ParseLegacyOperatingHours(legacy)
+	if err != nil {
+		return err
+	}
+	*h = parsed
+	return nil
+}
+
+// ParseLegacyOperatingHours converts the free-text map used before structured
+// schedules. Values may be "HH:MM-HH:MM", several comma-separated ranges,
+// "24/7" or "closed".
+func ParseLegacyOperatingHours(legacy map[string]string) (OperatingHours, error) {
+	hours := OperatingHours{Days: make(map[string][]TimeInterval, len(legacy))}
+	allDay := 0
+
+	for day, value := range legacy {
+		day = strings.ToLower(strings.TrimSpace(day))
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		switch value {
+		case "24/7", "24h", "24 hours":
+			hours.Days[day] = []TimeInterval{{Opens: "00:00", Closes: "24:00"}}
+			allDay++
+			continue
+		case "closed", "":
+			hours.Days[day] = []TimeInterval{}
+			continue
+		}
+
+		for _, part := range strings.Split(value, ",") {
+			opens, closes, found := strings.Cut(strings.TrimSpace(part), "-")
+			if !found {
+				return OperatingHours{}, fmt.Errorf("operating hours for %s: cannot parse %q", day, value)
+			}
+			hours.Days[day] = append(hours.Days[day], TimeInterval{
+				Opens:  strings.TrimSpace(opens),
+				Closes: strings.TrimSpace(closes),
+			})
+		}
+	}
+
+	if allDay == len(Weekdays) {
+		hours = OperatingHours{AlwaysOpen: true}
+	}
+
+	return hours, hours.Validate()
+}
+
+// Validate checks day names and interval times
+func (h OperatingHours) Validate() error {
+	for day, intervals := range h.Days {
+		if !isWeekday(day) {
+			return fmt.Errorf("operating hours: unknown day %q", day)
+		}
+		for _, interval := range intervals {
+			opens, err := parseClock(interval.Opens)
+			if err != nil || opens == minutesPerDay {
+				return fmt.Errorf("operating hours for %s: invalid opening time %q", day, interval.Opens)
+			}
+			closes, err := parseClock(interval.Closes)
+			if err != nil {
+				return fmt.Errorf("operating hours for %s: invalid closing time %q", day, interval.Closes)
+			}
+			if opens == closes {
+				return fmt.Errorf("operating hours for %s: interval %s-%s is empty", day, interval.Opens, interval.Closes)
+			}
+		}
+	}
+	return nil
+}
+
+func isWeekday(day string) bool {
+	for _, d := range Weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+const minutesPerDay = 24 * 60
+
+// parseClock converts "HH:MM" into minutes after midnight; "24:00" is allowed
+func parseClock(value string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hour, &minute); err != nil {
+		return 0, err
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("out of range")
+	}
+	return hour*60 + minute, nil
+}
+
+// span is an interval in minutes relative to the start of the day it is listed under
+type span struct {
+	start, end int
+}
+
+// spans returns the opening spans listed under a weekday, sorted by start.
+// Overnight intervals end after minutesPerDay.
+func (h OperatingHours) spans(weekday time.Weekday) []span {
+	if h.AlwaysOpen {
+		return []span{{0, minutesPerDay}}
+	}
+
+	var spans []span
+	for _, interval := range h.Days[Weekdays[weekday]] {
+		opens, err := parseClock(interval.Opens)
+		if err != nil {
+			continue
+		}
+		closes, err := parseClock(interval.Closes)
+		if err != nil || closes == opens {
+			continue
+		}
+		if closes < opens {
+			closes += minutesPerDay
+		}
+		spans = append(spans, span{opens, closes})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	return spans
+}
+
+// OpenAt reports whether the schedule covers t. closedOn reports days (in
+// FacilityTimezone) on which the facility does not open at all; it may be nil.
+func (h OperatingHours) OpenAt(t time.Time, closedOn func(day time.Time) bool) bool {
+	local := t.In(FacilityTimezone)
+	today := startOfDay(local)
+	minute := local.Hour()*60 + local.Minute()
+
+	// Check today's spans, then overnight spans carried over from yesterday
+	for offset, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+		if closedOn != nil && closedOn(day) {
+			continue
+		}
+		at := minute + offset*minutesPerDay
+		for _, s := range h.spans(day.Weekday()) {
+			if at >= s.start && at < s.end {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// NextOpen returns the next time after t at which the facility opens, looking
+// up to two weeks ahead. It returns false when no opening is scheduled.
+func (h OperatingHours) NextOpen(t time.Time, closedOn func(day time.Time) bool) (time.Time, bool) {
+	local := t.In(FacilityTimezone)
+	today := startOfDay(local)
+
+	for offset := 0; offset <= nextOpenHorizonDays; offset++ {
+		day := today.AddDate(0, 0, offset)
+		if closedOn != nil && closedOn(day) {
+			continue
+		}
+		for _, s := range h.spans(day.Weekday()) {
+			opens := day.Add(time.Duration(s.start) * time.Minute)
+			if opens.After(local) {
+				// A 24-hour day following an open evening is not a new opening
+				if h.OpenAt(opens.Add(-time.Minute), closedOn) {
+					continue
+				}
+				return opens, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// ClosureWindow returns the date range of closures needed to evaluate open
+// status between from and to, covering overnight spans and the NextOpen horizon
+func ClosureWindow(from, to time.Time) (time.Time, time.Time) {
+	return from.In(FacilityTimezone).AddDate(0, 0, -1), to.In(FacilityTimezone).AddDate(0, 0, nextOpenHorizonDays+1)
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eat builds a time in Nairobi; 15 January 2024 is a Monday
+func eat(day, hour, minute int) time.Time {
+	return time.Date(2024, 1, day, hour, minute, 0, 0, FacilityTimezone)
+}
+
+func TestOperatingHoursLegacyJSON(t *testing.T) {
+	var hours OperatingHours
+	err := json.Unmarshal([]byte(`{"monday": "08:00-12:00, 14:00-17:00", "saturday": "closed", "sunday": "24/7"}`), &hours)
+	require.NoError(t, err)
+
+	assert.False(t, hours.AlwaysOpen)
+	assert.Equal(t, []TimeInterval{{"08:00", "12:00"}, {"14:00", "17:00"}}, hours.Days["monday"])
+	assert.Empty(t, hours.Days["saturday"])
+	assert.Equal(t, []TimeInterval{{"00:00", "24:00"}}, hours.Days["sunday"])
+
+	var allWeek OperatingHours
+	err = json.Unmarshal([]byte(`{"monday": "24/7", "tuesday": "24/7", "wednesday": "24/7", "thursday": "24/7", "friday": "24/7", "saturday": "24/7", "sunday": "24/7"}`), &allWeek)
+	require.NoError(t, err)
+	assert.True(t, allWeek.AlwaysOpen)
+
+	err = json.Unmarshal([]byte(`{"monday": "8am to 5pm"}`), &hours)
+	assert.Error(t, err)
+}
+
+func TestOperatingHoursStructuredJSON(t *testing.T) {
+	hours := OperatingHours{Days: map[string][]TimeInterval{"friday": {{"20:00", "06:00"}}}}
+
+	data, err := json.Marshal(hours)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"always_open": false, "days": {"friday": [{"opens": "20:00", "closes": "06:00"}]}}`, string(data))
+
+	var decoded OperatingHours
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, hours, decoded)
+
+	var empty OperatingHours
+	require.NoError(t, json.Unmarshal([]byte(`{}`), &empty))
+	assert.True(t, empty.IsZero())
+}
+
+func TestOperatingHoursValidate(t *testing.T) {
+	valid := OperatingHours{Days: map[string][]TimeInterval{"monday": {{"08:00", "24:00"}}}}
+	assert.NoError(t, valid.Validate())
+
+	for name, hours := range map[string]OperatingHours{
+		"unknown day":  {Days: map[string][]TimeInterval{"funday": {{"08:00", "17:00"}}}},
+		"bad time":     {Days: map[string][]TimeInterval{"monday": {{"8", "17:00"}}}},
+		"out of range": {Days: map[string][]TimeInterval{"monday": {{"08:00", "25:00"}}}},
+		"empty":        {Days: map[string][]TimeInterval{"monday": {{"08:00", "08:00"}}}},
+		"opens at 24h": {Days: map[string][]TimeInterval{"monday": {{"24:00", "08:00"}}}},
+	} {
+		assert.Error(t, hours.Validate(), name)
+	}
+}
+
+func TestOperatingHoursOpenAt(t *testing.T) {
+	hours := OperatingHours{Days: map[string][]TimeInterval{
+		"monday":   {{"08:00", "12:00"}, {"14:00", "17:00"}},
+		"tuesday":  {{"20:00", "06:00"}},
+		"saturday": {},
+	}}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		open bool
+	}{
+		{"Monday morning", eat(15, 9, 0), true},
+		{"Monday lunch break", eat(15, 13, 0), false},
+		{"Monday afternoon", eat(15, 16, 59), true},
+		{"Monday at closing", eat(15, 17, 0), false},
+		{"Tuesday night", eat(16, 23, 0), true},
+		{"Wednesday early morning, overnight from Tuesday", eat(17, 5, 30), true},
+		{"Wednesday after overnight shift", eat(17, 6, 0), false},
+		{"Saturday", eat(20, 10, 0), false},
+		{"UTC input is read in Nairobi time", time.Date(2024, 1, 15, 6, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.open, hours.OpenAt(tt.t, nil))
+		})
+	}
+
+	assert.True(t, OperatingHours{AlwaysOpen: true}.OpenAt(eat(20, 3, 0), nil))
+}
+
+func TestOperatingHoursNextOpen(t *testing.T) {
+	hours := OperatingHours{Days: map[string][]TimeInterval{
+		"monday": {{"08:00", "12:00"}, {"14:00", "17:00"}},
+		"friday": {{"08:00", "17:00"}},
+	}}
+
+	next, ok := hours.NextOpen(eat(15, 12, 30), nil)
+	require.True(t, ok)
+	assert.Equal(t, eat(15, 14, 0), next)
+
+	next, ok = hours.NextOpen(eat(15, 18, 0), nil)
+	require.True(t, ok)
+	assert.Equal(t, eat(19, 8, 0), next)
+
+	// Closed on Friday 19th, so the next opening is the following Monday
+	closedFriday := func(day time.Time) bool { return day.Day() == 19 }
+	next, ok = hours.NextOpen(eat(15, 18, 0), closedFriday)
+	require.True(t, ok)
+	assert.Equal(t, eat(22, 8, 0), next)
+
+	_, ok = OperatingHours{Days: map[string][]TimeInterval{}}.NextOpen(eat(15, 18, 0), nil)
+	assert.False(t, ok)
+}
+
+func TestFacilitySetOpenStatus(t *testing.T) {
+	clinic := &Facility{
+		ID:             uuid.New(),
+		OperatingHours: OperatingHours{Days: map[string][]TimeInterval{"monday": {{"08:00", "17:00"}}, "tuesday": {{"08:00", "17:00"}}}},
+	}
+	hospital := &Facility{ID: uuid.New(), OperatingHours: OperatingHours{AlwaysOpen: true}}
+	holiday := &FacilityClosure{Kind: ClosureKindPublicHoliday, StartsOn: eat(15, 0, 0), EndsOn: eat(15, 0, 0)}
+	closures := []*FacilityClosure{holiday}
+
+	clinic.SetOpenStatus(eat(15, 10, 0), closures)
+	require.NotNil(t, clinic.OpenNow)
+	assert.False(t, *clinic.OpenNow)
+	require.NotNil(t, clinic.NextOpen)
+	assert.Equal(t, eat(16, 8, 0), *clinic.NextOpen)
+
+	// 24-hour facilities stay open on public holidays but not during closures
+	hospital.SetOpenStatus(eat(15, 10, 0), closures)
+	assert.True(t, *hospital.OpenNow)
+
+	shutdown := &FacilityClosure{FacilityID: &hospital.ID, Kind: ClosureKindClosure, StartsOn: eat(15, 0, 0), EndsOn: eat(15, 0, 0)}
+	open, known := hospital.IsOpenAt(eat(15, 10, 0), []*FacilityClosure{shutdown})
+	assert.True(t, known)
+	assert.False(t, open)
+
+	unknown := &Facility{}
+	unknown.SetOpenStatus(eat(15, 10, 0), nil)
+	assert.Nil(t, unknown.OpenNow)
+}
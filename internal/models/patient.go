@@ -1,28 +1,235 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/phone"
 )
 
 type Patient struct {
-	ID                uuid.UUID         `json:"id"`
-	Phone             string            `json:"phone"`
-	Name              *string           `json:"name,omitempty"`
-	DateOfBirth       *time.Time        `json:"date_of_birth,omitempty"`
-	Gender            *string           `json:"gender,omitempty"`
-	PreferredLanguage string            `json:"preferred_language"`
-	ConsentFlags      map[string]bool   `json:"consent_flags"`
-	CreatedAt         time.Time         `json:"created_at"`
-	UpdatedAt         time.Time         `json:"updated_at"`
+	ID                uuid.UUID       `json:"id"`
+	Phone             string          `json:"phone"`
+	PhoneCarrier      phone.Carrier   `json:"phone_carrier"`
+	Name              *string         `json:"name,omitempty"`
+	DateOfBirth       *time.Time      `json:"date_of_birth,omitempty"`
+	Gender            *string         `json:"gender,omitempty"`
+	PreferredLanguage string          `json:"preferred_language"`
+	ConsentFlags      map[string]bool `json:"consent_flags"`
+	// MergedInto is set once this record has been merged into another patient
+	MergedInto *uuid.UUID `json:"merged_into,omitempty"`
+	MergedAt   *time.Time `json:"merged_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ETag identifies this version of the record for If-Match checks
+func (p *Patient) ETag() string {
+	return `"` + strconv.FormatInt(p.UpdatedAt.UnixMicro(), 10) + `"`
+}
+
+// PatientContact is what is needed to text a patient
+type PatientContact struct {
+	Phone             string
+	PreferredLanguage string
+	SMSConsentRefused bool
 }
 
+// Genders accepted for patients
+var Genders = []string{"male", "female", "other"}
+
+// SupportedLanguages are the languages SMS and USSD content is available in
+var SupportedLanguages = []string{"en", "sw"}
+
+const DefaultLanguage = "en"
+
 type CreatePatientRequest struct {
-	Phone             string          `json:"phone" binding:"required"`
+	Phone             string          `json:"phone" binding:"required,kephone"`
 	Name              *string         `json:"name"`
 	DateOfBirth       *time.Time      `json:"date_of_birth"`
-	Gender            *string         `json:"gender"`
-	PreferredLanguage string          `json:"preferred_language"`
+	Gender            *string         `json:"gender" binding:"omitempty,oneof=male female other"`
+	PreferredLanguage string          `json:"preferred_language" binding:"omitempty,oneof=en sw"`
 	ConsentFlags      map[string]bool `json:"consent_flags"`
-}
\ No newline at end of file
+	// ConfirmNew creates the patient even though similar patients exist.
+	// It does not override a phone number that is already registered.
+	ConfirmNew bool `json:"confirm_new"`
+	// RegisteredBy is the user registering the patient, set by the handler
+	RegisteredBy *uuid.UUID `json:"-"`
+}
+
+// DateLayout is the format for date-only query parameters
+const DateLayout = "2006-01-02"
+
+const (
+	DefaultPatientSearchLimit = 20
+	MaxPatientSearchLimit     = 50
+)
+
+// SearchPatientsRequest finds patients by phone, fuzzy name and date of
+// birth. At least one filter is required; patients are never listed in bulk.
+type SearchPatientsRequest struct {
+	Phone       string `form:"phone" binding:"omitempty,kephone"`
+	Name        string `form:"name"`
+	DateOfBirth string `form:"dob"`
+	Limit       int    `form:"limit" binding:"omitempty,min=1,max=50"`
+	// FacilityID limits results to patients seen at a facility. It is set
+	// from a facility-bound API key, never from the query string.
+	FacilityID *uuid.UUID `form:"-"`
+	// CHVUserID limits results to the patients a CHV looks after. It is set
+	// from a CHV session, never from the query string.
+	CHVUserID *uuid.UUID `form:"-"`
+}
+
+// ParseDateOfBirth returns the dob filter, or nil when it is not set
+func (r *SearchPatientsRequest) ParseDateOfBirth() (*time.Time, error) {
+	if r.DateOfBirth == "" {
+		return nil, nil
+	}
+	dob, err := time.Parse(DateLayout, r.DateOfBirth)
+	if err != nil {
+		return nil, fmt.Errorf("dob must be a date in YYYY-MM-DD format")
+	}
+	return &dob, nil
+}
+
+// PatientMatch is an existing patient that may be the same person as a new registration
+type PatientMatch struct {
+	Patient *Patient `json:"patient"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// PatchField is one member of a JSON Merge Patch (RFC 7396): absent leaves
+// the value alone, null clears it and anything else replaces it
+type PatchField[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (f *PatchField[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+// PatchPatientRequest is a JSON Merge Patch of a patient. consent_flags is
+// merged key by key; a null flag removes it.
+type PatchPatientRequest struct {
+	Phone             PatchField[string]           `json:"phone"`
+	Name              PatchField[string]           `json:"name"`
+	DateOfBirth       PatchField[string]           `json:"date_of_birth"`
+	Gender            PatchField[string]           `json:"gender"`
+	PreferredLanguage PatchField[string]           `json:"preferred_language"`
+	ConsentFlags      PatchField[map[string]*bool] `json:"consent_flags"`
+
+	dateOfBirth *time.Time
+}
+
+// DecodePatchPatientRequest parses a merge patch, rejecting unknown members
+func DecodePatchPatientRequest(data []byte) (*PatchPatientRequest, error) {
+	var req PatchPatientRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Validate checks the patch against the field rules. Phone normalization is
+// left to the caller; now bounds the date of birth.
+func (r *PatchPatientRequest) Validate(now time.Time) error {
+	if r.Phone.Set && (r.Phone.Null || r.Phone.Value == "") {
+		return fmt.Errorf("phone cannot be removed")
+	}
+	if r.Name.Set && !r.Name.Null && r.Name.Value == "" {
+		return fmt.Errorf("name cannot be empty; send null to remove it")
+	}
+
+	if r.DateOfBirth.Set && !r.DateOfBirth.Null {
+		dob, err := parseDateOfBirth(r.DateOfBirth.Value)
+		if err != nil {
+			return err
+		}
+		if dob.After(now) {
+			return fmt.Errorf("date_of_birth cannot be in the future")
+		}
+		r.dateOfBirth = &dob
+	}
+
+	if r.Gender.Set && !r.Gender.Null && !contains(Genders, r.Gender.Value) {
+		return fmt.Errorf("gender must be one of %v", Genders)
+	}
+	if r.PreferredLanguage.Set && (r.PreferredLanguage.Null || !contains(SupportedLanguages, r.PreferredLanguage.Value)) {
+		return fmt.Errorf("preferred_language must be one of %v", SupportedLanguages)
+	}
+
+	return nil
+}
+
+// Apply merges a validated patch into p
+func (r *PatchPatientRequest) Apply(p *Patient) {
+	if r.Phone.Set {
+		p.Phone = r.Phone.Value
+	}
+	if r.Name.Set {
+		p.Name = nil
+		if !r.Name.Null {
+			name := r.Name.Value
+			p.Name = &name
+		}
+	}
+	if r.DateOfBirth.Set {
+		p.DateOfBirth = r.dateOfBirth
+	}
+	if r.Gender.Set {
+		p.Gender = nil
+		if !r.Gender.Null {
+			gender := r.Gender.Value
+			p.Gender = &gender
+		}
+	}
+	if r.PreferredLanguage.Set {
+		p.PreferredLanguage = r.PreferredLanguage.Value
+	}
+	if r.ConsentFlags.Set {
+		if r.ConsentFlags.Null || p.ConsentFlags == nil {
+			p.ConsentFlags = map[string]bool{}
+		}
+		for flag, value := range r.ConsentFlags.Value {
+			if value == nil {
+				delete(p.ConsentFlags, flag)
+			} else {
+				p.ConsentFlags[flag] = *value
+			}
+		}
+	}
+}
+
+// parseDateOfBirth accepts a date ("1990-05-14") or an RFC 3339 timestamp
+func parseDateOfBirth(value string) (time.Time, error) {
+	if dob, err := time.Parse(DateLayout, value); err == nil {
+		return dob, nil
+	}
+	if dob, err := time.Parse(time.RFC3339, value); err == nil {
+		return dob, nil
+	}
+	return time.Time{}, fmt.Errorf("date_of_birth must be a date in YYYY-MM-DD format")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PatientMerge records one duplicate patient folded into a survivor
+type PatientMerge struct {
+	ID         uuid.UUID `json:"id"`
+	SurvivorID uuid.UUID `json:"survivor_id"`
+	RetiredID  uuid.UUID `json:"retired_id"`
+	// Moved lists the IDs re-pointed from the retired patient, keyed by table
+	Moved map[string][]uuid.UUID `json:"moved"`
+	// FilledFields are survivor fields that were empty and copied from the retired patient
+	FilledFields []string   `json:"filled_fields"`
+	Reason       *string    `json:"reason,omitempty"`
+	MergedBy     *uuid.UUID `json:"merged_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UndoneAt     *time.Time `json:"undone_at,omitempty"`
+	UndoneBy     *uuid.UUID `json:"undone_by,omitempty"`
+}
+
+// MergePatientRequest merges DuplicateID into the patient in the URL
+type MergePatientRequest struct {
+	DuplicateID uuid.UUID `json:"duplicate_id" binding:"required"`
+	Reason      *string   `json:"reason"`
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limits on the history included in a patient summary
+const (
+	SummaryRecentTriageLimit    = 5
+	SummaryPastReferralLimit    = 10
+	SummaryUpcomingAppointments = 5
+	// Children under this age also get their age in months
+	summaryAgeInMonthsUnder = 5
+)
+
+// PatientSummary is the longitudinal view of a patient that a clinician
+// receiving a referral needs, assembled in a single query
+type PatientSummary struct {
+	Patient              *Patient              `json:"patient"`
+	AgeYears             *int                  `json:"age_years,omitempty"`
+	AgeMonths            *int                  `json:"age_months,omitempty"`
+	RecentTriage         []*TriageSummary      `json:"recent_triage"`
+	OpenReferrals        []*ReferralSummary    `json:"open_referrals"`
+	PastReferrals        []*ReferralSummary    `json:"past_referrals"`
+	UpcomingAppointments []*AppointmentSummary `json:"upcoming_appointments"`
+	Consents             []*ConsentStatus      `json:"consents"`
+	// Redacted lists the fields withheld from this caller
+	Redacted    []string  `json:"redacted,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// TriageSummary is a triage session without the raw model response
+type TriageSummary struct {
+	ID                uuid.UUID              `json:"id"`
+	TriageLevel       *TriageLevel           `json:"triage_level,omitempty"`
+	TriageCode        *string                `json:"triage_code,omitempty"`
+	RecommendedAction *string                `json:"recommended_action,omitempty"`
+	SummaryText       *string                `json:"summary_text,omitempty"`
+	Symptoms          map[string]interface{} `json:"symptoms,omitempty"`
+	Channel           string                 `json:"channel"`
+	CreatedAt         time.Time              `json:"created_at"`
+}
+
+// ReferralSummary is a referral with the name of the facility it went to.
+// For closed referrals the status is the outcome.
+type ReferralSummary struct {
+	ID            uuid.UUID      `json:"id"`
+	FacilityID    *uuid.UUID     `json:"facility_id,omitempty"`
+	FacilityName  *string        `json:"facility_name,omitempty"`
+	ReferralToken string         `json:"referral_token,omitempty"`
+	Status        ReferralStatus `json:"status"`
+	Priority      *TriageLevel   `json:"priority,omitempty"`
+	Notes         *string        `json:"notes,omitempty"`
+	AcceptedAt    *time.Time     `json:"accepted_at,omitempty"`
+	CompletedAt   *time.Time     `json:"completed_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	// Outcome is set once the receiving clinician has recorded one
+	Outcome *ReferralOutcome `json:"outcome,omitempty"`
+}
+
+// IsOpen reports whether the referral still awaits the patient
+func (r *ReferralSummary) IsOpen() bool {
+	return r.Status == ReferralStatusPending || r.Status == ReferralStatusAccepted
+}
+
+type AppointmentSummary struct {
+	ID            uuid.UUID  `json:"id"`
+	ReferralID    *uuid.UUID `json:"referral_id,omitempty"`
+	FacilityID    *uuid.UUID `json:"facility_id,omitempty"`
+	FacilityName  *string    `json:"facility_name,omitempty"`
+	ScheduledTime time.Time  `json:"scheduled_time"`
+	Status        string     `json:"status"`
+	Notes         *string    `json:"notes,omitempty"`
+}
+
+// ConsentStatus is the latest consent decision of one type
+type ConsentStatus struct {
+	ConsentType ConsentType `json:"consent_type"`
+	Granted     bool        `json:"granted"`
+	GrantedAt   time.Time   `json:"granted_at"`
+}
+
+// SummaryAccess is how much of a patient summary a caller may see
+type SummaryAccess string
+
+const (
+	// SummaryAccessFull is for clinicians and admins
+	SummaryAccessFull SummaryAccess = "full"
+	// SummaryAccessCareTeam is for CHVs: history and levels, no clinical notes
+	SummaryAccessCareTeam SummaryAccess = "care_team"
+	// SummaryAccessPartner is for facility systems using an API key: as for
+	// CHVs, and the patient is identified by age rather than contact details
+	SummaryAccessPartner SummaryAccess = "partner"
+)
+
+// SummaryAccessForRole maps a session role onto summary access. Patients
+// have no access; their own records are served through SMS and USSD.
+func SummaryAccessForRole(role UserRole) (SummaryAccess, bool) {
+	switch role {
+	case UserRoleAdmin, UserRoleClinician:
+		return SummaryAccessFull, true
+	case UserRoleCHV:
+		return SummaryAccessCareTeam, true
+	}
+	return "", false
+}
+
+// SetAge fills in the patient's age as of now
+func (s *PatientSummary) SetAge(now time.Time) {
+	s.AgeYears, s.AgeMonths = nil, nil
+	if s.Patient == nil || s.Patient.DateOfBirth == nil || s.Patient.DateOfBirth.After(now) {
+		return
+	}
+
+	months := AgeInMonths(*s.Patient.DateOfBirth, now)
+	years := months / 12
+	s.AgeYears = &years
+	if years < summaryAgeInMonthsUnder {
+		s.AgeMonths = &months
+	}
+}
+
+// AgeInMonths counts the whole months from dob to now
+func AgeInMonths(dob, now time.Time) int {
+	dobY, dobM, dobD := dob.Date()
+	nowY, nowM, nowD := now.In(dob.Location()).Date()
+
+	months := (nowY-dobY)*12 + int(nowM-dobM)
+	if nowD < dobD {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+// Redact removes what the given access level may not see and records it in Redacted
+func (s *PatientSummary) Redact(access SummaryAccess) {
+	if access == SummaryAccessFull {
+		return
+	}
+
+	redactTriage(s.RecentTriage)
+	redactReferrals(s.OpenReferrals)
+	redactReferrals(s.PastReferrals)
+	for _, appointment := range s.UpcomingAppointments {
+		appointment.Notes = nil
+	}
+	s.Redacted = []string{"triage.symptoms", "triage.summary_text", "referrals.notes", "appointments.notes"}
+
+	if access == SummaryAccessPartner && s.Patient != nil {
+		redacted := *s.Patient
+		redacted.Phone = ""
+		redacted.PhoneCarrier = ""
+		redacted.DateOfBirth = nil
+		redacted.ConsentFlags = nil
+		s.Patient = &redacted
+		for _, referral := range append(append([]*ReferralSummary{}, s.OpenReferrals...), s.PastReferrals...) {
+			referral.ReferralToken = ""
+		}
+		s.Redacted = append(s.Redacted, "patient.phone", "patient.date_of_birth", "patient.consent_flags", "referrals.referral_token")
+	}
+}
+
+// redactTriage drops the clinical free text from triage sessions
+func redactTriage(sessions []*TriageSummary) {
+	for _, triage := range sessions {
+		triage.Symptoms = nil
+		triage.SummaryText = nil
+	}
+}
+
+// redactReferrals drops clinicians' notes from referrals and their outcomes
+func redactReferrals(referrals []*ReferralSummary) {
+	for _, referral := range referrals {
+		referral.Notes = nil
+		if referral.Outcome != nil {
+			outcome := *referral.Outcome
+			outcome.Notes = nil
+			referral.Outcome = &outcome
+		}
+	}
+}
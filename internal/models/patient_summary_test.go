@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgeInMonths(t *testing.T) {
+	dob := time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 0, AgeInMonths(dob, time.Date(2020, 4, 14, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 1, AgeInMonths(dob, time.Date(2020, 4, 15, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 47, AgeInMonths(dob, time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 48, AgeInMonths(dob, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestPatientSummarySetAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	child := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+	summary := &PatientSummary{Patient: &Patient{DateOfBirth: &child}}
+	summary.SetAge(now)
+	require.NotNil(t, summary.AgeYears)
+	assert.Equal(t, 2, *summary.AgeYears)
+	require.NotNil(t, summary.AgeMonths, "young children also get months")
+	assert.Equal(t, 28, *summary.AgeMonths)
+
+	adult := time.Date(1990, 5, 14, 0, 0, 0, 0, time.UTC)
+	summary.Patient.DateOfBirth = &adult
+	summary.SetAge(now)
+	assert.Equal(t, 34, *summary.AgeYears)
+	assert.Nil(t, summary.AgeMonths)
+
+	summary.Patient.DateOfBirth = nil
+	summary.SetAge(now)
+	assert.Nil(t, summary.AgeYears)
+}
+
+func TestSummaryAccessForRole(t *testing.T) {
+	access, ok := SummaryAccessForRole(UserRoleClinician)
+	assert.True(t, ok)
+	assert.Equal(t, SummaryAccessFull, access)
+
+	access, ok = SummaryAccessForRole(UserRoleCHV)
+	assert.True(t, ok)
+	assert.Equal(t, SummaryAccessCareTeam, access)
+
+	_, ok = SummaryAccessForRole(UserRolePatient)
+	assert.False(t, ok)
+}
+
+func TestPatientSummaryRedact(t *testing.T) {
+	newSummary := func() *PatientSummary {
+		dob := time.Date(1990, 5, 14, 0, 0, 0, 0, time.UTC)
+		note := "Suspected pre-eclampsia"
+		return &PatientSummary{
+			Patient:              &Patient{Phone: "+254712345678", DateOfBirth: &dob, ConsentFlags: map[string]bool{"sms": true}},
+			RecentTriage:         []*TriageSummary{{SummaryText: &note, Symptoms: map[string]interface{}{"headache": true}}},
+			OpenReferrals:        []*ReferralSummary{{ReferralToken: "REF123", Notes: &note}},
+			PastReferrals:        []*ReferralSummary{{ReferralToken: "REF122", Notes: &note}},
+			UpcomingAppointments: []*AppointmentSummary{{Notes: &note}},
+		}
+	}
+
+	full := newSummary()
+	full.Redact(SummaryAccessFull)
+	assert.NotNil(t, full.RecentTriage[0].SummaryText)
+	assert.Empty(t, full.Redacted)
+
+	careTeam := newSummary()
+	careTeam.Redact(SummaryAccessCareTeam)
+	assert.Nil(t, careTeam.RecentTriage[0].SummaryText)
+	assert.Nil(t, careTeam.RecentTriage[0].Symptoms)
+	assert.Nil(t, careTeam.OpenReferrals[0].Notes)
+	assert.Nil(t, careTeam.PastReferrals[0].Notes)
+	assert.Nil(t, careTeam.UpcomingAppointments[0].Notes)
+	assert.Equal(t, "+254712345678", careTeam.Patient.Phone)
+	assert.Equal(t, "REF123", careTeam.OpenReferrals[0].ReferralToken)
+
+	original := newSummary()
+	patient := original.Patient
+	original.Redact(SummaryAccessPartner)
+	assert.Empty(t, original.Patient.Phone)
+	assert.Nil(t, original.Patient.DateOfBirth)
+	assert.Empty(t, original.OpenReferrals[0].ReferralToken)
+	assert.Contains(t, original.Redacted, "patient.phone")
+	assert.Equal(t, "+254712345678", patient.Phone, "the patient record itself is not modified")
+}
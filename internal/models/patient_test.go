@@ -0,0 +1,101 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePatchPatientRequest(t *testing.T) {
+	req, err := DecodePatchPatientRequest([]byte(`{"name": null, "gender": "female"}`))
+	require.NoError(t, err)
+
+	assert.True(t, req.Name.Set)
+	assert.True(t, req.Name.Null)
+	assert.True(t, req.Gender.Set)
+	assert.Equal(t, "female", req.Gender.Value)
+	assert.False(t, req.Phone.Set, "absent members are left alone")
+
+	_, err = DecodePatchPatientRequest([]byte(`{"nickname": "Shiku"}`))
+	assert.Error(t, err, "unknown members are rejected")
+}
+
+func TestPatchPatientRequestValidate(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "Valid patch", body: `{"name": "Wanjiku", "date_of_birth": "1990-05-14", "gender": "female", "preferred_language": "sw"}`},
+		{name: "Clear optional fields", body: `{"name": null, "date_of_birth": null, "gender": null}`},
+		{name: "Remove phone", body: `{"phone": null}`, wantErr: "phone cannot be removed"},
+		{name: "Empty name", body: `{"name": ""}`, wantErr: "name cannot be empty"},
+		{name: "Future date of birth", body: `{"date_of_birth": "2024-06-02"}`, wantErr: "cannot be in the future"},
+		{name: "Malformed date of birth", body: `{"date_of_birth": "14/05/1990"}`, wantErr: "YYYY-MM-DD"},
+		{name: "Unknown gender", body: `{"gender": "unknown"}`, wantErr: "gender must be one of"},
+		{name: "Unsupported language", body: `{"preferred_language": "fr"}`, wantErr: "preferred_language must be one of"},
+		{name: "Remove language", body: `{"preferred_language": null}`, wantErr: "preferred_language must be one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := DecodePatchPatientRequest([]byte(tt.body))
+			require.NoError(t, err)
+
+			err = req.Validate(now)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatchPatientRequestApply(t *testing.T) {
+	name := "Wanjiku Kamau"
+	gender := "female"
+	patient := &Patient{
+		Phone:             "+254712345678",
+		Name:              &name,
+		Gender:            &gender,
+		PreferredLanguage: "en",
+		ConsentFlags:      map[string]bool{"sms": true, "research": true},
+	}
+
+	req, err := DecodePatchPatientRequest([]byte(`{
+		"gender": null,
+		"date_of_birth": "1990-05-14",
+		"preferred_language": "sw",
+		"consent_flags": {"research": null, "data_sharing": false}
+	}`))
+	require.NoError(t, err)
+	require.NoError(t, req.Validate(time.Now()))
+	req.Apply(patient)
+
+	assert.Equal(t, "Wanjiku Kamau", *patient.Name, "absent fields are unchanged")
+	assert.Nil(t, patient.Gender)
+	require.NotNil(t, patient.DateOfBirth)
+	assert.Equal(t, "1990-05-14", patient.DateOfBirth.Format(DateLayout))
+	assert.Equal(t, "sw", patient.PreferredLanguage)
+	assert.Equal(t, map[string]bool{"sms": true, "data_sharing": false}, patient.ConsentFlags)
+
+	req, err = DecodePatchPatientRequest([]byte(`{"consent_flags": null}`))
+	require.NoError(t, err)
+	req.Apply(patient)
+	assert.Empty(t, patient.ConsentFlags)
+}
+
+func TestPatientETag(t *testing.T) {
+	updated := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	patient := &Patient{UpdatedAt: updated}
+	etag := patient.ETag()
+
+	assert.Equal(t, etag, (&Patient{UpdatedAt: updated}).ETag())
+	patient.UpdatedAt = updated.Add(time.Microsecond)
+	assert.NotEqual(t, etag, patient.ETag())
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneNormalizationIssue is a record whose phone number could not be moved
+// to E.164 form, either because it does not parse or because another record
+// in the same table already holds the normalized number
+type PhoneNormalizationIssue struct {
+	ID                  uuid.UUID  `json:"id"`
+	TableName           string     `json:"table_name"`
+	RecordID            uuid.UUID  `json:"record_id"`
+	OriginalPhone       string     `json:"original_phone"`
+	NormalizedPhone     *string    `json:"normalized_phone,omitempty"`
+	ConflictingRecordID *uuid.UUID `json:"conflicting_record_id,omitempty"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+type ListPhoneIssuesRequest struct {
+	Table           string `form:"table" binding:"omitempty,oneof=patients users clinicians"`
+	IncludeResolved bool   `form:"include_resolved"`
+}
@@ -0,0 +1,25 @@
+package models
+
+type RecommendFacilitiesRequest struct {
+	Latitude    float64 `form:"latitude" binding:"required"`
+	Longitude   float64 `form:"longitude" binding:"required"`
+	RadiusKM    float64 `form:"radius_km" binding:"omitempty,min=1,max=100"`
+	Limit       int     `form:"limit" binding:"omitempty,min=1,max=20"`
+	PreferMpesa bool    `form:"prefer_mpesa"`
+}
+
+// FacilityRecommendation is one ranked destination for a triaged patient
+type FacilityRecommendation struct {
+	Facility     *NearbyFacility `json:"facility"`
+	Score        float64         `json:"score"`
+	OpenNow      *bool           `json:"open_now,omitempty"`
+	ReferralLoad int             `json:"referral_load"`
+	Reason       string          `json:"reason"`
+}
+
+// RecommendationCriteria are the hard requirements derived from a triage session
+type RecommendationCriteria struct {
+	TriageLevel      TriageLevel `json:"triage_level"`
+	MinimumLevel     int         `json:"minimum_level"`
+	RequiredServices []string    `json:"required_services"`
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReferralStatus string
+
+const (
+	ReferralStatusPending   ReferralStatus = "pending"
+	ReferralStatusAccepted  ReferralStatus = "accepted"
+	ReferralStatusCompleted ReferralStatus = "completed"
+	ReferralStatusCancelled ReferralStatus = "cancelled"
+	// ReferralStatusNoShow means the patient never arrived at the facility
+	ReferralStatusNoShow ReferralStatus = "no_show"
+	// ReferralStatusExpired is set by the reminder scheduler on referrals
+	// left pending past their schedule's expiry
+	ReferralStatusExpired ReferralStatus = "expired"
+	// ReferralStatusTransferred means the facility forwarded the referral
+	// elsewhere; the new referral names this one as its parent
+	ReferralStatusTransferred ReferralStatus = "transferred"
+)
+
+// referralTransitions lists the statuses the receiving facility may move a
+// referral to from each status. Cancelling is left to the referrer.
+var referralTransitions = map[ReferralStatus][]ReferralStatus{
+	ReferralStatusPending:  {ReferralStatusAccepted, ReferralStatusCompleted, ReferralStatusNoShow},
+	ReferralStatusAccepted: {ReferralStatusCompleted, ReferralStatusNoShow},
+}
+
+// CheckTransition returns an error unless a facility may move a referral
+// from one status to the other
+func (s ReferralStatus) CheckTransition(to ReferralStatus) error {
+	for _, allowed := range referralTransitions[s] {
+		if to == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("a %s referral cannot be marked %s", s, to)
+}
+
+// CanTransfer reports whether a referral in this status may still be
+// forwarded to another facility
+func (s ReferralStatus) CanTransfer() bool {
+	return s == ReferralStatusPending || s == ReferralStatusAccepted
+}
+
+type Referral struct {
+	ID                  uuid.UUID      `json:"id"`
+	PatientID           *uuid.UUID     `json:"patient_id,omitempty"`
+	TriageSessionID     *uuid.UUID     `json:"triage_session_id,omitempty"`
+	FacilityID          *uuid.UUID     `json:"facility_id,omitempty"`
+	ReferralToken       string         `json:"referral_token"`
+	Status              ReferralStatus `json:"status"`
+	Priority            *TriageLevel   `json:"priority,omitempty"`
+	Notes               *string        `json:"notes,omitempty"`
+	CreatedByCHV        *uuid.UUID     `json:"created_by_chv,omitempty"`
+	AcceptedByClinician *uuid.UUID     `json:"accepted_by_clinician,omitempty"`
+	AcceptedAt          *time.Time     `json:"accepted_at,omitempty"`
+	CompletedAt         *time.Time     `json:"completed_at,omitempty"`
+	ParentReferralID    *uuid.UUID     `json:"parent_referral_id,omitempty"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+}
+
+type CreateReferralRequest struct {
+	PatientID       uuid.UUID    `json:"patient_id" binding:"required"`
+	TriageSessionID *uuid.UUID   `json:"triage_session_id"`
+	FacilityID      uuid.UUID    `json:"facility_id" binding:"required"`
+	Priority        *TriageLevel `json:"priority" binding:"omitempty,oneof=red yellow green"`
+	Notes           *string      `json:"notes"`
+	// AcknowledgeWarnings sends the referral even though the facility has
+	// reported it is full or out of stock
+	AcknowledgeWarnings bool `json:"acknowledge_warnings"`
+}
+
+type ListReferralsRequest struct {
+	FacilityID *uuid.UUID      `form:"facility_id"`
+	PatientID  *uuid.UUID      `form:"patient_id"`
+	Status     *ReferralStatus `form:"status"`
+	Limit      int             `form:"limit" binding:"omitempty,min=1,max=100"`
+	// CreatedByCHV limits results to one CHV's referrals. It is set from the
+	// caller's session, never from the query string.
+	CreatedByCHV *uuid.UUID `form:"-"`
+}
+
+// UpdateReferralStatusRequest is how the receiving facility reports progress
+type UpdateReferralStatusRequest struct {
+	Status ReferralStatus `json:"status" binding:"required,oneof=accepted completed no_show"`
+}
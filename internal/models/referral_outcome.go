@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DiagnosisCategory is a coarse diagnosis, detailed enough for the CHV to
+// follow up and for triage accuracy reporting
+type DiagnosisCategory string
+
+const (
+	DiagnosisMalaria        DiagnosisCategory = "malaria"
+	DiagnosisRespiratory    DiagnosisCategory = "respiratory_infection"
+	DiagnosisDiarrhoeal     DiagnosisCategory = "diarrhoeal_disease"
+	DiagnosisMalnutrition   DiagnosisCategory = "malnutrition"
+	DiagnosisMaternal       DiagnosisCategory = "maternal_complication"
+	DiagnosisNeonatal       DiagnosisCategory = "neonatal_condition"
+	DiagnosisInjury         DiagnosisCategory = "injury"
+	DiagnosisChronicDisease DiagnosisCategory = "chronic_disease"
+	DiagnosisOther          DiagnosisCategory = "other"
+)
+
+var ValidDiagnosisCategories = []DiagnosisCategory{
+	DiagnosisMalaria,
+	DiagnosisRespiratory,
+	DiagnosisDiarrhoeal,
+	DiagnosisMalnutrition,
+	DiagnosisMaternal,
+	DiagnosisNeonatal,
+	DiagnosisInjury,
+	DiagnosisChronicDisease,
+	DiagnosisOther,
+}
+
+func (d DiagnosisCategory) IsValid() bool {
+	for _, valid := range ValidDiagnosisCategories {
+		if d == valid {
+			return true
+		}
+	}
+	return false
+}
+
+type Disposition string
+
+const (
+	DispositionAdmitted   Disposition = "admitted"
+	DispositionDischarged Disposition = "discharged"
+)
+
+// ReferralOutcome is the clinician's account of a completed referral
+type ReferralOutcome struct {
+	ReferralID        uuid.UUID         `json:"referral_id"`
+	DiagnosisCategory DiagnosisCategory `json:"diagnosis_category"`
+	TreatmentGiven    *string           `json:"treatment_given,omitempty"`
+	Disposition       Disposition       `json:"disposition"`
+	TriageAppropriate bool              `json:"triage_appropriate"`
+	Notes             *string           `json:"notes,omitempty"`
+	RecordedBy        *uuid.UUID        `json:"recorded_by,omitempty"`
+	RecordedAt        time.Time         `json:"recorded_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+type RecordReferralOutcomeRequest struct {
+	DiagnosisCategory DiagnosisCategory `json:"diagnosis_category" binding:"required"`
+	TreatmentGiven    *string           `json:"treatment_given"`
+	Disposition       Disposition       `json:"disposition" binding:"required,oneof=admitted discharged"`
+	TriageAppropriate *bool             `json:"triage_appropriate" binding:"required"`
+	Notes             *string           `json:"notes"`
+}
+
+func (r *RecordReferralOutcomeRequest) Validate() error {
+	if !r.DiagnosisCategory.IsValid() {
+		return fmt.Errorf("diagnosis_category must be one of %v", ValidDiagnosisCategories)
+	}
+	if r.TreatmentGiven != nil && strings.TrimSpace(*r.TreatmentGiven) == "" {
+		r.TreatmentGiven = nil
+	}
+	return nil
+}
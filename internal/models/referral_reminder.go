@@ -0,0 +1,144 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReminderSchedule says when to chase a pending referral of one priority,
+// in minutes after it was made. County is nil for the default schedule.
+type ReminderSchedule struct {
+	ID                     uuid.UUID   `json:"id"`
+	County                 *string     `json:"county,omitempty"`
+	Priority               TriageLevel `json:"priority"`
+	PatientReminderMinutes []int       `json:"patient_reminder_minutes"`
+	CHVAlertMinutes        *int        `json:"chv_alert_minutes,omitempty"`
+	ExpireAfterMinutes     int         `json:"expire_after_minutes"`
+	UpdatedBy              *uuid.UUID  `json:"updated_by,omitempty"`
+	CreatedAt              time.Time   `json:"created_at"`
+	UpdatedAt              time.Time   `json:"updated_at"`
+}
+
+type PutReminderScheduleRequest struct {
+	County                 *string     `json:"county"`
+	Priority               TriageLevel `json:"priority" binding:"required,oneof=red yellow green"`
+	PatientReminderMinutes []int       `json:"patient_reminder_minutes"`
+	CHVAlertMinutes        *int        `json:"chv_alert_minutes"`
+	ExpireAfterMinutes     int         `json:"expire_after_minutes" binding:"required"`
+}
+
+// Validate checks the reminders come in order and all before the referral
+// expires, and sorts them
+func (r *PutReminderScheduleRequest) Validate() error {
+	if r.County != nil {
+		county := strings.TrimSpace(*r.County)
+		if county == "" {
+			r.County = nil
+		} else {
+			r.County = &county
+		}
+	}
+	if r.PatientReminderMinutes == nil {
+		r.PatientReminderMinutes = []int{}
+	}
+	sort.Ints(r.PatientReminderMinutes)
+
+	if r.ExpireAfterMinutes <= 0 {
+		return fmt.Errorf("expire_after_minutes must be positive")
+	}
+	for i, minutes := range r.PatientReminderMinutes {
+		if minutes <= 0 || minutes >= r.ExpireAfterMinutes {
+			return fmt.Errorf("patient reminders must fall between 0 and expire_after_minutes")
+		}
+		if i > 0 && minutes == r.PatientReminderMinutes[i-1] {
+			return fmt.Errorf("patient reminders must not repeat")
+		}
+	}
+	if r.CHVAlertMinutes != nil && (*r.CHVAlertMinutes <= 0 || *r.CHVAlertMinutes >= r.ExpireAfterMinutes) {
+		return fmt.Errorf("chv_alert_minutes must fall between 0 and expire_after_minutes")
+	}
+	return nil
+}
+
+type ReminderKind string
+
+const (
+	ReminderKindPatient ReminderKind = "patient_reminder"
+	ReminderKindCHV     ReminderKind = "chv_alert"
+	ReminderKindExpired ReminderKind = "expired"
+)
+
+// ReminderStep identifies one action on a referral. Step numbers the
+// patient reminders from 0 and is 0 for the others.
+type ReminderStep struct {
+	Kind ReminderKind `json:"kind"`
+	Step int          `json:"step"`
+}
+
+// ReferralReminder is a logged reminder, alert or expiry
+type ReferralReminder struct {
+	ID         uuid.UUID    `json:"id"`
+	ReferralID uuid.UUID    `json:"referral_id"`
+	Kind       ReminderKind `json:"kind"`
+	Step       int          `json:"step"`
+	Recipient  *string      `json:"recipient,omitempty"`
+	SMSStatus  SMSStatus    `json:"sms_status"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// PendingReferral is a referral awaiting the patient with what the scheduler
+// needs to chase it
+type PendingReferral struct {
+	Referral          *Referral
+	FacilityName      string
+	PatientName       *string
+	PatientPhone      string
+	PatientLanguage   string
+	SMSConsentRefused bool
+	Schedule          *ReminderSchedule
+	Done              []ReminderStep
+}
+
+// DueSteps returns what the scheduler should do now. Once the referral has
+// expired nothing else is sent. Only the latest due patient reminder is
+// sent, so a scheduler that was down does not send a burst of them.
+func (p *PendingReferral) DueSteps(now time.Time) []ReminderStep {
+	done := map[ReminderStep]bool{}
+	for _, step := range p.Done {
+		done[step] = true
+	}
+	elapsed := now.Sub(p.Referral.CreatedAt)
+	minutes := func(m int) time.Duration { return time.Duration(m) * time.Minute }
+
+	if elapsed >= minutes(p.Schedule.ExpireAfterMinutes) {
+		return []ReminderStep{{Kind: ReminderKindExpired}}
+	}
+
+	steps := []ReminderStep{}
+	latest := -1
+	for i, m := range p.Schedule.PatientReminderMinutes {
+		if elapsed >= minutes(m) {
+			latest = i
+		}
+	}
+	if latest >= 0 {
+		sentSince := false
+		for i := latest; i < len(p.Schedule.PatientReminderMinutes); i++ {
+			sentSince = sentSince || done[ReminderStep{Kind: ReminderKindPatient, Step: i}]
+		}
+		if !sentSince {
+			steps = append(steps, ReminderStep{Kind: ReminderKindPatient, Step: latest})
+		}
+	}
+
+	alert := ReminderStep{Kind: ReminderKindCHV}
+	if p.Schedule.CHVAlertMinutes != nil && elapsed >= minutes(*p.Schedule.CHVAlertMinutes) && !done[alert] {
+		steps = append(steps, alert)
+	}
+
+	return steps
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingReferralDueSteps(t *testing.T) {
+	created := time.Date(2026, 3, 2, 9, 0, 0, 0, FacilityTimezone)
+	alert := 480
+	pending := func(done ...ReminderStep) *PendingReferral {
+		return &PendingReferral{
+			Referral: &Referral{CreatedAt: created, Status: ReferralStatusPending},
+			Schedule: &ReminderSchedule{
+				PatientReminderMinutes: []int{120, 360},
+				CHVAlertMinutes:        &alert,
+				ExpireAfterMinutes:     2880,
+			},
+			Done: done,
+		}
+	}
+
+	assert.Empty(t, pending().DueSteps(created.Add(time.Hour)))
+	assert.Equal(t, []ReminderStep{{Kind: ReminderKindPatient, Step: 0}}, pending().DueSteps(created.Add(2*time.Hour)))
+	assert.Empty(t, pending(ReminderStep{Kind: ReminderKindPatient, Step: 0}).DueSteps(created.Add(3*time.Hour)))
+
+	t.Run("Only the latest missed reminder is sent", func(t *testing.T) {
+		steps := pending().DueSteps(created.Add(7 * time.Hour))
+		assert.Equal(t, []ReminderStep{{Kind: ReminderKindPatient, Step: 1}}, steps)
+	})
+
+	t.Run("CHV alert", func(t *testing.T) {
+		steps := pending(ReminderStep{Kind: ReminderKindPatient, Step: 1}).DueSteps(created.Add(8 * time.Hour))
+		assert.Equal(t, []ReminderStep{{Kind: ReminderKindCHV}}, steps)
+
+		done := pending(ReminderStep{Kind: ReminderKindPatient, Step: 1}, ReminderStep{Kind: ReminderKindCHV})
+		assert.Empty(t, done.DueSteps(created.Add(9*time.Hour)))
+	})
+
+	t.Run("Expiry stops everything else", func(t *testing.T) {
+		steps := pending().DueSteps(created.Add(48 * time.Hour))
+		assert.Equal(t, []ReminderStep{{Kind: ReminderKindExpired}}, steps)
+	})
+}
+
+func TestPutReminderScheduleRequestValidate(t *testing.T) {
+	blank := " "
+	req := &PutReminderScheduleRequest{County: &blank, Priority: TriageLevelRed, PatientReminderMinutes: []int{360, 120}, ExpireAfterMinutes: 2880}
+	require.NoError(t, req.Validate())
+	assert.Nil(t, req.County, "blank county is the default schedule")
+	assert.Equal(t, []int{120, 360}, req.PatientReminderMinutes)
+
+	late := 3000
+	req.CHVAlertMinutes = &late
+	assert.Error(t, req.Validate())
+
+	req.CHVAlertMinutes = nil
+	req.PatientReminderMinutes = []int{120, 120}
+	assert.Error(t, req.Validate())
+
+	req.PatientReminderMinutes = []int{2880}
+	assert.Error(t, req.Validate())
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferralStatusCheckTransition(t *testing.T) {
+	assert.NoError(t, ReferralStatusPending.CheckTransition(ReferralStatusAccepted))
+	assert.NoError(t, ReferralStatusPending.CheckTransition(ReferralStatusNoShow))
+	assert.NoError(t, ReferralStatusAccepted.CheckTransition(ReferralStatusCompleted))
+
+	assert.Error(t, ReferralStatusAccepted.CheckTransition(ReferralStatusAccepted))
+	assert.Error(t, ReferralStatusCompleted.CheckTransition(ReferralStatusNoShow))
+	assert.Error(t, ReferralStatusCancelled.CheckTransition(ReferralStatusAccepted))
+	assert.Error(t, ReferralStatusPending.CheckTransition(ReferralStatusCancelled), "cancelling is left to the referrer")
+}
+
+func TestRecordReferralOutcomeRequestValidate(t *testing.T) {
+	blank := "  "
+	req := &RecordReferralOutcomeRequest{DiagnosisCategory: DiagnosisMalaria, Disposition: DispositionDischarged, TreatmentGiven: &blank}
+	assert.NoError(t, req.Validate())
+	assert.Nil(t, req.TreatmentGiven)
+
+	req.DiagnosisCategory = "flu"
+	assert.Error(t, req.Validate())
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransferReason is why a facility could not handle a referral it received
+type TransferReason string
+
+const (
+	TransferNoTheatre        TransferReason = "no_theatre"
+	TransferPowerOutage      TransferReason = "power_outage"
+	TransferNoSpecialist     TransferReason = "no_specialist"
+	TransferNoBeds           TransferReason = "no_beds"
+	TransferEquipmentFailure TransferReason = "equipment_failure"
+	TransferOther            TransferReason = "other"
+)
+
+var ValidTransferReasons = []TransferReason{
+	TransferNoTheatre,
+	TransferPowerOutage,
+	TransferNoSpecialist,
+	TransferNoBeds,
+	TransferEquipmentFailure,
+	TransferOther,
+}
+
+func (r TransferReason) IsValid() bool {
+	for _, valid := range ValidTransferReasons {
+		if r == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ReferralTransfer records one link in a referral's chain of custody: the
+// referral that was forwarded and the one that replaced it
+type ReferralTransfer struct {
+	ID             uuid.UUID      `json:"id"`
+	FromReferralID uuid.UUID      `json:"from_referral_id"`
+	ToReferralID   uuid.UUID      `json:"to_referral_id"`
+	FromFacilityID *uuid.UUID     `json:"from_facility_id,omitempty"`
+	ToFacilityID   *uuid.UUID     `json:"to_facility_id,omitempty"`
+	Reason         TransferReason `json:"reason"`
+	Notes          *string        `json:"notes,omitempty"`
+	TransferredBy  *uuid.UUID     `json:"transferred_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+type TransferReferralRequest struct {
+	FacilityID uuid.UUID      `json:"facility_id" binding:"required"`
+	Reason     TransferReason `json:"reason" binding:"required"`
+	Notes      *string        `json:"notes"`
+	// AcknowledgeWarnings forwards the referral even though the new
+	// facility has reported it is full or out of stock
+	AcknowledgeWarnings bool `json:"acknowledge_warnings"`
+}
+
+func (r *TransferReferralRequest) Validate() error {
+	if !r.Reason.IsValid() {
+		return fmt.Errorf("reason must be one of %v", ValidTransferReasons)
+	}
+	if r.Notes != nil && strings.TrimSpace(*r.Notes) == "" {
+		r.Notes = nil
+	}
+	if r.Reason == TransferOther && r.Notes == nil {
+		return fmt.Errorf("notes are required when the reason is other")
+	}
+	return nil
+}
+
+// TransferStats is one facility's transfer activity over a period
+type TransferStats struct {
+	FacilityID     uuid.UUID              `json:"facility_id"`
+	FacilityName   string                 `json:"facility_name"`
+	Received       int                    `json:"received"`
+	TransferredOut int                    `json:"transferred_out"`
+	TransferredIn  int                    `json:"transferred_in"`
+	TransferRate   float64                `json:"transfer_rate"`
+	Reasons        map[TransferReason]int `json:"reasons"`
+}
+
+// SetRate works out the share of received referrals that were forwarded
+func (s *TransferStats) SetRate() {
+	s.TransferRate = 0
+	if s.Received > 0 {
+		s.TransferRate = float64(s.TransferredOut) / float64(s.Received)
+	}
+}
+
+type TransferStatsRequest struct {
+	From *time.Time `form:"from" time_format:"2006-01-02"`
+	To   *time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+// Period returns the half-open range to report on, the 30 days up to now by
+// default. Dates are days in FacilityTimezone and to includes the whole day.
+func (r *TransferStatsRequest) Period(now time.Time) (time.Time, time.Time, error) {
+	to := now
+	if r.To != nil {
+		to = facilityDay(*r.To).AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -30)
+	if r.From != nil {
+		from = facilityDay(*r.From)
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+func facilityDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, FacilityTimezone)
+}
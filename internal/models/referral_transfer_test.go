@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferReferralRequestValidate(t *testing.T) {
+	assert.NoError(t, (&TransferReferralRequest{Reason: TransferPowerOutage}).Validate())
+	assert.Error(t, (&TransferReferralRequest{Reason: "no_fuel"}).Validate())
+	assert.Error(t, (&TransferReferralRequest{Reason: TransferOther}).Validate(), "other needs notes")
+
+	blank := "  "
+	assert.Error(t, (&TransferReferralRequest{Reason: TransferOther, Notes: &blank}).Validate())
+
+	notes := "Ambulance broke down"
+	assert.NoError(t, (&TransferReferralRequest{Reason: TransferOther, Notes: &notes}).Validate())
+}
+
+func TestReferralStatusCanTransfer(t *testing.T) {
+	assert.True(t, ReferralStatusPending.CanTransfer())
+	assert.True(t, ReferralStatusAccepted.CanTransfer())
+	assert.False(t, ReferralStatusCompleted.CanTransfer())
+	assert.False(t, ReferralStatusTransferred.CanTransfer())
+}
+
+func TestTransferStatsPeriod(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, FacilityTimezone)
+
+	from, to, err := (&TransferStatsRequest{}).Period(now)
+	require.NoError(t, err)
+	assert.Equal(t, now, to)
+	assert.Equal(t, now.AddDate(0, 0, -30), from)
+
+	day := func(d int) *time.Time {
+		date := time.Date(2026, 2, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	from, to, err = (&TransferStatsRequest{From: day(1), To: day(28)}).Period(now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, FacilityTimezone), from)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, FacilityTimezone), to, "to includes the whole day")
+
+	_, _, err = (&TransferStatsRequest{From: day(10), To: day(9)}).Period(now)
+	assert.Error(t, err)
+}
+
+func TestTransferStatsSetRate(t *testing.T) {
+	stats := &TransferStats{Received: 8, TransferredOut: 2}
+	stats.SetRate()
+	assert.Equal(t, 0.25, stats.TransferRate)
+
+	empty := &TransferStats{TransferredOut: 1}
+	empty.SetRate()
+	assert.Equal(t, 0.0, empty.TransferRate)
+}
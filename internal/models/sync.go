@@ -0,0 +1,330 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Offline sync for the CHV app. The device queues mutations while offline and
+// sends them in order with POST /v1/sync. Each mutation and each record it
+// creates carries a client-generated UUID. Conflicts are resolved as follows:
+//
+//   - A mutation ID that has been applied before returns its original result
+//     and changes nothing, so a batch can be retried safely.
+//   - Creating a record whose ID already exists returns the server's record
+//     unchanged, so a create replayed under a new mutation ID is harmless.
+//   - A patient created with a phone number that is already registered is a
+//     PHONE_TAKEN conflict carrying the existing patient; the device should
+//     adopt that patient's ID.
+//   - A patient update is a JSON Merge Patch. If the server record changed
+//     after the client timestamp, other than by this device's own earlier
+//     mutations, the server wins: the update is a VERSION_CONFLICT carrying
+//     the current record.
+//   - Triage sessions and consents are append-only. They keep the client
+//     timestamp as when they happened; for consents the latest decision of
+//     each type wins, whatever order they arrive in.
+//   - Referrals are recorded even if the facility has since paused, filled
+//     up or stopped taking referrals, because the patient has already been
+//     sent; the warnings are returned. They also keep the client timestamp.
+//     The only update a device may make is cancelling a pending referral it
+//     sent; once a facility has acted on it the server wins.
+//   - Client timestamps more than MaxSyncClockSkew ahead of the server are
+//     clamped to the server time.
+const (
+	MaxSyncMutations     = 500
+	MaxSyncClockSkew     = 5 * time.Minute
+	DefaultSyncFeedLimit = 200
+	MaxSyncFeedLimit     = 1000
+	// TriageChannelCHVApp marks triage sessions captured in the CHV app
+	TriageChannelCHVApp = "chv_app"
+)
+
+type SyncEntity string
+
+const (
+	SyncEntityPatient       SyncEntity = "patient"
+	SyncEntityTriageSession SyncEntity = "triage_session"
+	SyncEntityReferral      SyncEntity = "referral"
+	SyncEntityConsent       SyncEntity = "consent"
+	// Only sent by the server, in the change feed
+	SyncEntityFacility       SyncEntity = "facility"
+	SyncEntityFacilityStatus SyncEntity = "facility_status"
+)
+
+type SyncOperation string
+
+const (
+	SyncOperationCreate SyncOperation = "create"
+	SyncOperationUpdate SyncOperation = "update"
+)
+
+type SyncStatus string
+
+const (
+	SyncStatusApplied  SyncStatus = "applied"
+	SyncStatusConflict SyncStatus = "conflict"
+	SyncStatusRejected SyncStatus = "rejected"
+)
+
+type SyncRequest struct {
+	DeviceID  string          `json:"device_id" binding:"required,max=100"`
+	Cursor    string          `json:"cursor"`
+	FeedLimit int             `json:"feed_limit" binding:"omitempty,min=1,max=1000"`
+	Mutations []*SyncMutation `json:"mutations" binding:"max=500"`
+}
+
+// SyncMutation is one queued change. Data holds the entity payload; Decode
+// parses it into Payload.
+type SyncMutation struct {
+	ID              uuid.UUID       `json:"id"`
+	Entity          SyncEntity      `json:"entity"`
+	Operation       SyncOperation   `json:"op"`
+	EntityID        uuid.UUID       `json:"entity_id"`
+	ClientTimestamp time.Time       `json:"client_timestamp"`
+	Data            json.RawMessage `json:"data"`
+
+	Payload interface{} `json:"-"`
+}
+
+// SyncTriageCreate records a triage session captured offline. A verdict the
+// app reached with its rule bundle is recorded with the bundle version.
+type SyncTriageCreate struct {
+	PatientID     uuid.UUID              `json:"patient_id"`
+	Symptoms      map[string]interface{} `json:"symptoms"`
+	BundleVersion *string                `json:"bundle_version"`
+	DeviceVerdict *TriageVerdict         `json:"device_verdict"`
+}
+
+type SyncReferralCreate struct {
+	PatientID       uuid.UUID    `json:"patient_id"`
+	TriageSessionID *uuid.UUID   `json:"triage_session_id"`
+	FacilityID      uuid.UUID    `json:"facility_id"`
+	Priority        *TriageLevel `json:"priority"`
+	Notes           *string      `json:"notes"`
+	// Token and Warnings are filled in by the server
+	Token    string   `json:"-"`
+	Warnings []string `json:"-"`
+}
+
+type SyncReferralUpdate struct {
+	Status ReferralStatus `json:"status"`
+}
+
+type SyncConsentCreate struct {
+	PatientID   uuid.UUID              `json:"patient_id"`
+	ConsentType ConsentType            `json:"consent_type"`
+	Granted     bool                   `json:"granted"`
+	Details     map[string]interface{} `json:"details"`
+}
+
+// Decode validates the mutation envelope and parses Data into Payload. Client
+// timestamps too far in the future are clamped to now.
+func (m *SyncMutation) Decode(now time.Time) error {
+	if m.ID == uuid.Nil || m.EntityID == uuid.Nil {
+		return fmt.Errorf("id and entity_id are required")
+	}
+	if m.ClientTimestamp.IsZero() {
+		return fmt.Errorf("client_timestamp is required")
+	}
+	if m.ClientTimestamp.After(now.Add(MaxSyncClockSkew)) {
+		m.ClientTimestamp = now
+	}
+
+	switch {
+	case m.Entity == SyncEntityPatient && m.Operation == SyncOperationCreate:
+		var payload CreatePatientRequest
+		if err := decodeSyncData(m.Data, &payload); err != nil {
+			return err
+		}
+		if err := validateSyncPatient(&payload, now); err != nil {
+			return err
+		}
+		m.Payload = &payload
+
+	case m.Entity == SyncEntityPatient && m.Operation == SyncOperationUpdate:
+		payload, err := DecodePatchPatientRequest(m.Data)
+		if err != nil {
+			return fmt.Errorf("invalid data: %w", err)
+		}
+		if err := payload.Validate(now); err != nil {
+			return err
+		}
+		m.Payload = payload
+
+	case m.Entity == SyncEntityTriageSession && m.Operation == SyncOperationCreate:
+		var payload SyncTriageCreate
+		if err := decodeSyncData(m.Data, &payload); err != nil {
+			return err
+		}
+		if payload.PatientID == uuid.Nil || len(payload.Symptoms) == 0 {
+			return fmt.Errorf("patient_id and symptoms are required")
+		}
+		if payload.DeviceVerdict != nil {
+			if payload.BundleVersion == nil || *payload.BundleVersion == "" {
+				return fmt.Errorf("bundle_version is required with device_verdict")
+			}
+			if !isTriageLevel(payload.DeviceVerdict.TriageLevel) {
+				return fmt.Errorf("device_verdict.triage_level must be red, yellow or green")
+			}
+		}
+		m.Payload = &payload
+
+	case m.Entity == SyncEntityReferral && m.Operation == SyncOperationCreate:
+		var payload SyncReferralCreate
+		if err := decodeSyncData(m.Data, &payload); err != nil {
+			return err
+		}
+		if payload.PatientID == uuid.Nil || payload.FacilityID == uuid.Nil {
+			return fmt.Errorf("patient_id and facility_id are required")
+		}
+		if payload.Priority != nil && !isTriageLevel(*payload.Priority) {
+			return fmt.Errorf("priority must be red, yellow or green")
+		}
+		m.Payload = &payload
+
+	case m.Entity == SyncEntityReferral && m.Operation == SyncOperationUpdate:
+		var payload SyncReferralUpdate
+		if err := decodeSyncData(m.Data, &payload); err != nil {
+			return err
+		}
+		if payload.Status != ReferralStatusCancelled {
+			return fmt.Errorf("the only referral update allowed from a device is status %q", ReferralStatusCancelled)
+		}
+		m.Payload = &payload
+
+	case m.Entity == SyncEntityConsent && m.Operation == SyncOperationCreate:
+		var payload SyncConsentCreate
+		if err := decodeSyncData(m.Data, &payload); err != nil {
+			return err
+		}
+		if payload.PatientID == uuid.Nil {
+			return fmt.Errorf("patient_id is required")
+		}
+		if !payload.ConsentType.IsValid() {
+			return fmt.Errorf("consent_type must be one of %v", ValidConsentTypes)
+		}
+		m.Payload = &payload
+
+	default:
+		return fmt.Errorf("unsupported mutation: %s %s", m.Operation, m.Entity)
+	}
+
+	return nil
+}
+
+func decodeSyncData(data json.RawMessage, dest interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dest); err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+	return nil
+}
+
+// validateSyncPatient applies the binding rules of CreatePatientRequest, which
+// are not run for payloads nested in a sync batch. The phone number is
+// normalized when the mutation is applied.
+func validateSyncPatient(req *CreatePatientRequest, now time.Time) error {
+	if req.Phone == "" {
+		return fmt.Errorf("phone is required")
+	}
+	if req.Gender != nil && !contains(Genders, *req.Gender) {
+		return fmt.Errorf("gender must be one of %v", Genders)
+	}
+	if req.PreferredLanguage != "" && !contains(SupportedLanguages, req.PreferredLanguage) {
+		return fmt.Errorf("preferred_language must be one of %v", SupportedLanguages)
+	}
+	if req.DateOfBirth != nil && req.DateOfBirth.After(now) {
+		return fmt.Errorf("date_of_birth cannot be in the future")
+	}
+	return nil
+}
+
+func isTriageLevel(level TriageLevel) bool {
+	return level == TriageLevelRed || level == TriageLevelYellow || level == TriageLevelGreen
+}
+
+// SyncResult reports what happened to one mutation. Record is the server's
+// copy of the entity after the mutation, or the copy that won a conflict.
+type SyncResult struct {
+	MutationID uuid.UUID   `json:"mutation_id"`
+	Entity     SyncEntity  `json:"entity"`
+	EntityID   uuid.UUID   `json:"entity_id"`
+	Status     SyncStatus  `json:"status"`
+	Code       string      `json:"code,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	Warnings   []string    `json:"warnings,omitempty"`
+	Record     interface{} `json:"record,omitempty"`
+	// Replayed is set when the mutation had already been applied
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// Conflict marks the mutation as lost to the server's copy in record
+func (r *SyncResult) Conflict(code, message string, record interface{}) {
+	r.Status = SyncStatusConflict
+	r.Code = code
+	r.Message = message
+	r.Record = record
+}
+
+// Reject marks the mutation as not applied because it is invalid
+func (r *SyncResult) Reject(code, message string) {
+	r.Status = SyncStatusRejected
+	r.Code = code
+	r.Message = message
+}
+
+// SyncChanges is the part of the server change feed a device pulls. Cursor
+// is passed back on the next sync; HasMore means another sync should follow
+// straight away.
+type SyncChanges struct {
+	Facilities         []*Facility       `json:"facilities"`
+	FacilityStatuses   []*FacilityStatus `json:"facility_statuses"`
+	Referrals          []*Referral       `json:"referrals"`
+	DeletedFacilityIDs []uuid.UUID       `json:"deleted_facility_ids"`
+	Cursor             string            `json:"cursor"`
+	HasMore            bool              `json:"has_more"`
+}
+
+type SyncResponse struct {
+	Results []*SyncResult `json:"results"`
+	Changes *SyncChanges  `json:"changes"`
+}
+
+// SyncCursor is a position in the change feed. Changes are ordered by the
+// writing transaction and then by sequence, and only transactions older than
+// every one still running are handed out, so a change committed late cannot
+// land behind a cursor that has already moved past it.
+type SyncCursor struct {
+	TxID int64 `json:"t"`
+	Seq  int64 `json:"s"`
+}
+
+// Encode returns an opaque token for the cursor
+func (c SyncCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeSyncCursor parses a token from Encode. An empty token is the start of the feed.
+func DecodeSyncCursor(token string) (SyncCursor, error) {
+	var c SyncCursor
+	if token == "" {
+		return c, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.TxID < 0 || c.Seq < 0 {
+		return SyncCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return c, nil
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncMutationDecode(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	mutation := func(entity SyncEntity, op SyncOperation, data string) *SyncMutation {
+		return &SyncMutation{
+			ID:              uuid.New(),
+			Entity:          entity,
+			Operation:       op,
+			EntityID:        uuid.New(),
+			ClientTimestamp: now.Add(-time.Hour),
+			Data:            json.RawMessage(data),
+		}
+	}
+
+	t.Run("Patient create", func(t *testing.T) {
+		m := mutation(SyncEntityPatient, SyncOperationCreate, `{"phone": "0712345678", "name": "Wanjiku"}`)
+		require.NoError(t, m.Decode(now))
+		payload, ok := m.Payload.(*CreatePatientRequest)
+		require.True(t, ok)
+		assert.Equal(t, "0712345678", payload.Phone)
+	})
+
+	t.Run("Patient update is a merge patch", func(t *testing.T) {
+		m := mutation(SyncEntityPatient, SyncOperationUpdate, `{"name": null}`)
+		require.NoError(t, m.Decode(now))
+		payload, ok := m.Payload.(*PatchPatientRequest)
+		require.True(t, ok)
+		assert.True(t, payload.Name.Null)
+	})
+
+	t.Run("Unknown fields are rejected", func(t *testing.T) {
+		m := mutation(SyncEntityTriageSession, SyncOperationCreate, `{"patient_id": "`+uuid.NewString()+`", "symptoms": {"fever": true}, "level": "red"}`)
+		assert.Error(t, m.Decode(now))
+	})
+
+	t.Run("Device verdict needs its bundle version", func(t *testing.T) {
+		patientID := uuid.NewString()
+		verdict := `"device_verdict": {"triage_level": "red", "triage_code": "FEVER_DANGER_SIGNS"}`
+		assert.Error(t, mutation(SyncEntityTriageSession, SyncOperationCreate, `{"patient_id": "`+patientID+`", "symptoms": {"fever": true}, `+verdict+`}`).Decode(now))
+
+		m := mutation(SyncEntityTriageSession, SyncOperationCreate, `{"patient_id": "`+patientID+`", "symptoms": {"fever": true}, "bundle_version": "2026.10.1-3fa2c1d9e0ab", `+verdict+`}`)
+		require.NoError(t, m.Decode(now))
+		payload, ok := m.Payload.(*SyncTriageCreate)
+		require.True(t, ok)
+		assert.Equal(t, TriageLevelRed, payload.DeviceVerdict.TriageLevel)
+	})
+
+	t.Run("Only cancellation updates referrals", func(t *testing.T) {
+		assert.NoError(t, mutation(SyncEntityReferral, SyncOperationUpdate, `{"status": "cancelled"}`).Decode(now))
+		assert.Error(t, mutation(SyncEntityReferral, SyncOperationUpdate, `{"status": "completed"}`).Decode(now))
+	})
+
+	t.Run("Invalid consent type", func(t *testing.T) {
+		m := mutation(SyncEntityConsent, SyncOperationCreate, `{"patient_id": "`+uuid.NewString()+`", "consent_type": "marketing", "granted": true}`)
+		assert.Error(t, m.Decode(now))
+	})
+
+	t.Run("Unsupported operation", func(t *testing.T) {
+		assert.Error(t, mutation(SyncEntityConsent, SyncOperationUpdate, `{}`).Decode(now))
+	})
+
+	t.Run("Missing ids", func(t *testing.T) {
+		m := mutation(SyncEntityPatient, SyncOperationCreate, `{"phone": "0712345678"}`)
+		m.EntityID = uuid.Nil
+		assert.Error(t, m.Decode(now))
+	})
+
+	t.Run("Future timestamps are clamped", func(t *testing.T) {
+		m := mutation(SyncEntityPatient, SyncOperationCreate, `{"phone": "0712345678"}`)
+		m.ClientTimestamp = now.Add(time.Hour)
+		require.NoError(t, m.Decode(now))
+		assert.Equal(t, now, m.ClientTimestamp)
+
+		m.ClientTimestamp = now.Add(MaxSyncClockSkew / 2)
+		require.NoError(t, m.Decode(now))
+		assert.Equal(t, now.Add(MaxSyncClockSkew/2), m.ClientTimestamp)
+	})
+}
+
+func TestSyncCursor(t *testing.T) {
+	cursor := SyncCursor{TxID: 7421, Seq: 19}
+	decoded, err := DecodeSyncCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	start, err := DecodeSyncCursor("")
+	require.NoError(t, err)
+	assert.Equal(t, SyncCursor{}, start)
+
+	_, err = DecodeSyncCursor("not a cursor")
+	assert.Error(t, err)
+}
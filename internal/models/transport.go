@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DispatchStatus is how far a transport provider has got with getting a
+// red-referral patient to the facility
+type DispatchStatus string
+
+const (
+	DispatchStatusRequested DispatchStatus = "requested"
+	DispatchStatusAssigned  DispatchStatus = "assigned"
+	DispatchStatusArrived   DispatchStatus = "arrived"
+	// DispatchStatusFailed means the provider could not send anyone
+	DispatchStatusFailed DispatchStatus = "failed"
+)
+
+// dispatchTransitions lists the statuses a provider may report from each
+// status. A provider may skip assigned and report arrival directly.
+var dispatchTransitions = map[DispatchStatus][]DispatchStatus{
+	DispatchStatusRequested: {DispatchStatusAssigned, DispatchStatusArrived, DispatchStatusFailed},
+	DispatchStatusAssigned:  {DispatchStatusAssigned, DispatchStatusArrived, DispatchStatusFailed},
+}
+
+// CheckTransition returns an error unless a dispatch may move from one
+// status to the other. Assigned may be repeated to update the driver or ETA.
+func (s DispatchStatus) CheckTransition(to DispatchStatus) error {
+	for _, allowed := range dispatchTransitions[s] {
+		if to == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("a %s dispatch cannot be marked %s", s, to)
+}
+
+type VehicleType string
+
+const (
+	VehicleAmbulance VehicleType = "ambulance"
+	VehicleBodaBoda  VehicleType = "boda_boda"
+)
+
+type TransportDispatch struct {
+	ID            uuid.UUID      `json:"id"`
+	ReferralID    uuid.UUID      `json:"referral_id"`
+	Provider      string         `json:"provider"`
+	ProviderRef   *string        `json:"provider_ref,omitempty"`
+	Status        DispatchStatus `json:"status"`
+	VehicleType   *VehicleType   `json:"vehicle_type,omitempty"`
+	DriverName    *string        `json:"driver_name,omitempty"`
+	DriverPhone   *string        `json:"driver_phone,omitempty"`
+	ETAMinutes    *int           `json:"eta_minutes,omitempty"`
+	FailureReason *string        `json:"failure_reason,omitempty"`
+	RequestedAt   time.Time      `json:"requested_at"`
+	AssignedAt    *time.Time     `json:"assigned_at,omitempty"`
+	ArrivedAt     *time.Time     `json:"arrived_at,omitempty"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TransportRequest is what a provider is told when transport is requested.
+// The pickup is the patient's household, when it has been located.
+type TransportRequest struct {
+	ReferralID        uuid.UUID
+	ReferralToken     string
+	PatientPhone      string
+	PickupLatitude    *float64
+	PickupLongitude   *float64
+	FacilityName      string
+	FacilityLatitude  float64
+	FacilityLongitude float64
+}
+
+// TransportBooking is a provider's acknowledgement of a request
+type TransportBooking struct {
+	ProviderRef string
+	VehicleType *VehicleType
+}
+
+// TransportUpdate is a status report from a transport provider
+type TransportUpdate struct {
+	ProviderRef string         `json:"provider_ref" binding:"required"`
+	Status      DispatchStatus `json:"status" binding:"required,oneof=assigned arrived failed"`
+	VehicleType *VehicleType   `json:"vehicle_type" binding:"omitempty,oneof=ambulance boda_boda"`
+	DriverName  *string        `json:"driver_name"`
+	DriverPhone *string        `json:"driver_phone"`
+	ETAMinutes  *int           `json:"eta_minutes" binding:"omitempty,min=0"`
+	Reason      *string        `json:"reason"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchStatusCheckTransition(t *testing.T) {
+	assert.NoError(t, DispatchStatusRequested.CheckTransition(DispatchStatusAssigned))
+	assert.NoError(t, DispatchStatusRequested.CheckTransition(DispatchStatusArrived), "assigned may be skipped")
+	assert.NoError(t, DispatchStatusAssigned.CheckTransition(DispatchStatusAssigned), "driver or ETA may be updated")
+	assert.NoError(t, DispatchStatusAssigned.CheckTransition(DispatchStatusFailed))
+
+	assert.Error(t, DispatchStatusArrived.CheckTransition(DispatchStatusAssigned))
+	assert.Error(t, DispatchStatusFailed.CheckTransition(DispatchStatusAssigned))
+	assert.Error(t, DispatchStatusAssigned.CheckTransition(DispatchStatusRequested))
+}
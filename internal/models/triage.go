@@ -34,8 +34,19 @@ type TriageSession struct {
 	LLMResponse       map[string]interface{} `json:"llm_response,omitempty"`
 	Channel           string                 `json:"channel"`
 	Status            TriageStatus           `json:"status"`
-	CreatedAt         time.Time              `json:"created_at"`
-	UpdatedAt         time.Time              `json:"updated_at"`
+	// BundleVersion is the offline bundle that produced DeviceVerdict, for
+	// sessions triaged in the CHV app
+	BundleVersion *string        `json:"bundle_version,omitempty"`
+	DeviceVerdict *TriageVerdict `json:"device_verdict,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TriageVerdict is the outcome of the red-flag rulebook for a set of symptoms
+type TriageVerdict struct {
+	TriageLevel       TriageLevel `json:"triage_level"`
+	TriageCode        string      `json:"triage_code"`
+	RecommendedAction string      `json:"recommended_action,omitempty"`
 }
 
 type CreateTriageRequest struct {
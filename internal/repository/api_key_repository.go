@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type APIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, name, key_prefix, key_hash, scopes, facility_id, created_by, expires_at, last_used_at, revoked_at, created_at, updated_at`
+
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopesRaw []byte
+
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.KeyPrefix,
+		&key.KeyHash,
+		&scopesRaw,
+		&key.FacilityID,
+		&key.CreatedBy,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(scopesRaw, &key.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scopes: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, req *models.CreateAPIKeyRequest, prefix, hash string, createdBy *uuid.UUID) (*models.APIKey, error) {
+	scopesJSON, err := json.Marshal(req.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal scopes: %w", err)
+	}
+
+	query := `
+		INSERT INTO api_keys (name, key_prefix, key_hash, scopes, facility_id, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query,
+		req.Name,
+		prefix,
+		hash,
+		scopesJSON,
+		req.FacilityID,
+		createdBy,
+		req.ExpiresAt,
+	))
+	if isForeignKeyViolation(err) {
+		return nil, fmt.Errorf("%w: facility does not exist", ErrValidation)
+	}
+	if err != nil {
+		log.Printf("Error creating API key: %v", err)
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_prefix = $1`
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, prefix))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("api key not found")
+	}
+	if err != nil {
+		log.Printf("Error getting API key by prefix: %v", err)
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) List(ctx context.Context, facilityID *uuid.UUID) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE 1=1`
+
+	args := []interface{}{}
+	if facilityID != nil {
+		query += " AND facility_id = $1"
+		args = append(args, *facilityID)
+	}
+	query += " ORDER BY created_at DESC"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		log.Printf("Error revoking API key: %v", err)
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`
+
+	_, err := r.db.Exec(ctx, query, usedAt, id)
+	if err != nil {
+		log.Printf("Error updating API key last used: %v", err)
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestAppointments(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	appointments := NewAppointmentRepository(pool)
+	patients := NewPatientRepository(pool)
+
+	var facilityID uuid.UUID
+	err := pool.QueryRow(ctx, `
+		INSERT INTO facilities (name, type, latitude, longitude)
+		VALUES ('Appointment Test Dispensary', 'dispensary', -0.1022, 34.7617)
+		RETURNING id`).Scan(&facilityID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM facilities WHERE id = $1`, facilityID)
+	})
+
+	patient, err := patients.Create(ctx, &models.CreatePatientRequest{Phone: fmt.Sprintf("+2547%08d", rand.Intn(100_000_000))})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+	})
+
+	now := time.Now().Truncate(time.Minute)
+	slot := now.Add(3 * time.Hour)
+	book := func(at time.Time) (*models.Appointment, error) {
+		return appointments.Create(ctx, &models.CreateAppointmentRequest{PatientID: patient.ID, FacilityID: facilityID, ScheduledTime: at})
+	}
+
+	appointment, err := book(slot)
+	require.NoError(t, err)
+	assert.Equal(t, models.AppointmentStatusScheduled, appointment.Status)
+
+	t.Run("Slot is held until cancelled", func(t *testing.T) {
+		_, err := book(slot)
+		assert.ErrorIs(t, err, ErrDuplicate)
+
+		cancelled, err := appointments.UpdateStatus(ctx, appointment.ID, models.AppointmentStatusCancelled)
+		require.NoError(t, err)
+		assert.Equal(t, models.AppointmentStatusCancelled, cancelled.Status)
+
+		_, err = appointments.UpdateStatus(ctx, appointment.ID, models.AppointmentStatusConfirmed)
+		assert.ErrorIs(t, err, ErrValidation)
+
+		appointment, err = book(slot)
+		require.NoError(t, err)
+	})
+
+	t.Run("Reply finds the next appointment", func(t *testing.T) {
+		upcoming, err := appointments.FindForReply(ctx, patient.Phone, now)
+		require.NoError(t, err)
+		require.NotNil(t, upcoming)
+		assert.Equal(t, appointment.ID, upcoming.Appointment.ID)
+		assert.Equal(t, "Appointment Test Dispensary", upcoming.FacilityName)
+
+		msg := &models.InboundSMS{ProviderID: uuid.NewString(), From: patient.Phone, Text: "YES"}
+		confirmed, recorded, err := appointments.RecordInbound(ctx, msg, &appointment.ID, models.AppointmentReplyConfirm, models.AppointmentStatusConfirmed)
+		require.NoError(t, err)
+		assert.True(t, recorded)
+		require.NotNil(t, confirmed)
+		assert.Equal(t, models.AppointmentStatusConfirmed, confirmed.Status)
+		_, recorded, err = appointments.RecordInbound(ctx, msg, &appointment.ID, models.AppointmentReplyConfirm, models.AppointmentStatusConfirmed)
+		require.NoError(t, err)
+		assert.False(t, recorded, "gateway retries are recorded once")
+	})
+
+	t.Run("Reminder claims are idempotent", func(t *testing.T) {
+		_, claimed, err := appointments.ClaimReminder(ctx, appointment.ID, models.AppointmentReminder24h, &patient.Phone)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		_, claimed, err = appointments.ClaimReminder(ctx, appointment.ID, models.AppointmentReminder24h, &patient.Phone)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		upcoming, err := appointments.ListUpcoming(ctx, now)
+		require.NoError(t, err)
+		for _, entry := range upcoming {
+			if entry.Appointment.ID == appointment.ID {
+				assert.Equal(t, []models.AppointmentReminderKind{models.AppointmentReminder24h}, entry.Sent)
+			}
+		}
+	})
+
+	t.Run("Missed appointments become no-shows", func(t *testing.T) {
+		_, err := appointments.MarkNoShows(ctx, slot.Add(time.Minute))
+		require.NoError(t, err)
+
+		missed, err := appointments.GetByID(ctx, appointment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.AppointmentStatusNoShow, missed.Status)
+
+		_, claimed, err := appointments.ClaimReminder(ctx, appointment.ID, models.AppointmentReminder2h, &patient.Phone)
+		require.NoError(t, err)
+		assert.False(t, claimed, "no reminders once the appointment is over")
+	})
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type AppointmentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAppointmentRepository(db *pgxpool.Pool) *AppointmentRepository {
+	return &AppointmentRepository{db: db}
+}
+
+const appointmentColumns = `
+			id, referral_id, patient_id, facility_id, clinician_id, scheduled_time,
+			status, notes, created_at, updated_at`
+
+// scanAppointment scans the appointmentColumns into an appointment. Any
+// extra destinations are scanned after them.
+func scanAppointment(row pgx.Row, extra ...interface{}) (*models.Appointment, error) {
+	var appointment models.Appointment
+
+	dest := []interface{}{
+		&appointment.ID,
+		&appointment.ReferralID,
+		&appointment.PatientID,
+		&appointment.FacilityID,
+		&appointment.ClinicianID,
+		&appointment.ScheduledTime,
+		&appointment.Status,
+		&appointment.Notes,
+		&appointment.CreatedAt,
+		&appointment.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	return &appointment, nil
+}
+
+// Create books a slot and records appointment.booked. A slot already held by
+// a live appointment returns ErrDuplicate; an unknown patient, facility,
+// clinician or referral returns ErrNotFound.
+func (r *AppointmentRepository) Create(ctx context.Context, req *models.CreateAppointmentRequest) (*models.Appointment, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO appointments (patient_id, referral_id, facility_id, clinician_id, scheduled_time, notes)
+		VALUES (` + currentPatientID("$1") + `, $2, $3, $4, $5, $6)
+		RETURNING ` + appointmentColumns
+
+	appointment, err := scanAppointment(tx.QueryRow(ctx, query,
+		req.PatientID,
+		req.ReferralID,
+		req.FacilityID,
+		req.ClinicianID,
+		req.ScheduledTime,
+		req.Notes,
+	))
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicate
+	}
+	if isForeignKeyViolation(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error creating appointment: %v", err)
+		return nil, fmt.Errorf("failed to create appointment: %w", err)
+	}
+
+	if err := recordEvent(ctx, tx, models.EventAppointmentBooked, appointment.ID, appointment); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit appointment: %w", err)
+	}
+
+	return appointment, nil
+}
+
+func (r *AppointmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + ` FROM appointments WHERE id = $1`
+
+	appointment, err := scanAppointment(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get appointment: %w", err)
+	}
+
+	return appointment, nil
+}
+
+// UpdateStatus moves a live appointment to another status. Appointments
+// that are no longer live return ErrValidation.
+func (r *AppointmentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.AppointmentStatus) (*models.Appointment, error) {
+	query := `
+		UPDATE appointments SET status = $2
+		WHERE id = $1 AND status IN ('scheduled', 'confirmed')
+		RETURNING ` + appointmentColumns
+
+	appointment, err := scanAppointment(r.db.QueryRow(ctx, query, id, status))
+	if errors.Is(err, pgx.ErrNoRows) {
+		existing, getErr := r.GetByID(ctx, id)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return nil, fmt.Errorf("%w: appointment is already %s", ErrValidation, existing.Status)
+	}
+	if err != nil {
+		log.Printf("Error updating appointment %s to %s: %v", id, status, err)
+		return nil, fmt.Errorf("failed to update appointment status: %w", err)
+	}
+
+	return appointment, nil
+}
+
+// upcomingAppointmentQuery selects live appointments with the patient's
+// contact details; callers add the WHERE conditions
+var upcomingAppointmentQuery = `
+		SELECT ` + appointmentColumns + `, COALESCE(f.name, ''), COALESCE(p.phone, ''), COALESCE(p.preferred_language, 'en'),
+			` + smsConsentRefused("p.id") + `,
+			ARRAY(SELECT ar.kind FROM appointment_reminders ar WHERE ar.appointment_id = a.id)
+		FROM appointments a
+		LEFT JOIN facilities f ON f.id = a.facility_id
+		LEFT JOIN patients p ON p.id = a.patient_id
+		WHERE a.status IN ('scheduled', 'confirmed')`
+
+func scanUpcomingAppointment(row pgx.Row) (*models.UpcomingAppointment, error) {
+	var upcoming models.UpcomingAppointment
+	var sent []string
+
+	appointment, err := scanAppointment(row,
+		&upcoming.FacilityName, &upcoming.PatientPhone, &upcoming.PatientLanguage, &upcoming.SMSConsentRefused, &sent)
+	if err != nil {
+		return nil, err
+	}
+	upcoming.Appointment = appointment
+	for _, kind := range sent {
+		upcoming.Sent = append(upcoming.Sent, models.AppointmentReminderKind(kind))
+	}
+
+	return &upcoming, nil
+}
+
+// ListUpcoming returns the live appointments in the reminder horizon
+func (r *AppointmentRepository) ListUpcoming(ctx context.Context, now time.Time) ([]*models.UpcomingAppointment, error) {
+	rows, err := r.db.Query(ctx, upcomingAppointmentQuery+`
+			AND a.scheduled_time > $1 AND a.scheduled_time <= $2
+		ORDER BY a.scheduled_time`, now, now.Add(models.AppointmentHorizon))
+	if err != nil {
+		log.Printf("Error listing upcoming appointments: %v", err)
+		return nil, fmt.Errorf("failed to list upcoming appointments: %w", err)
+	}
+	defer rows.Close()
+
+	upcoming := []*models.UpcomingAppointment{}
+	for rows.Next() {
+		entry, err := scanUpcomingAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan upcoming appointment: %w", err)
+		}
+		upcoming = append(upcoming, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating upcoming appointments: %w", err)
+	}
+
+	return upcoming, nil
+}
+
+// FindForReply returns the next live appointment of the patient with this
+// phone number, or nil when there is none
+func (r *AppointmentRepository) FindForReply(ctx context.Context, phone string, now time.Time) (*models.UpcomingAppointment, error) {
+	row := r.db.QueryRow(ctx, upcomingAppointmentQuery+`
+			AND p.phone = ANY($1) AND p.merged_into IS NULL AND a.scheduled_time > $2
+		ORDER BY a.scheduled_time
+		LIMIT 1`, phoneLookupKeys(phone), now)
+
+	upcoming, err := scanUpcomingAppointment(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find appointment for reply: %w", err)
+	}
+
+	return upcoming, nil
+}
+
+// ClaimReminder logs a reminder before it is sent. It returns nil when the
+// reminder was already sent or the appointment is no longer live.
+func (r *AppointmentRepository) ClaimReminder(ctx context.Context, appointmentID uuid.UUID, kind models.AppointmentReminderKind, recipient *string) (uuid.UUID, bool, error) {
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO appointment_reminders (appointment_id, kind, recipient)
+		SELECT $1, $2, $3
+		WHERE EXISTS (SELECT 1 FROM appointments WHERE id = $1 AND status IN ('scheduled', 'confirmed'))
+		ON CONFLICT (appointment_id, kind) DO NOTHING
+		RETURNING id`, appointmentID, kind, recipient).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to claim appointment reminder: %w", err)
+	}
+
+	return id, true, nil
+}
+
+func (r *AppointmentRepository) SetReminderSMSStatus(ctx context.Context, id uuid.UUID, status models.SMSStatus) error {
+	_, err := r.db.Exec(ctx, `UPDATE appointment_reminders SET sms_status = $2 WHERE id = $1`, id, status)
+	if err != nil {
+		return fmt.Errorf("failed to update appointment reminder SMS status: %w", err)
+	}
+	return nil
+}
+
+// MarkNoShows marks live appointments whose slot started before the cutoff
+// as no_show and returns how many there were
+func (r *AppointmentRepository) MarkNoShows(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE appointments SET status = 'no_show'
+		WHERE status IN ('scheduled', 'confirmed') AND scheduled_time < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark no-shows: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RecordInbound stores an inbound SMS and, when status is set, moves the
+// appointment to it in the same transaction, so a reply is never recorded
+// without its action. It returns false if the gateway has delivered the same
+// message before, and a nil appointment when none was moved, including when
+// the appointment is no longer live.
+func (r *AppointmentRepository) RecordInbound(ctx context.Context, msg *models.InboundSMS, appointmentID *uuid.UUID, action models.AppointmentReply, status models.AppointmentStatus) (*models.Appointment, bool, error) {
+	var providerID *string
+	if msg.ProviderID != "" {
+		providerID = &msg.ProviderID
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO inbound_sms (provider_id, phone, body, appointment_id, action)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (provider_id) DO NOTHING`,
+		providerID, msg.From, msg.Text, appointmentID, action)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record inbound SMS: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, false, nil
+	}
+
+	var appointment *models.Appointment
+	if appointmentID != nil && status != "" {
+		query := `
+			UPDATE appointments SET status = $2
+			WHERE id = $1 AND status IN ('scheduled', 'confirmed')
+			RETURNING ` + appointmentColumns
+
+		appointment, err = scanAppointment(tx.QueryRow(ctx, query, *appointmentID, status))
+		if errors.Is(err, pgx.ErrNoRows) {
+			appointment = nil
+		} else if err != nil {
+			log.Printf("Error updating appointment %s to %s: %v", *appointmentID, status, err)
+			return nil, false, fmt.Errorf("failed to update appointment status: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit inbound SMS: %w", err)
+	}
+
+	return appointment, true, nil
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestClinicianReactivation(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	clinicians := NewClinicianRepository(pool)
+	users := NewUserRepository(pool)
+
+	randomPhone := func() string { return fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)) }
+
+	clinician, err := clinicians.Create(ctx, &models.CreateClinicianRequest{Name: "Dr. Jane Wambui", Phone: randomPhone()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM clinicians WHERE id = $1`, clinician.ID)
+	})
+
+	active, err := users.Create(ctx, randomPhone(), models.UserRoleClinician)
+	require.NoError(t, err)
+	disabled, err := users.Create(ctx, randomPhone(), models.UserRoleClinician)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = ANY($1)`, []uuid.UUID{active.ID, disabled.ID})
+	})
+	require.NoError(t, clinicians.LinkUser(ctx, clinician.ID, active.ID))
+	require.NoError(t, clinicians.LinkUser(ctx, clinician.ID, disabled.ID))
+
+	// An admin disabled this account before the clinician was deactivated
+	_, err = pool.Exec(ctx, `UPDATE users SET is_active = false WHERE id = $1`, disabled.ID)
+	require.NoError(t, err)
+
+	_, err = clinicians.Deactivate(ctx, clinician.ID)
+	require.NoError(t, err)
+	require.NoError(t, clinicians.Reactivate(ctx, clinician.ID))
+
+	isActive := func(id uuid.UUID) bool {
+		var active bool
+		require.NoError(t, pool.QueryRow(ctx, `SELECT is_active FROM users WHERE id = $1`, id).Scan(&active))
+		return active
+	}
+	assert.True(t, isActive(active.ID))
+	assert.False(t, isActive(disabled.ID), "reactivating the clinician does not re-enable a user disabled for another reason")
+
+	assert.ErrorIs(t, clinicians.Reactivate(ctx, uuid.New()), ErrNotFound)
+	assert.ErrorIs(t, clinicians.LinkUser(ctx, uuid.New(), active.ID), ErrNotFound)
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// ClinicianRepositoryInterface defines the interface for clinician operations
+type ClinicianRepositoryInterface interface {
+	Create(ctx context.Context, req *models.CreateClinicianRequest) (*models.Clinician, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Clinician, error)
+	List(ctx context.Context, facilityID *uuid.UUID, activeOnly bool) ([]*models.Clinician, error)
+	Update(ctx context.Context, id uuid.UUID, req *models.UpdateClinicianRequest) (*models.Clinician, error)
+	Deactivate(ctx context.Context, id uuid.UUID) (int64, error)
+	Reactivate(ctx context.Context, id uuid.UUID) error
+	LinkUser(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+}
+
+type ClinicianRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewClinicianRepository(db *pgxpool.Pool) *ClinicianRepository {
+	return &ClinicianRepository{db: db}
+}
+
+const clinicianColumns = `id, name, phone, email, facility_id, specialization, license_number, is_active, created_at, updated_at`
+
+func scanClinician(row pgx.Row) (*models.Clinician, error) {
+	var clinician models.Clinician
+	err := row.Scan(
+		&clinician.ID,
+		&clinician.Name,
+		&clinician.Phone,
+		&clinician.Email,
+		&clinician.FacilityID,
+		&clinician.Specialization,
+		&clinician.LicenseNumber,
+		&clinician.IsActive,
+		&clinician.CreatedAt,
+		&clinician.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &clinician, nil
+}
+
+// Create inserts a clinician under the E.164 form of req.Phone
+func (r *ClinicianRepository) Create(ctx context.Context, req *models.CreateClinicianRequest) (*models.Clinician, error) {
+	phone, err := normalizePhone(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO clinicians (name, phone, email, facility_id, specialization, license_number)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + clinicianColumns
+
+	clinician, err := scanClinician(r.db.QueryRow(ctx, query,
+		req.Name,
+		phone,
+		req.Email,
+		req.FacilityID,
+		req.Specialization,
+		req.LicenseNumber,
+	))
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicate
+	}
+	if isForeignKeyViolation(err) {
+		return nil, fmt.Errorf("%w: facility does not exist", ErrValidation)
+	}
+	if err != nil {
+		log.Printf("Error creating clinician: %v", err)
+		return nil, fmt.Errorf("failed to create clinician: %w", err)
+	}
+
+	return clinician, nil
+}
+
+func (r *ClinicianRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Clinician, error) {
+	query := `SELECT ` + clinicianColumns + ` FROM clinicians WHERE id = $1`
+
+	clinician, err := scanClinician(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("clinician not found")
+	}
+	if err != nil {
+		log.Printf("Error getting clinician: %v", err)
+		return nil, fmt.Errorf("failed to get clinician: %w", err)
+	}
+
+	return clinician, nil
+}
+
+func (r *ClinicianRepository) List(ctx context.Context, facilityID *uuid.UUID, activeOnly bool) ([]*models.Clinician, error) {
+	query := `SELECT ` + clinicianColumns + ` FROM clinicians WHERE 1=1`
+
+	args := []interface{}{}
+	argCount := 1
+
+	if facilityID != nil {
+		query += fmt.Sprintf(" AND facility_id = $%d", argCount)
+		args = append(args, *facilityID)
+		argCount++
+	}
+
+	if activeOnly {
+		query += " AND is_active = true"
+	}
+
+	query += " ORDER BY name ASC"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clinicians: %w", err)
+	}
+	defer rows.Close()
+
+	var clinicians []*models.Clinician
+	for rows.Next() {
+		clinician, err := scanClinician(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan clinician: %w", err)
+		}
+		clinicians = append(clinicians, clinician)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clinicians: %w", err)
+	}
+
+	return clinicians, nil
+}
+
+// Update applies the non-nil fields of req; omitted fields keep their current value
+func (r *ClinicianRepository) Update(ctx context.Context, id uuid.UUID, req *models.UpdateClinicianRequest) (*models.Clinician, error) {
+	query := `
+		UPDATE clinicians
+		SET name = COALESCE($1, name),
+		    email = COALESCE($2, email),
+		    facility_id = COALESCE($3, facility_id),
+		    specialization = COALESCE($4, specialization),
+		    license_number = COALESCE($5, license_number)
+		WHERE id = $6
+		RETURNING ` + clinicianColumns
+
+	clinician, err := scanClinician(r.db.QueryRow(ctx, query,
+		req.Name,
+		req.Email,
+		req.FacilityID,
+		req.Specialization,
+		req.LicenseNumber,
+		id,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if isForeignKeyViolation(err) {
+		return nil, fmt.Errorf("%w: facility does not exist", ErrValidation)
+	}
+	if err != nil {
+		log.Printf("Error updating clinician: %v", err)
+		return nil, fmt.Errorf("failed to update clinician: %w", err)
+	}
+
+	return clinician, nil
+}
+
+// Deactivate marks a clinician and every user linked to them inactive, so
+// they can no longer log in, and ends those users' sessions. It returns the
+// number of sessions revoked.
+func (r *ClinicianRepository) Deactivate(ctx context.Context, id uuid.UUID) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `UPDATE clinicians SET is_active = false WHERE id = $1`, id)
+	if err != nil {
+		log.Printf("Error deactivating clinician: %v", err)
+		return 0, fmt.Errorf("failed to deactivate clinician: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return 0, fmt.Errorf("clinician not found")
+	}
+
+	// Only users that are still active are marked, so Reactivate leaves
+	// users that were disabled for another reason alone
+	if _, err := tx.Exec(ctx, `
+		UPDATE users
+		SET is_active = false, deactivated_with_clinician_at = CURRENT_TIMESTAMP
+		WHERE clinician_id = $1 AND is_active
+	`, id); err != nil {
+		log.Printf("Error deactivating clinician users: %v", err)
+		return 0, fmt.Errorf("failed to deactivate clinician users: %w", err)
+	}
+
+	result, err = tx.Exec(ctx, `
+		DELETE FROM sessions
+		WHERE user_id IN (SELECT id FROM users WHERE clinician_id = $1)
+	`, id)
+	if err != nil {
+		log.Printf("Error revoking clinician sessions: %v", err)
+		return 0, fmt.Errorf("failed to revoke clinician sessions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// Reactivate marks a clinician active again, along with the linked users
+// that Deactivate turned off
+func (r *ClinicianRepository) Reactivate(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `UPDATE clinicians SET is_active = true WHERE id = $1`, id)
+	if err != nil {
+		log.Printf("Error reactivating clinician: %v", err)
+		return fmt.Errorf("failed to reactivate clinician: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE users
+		SET is_active = true, deactivated_with_clinician_at = NULL
+		WHERE clinician_id = $1 AND deactivated_with_clinician_at IS NOT NULL
+	`, id); err != nil {
+		log.Printf("Error reactivating clinician users: %v", err)
+		return fmt.Errorf("failed to reactivate clinician users: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// LinkUser attaches a clinician identity to a user account with the clinician
+// role. It returns ErrNotFound when either the clinician or such a user is missing.
+func (r *ClinicianRepository) LinkUser(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	query := `
+		UPDATE users
+		SET clinician_id = $1
+		WHERE id = $2 AND role = 'clinician'
+		  AND EXISTS (SELECT 1 FROM clinicians WHERE id = $1)
+	`
+
+	result, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		log.Printf("Error linking clinician to user: %v", err)
+		return fmt.Errorf("failed to link clinician to user: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
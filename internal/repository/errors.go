@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/phone"
+)
+
+// ErrDuplicate is returned when an insert or update violates a unique constraint
+var ErrDuplicate = errors.New("record already exists")
+
+// ErrNotFound is returned when a record the operation depends on does not exist
+var ErrNotFound = errors.New("record not found")
+
+// ErrValidation wraps errors caused by the caller's input rather than the database
+var ErrValidation = errors.New("validation failed")
+
+// ErrVersionConflict is returned when an If-Match precondition no longer holds
+var ErrVersionConflict = errors.New("record was modified by another request")
+
+// ErrInvalidCursor is returned when a pagination cursor does not fit the query
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// normalizePhone returns the E.164 form stored for new records
+func normalizePhone(raw string) (string, error) {
+	normalized, err := phone.Normalize(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return normalized, nil
+}
+
+// phoneLookupKeys returns the stored forms to match when looking a number up.
+// Rows that could not be normalized by migration 000012 keep their original form.
+func phoneLookupKeys(raw string) []string {
+	normalized, err := phone.Normalize(raw)
+	if err != nil {
+		return []string{raw}
+	}
+	return phone.Variants(normalized)
+}
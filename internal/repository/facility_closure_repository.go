@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type FacilityClosureRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFacilityClosureRepository(db *pgxpool.Pool) *FacilityClosureRepository {
+	return &FacilityClosureRepository{db: db}
+}
+
+const facilityClosureColumns = `id, facility_id, kind, name, starts_on, ends_on, created_by, created_at`
+
+func scanFacilityClosure(row pgx.Row) (*models.FacilityClosure, error) {
+	var closure models.FacilityClosure
+
+	err := row.Scan(
+		&closure.ID,
+		&closure.FacilityID,
+		&closure.Kind,
+		&closure.Name,
+		&closure.StartsOn,
+		&closure.EndsOn,
+		&closure.CreatedBy,
+		&closure.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &closure, nil
+}
+
+func (r *FacilityClosureRepository) Create(ctx context.Context, req *models.CreateFacilityClosureRequest, startsOn, endsOn time.Time, createdBy *uuid.UUID) (*models.FacilityClosure, error) {
+	query := `
+		INSERT INTO facility_closures (facility_id, kind, name, starts_on, ends_on, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + facilityClosureColumns
+
+	closure, err := scanFacilityClosure(r.db.QueryRow(ctx, query,
+		req.FacilityID,
+		req.Kind,
+		req.Name,
+		startsOn,
+		endsOn,
+		createdBy,
+	))
+	if err != nil {
+		log.Printf("Error creating facility closure: %v", err)
+		return nil, fmt.Errorf("failed to create facility closure: %w", err)
+	}
+
+	return closure, nil
+}
+
+// List returns closures overlapping [from, to], optionally limited to one
+// facility (closures for all facilities are always included)
+func (r *FacilityClosureRepository) List(ctx context.Context, from, to time.Time, facilityID *uuid.UUID) ([]*models.FacilityClosure, error) {
+	query := `SELECT ` + facilityClosureColumns + `
+		FROM facility_closures
+		WHERE starts_on <= $2::date AND ends_on >= $1::date
+	`
+	args := []interface{}{from, to}
+
+	if facilityID != nil {
+		query += " AND (facility_id IS NULL OR facility_id = $3)"
+		args = append(args, *facilityID)
+	}
+
+	query += " ORDER BY starts_on ASC"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list facility closures: %w", err)
+	}
+	defer rows.Close()
+
+	closures := []*models.FacilityClosure{}
+	for rows.Next() {
+		closure, err := scanFacilityClosure(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan facility closure: %w", err)
+		}
+		closures = append(closures, closure)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating facility closures: %w", err)
+	}
+
+	return closures, nil
+}
+
+func (r *FacilityClosureRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM facility_closures WHERE id = $1`, id)
+	if err != nil {
+		log.Printf("Error deleting facility closure: %v", err)
+		return fmt.Errorf("failed to delete facility closure: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("facility closure not found")
+	}
+
+	return nil
+}
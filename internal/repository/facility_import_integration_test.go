@@ -0,0 +1,51 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestFacilityUpsertByMFLCode(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFacilityRepository(testPool(t), NewHaversineBackend())
+
+	mflCode := uuid.NewString()[:8]
+	level, county, subCounty := 3, "Kisumu", "Kisumu Central"
+	lat, lng := -0.1022, 34.7617
+	inserted, err := repo.UpsertByMFLCode(ctx, &models.CreateFacilityRequest{
+		MFLCode: &mflCode, Name: "Import Test Health Center", Type: models.FacilityTypeHealthCenter,
+		Level: &level, County: &county, SubCounty: &subCounty, Latitude: &lat, Longitude: &lng,
+	})
+	require.NoError(t, err)
+	assert.True(t, inserted)
+	t.Cleanup(func() {
+		repo.db.Exec(context.Background(), `DELETE FROM facilities WHERE mfl_code = $1`, mflCode)
+	})
+
+	// A later export that leaves out the optional fields, and has only half
+	// of the coordinates, changes the name alone
+	movedLat := -0.2
+	inserted, err = repo.UpsertByMFLCode(ctx, &models.CreateFacilityRequest{
+		MFLCode: &mflCode, Name: "Import Test Sub-County Hospital", Type: models.FacilityTypeHealthCenter, Latitude: &movedLat,
+	})
+	require.NoError(t, err)
+	assert.False(t, inserted)
+
+	var facilityID uuid.UUID
+	require.NoError(t, repo.db.QueryRow(ctx, `SELECT id FROM facilities WHERE mfl_code = $1`, mflCode).Scan(&facilityID))
+	facility, err := repo.GetByID(ctx, facilityID)
+	require.NoError(t, err)
+	assert.Equal(t, "Import Test Sub-County Hospital", facility.Name)
+	assert.Equal(t, &level, facility.Level)
+	assert.Equal(t, &county, facility.County)
+	assert.Equal(t, &subCounty, facility.SubCounty)
+	assert.InDelta(t, lat, *facility.Latitude, 1e-9)
+	assert.InDelta(t, lng, *facility.Longitude, 1e-9)
+}
@@ -4,39 +4,43 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/pagination"
 )
 
 type FacilityRepository struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	geo GeoBackend
 }
 
-func NewFacilityRepository(db *pgxpool.Pool) *FacilityRepository {
-	return &FacilityRepository{db: db}
+func NewFacilityRepository(db *pgxpool.Pool, geo GeoBackend) *FacilityRepository {
+	return &FacilityRepository{db: db, geo: geo}
 }
 
-func (r *FacilityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Facility, error) {
-	query := `
-		SELECT 
-			id, name, type, level, county, sub_county,
-			ST_Y(location::geometry) as latitude,
-			ST_X(location::geometry) as longitude,
+const facilityColumns = `
+			id, mfl_code, name, type, level, county, sub_county,
+			latitude::float8 as latitude,
+			longitude::float8 as longitude,
 			address, phone, email, services, operating_hours,
 			accepts_referrals, accepts_mpesa, bed_capacity, staff_count,
-			available_slots, created_at, updated_at
-		FROM facilities
-		WHERE id = $1
-	`
+			available_slots, is_active, created_at, updated_at`
 
+// scanFacility scans the facilityColumns into a facility. Any extra
+// destinations (e.g. a computed distance) are scanned after them.
+func scanFacility(row pgx.Row, extra ...interface{}) (*models.Facility, error) {
 	var facility models.Facility
 	var servicesRaw, operatingHoursRaw, availableSlotsRaw []byte
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	dest := []interface{}{
 		&facility.ID,
+		&facility.MFLCode,
 		&facility.Name,
 		&facility.Type,
 		&facility.Level,
@@ -54,22 +58,23 @@ func (r *FacilityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 		&facility.BedCapacity,
 		&facility.StaffCount,
 		&availableSlotsRaw,
+		&facility.IsActive,
 		&facility.CreatedAt,
 		&facility.UpdatedAt,
-	)
-
-	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("facility not found")
 	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get facility: %w", err)
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
 	}
 
 	if err := json.Unmarshal(servicesRaw, &facility.Services); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal services: %w", err)
 	}
+	// Free-text hours from before structured schedules may not parse; treat
+	// them as unknown rather than failing the whole read
 	if err := json.Unmarshal(operatingHoursRaw, &facility.OperatingHours); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal operating hours: %w", err)
+		log.Printf("Warning: unreadable operating hours for facility %s: %v", facility.ID, err)
+		facility.OperatingHours = models.OperatingHours{}
 	}
 	if err := json.Unmarshal(availableSlotsRaw, &facility.AvailableSlots); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal available slots: %w", err)
@@ -78,78 +83,170 @@ func (r *FacilityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	return &facility, nil
 }
 
-func (r *FacilityRepository) GetNearby(ctx context.Context, lat, lng, radiusKM float64) ([]*models.Facility, error) {
-	query := `
-		SELECT 
-			id, name, type, level, county, sub_county,
-			ST_Y(location::geometry) as latitude,
-			ST_X(location::geometry) as longitude,
-			address, phone, email, services, operating_hours,
-			accepts_referrals, accepts_mpesa, bed_capacity, staff_count,
-			available_slots, created_at, updated_at,
-			ST_Distance(location, ST_GeogFromText($1)) / 1000 as distance_km
+func (r *FacilityRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Facility, error) {
+	query := `SELECT ` + facilityColumns + `
 		FROM facilities
-		WHERE 
-			accepts_referrals = true
-			AND ST_DWithin(location, ST_GeogFromText($1), $2)
-		ORDER BY distance_km ASC
-		LIMIT 20
+		WHERE id = $1
 	`
 
-	point := fmt.Sprintf("POINT(%f %f)", lng, lat)
-	radiusMeters := radiusKM * 1000
+	facility, err := scanFacility(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility: %w", err)
+	}
 
-	rows, err := r.db.Query(ctx, query, point, radiusMeters)
+	return facility, nil
+}
+
+// ListByCounty returns the active facilities in a county, ordered by name.
+// Counties match exactly, ignoring case
+func (r *FacilityRepository) ListByCounty(ctx context.Context, county string) ([]*models.Facility, error) {
+	query := `SELECT ` + facilityColumns + `
+		FROM facilities
+		WHERE is_active = true AND lower(county) = lower($1)
+		ORDER BY name, id
+	`
+
+	rows, err := r.db.Query(ctx, query, county)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query nearby facilities: %w", err)
+		return nil, fmt.Errorf("failed to list county facilities: %w", err)
 	}
 	defer rows.Close()
 
-	var facilities []*models.Facility
+	facilities := []*models.Facility{}
 	for rows.Next() {
-		var facility models.Facility
-		var servicesRaw, operatingHoursRaw, availableSlotsRaw []byte
-		var distanceKM float64
+		facility, err := scanFacility(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan facility: %w", err)
+		}
+		facilities = append(facilities, facility)
+	}
 
-		err := rows.Scan(
-			&facility.ID,
-			&facility.Name,
-			&facility.Type,
-			&facility.Level,
-			&facility.County,
-			&facility.SubCounty,
-			&facility.Latitude,
-			&facility.Longitude,
-			&facility.Address,
-			&facility.Phone,
-			&facility.Email,
-			&servicesRaw,
-			&operatingHoursRaw,
-			&facility.AcceptsReferrals,
-			&facility.AcceptsMpesa,
-			&facility.BedCapacity,
-			&facility.StaffCount,
-			&availableSlotsRaw,
-			&facility.CreatedAt,
-			&facility.UpdatedAt,
-			&distanceKM,
-		)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating facilities: %w", err)
+	}
+
+	return facilities, nil
+}
+
+// FacilityFilter decides in Go whether a facility read from the database is
+// returned, for conditions SQL cannot express such as opening hours. A nil
+// filter keeps every facility.
+type FacilityFilter func(facility *models.Facility) bool
+
+// facilityFilterBatch is how many rows are read at a time while a filter
+// fills a page
+const facilityFilterBatch = 100
 
+// filterBatchSize is how many rows to read per query for a page of limit.
+// One extra row tells whether another page exists.
+func filterBatchSize(limit int, keep FacilityFilter) int {
+	if keep == nil || limit+1 >= facilityFilterBatch {
+		return limit + 1
+	}
+	return facilityFilterBatch
+}
+
+// GetNearby returns active, referral-accepting facilities within radiusKM of
+// (lat, lng), closest first. Facilities that have temporarily paused
+// referrals are left out, as are those keep rejects. Results are
+// keyset-paginated on (distance, id); pass the returned cursor back as after
+// to fetch the next page. Rows are read until the page is full, so a
+// filtered page still holds limit facilities when enough match.
+func (r *FacilityRepository) GetNearby(ctx context.Context, lat, lng, radiusKM float64, limit int, after *pagination.Cursor, keep FacilityFilter) ([]*models.NearbyFacility, *pagination.Cursor, error) {
+	if after != nil {
+		if _, err := strconv.ParseFloat(after.Value, 64); err != nil || after.Sort != "" {
+			return nil, nil, ErrInvalidCursor
+		}
+	}
+
+	batch := filterBatchSize(limit, keep)
+	var facilities []*models.NearbyFacility
+	for {
+		page, err := r.nearbyPage(ctx, lat, lng, radiusKM, batch, after)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan facility: %w", err)
+			return nil, nil, err
 		}
+		for _, facility := range page {
+			if keep == nil || keep(&facility.Facility) {
+				facilities = append(facilities, facility)
+			}
+		}
+		if len(facilities) > limit || len(page) < batch {
+			break
+		}
+		last := page[len(page)-1]
+		after = &pagination.Cursor{Value: strconv.FormatFloat(last.DistanceKM, 'g', -1, 64), ID: last.ID}
+	}
 
-		if err := json.Unmarshal(servicesRaw, &facility.Services); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal services: %w", err)
+	var next *pagination.Cursor
+	if len(facilities) > limit {
+		facilities = facilities[:limit]
+		last := facilities[limit-1]
+		next = &pagination.Cursor{
+			Value: strconv.FormatFloat(last.DistanceKM, 'g', -1, 64),
+			ID:    last.ID,
 		}
-		if err := json.Unmarshal(operatingHoursRaw, &facility.OperatingHours); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal operating hours: %w", err)
+	}
+
+	return facilities, next, nil
+}
+
+// nearbyPage reads up to size facilities for GetNearby after the cursor
+func (r *FacilityRepository) nearbyPage(ctx context.Context, lat, lng, radiusKM float64, size int, after *pagination.Cursor) ([]*models.NearbyFacility, error) {
+	distanceExpr, within, args := r.geo.Nearby(lat, lng, radiusKM, 1)
+
+	query := `SELECT nearby.* FROM (
+			SELECT ` + facilityColumns + `,
+				` + distanceExpr + ` as distance_km
+			FROM facilities
+			WHERE
+				is_active = true
+				AND accepts_referrals = true
+				AND NOT EXISTS (
+					SELECT 1 FROM facility_status s
+					WHERE s.facility_id = facilities.id
+					  AND s.referrals_paused
+					  AND s.paused_until > NOW()
+				)
+				AND ` + within + `
+		) nearby
+		WHERE 1=1
+	`
+
+	if after != nil {
+		afterDistance, err := strconv.ParseFloat(after.Value, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
 		}
-		if err := json.Unmarshal(availableSlotsRaw, &facility.AvailableSlots); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal available slots: %w", err)
+		query += fmt.Sprintf(" AND (distance_km, id) > ($%d, $%d)", len(args)+1, len(args)+2)
+		args = append(args, afterDistance, after.ID)
+	}
+
+	query += fmt.Sprintf(" ORDER BY distance_km ASC, id ASC LIMIT $%d", len(args)+1)
+	args = append(args, size)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nearby facilities: %w", err)
+	}
+	defer rows.Close()
+
+	var facilities []*models.NearbyFacility
+	for rows.Next() {
+		var distanceKM float64
+
+		facility, err := scanFacility(rows, &distanceKM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan facility: %w", err)
 		}
 
-		facilities = append(facilities, &facility)
+		facilities = append(facilities, &models.NearbyFacility{
+			Facility:   *facility,
+			DistanceKM: distanceKM,
+		})
 	}
 
 	if err := rows.Err(); err != nil {
@@ -159,90 +256,450 @@ func (r *FacilityRepository) GetNearby(ctx context.Context, lat, lng, radiusKM f
 	return facilities, nil
 }
 
-func (r *FacilityRepository) List(ctx context.Context, county *string, facilityType *models.FacilityType) ([]*models.Facility, error) {
-	query := `
-		SELECT 
-			id, name, type, level, county, sub_county,
-			ST_Y(location::geometry) as latitude,
-			ST_X(location::geometry) as longitude,
-			address, phone, email, services, operating_hours,
-			accepts_referrals, accepts_mpesa, bed_capacity, staff_count,
-			available_slots, created_at, updated_at
-		FROM facilities
-		WHERE 1=1
-	`
+// facilitySortKeys maps search sort names onto the SQL expression rows are
+// ordered by and the type its cursor value is cast back to
+var facilitySortKeys = map[string]struct {
+	expr string
+	cast string
+}{
+	models.FacilitySortName:      {"name", "text"},
+	models.FacilitySortLevel:     {"COALESCE(level, 0)", "int"},
+	models.FacilitySortCreatedAt: {"created_at", "timestamptz"},
+	models.FacilitySortRelevance: {"word_similarity($1, name)", "real"},
+}
 
+// Search returns active facilities matching req and kept by keep,
+// keyset-paginated in the requested sort order, along with the total number
+// of matches. As with GetNearby, rows are read until the page is full. req
+// must have been normalized.
+func (r *FacilityRepository) Search(ctx context.Context, req *models.FacilitySearchRequest, limit int, after *pagination.Cursor, keep FacilityFilter) ([]*models.Facility, *pagination.Cursor, int, error) {
+	where := []string{"is_active = true"}
 	args := []interface{}{}
-	argCount := 1
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
 
-	if county != nil {
-		query += fmt.Sprintf(" AND county = $%d", argCount)
-		args = append(args, *county)
-		argCount++
+	// The search text is always $1 so the relevance sort expression can refer to it
+	if req.Query != "" {
+		arg(req.Query)
+		where = append(where, "(name ILIKE '%' || $1 || '%' OR $1 <% name)")
+	}
+	if req.County != "" {
+		where = append(where, "lower(county) = lower("+arg(req.County)+")")
+	}
+	if req.SubCounty != "" {
+		where = append(where, "lower(sub_county) = lower("+arg(req.SubCounty)+")")
+	}
+	if req.Type != "" {
+		where = append(where, "type = "+arg(req.Type))
+	}
+	if len(req.Services) > 0 {
+		// ?| and ?& are served by idx_facilities_services
+		operator := "?|"
+		if req.ServicesMatch == "all" {
+			operator = "?&"
+		}
+		where = append(where, "services "+operator+" "+arg(req.Services)+"::text[]")
+	}
+	if req.MinLevel != nil {
+		where = append(where, "level >= "+arg(*req.MinLevel))
+	}
+	if req.MaxLevel != nil {
+		where = append(where, "level <= "+arg(*req.MaxLevel))
+	}
+	if req.AcceptsReferrals != nil {
+		where = append(where, "accepts_referrals = "+arg(*req.AcceptsReferrals))
+	}
+	if req.AcceptsMpesa != nil {
+		where = append(where, "accepts_mpesa = "+arg(*req.AcceptsMpesa))
+	}
+
+	filter := strings.Join(where, " AND ")
+
+	if after != nil && after.Sort != req.Sort {
+		return nil, nil, 0, ErrInvalidCursor
+	}
+
+	total, err := r.countMatches(ctx, filter, args, keep)
+	if err != nil {
+		return nil, nil, 0, err
 	}
 
-	if facilityType != nil {
-		query += fmt.Sprintf(" AND type = $%d", argCount)
-		args = append(args, *facilityType)
-		argCount++
+	batch := filterBatchSize(limit, keep)
+	facilities := []*models.Facility{}
+	var sortKeys []string
+	for {
+		page, keys, err := r.searchPage(ctx, req.Sort, filter, args, batch, after)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		for i, facility := range page {
+			if keep == nil || keep(facility) {
+				facilities = append(facilities, facility)
+				sortKeys = append(sortKeys, keys[i])
+			}
+		}
+		if len(facilities) > limit || len(page) < batch {
+			break
+		}
+		after = &pagination.Cursor{Value: keys[len(keys)-1], ID: page[len(page)-1].ID, Sort: req.Sort}
 	}
 
-	query += " ORDER BY name ASC LIMIT 100"
+	var next *pagination.Cursor
+	if len(facilities) > limit {
+		facilities = facilities[:limit]
+		next = &pagination.Cursor{
+			Value: sortKeys[limit-1],
+			ID:    facilities[limit-1].ID,
+			Sort:  req.Sort,
+		}
+	}
+
+	return facilities, next, total, nil
+}
+
+// countMatches counts the facilities matching a Search filter. With keep
+// every match has to be read to be counted.
+func (r *FacilityRepository) countMatches(ctx context.Context, filter string, args []interface{}, keep FacilityFilter) (int, error) {
+	var total int
+	if keep == nil {
+		if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM facilities WHERE `+filter, args...).Scan(&total); err != nil {
+			return 0, fmt.Errorf("failed to count facilities: %w", err)
+		}
+		return total, nil
+	}
+
+	rows, err := r.db.Query(ctx, `SELECT `+facilityColumns+` FROM facilities WHERE `+filter, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count facilities: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		facility, err := scanFacility(rows)
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan facility: %w", err)
+		}
+		if keep(facility) {
+			total++
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating facilities: %w", err)
+	}
+
+	return total, nil
+}
+
+// searchPage reads up to size facilities matching a Search filter after the
+// cursor, with the sort key of each
+func (r *FacilityRepository) searchPage(ctx context.Context, sort, filter string, args []interface{}, size int, after *pagination.Cursor) ([]*models.Facility, []string, error) {
+	args = append([]interface{}{}, args...)
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	desc := strings.HasPrefix(sort, "-")
+	sortName := strings.TrimPrefix(sort, "-")
+	key := facilitySortKeys[sortName]
+	// Relevance reads best highest first, so "relevance" is descending
+	if sortName == models.FacilitySortRelevance {
+		desc = !desc
+	}
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if after != nil {
+		filter += fmt.Sprintf(" AND (%s, id) %s (%s::%s, %s)", key.expr, comparison, arg(after.Value), key.cast, arg(after.ID))
+	}
+
+	query := `SELECT ` + facilityColumns + `, (` + key.expr + `)::text AS sort_key
+		FROM facilities
+		WHERE ` + filter + `
+		ORDER BY ` + key.expr + ` ` + direction + `, id ` + direction + `
+		LIMIT ` + arg(size)
 
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list facilities: %w", err)
+		return nil, nil, fmt.Errorf("failed to search facilities: %w", err)
 	}
 	defer rows.Close()
 
 	var facilities []*models.Facility
+	var sortKeys []string
 	for rows.Next() {
-		var facility models.Facility
-		var servicesRaw, operatingHoursRaw, availableSlotsRaw []byte
-
-		err := rows.Scan(
-			&facility.ID,
-			&facility.Name,
-			&facility.Type,
-			&facility.Level,
-			&facility.County,
-			&facility.SubCounty,
-			&facility.Latitude,
-			&facility.Longitude,
-			&facility.Address,
-			&facility.Phone,
-			&facility.Email,
-			&servicesRaw,
-			&operatingHoursRaw,
-			&facility.AcceptsReferrals,
-			&facility.AcceptsMpesa,
-			&facility.BedCapacity,
-			&facility.StaffCount,
-			&availableSlotsRaw,
-			&facility.CreatedAt,
-			&facility.UpdatedAt,
-		)
+		var sortKey string
 
+		facility, err := scanFacility(rows, &sortKey)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan facility: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan facility: %w", err)
 		}
 
-		if err := json.Unmarshal(servicesRaw, &facility.Services); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal services: %w", err)
-		}
-		if err := json.Unmarshal(operatingHoursRaw, &facility.OperatingHours); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal operating hours: %w", err)
+		facilities = append(facilities, facility)
+		sortKeys = append(sortKeys, sortKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating facilities: %w", err)
+	}
+
+	return facilities, sortKeys, nil
+}
+
+func (r *FacilityRepository) Create(ctx context.Context, req *models.CreateFacilityRequest) (*models.Facility, error) {
+	services := req.Services
+	if services == nil {
+		services = []string{}
+	}
+	servicesJSON, err := json.Marshal(services)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal services: %w", err)
+	}
+
+	var operatingHours models.OperatingHours
+	if req.OperatingHours != nil {
+		operatingHours = *req.OperatingHours
+	}
+	operatingHoursJSON, err := json.Marshal(operatingHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal operating hours: %w", err)
+	}
+
+	// Referrals are accepted unless the caller says otherwise; M-Pesa is opt-in
+	acceptsReferrals := true
+	if req.AcceptsReferrals != nil {
+		acceptsReferrals = *req.AcceptsReferrals
+	}
+	acceptsMpesa := false
+	if req.AcceptsMpesa != nil {
+		acceptsMpesa = *req.AcceptsMpesa
+	}
+
+	query := `
+		INSERT INTO facilities (
+			name, type, level, county, sub_county, latitude, longitude,
+			address, phone, email, services, operating_hours,
+			accepts_referrals, accepts_mpesa, bed_capacity, staff_count, mfl_code
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING ` + facilityColumns
+
+	facility, err := scanFacility(r.db.QueryRow(ctx, query,
+		req.Name,
+		req.Type,
+		req.Level,
+		req.County,
+		req.SubCounty,
+		req.Latitude,
+		req.Longitude,
+		req.Address,
+		req.Phone,
+		req.Email,
+		servicesJSON,
+		operatingHoursJSON,
+		acceptsReferrals,
+		acceptsMpesa,
+		req.BedCapacity,
+		req.StaffCount,
+		req.MFLCode,
+	))
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		log.Printf("Error creating facility: %v", err)
+		return nil, fmt.Errorf("failed to create facility: %w", err)
+	}
+
+	return facility, nil
+}
+
+// Update applies the non-nil fields of req; omitted fields keep their current value
+func (r *FacilityRepository) Update(ctx context.Context, id uuid.UUID, req *models.UpdateFacilityRequest) (*models.Facility, error) {
+	var servicesJSON, operatingHoursJSON []byte
+	var err error
+
+	if req.Services != nil {
+		if servicesJSON, err = json.Marshal(req.Services); err != nil {
+			return nil, fmt.Errorf("failed to marshal services: %w", err)
 		}
-		if err := json.Unmarshal(availableSlotsRaw, &facility.AvailableSlots); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal available slots: %w", err)
+	}
+	if req.OperatingHours != nil {
+		if operatingHoursJSON, err = json.Marshal(req.OperatingHours); err != nil {
+			return nil, fmt.Errorf("failed to marshal operating hours: %w", err)
 		}
+	}
+
+	query := `
+		UPDATE facilities
+		SET name = COALESCE($1, name),
+		    type = COALESCE($2, type),
+		    level = COALESCE($3, level),
+		    county = COALESCE($4, county),
+		    sub_county = COALESCE($5, sub_county),
+		    latitude = COALESCE($6, latitude),
+		    longitude = COALESCE($7, longitude),
+		    address = COALESCE($8, address),
+		    phone = COALESCE($9, phone),
+		    email = COALESCE($10, email),
+		    services = COALESCE($11::jsonb, services),
+		    operating_hours = COALESCE($12::jsonb, operating_hours),
+		    accepts_referrals = COALESCE($13, accepts_referrals),
+		    accepts_mpesa = COALESCE($14, accepts_mpesa),
+		    bed_capacity = COALESCE($15, bed_capacity),
+		    staff_count = COALESCE($16, staff_count),
+		    is_active = COALESCE($17, is_active)
+		WHERE id = $18
+		RETURNING ` + facilityColumns
+
+	facility, err := scanFacility(r.db.QueryRow(ctx, query,
+		req.Name,
+		req.Type,
+		req.Level,
+		req.County,
+		req.SubCounty,
+		req.Latitude,
+		req.Longitude,
+		req.Address,
+		req.Phone,
+		req.Email,
+		servicesJSON,
+		operatingHoursJSON,
+		req.AcceptsReferrals,
+		req.AcceptsMpesa,
+		req.BedCapacity,
+		req.StaffCount,
+		req.IsActive,
+		id,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		log.Printf("Error updating facility: %v", err)
+		return nil, fmt.Errorf("failed to update facility: %w", err)
+	}
+
+	return facility, nil
+}
+
+// Deactivate hides a facility from search without deleting its referral history
+func (r *FacilityRepository) Deactivate(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE facilities SET is_active = false WHERE id = $1`
 
-		facilities = append(facilities, &facility)
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		log.Printf("Error deactivating facility: %v", err)
+		return fmt.Errorf("failed to deactivate facility: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("facility not found")
+	}
+
+	return nil
+}
+
+func (r *FacilityRepository) GetByMFLCode(ctx context.Context, mflCode string) (*models.Facility, error) {
+	query := `SELECT ` + facilityColumns + `
+		FROM facilities
+		WHERE mfl_code = $1
+	`
+
+	facility, err := scanFacility(r.db.QueryRow(ctx, query, mflCode))
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not found, not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility by MFL code: %w", err)
+	}
+
+	return facility, nil
+}
+
+// UpsertByMFLCode inserts a facility or refreshes the registry-owned fields of
+// an existing one. Locally maintained fields (services, hours, capacity,
+// payment and referral flags) are never overwritten by an import, and
+// optional fields the registry leaves out keep their current value.
+// Coordinates are only replaced as a pair.
+func (r *FacilityRepository) UpsertByMFLCode(ctx context.Context, req *models.CreateFacilityRequest) (bool, error) {
+	if req.MFLCode == nil {
+		return false, fmt.Errorf("mfl_code is required for upsert")
+	}
+
+	query := `
+		INSERT INTO facilities (mfl_code, name, type, level, county, sub_county, latitude, longitude)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (mfl_code) DO UPDATE
+		SET name = EXCLUDED.name,
+		    type = EXCLUDED.type,
+		    level = COALESCE(EXCLUDED.level, facilities.level),
+		    county = COALESCE(EXCLUDED.county, facilities.county),
+		    sub_county = COALESCE(EXCLUDED.sub_county, facilities.sub_county),
+		    latitude = CASE WHEN EXCLUDED.latitude IS NULL OR EXCLUDED.longitude IS NULL
+		                    THEN facilities.latitude ELSE EXCLUDED.latitude END,
+		    longitude = CASE WHEN EXCLUDED.latitude IS NULL OR EXCLUDED.longitude IS NULL
+		                     THEN facilities.longitude ELSE EXCLUDED.longitude END
+		RETURNING (xmax = 0) AS inserted
+	`
+
+	var inserted bool
+	err := r.db.QueryRow(ctx, query,
+		req.MFLCode,
+		req.Name,
+		req.Type,
+		req.Level,
+		req.County,
+		req.SubCounty,
+		req.Latitude,
+		req.Longitude,
+	).Scan(&inserted)
+	if err != nil {
+		log.Printf("Error upserting facility %s: %v", *req.MFLCode, err)
+		return false, fmt.Errorf("failed to upsert facility: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// GetReferralLoad counts open (pending or accepted) referrals per facility.
+// Facilities with no open referrals are absent from the result.
+func (r *FacilityRepository) GetReferralLoad(ctx context.Context, facilityIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	query := `
+		SELECT facility_id, COUNT(*)
+		FROM referrals
+		WHERE facility_id = ANY($1)
+		  AND status IN ('pending', 'accepted')
+		GROUP BY facility_id
+	`
+
+	rows, err := r.db.Query(ctx, query, facilityIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referral load: %w", err)
+	}
+	defer rows.Close()
+
+	load := make(map[uuid.UUID]int, len(facilityIDs))
+	for rows.Next() {
+		var id uuid.UUID
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan referral load: %w", err)
+		}
+		load[id] = count
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating facilities: %w", err)
+		return nil, fmt.Errorf("error iterating referral load: %w", err)
 	}
 
-	return facilities, nil
-}
\ No newline at end of file
+	return load, nil
+}
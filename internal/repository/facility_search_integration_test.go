@@ -0,0 +1,128 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/pagination"
+)
+
+func TestFacilitySearch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFacilityRepository(testPool(t), NewHaversineBackend())
+
+	// A sub-county unique to this run keeps the assertions independent of other data
+	subCounty := "Search Test " + uuid.NewString()[:8]
+	create := func(name string, level int, services ...string) *models.Facility {
+		facility, err := repo.Create(ctx, &models.CreateFacilityRequest{
+			Name:      name,
+			Type:      models.FacilityTypeHealthCenter,
+			Level:     &level,
+			SubCounty: &subCounty,
+			Services:  services,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repo.db.Exec(context.Background(), `DELETE FROM facilities WHERE id = $1`, facility.ID)
+		})
+		return facility
+	}
+
+	kamulu := create("Kamulu Health Center", 3, "maternity", "lab")
+	kangundo := create("Kangundo Level 4 Hospital", 4, "maternity", "surgery")
+	create("Tala Dispensary", 2, "outpatient")
+
+	search := func(req models.FacilitySearchRequest, limit int, after *pagination.Cursor, keep ...FacilityFilter) ([]*models.Facility, *pagination.Cursor, int) {
+		req.SubCounty = subCounty
+		require.NoError(t, req.Normalize())
+		var filter FacilityFilter
+		if len(keep) > 0 {
+			filter = keep[0]
+		}
+		facilities, next, total, err := repo.Search(ctx, &req, limit, after, filter)
+		require.NoError(t, err)
+		return facilities, next, total
+	}
+
+	t.Run("Services any and all", func(t *testing.T) {
+		_, _, total := search(models.FacilitySearchRequest{Services: []string{"lab,surgery"}}, 10, nil)
+		assert.Equal(t, 2, total)
+
+		facilities, _, total := search(models.FacilitySearchRequest{Services: []string{"maternity,surgery"}, ServicesMatch: "all"}, 10, nil)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, kangundo.ID, facilities[0].ID)
+	})
+
+	t.Run("Level range", func(t *testing.T) {
+		_, _, total := search(models.FacilitySearchRequest{MinLevel: intPtr(3)}, 10, nil)
+		assert.Equal(t, 2, total)
+	})
+
+	t.Run("Fuzzy name", func(t *testing.T) {
+		facilities, _, _ := search(models.FacilitySearchRequest{Query: "Kamulu Helth Centre"}, 10, nil)
+		require.NotEmpty(t, facilities)
+		assert.Equal(t, kamulu.ID, facilities[0].ID)
+	})
+
+	t.Run("Keyset pages by level descending", func(t *testing.T) {
+		first, next, total := search(models.FacilitySearchRequest{Sort: "-level"}, 2, nil)
+		assert.Equal(t, 3, total)
+		require.Len(t, first, 2)
+		require.NotNil(t, next)
+		assert.Equal(t, kangundo.ID, first[0].ID)
+
+		second, next, _ := search(models.FacilitySearchRequest{Sort: "-level"}, 2, next)
+		require.Len(t, second, 1)
+		assert.Nil(t, next)
+		assert.Equal(t, 2, *second[0].Level)
+	})
+
+	t.Run("Filtered pages are filled before the cursor", func(t *testing.T) {
+		notKamulu := func(facility *models.Facility) bool { return facility.ID != kamulu.ID }
+
+		first, next, total := search(models.FacilitySearchRequest{Sort: "-level"}, 2, nil, notKamulu)
+		assert.Equal(t, 2, total, "the total counts only kept facilities")
+		require.Len(t, first, 2)
+		assert.Equal(t, kangundo.ID, first[0].ID)
+		assert.Equal(t, 2, *first[1].Level)
+		assert.Nil(t, next)
+
+		first, next, _ = search(models.FacilitySearchRequest{Sort: "-level"}, 1, nil, notKamulu)
+		require.Len(t, first, 1)
+		require.NotNil(t, next)
+		second, next, _ := search(models.FacilitySearchRequest{Sort: "-level"}, 1, next, notKamulu)
+		require.Len(t, second, 1)
+		assert.Equal(t, 2, *second[0].Level)
+		assert.Nil(t, next)
+	})
+
+	t.Run("Sub-county matches whole names, ignoring case", func(t *testing.T) {
+		count := func(sub string) int {
+			req := models.FacilitySearchRequest{SubCounty: sub}
+			require.NoError(t, req.Normalize())
+			_, _, total, err := repo.Search(ctx, &req, 10, nil, nil)
+			require.NoError(t, err)
+			return total
+		}
+		assert.Equal(t, 3, count(strings.ToUpper(subCounty)))
+		assert.Zero(t, count("Search Test %"))
+		assert.Zero(t, count("Search_Test%"))
+	})
+
+	t.Run("Cursor from another sort is rejected", func(t *testing.T) {
+		_, next, _ := search(models.FacilitySearchRequest{Sort: "name"}, 1, nil)
+		req := models.FacilitySearchRequest{SubCounty: subCounty, Sort: "-level"}
+		require.NoError(t, req.Normalize())
+		_, _, _, err := repo.Search(ctx, &req, 1, next, nil)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func intPtr(v int) *int { return &v }
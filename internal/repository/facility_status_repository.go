@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type FacilityStatusRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFacilityStatusRepository(db *pgxpool.Pool) *FacilityStatusRepository {
+	return &FacilityStatusRepository{db: db}
+}
+
+const facilityStatusColumns = `facility_id, beds_total, beds_occupied, stockouts, referrals_paused, pause_reason, paused_until, reported_by, reported_by_api_key`
+
+func scanFacilityStatus(row pgx.Row, status *models.FacilityStatus, extra ...interface{}) error {
+	var stockoutsRaw []byte
+
+	dest := []interface{}{
+		&status.FacilityID,
+		&status.BedsTotal,
+		&status.BedsOccupied,
+		&stockoutsRaw,
+		&status.ReferralsPaused,
+		&status.PauseReason,
+		&status.PausedUntil,
+		&status.ReportedBy,
+		&status.ReportedByAPIKey,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(stockoutsRaw, &status.Stockouts); err != nil {
+		return fmt.Errorf("failed to unmarshal stockouts: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the latest status for a facility, or nil if none has been reported
+func (r *FacilityStatusRepository) Get(ctx context.Context, facilityID uuid.UUID) (*models.FacilityStatus, error) {
+	query := `SELECT ` + facilityStatusColumns + `, updated_at FROM facility_status WHERE facility_id = $1`
+
+	var status models.FacilityStatus
+	err := scanFacilityStatus(r.db.QueryRow(ctx, query, facilityID), &status, &status.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not reported yet, not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// GetMany returns the latest status of each facility that has reported one
+func (r *FacilityStatusRepository) GetMany(ctx context.Context, facilityIDs []uuid.UUID) (map[uuid.UUID]*models.FacilityStatus, error) {
+	query := `SELECT ` + facilityStatusColumns + `, updated_at FROM facility_status WHERE facility_id = ANY($1)`
+
+	rows, err := r.db.Query(ctx, query, facilityIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query facility status: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := make(map[uuid.UUID]*models.FacilityStatus, len(facilityIDs))
+	for rows.Next() {
+		var status models.FacilityStatus
+		if err := scanFacilityStatus(rows, &status, &status.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan facility status: %w", err)
+		}
+		statuses[status.FacilityID] = &status
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating facility status: %w", err)
+	}
+
+	return statuses, nil
+}
+
+// Report merges req into the facility's current status and records the
+// result in the history table. A report that leaves the status inconsistent
+// (more occupied beds than total) fails with ErrValidation.
+func (r *FacilityStatusRepository) Report(ctx context.Context, facilityID uuid.UUID, req *models.ReportFacilityStatusRequest, reportedBy, apiKeyID *uuid.UUID) (*models.FacilityStatus, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	status := models.FacilityStatus{FacilityID: facilityID}
+	query := `SELECT ` + facilityStatusColumns + ` FROM facility_status WHERE facility_id = $1 FOR UPDATE`
+	if err := scanFacilityStatus(tx.QueryRow(ctx, query, facilityID), &status); err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to load facility status: %w", err)
+	}
+
+	if err := status.Apply(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	status.ReportedBy = reportedBy
+	status.ReportedByAPIKey = apiKeyID
+
+	stockoutsJSON, err := json.Marshal(status.Stockouts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stockouts: %w", err)
+	}
+
+	args := []interface{}{
+		facilityID,
+		status.BedsTotal,
+		status.BedsOccupied,
+		stockoutsJSON,
+		status.ReferralsPaused,
+		status.PauseReason,
+		status.PausedUntil,
+		reportedBy,
+		apiKeyID,
+	}
+
+	upsert := `
+		INSERT INTO facility_status (` + facilityStatusColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (facility_id) DO UPDATE
+		SET beds_total = EXCLUDED.beds_total,
+		    beds_occupied = EXCLUDED.beds_occupied,
+		    stockouts = EXCLUDED.stockouts,
+		    referrals_paused = EXCLUDED.referrals_paused,
+		    pause_reason = EXCLUDED.pause_reason,
+		    paused_until = EXCLUDED.paused_until,
+		    reported_by = EXCLUDED.reported_by,
+		    reported_by_api_key = EXCLUDED.reported_by_api_key
+		RETURNING updated_at
+	`
+	if err := tx.QueryRow(ctx, upsert, args...).Scan(&status.UpdatedAt); err != nil {
+		log.Printf("Error saving facility status: %v", err)
+		return nil, fmt.Errorf("failed to save facility status: %w", err)
+	}
+
+	history := `
+		INSERT INTO facility_status_history (` + facilityStatusColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	if _, err := tx.Exec(ctx, history, args...); err != nil {
+		log.Printf("Error recording facility status history: %v", err)
+		return nil, fmt.Errorf("failed to record facility status history: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit facility status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// History returns a facility's capacity reports, newest first
+func (r *FacilityStatusRepository) History(ctx context.Context, facilityID uuid.UUID, limit int) ([]*models.FacilityStatusReport, error) {
+	query := `SELECT ` + facilityStatusColumns + `, id, created_at
+		FROM facility_status_history
+		WHERE facility_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, facilityID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query facility status history: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []*models.FacilityStatusReport{}
+	for rows.Next() {
+		var report models.FacilityStatusReport
+		if err := scanFacilityStatus(rows, &report.FacilityStatus, &report.ID, &report.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan facility status history: %w", err)
+		}
+		report.UpdatedAt = report.CreatedAt
+		reports = append(reports, &report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating facility status history: %w", err)
+	}
+
+	return reports, nil
+}
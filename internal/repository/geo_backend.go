@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/geo"
+)
+
+const (
+	GeoBackendAuto      = "auto"
+	GeoBackendPostGIS   = "postgis"
+	GeoBackendHaversine = "haversine"
+)
+
+// GeoBackend builds the SQL for distance searches over facilities. latitude
+// and longitude are always the source of truth; backends only differ in how
+// they compute distances and which index they use to do it.
+type GeoBackend interface {
+	Name() string
+	// Nearby returns an SQL expression for the distance in km from (lat, lng)
+	// and a WHERE condition limiting rows to radiusKM, with placeholders
+	// numbered from argStart
+	Nearby(lat, lng, radiusKM float64, argStart int) (distanceExpr string, where string, args []interface{})
+}
+
+// PostGISBackend uses the geography column maintained by migration 000006
+type PostGISBackend struct{}
+
+func NewPostGISBackend() *PostGISBackend {
+	return &PostGISBackend{}
+}
+
+func (b *PostGISBackend) Name() string {
+	return GeoBackendPostGIS
+}
+
+func (b *PostGISBackend) Nearby(lat, lng, radiusKM float64, argStart int) (string, string, []interface{}) {
+	point := fmt.Sprintf("$%d", argStart)
+	radius := fmt.Sprintf("$%d", argStart+1)
+
+	distanceExpr := fmt.Sprintf("ST_Distance(location, ST_GeogFromText(%s)) / 1000", point)
+	where := fmt.Sprintf("ST_DWithin(location, ST_GeogFromText(%s), %s)", point, radius)
+	args := []interface{}{
+		fmt.Sprintf("SRID=4326;POINT(%f %f)", lng, lat),
+		radiusKM * 1000,
+	}
+
+	return distanceExpr, where, args
+}
+
+// HaversineBackend works on plain PostgreSQL. A bounding box on
+// idx_facilities_lat_lng narrows the candidates before the exact great-circle
+// distance is computed.
+type HaversineBackend struct{}
+
+func NewHaversineBackend() *HaversineBackend {
+	return &HaversineBackend{}
+}
+
+func (b *HaversineBackend) Name() string {
+	return GeoBackendHaversine
+}
+
+func (b *HaversineBackend) Nearby(lat, lng, radiusKM float64, argStart int) (string, string, []interface{}) {
+	latArg := fmt.Sprintf("$%d::float8", argStart)
+	lngArg := fmt.Sprintf("$%d::float8", argStart+1)
+
+	distanceExpr := fmt.Sprintf(`(%f * 2 * ASIN(SQRT(
+			POWER(SIN(RADIANS(latitude::float8 - %s) / 2), 2) +
+			COS(RADIANS(%s)) * COS(RADIANS(latitude::float8)) *
+			POWER(SIN(RADIANS(longitude::float8 - %s) / 2), 2)
+		)))`, geo.EarthRadiusKM, latArg, latArg, lngArg)
+
+	box := geo.Bounds(lat, lng, radiusKM)
+	where := fmt.Sprintf(`latitude BETWEEN $%d AND $%d
+			AND longitude BETWEEN $%d AND $%d
+			AND %s <= $%d`,
+		argStart+2, argStart+3, argStart+4, argStart+5, distanceExpr, argStart+6)
+
+	args := []interface{}{lat, lng, box.MinLat, box.MaxLat, box.MinLng, box.MaxLng, radiusKM}
+
+	return distanceExpr, where, args
+}
+
+// DetectGeoBackend picks the backend to use. With "auto" it probes the
+// database for the PostGIS extension and the facilities.location column.
+func DetectGeoBackend(ctx context.Context, db *pgxpool.Pool, preference string) (GeoBackend, error) {
+	switch preference {
+	case GeoBackendHaversine:
+		return NewHaversineBackend(), nil
+	case GeoBackendPostGIS:
+		return NewPostGISBackend(), nil
+	case GeoBackendAuto, "":
+	default:
+		return nil, fmt.Errorf("unknown geo backend %q", preference)
+	}
+
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'postgis')
+			AND EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'facilities' AND column_name = 'location'
+			)
+	`
+
+	var hasPostGIS bool
+	if err := db.QueryRow(ctx, query).Scan(&hasPostGIS); err != nil {
+		return nil, fmt.Errorf("failed to probe for PostGIS: %w", err)
+	}
+
+	if hasPostGIS {
+		log.Println("Geo backend: PostGIS")
+		return NewPostGISBackend(), nil
+	}
+
+	log.Println("Geo backend: Haversine (PostGIS not available)")
+	return NewHaversineBackend(), nil
+}
@@ -0,0 +1,146 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// Run against a migrated database:
+//
+//	TEST_DATABASE_URL=postgres://... go test -tags integration ./internal/repository/...
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// seedGeoFacilities inserts two facilities ~85 km apart and removes them after the test
+func seedGeoFacilities(t *testing.T, repo *FacilityRepository) (near, far *models.Facility) {
+	t.Helper()
+	ctx := context.Background()
+
+	create := func(name string, lat, lng float64) *models.Facility {
+		facility, err := repo.Create(ctx, &models.CreateFacilityRequest{
+			Name:      name + " " + uuid.NewString()[:8],
+			Type:      models.FacilityTypeHealthCenter,
+			Latitude:  &lat,
+			Longitude: &lng,
+		})
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			repo.db.Exec(context.Background(), `DELETE FROM facilities WHERE id = $1`, facility.ID)
+		})
+		return facility
+	}
+
+	near = create("Geo Test Near", -1.2345, 37.1234)
+	far = create("Geo Test Far", -1.8345, 37.6234)
+	return near, far
+}
+
+func containsFacility(facilities []*models.NearbyFacility, id uuid.UUID) bool {
+	for _, f := range facilities {
+		if f.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func testNearby(t *testing.T, backend GeoBackend) {
+	ctx := context.Background()
+	repo := NewFacilityRepository(testPool(t), backend)
+	near, far := seedGeoFacilities(t, repo)
+
+	t.Run("Coordinates round-trip", func(t *testing.T) {
+		got, err := repo.GetByID(ctx, near.ID)
+		require.NoError(t, err)
+		assert.InDelta(t, -1.2345, *got.Latitude, 1e-6)
+		assert.InDelta(t, 37.1234, *got.Longitude, 1e-6)
+	})
+
+	t.Run("Small radius only returns the close facility", func(t *testing.T) {
+		facilities, _, err := repo.GetNearby(ctx, -1.2400, 37.1300, 5, 20, nil, nil)
+		require.NoError(t, err)
+		assert.True(t, containsFacility(facilities, near.ID))
+		assert.False(t, containsFacility(facilities, far.ID))
+	})
+
+	t.Run("Paging returns each facility once", func(t *testing.T) {
+		first, next, err := repo.GetNearby(ctx, -1.5, 37.4, 50, 1, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, first, 1)
+		require.NotNil(t, next)
+
+		second, _, err := repo.GetNearby(ctx, -1.5, 37.4, 50, 100, next, nil)
+		require.NoError(t, err)
+		assert.False(t, containsFacility(second, first[0].ID))
+		assert.GreaterOrEqual(t, second[0].DistanceKM, first[0].DistanceKM)
+	})
+
+	t.Run("Midpoint search returns both, closest first", func(t *testing.T) {
+		// (-1.5, 37.4) is ~43 km from the near facility and ~45 km from the far one
+		facilities, _, err := repo.GetNearby(ctx, -1.5, 37.4, 50, 100, nil, nil)
+		require.NoError(t, err)
+
+		nearIdx, farIdx := -1, -1
+		for i, f := range facilities {
+			switch f.ID {
+			case near.ID:
+				nearIdx = i
+			case far.ID:
+				farIdx = i
+			}
+		}
+		require.NotEqual(t, -1, nearIdx)
+		require.NotEqual(t, -1, farIdx)
+		assert.Less(t, nearIdx, farIdx)
+	})
+}
+
+func TestHaversineBackendNearby(t *testing.T) {
+	testNearby(t, NewHaversineBackend())
+}
+
+func TestPostGISBackendNearby(t *testing.T) {
+	pool := testPool(t)
+
+	backend, err := DetectGeoBackend(context.Background(), pool, GeoBackendAuto)
+	require.NoError(t, err)
+	if backend.Name() != GeoBackendPostGIS {
+		t.Skip("PostGIS not installed in test database")
+	}
+
+	testNearby(t, backend)
+}
+
+func TestDetectGeoBackendPreference(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+
+	backend, err := DetectGeoBackend(ctx, pool, GeoBackendHaversine)
+	require.NoError(t, err)
+	assert.Equal(t, GeoBackendHaversine, backend.Name())
+
+	_, err = DetectGeoBackend(ctx, pool, "spatialite")
+	assert.Error(t, err)
+}
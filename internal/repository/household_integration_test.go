@@ -0,0 +1,100 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestHouseholdCaseload(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	households := NewHouseholdRepository(pool)
+	patients := NewPatientRepository(pool)
+	triage := NewTriageRepository(pool)
+	users := NewUserRepository(pool)
+
+	randomPhone := func() string { return fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)) }
+
+	chv, err := users.Create(ctx, randomPhone(), models.UserRoleCHV)
+	require.NoError(t, err)
+	otherCHV, err := users.Create(ctx, randomPhone(), models.UserRoleCHV)
+	require.NoError(t, err)
+	clinician, err := users.Create(ctx, randomPhone(), models.UserRoleClinician)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = ANY($1)`, []uuid.UUID{chv.ID, otherCHV.ID, clinician.ID})
+	})
+
+	village := "Kamulu"
+	household, err := households.Create(ctx, &models.CreateHouseholdRequest{Name: "Kamau household", Village: &village}, &chv.ID, &chv.ID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM households WHERE id = $1`, household.ID)
+	})
+	require.NotNil(t, household.CHV)
+	assert.Equal(t, chv.ID, household.CHV.CHVUserID)
+
+	name := "Wanjiku Kamau"
+	patient, err := patients.Create(ctx, &models.CreatePatientRequest{Phone: randomPhone(), Name: &name})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+	})
+
+	head := "head"
+	_, err = households.AddMember(ctx, household.ID, &models.AddHouseholdMemberRequest{PatientID: patient.ID, Relationship: &head, IsHead: true}, &chv.ID)
+	require.NoError(t, err)
+
+	_, err = households.AddMember(ctx, household.ID, &models.AddHouseholdMemberRequest{PatientID: patient.ID}, &chv.ID)
+	assert.ErrorIs(t, err, ErrDuplicate)
+
+	_, err = triage.Create(ctx, &models.CreateTriageRequest{PatientID: &patient.ID, Symptoms: map[string]interface{}{"cough": true}, Channel: "sms"})
+	require.NoError(t, err)
+
+	t.Run("Caseload", func(t *testing.T) {
+		caseload, err := households.Caseload(ctx, chv.ID, time.Now())
+		require.NoError(t, err)
+		require.Len(t, caseload.Households, 1)
+		require.Len(t, caseload.Households[0].Patients, 1)
+
+		member := caseload.Households[0].Patients[0]
+		assert.Equal(t, patient.ID, member.Patient.ID)
+		assert.True(t, member.IsHead)
+		require.NotNil(t, member.LastTriage)
+		assert.Contains(t, member.LastTriage.Symptoms, "cough")
+		assert.Equal(t, 1, caseload.PatientCount)
+	})
+
+	t.Run("Only CHVs can be assigned", func(t *testing.T) {
+		_, err := households.AssignCHV(ctx, household.ID, clinician.ID, nil)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("Reassignment moves the household", func(t *testing.T) {
+		_, err := households.AssignCHV(ctx, household.ID, otherCHV.ID, nil)
+		require.NoError(t, err)
+
+		caseload, err := households.Caseload(ctx, chv.ID, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, caseload.Households)
+
+		current, err := households.CurrentCHV(ctx, household.ID)
+		require.NoError(t, err)
+		assert.Equal(t, otherCHV.ID, current.CHVUserID)
+	})
+
+	t.Run("Remove member", func(t *testing.T) {
+		require.NoError(t, households.RemoveMember(ctx, household.ID, patient.ID))
+		assert.ErrorIs(t, households.RemoveMember(ctx, household.ID, patient.ID), ErrNotFound)
+	})
+}
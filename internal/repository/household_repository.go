@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type HouseholdRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHouseholdRepository(db *pgxpool.Pool) *HouseholdRepository {
+	return &HouseholdRepository{db: db}
+}
+
+const householdColumns = `
+	h.id, h.name, h.village, h.landmark,
+	h.latitude::float8 as latitude,
+	h.longitude::float8 as longitude,
+	h.created_by, h.created_at, h.updated_at`
+
+const chvAssignmentColumns = `ca.id, ca.household_id, ca.chv_user_id, u.name, ca.assigned_by, ca.assigned_at, ca.ended_at`
+
+func scanHousehold(row pgx.Row) (*models.Household, error) {
+	var household models.Household
+
+	err := row.Scan(
+		&household.ID,
+		&household.Name,
+		&household.Village,
+		&household.Landmark,
+		&household.Latitude,
+		&household.Longitude,
+		&household.CreatedBy,
+		&household.CreatedAt,
+		&household.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &household, nil
+}
+
+func scanCHVAssignment(row pgx.Row) (*models.CHVAssignment, error) {
+	var assignment models.CHVAssignment
+
+	err := row.Scan(
+		&assignment.ID,
+		&assignment.HouseholdID,
+		&assignment.CHVUserID,
+		&assignment.CHVName,
+		&assignment.AssignedBy,
+		&assignment.AssignedAt,
+		&assignment.EndedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+// Create registers a household. When chvUserID is set the household is
+// assigned to that CHV straight away, as when a CHV registers it on a visit.
+func (r *HouseholdRepository) Create(ctx context.Context, req *models.CreateHouseholdRequest, createdBy, chvUserID *uuid.UUID) (*models.Household, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO households AS h (name, village, landmark, latitude, longitude, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + householdColumns
+
+	household, err := scanHousehold(tx.QueryRow(ctx, query,
+		req.Name,
+		req.Village,
+		req.Landmark,
+		req.Latitude,
+		req.Longitude,
+		createdBy,
+	))
+	if err != nil {
+		log.Printf("Error creating household: %v", err)
+		return nil, fmt.Errorf("failed to create household: %w", err)
+	}
+
+	if chvUserID != nil {
+		if household.CHV, err = assignCHV(ctx, tx, household.ID, *chvUserID, createdBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit household: %w", err)
+	}
+
+	return household, nil
+}
+
+// GetByID returns a household with its members and current CHV, or nil if it does not exist
+func (r *HouseholdRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Household, error) {
+	query := `SELECT ` + householdColumns + ` FROM households h WHERE h.id = $1`
+
+	household, err := scanHousehold(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get household: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT m.id, m.household_id, m.patient_id, p.name, m.relationship, m.is_head, m.added_by, m.added_at
+		FROM household_members m
+		JOIN patients p ON p.id = m.patient_id
+		WHERE m.household_id = $1 AND p.merged_into IS NULL
+		ORDER BY m.is_head DESC, m.added_at`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list household members: %w", err)
+	}
+	defer rows.Close()
+
+	household.Members = []*models.HouseholdMember{}
+	for rows.Next() {
+		var member models.HouseholdMember
+		err := rows.Scan(&member.ID, &member.HouseholdID, &member.PatientID, &member.PatientName,
+			&member.Relationship, &member.IsHead, &member.AddedBy, &member.AddedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan household member: %w", err)
+		}
+		household.Members = append(household.Members, &member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating household members: %w", err)
+	}
+
+	household.CHV, err = r.CurrentCHV(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return household, nil
+}
+
+// CurrentCHV returns the household's active CHV assignment, or nil if it has none
+func (r *HouseholdRepository) CurrentCHV(ctx context.Context, householdID uuid.UUID) (*models.CHVAssignment, error) {
+	query := `
+		SELECT ` + chvAssignmentColumns + `
+		FROM chv_assignments ca
+		JOIN users u ON u.id = ca.chv_user_id
+		WHERE ca.household_id = $1 AND ca.ended_at IS NULL`
+
+	assignment, err := scanCHVAssignment(r.db.QueryRow(ctx, query, householdID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CHV assignment: %w", err)
+	}
+
+	return assignment, nil
+}
+
+// AddMember attaches a patient to a household. A merged patient is added as
+// its survivor. Returns ErrDuplicate if the patient already has a household.
+func (r *HouseholdRepository) AddMember(ctx context.Context, householdID uuid.UUID, req *models.AddHouseholdMemberRequest, addedBy *uuid.UUID) (*models.HouseholdMember, error) {
+	query := `
+		WITH m AS (
+			INSERT INTO household_members (household_id, patient_id, relationship, is_head, added_by)
+			SELECT h.id, p.id, $3, $4, $5
+			FROM households h, patients p
+			WHERE h.id = $1 AND p.id = ` + currentPatientID("$2") + `
+			RETURNING *
+		)
+		SELECT m.id, m.household_id, m.patient_id, p.name, m.relationship, m.is_head, m.added_by, m.added_at
+		FROM m JOIN patients p ON p.id = m.patient_id`
+
+	var member models.HouseholdMember
+	err := r.db.QueryRow(ctx, query, householdID, req.PatientID, req.Relationship, req.IsHead, addedBy).Scan(
+		&member.ID, &member.HouseholdID, &member.PatientID, &member.PatientName,
+		&member.Relationship, &member.IsHead, &member.AddedBy, &member.AddedAt)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("household or patient: %w", ErrNotFound)
+	}
+	if isUniqueViolation(err) {
+		return nil, fmt.Errorf("patient %s already belongs to a household: %w", req.PatientID, ErrDuplicate)
+	}
+	if err != nil {
+		log.Printf("Error adding patient %s to household %s: %v", req.PatientID, householdID, err)
+		return nil, fmt.Errorf("failed to add household member: %w", err)
+	}
+
+	return &member, nil
+}
+
+// RemoveMember detaches a patient from a household
+func (r *HouseholdRepository) RemoveMember(ctx context.Context, householdID, patientID uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM household_members
+		WHERE household_id = $1 AND patient_id = `+currentPatientID("$2"), householdID, patientID)
+	if err != nil {
+		log.Printf("Error removing patient %s from household %s: %v", patientID, householdID, err)
+		return fmt.Errorf("failed to remove household member: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("household member: %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// AssignCHV moves a household onto a CHV's caseload, ending any current assignment
+func (r *HouseholdRepository) AssignCHV(ctx context.Context, householdID, chvUserID uuid.UUID, assignedBy *uuid.UUID) (*models.CHVAssignment, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the household serializes concurrent reassignments
+	err = tx.QueryRow(ctx, `SELECT id FROM households WHERE id = $1 FOR UPDATE`, householdID).Scan(&householdID)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("household %s: %w", householdID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock household: %w", err)
+	}
+
+	assignment, err := assignCHV(ctx, tx, householdID, chvUserID, assignedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit CHV assignment: %w", err)
+	}
+
+	return assignment, nil
+}
+
+// assignCHV ends the household's current assignment and creates a new one.
+// The user must be an active CHV.
+func assignCHV(ctx context.Context, tx pgx.Tx, householdID, chvUserID uuid.UUID, assignedBy *uuid.UUID) (*models.CHVAssignment, error) {
+	var isCHV bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND role = 'chv' AND is_active)`, chvUserID).Scan(&isCHV)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up CHV: %w", err)
+	}
+	if !isCHV {
+		return nil, fmt.Errorf("%w: user %s is not an active CHV", ErrValidation, chvUserID)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE chv_assignments SET ended_at = CURRENT_TIMESTAMP
+		WHERE household_id = $1 AND ended_at IS NULL`, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to end CHV assignment: %w", err)
+	}
+
+	query := `
+		WITH ca AS (
+			INSERT INTO chv_assignments (household_id, chv_user_id, assigned_by)
+			VALUES ($1, $2, $3)
+			RETURNING *
+		)
+		SELECT ` + chvAssignmentColumns + `
+		FROM ca JOIN users u ON u.id = ca.chv_user_id`
+
+	assignment, err := scanCHVAssignment(tx.QueryRow(ctx, query, householdID, chvUserID, assignedBy))
+	if err != nil {
+		log.Printf("Error assigning household %s to CHV %s: %v", householdID, chvUserID, err)
+		return nil, fmt.Errorf("failed to assign CHV: %w", err)
+	}
+
+	return assignment, nil
+}
+
+// Caseload returns the households assigned to a CHV with each member's last
+// triage, open referrals, recent referral outcomes and follow-ups overdue at
+// now. However large the caseload, it takes two queries: one for the
+// households and one for all their members.
+func (r *HouseholdRepository) Caseload(ctx context.Context, chvUserID uuid.UUID, now time.Time) (*models.Caseload, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+householdColumns+`
+		FROM chv_assignments ca
+		JOIN households h ON h.id = ca.household_id
+		WHERE ca.chv_user_id = $1 AND ca.ended_at IS NULL
+		ORDER BY h.village NULLS LAST, h.name`, chvUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list caseload households: %w", err)
+	}
+	defer rows.Close()
+
+	caseload := &models.Caseload{Households: []*models.CaseloadHousehold{}, GeneratedAt: now}
+	byID := map[uuid.UUID]*models.CaseloadHousehold{}
+	var ids []uuid.UUID
+	for rows.Next() {
+		household, err := scanHousehold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan household: %w", err)
+		}
+		entry := &models.CaseloadHousehold{Household: household, Patients: []*models.CaseloadPatient{}}
+		caseload.Households = append(caseload.Households, entry)
+		byID[household.ID] = entry
+		ids = append(ids, household.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating caseload households: %w", err)
+	}
+	if len(ids) == 0 {
+		return caseload, nil
+	}
+
+	query := `
+		SELECT ` + patientColumns + `, m.household_id, m.relationship, m.is_head,
+			(SELECT row_to_json(t) FROM (
+				SELECT ` + triageSummaryColumns + `
+				FROM triage_sessions
+				WHERE patient_id = p.id
+				ORDER BY created_at DESC
+				LIMIT 1
+			) t),
+			(SELECT COALESCE(json_agg(o ORDER BY o.created_at), '[]') FROM (
+				SELECT ` + referralSummaryColumns + `
+				FROM referrals r
+				LEFT JOIN facilities f ON f.id = r.facility_id
+				WHERE r.patient_id = p.id AND r.status IN ('pending', 'accepted')
+			) o),
+			(SELECT COALESCE(json_agg(d ORDER BY d.created_at DESC), '[]') FROM (
+				SELECT ` + referralSummaryColumns + `
+				FROM referrals r
+				LEFT JOIN facilities f ON f.id = r.facility_id
+				WHERE r.patient_id = p.id AND r.status IN ('completed', 'no_show', 'expired')
+					AND r.updated_at >= $4
+			) d),
+			(SELECT COALESCE(json_agg(a ORDER BY a.scheduled_time), '[]') FROM (
+				SELECT ` + appointmentSummaryColumns + `
+				FROM appointments a
+				LEFT JOIN facilities f ON f.id = a.facility_id
+				WHERE a.patient_id = p.id
+					AND a.scheduled_time < $2 AND a.scheduled_time >= $3
+					AND a.status IN ('scheduled', 'confirmed', 'no_show')
+			) a)
+		FROM household_members m
+		JOIN patients p ON p.id = m.patient_id
+		WHERE m.household_id = ANY($1) AND p.merged_into IS NULL
+		ORDER BY m.is_head DESC, p.name NULLS LAST`
+
+	rows, err = r.db.Query(ctx, query, ids, now, now.Add(-models.MissedAppointmentWindow), now.Add(-models.RecentOutcomeWindow))
+	if err != nil {
+		log.Printf("Error loading caseload for CHV %s: %v", chvUserID, err)
+		return nil, fmt.Errorf("failed to list caseload patients: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.CaseloadPatient
+		var householdID uuid.UUID
+		var triageJSON, referralsJSON, outcomesJSON, missedJSON []byte
+
+		entry.Patient, err = scanPatient(rows, &householdID, &entry.Relationship, &entry.IsHead, &triageJSON, &referralsJSON, &outcomesJSON, &missedJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan caseload patient: %w", err)
+		}
+
+		if triageJSON != nil {
+			if err := json.Unmarshal(triageJSON, &entry.LastTriage); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal last triage: %w", err)
+			}
+		}
+		if err := json.Unmarshal(referralsJSON, &entry.OpenReferrals); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal open referrals: %w", err)
+		}
+		if err := json.Unmarshal(outcomesJSON, &entry.RecentOutcomes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal recent outcomes: %w", err)
+		}
+		var missed []*models.AppointmentSummary
+		if err := json.Unmarshal(missedJSON, &missed); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal missed appointments: %w", err)
+		}
+		entry.SetOverdueFollowUps(missed, now)
+
+		household := byID[householdID]
+		household.Patients = append(household.Patients, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating caseload patients: %w", err)
+	}
+
+	caseload.Tally()
+
+	return caseload, nil
+}
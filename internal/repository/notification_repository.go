@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type NotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+const notificationColumns = `id, user_id, kind, referral_id, body, sms_status, read_at, created_at`
+
+func scanNotification(row pgx.Row) (*models.Notification, error) {
+	var notification models.Notification
+
+	err := row.Scan(
+		&notification.ID,
+		&notification.UserID,
+		&notification.Kind,
+		&notification.ReferralID,
+		&notification.Body,
+		&notification.SMSStatus,
+		&notification.ReadAt,
+		&notification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// Create puts a notification in the user's inbox with its SMS pending
+func (r *NotificationRepository) Create(ctx context.Context, userID uuid.UUID, kind models.NotificationKind, referralID *uuid.UUID, body string) (*models.Notification, error) {
+	query := `
+		INSERT INTO notifications (user_id, kind, referral_id, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + notificationColumns
+
+	notification, err := scanNotification(r.db.QueryRow(ctx, query, userID, kind, referralID, body))
+	if err != nil {
+		log.Printf("Error creating %s notification for user %s: %v", kind, userID, err)
+		return nil, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return notification, nil
+}
+
+func (r *NotificationRepository) SetSMSStatus(ctx context.Context, id uuid.UUID, status models.SMSStatus) error {
+	_, err := r.db.Exec(ctx, `UPDATE notifications SET sms_status = $2 WHERE id = $1`, id, status)
+	if err != nil {
+		return fmt.Errorf("failed to update notification SMS status: %w", err)
+	}
+	return nil
+}
+
+// ListForUser returns the user's inbox, newest first
+func (r *NotificationRepository) ListForUser(ctx context.Context, userID uuid.UUID, req *models.ListNotificationsRequest) ([]*models.Notification, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1 AND ($2 = false OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, userID, req.UnreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*models.Notification{}
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkRead marks one of the user's notifications read. Other users'
+// notifications return ErrNotFound.
+func (r *NotificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID) (*models.Notification, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + notificationColumns
+
+	notification, err := scanNotification(r.db.QueryRow(ctx, query, id, userID))
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	return notification, nil
+}
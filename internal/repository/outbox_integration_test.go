@@ -0,0 +1,176 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestOutboxEvents(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	outbox := NewOutboxRepository(pool)
+	patients := NewPatientRepository(pool)
+	triage := NewTriageRepository(pool)
+	referrals := NewReferralRepository(pool)
+	appointments := NewAppointmentRepository(pool)
+
+	var facilityID uuid.UUID
+	err := pool.QueryRow(ctx, `
+		INSERT INTO facilities (name, type, latitude, longitude)
+		VALUES ('Outbox Test Dispensary', 'dispensary', -0.1022, 34.7617)
+		RETURNING id`).Scan(&facilityID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM facilities WHERE id = $1`, facilityID)
+	})
+
+	req := &models.CreatePatientRequest{
+		Phone:        fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)),
+		ConsentFlags: map[string]bool{"data_collection": true},
+	}
+	patient, err := patients.Create(ctx, req)
+	require.NoError(t, err)
+	var aggregates []uuid.UUID
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM outbox_events WHERE aggregate_id = ANY($1)`, append(aggregates, patient.ID))
+		pool.Exec(context.Background(), `DELETE FROM triage_sessions WHERE patient_id = $1`, patient.ID)
+		pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+	})
+
+	events := func(t *testing.T, aggregateID uuid.UUID) []*models.DomainEvent {
+		list, err := outbox.ListForAggregate(ctx, aggregateID)
+		require.NoError(t, err)
+		return list
+	}
+	consents := func(t *testing.T, event *models.DomainEvent) map[string]bool {
+		var change models.ConsentChange
+		require.NoError(t, event.DecodePayload(&change))
+		assert.Equal(t, patient.ID, change.PatientID)
+		return change.Consents
+	}
+
+	t.Run("Consent changes are recorded", func(t *testing.T) {
+		list := events(t, patient.ID)
+		require.Len(t, list, 1)
+		assert.Equal(t, models.EventConsentChanged, list[0].Type)
+		assert.Equal(t, map[string]bool{"data_collection": true}, consents(t, list[0]))
+
+		req.ConsentFlags = map[string]bool{"data_collection": true}
+		name := "Achieng Otieno"
+		req.Name = &name
+		_, err := patients.Update(ctx, patient.ID, req, "*")
+		require.NoError(t, err)
+		assert.Len(t, events(t, patient.ID), 1, "other details are not a consent change")
+
+		req.ConsentFlags = map[string]bool{"data_collection": true, "research": false}
+		_, err = patients.Update(ctx, patient.ID, req, "*")
+		require.NoError(t, err)
+		list = events(t, patient.ID)
+		require.Len(t, list, 2)
+		assert.Equal(t, map[string]bool{"research": false}, consents(t, list[1]))
+	})
+
+	t.Run("Completed triage is recorded", func(t *testing.T) {
+		session, err := triage.Create(ctx, &models.CreateTriageRequest{PatientID: &patient.ID, Symptoms: map[string]interface{}{"fever": true}, Channel: "sms"})
+		require.NoError(t, err)
+		aggregates = append(aggregates, session.ID)
+		assert.Empty(t, events(t, session.ID))
+
+		err = triage.UpdateTriageResult(ctx, session.ID, models.TriageLevelYellow, "Y1", 0.8, "Visit a clinic today", nil)
+		require.NoError(t, err)
+		list := events(t, session.ID)
+		require.Len(t, list, 1)
+		assert.Equal(t, models.EventTriageCompleted, list[0].Type)
+
+		var completed models.TriageSession
+		require.NoError(t, list[0].DecodePayload(&completed))
+		assert.Equal(t, models.TriageStatusCompleted, completed.Status)
+		assert.Equal(t, models.TriageLevelYellow, *completed.TriageLevel)
+	})
+
+	t.Run("Rulebook verdicts are recorded with the session", func(t *testing.T) {
+		verdict := &models.TriageVerdict{TriageLevel: models.TriageLevelRed, TriageCode: "R1", RecommendedAction: "Go to hospital now"}
+		session, err := triage.CreateWithVerdict(ctx, &models.CreateTriageRequest{PatientID: &patient.ID, Symptoms: map[string]interface{}{"convulsions": true}, Channel: "web"}, verdict, nil)
+		require.NoError(t, err)
+		aggregates = append(aggregates, session.ID)
+		assert.Equal(t, models.TriageStatusCompleted, session.Status)
+		assert.Equal(t, models.TriageLevelRed, *session.TriageLevel)
+
+		list := events(t, session.ID)
+		require.Len(t, list, 1)
+		assert.Equal(t, models.EventTriageCompleted, list[0].Type)
+	})
+
+	t.Run("Referrals are recorded when created and accepted", func(t *testing.T) {
+		referral, err := referrals.Create(ctx, &models.CreateReferralRequest{PatientID: patient.ID, FacilityID: facilityID}, fmt.Sprintf("REF-O%07d", rand.Intn(10_000_000)), nil)
+		require.NoError(t, err)
+		aggregates = append(aggregates, referral.ID)
+
+		_, err = referrals.UpdateStatus(ctx, referral.ID, models.ReferralStatusAccepted, nil)
+		require.NoError(t, err)
+		_, err = referrals.UpdateStatus(ctx, referral.ID, models.ReferralStatusCompleted, nil)
+		require.NoError(t, err)
+
+		list := events(t, referral.ID)
+		require.Len(t, list, 2)
+		assert.Equal(t, models.EventReferralCreated, list[0].Type)
+		assert.Equal(t, models.EventReferralAccepted, list[1].Type)
+
+		var accepted models.Referral
+		require.NoError(t, list[1].DecodePayload(&accepted))
+		assert.Equal(t, models.ReferralStatusAccepted, accepted.Status)
+	})
+
+	t.Run("Booked appointments are recorded", func(t *testing.T) {
+		appointment, err := appointments.Create(ctx, &models.CreateAppointmentRequest{PatientID: patient.ID, FacilityID: facilityID, ScheduledTime: time.Now().Add(3 * time.Hour).Truncate(time.Minute)})
+		require.NoError(t, err)
+		aggregates = append(aggregates, appointment.ID)
+
+		list := events(t, appointment.ID)
+		require.Len(t, list, 1)
+		assert.Equal(t, models.EventAppointmentBooked, list[0].Type)
+	})
+
+	t.Run("Events are published once delivered", func(t *testing.T) {
+		want := events(t, patient.ID)[0].ID
+
+		// A failed delivery leaves the batch to be claimed again
+		_, err := outbox.Publish(ctx, 1000, func(ctx context.Context, events []*models.DomainEvent) error {
+			return errors.New("queue unavailable")
+		})
+		assert.Error(t, err)
+		assert.Nil(t, events(t, patient.ID)[0].PublishedAt)
+
+		// Other tests' events may be ahead of ours
+		seen := false
+		for attempt := 0; attempt < 10 && !seen; attempt++ {
+			published, err := outbox.Publish(ctx, 1000, func(ctx context.Context, events []*models.DomainEvent) error {
+				for _, event := range events {
+					seen = seen || event.ID == want
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			if published == 0 {
+				break
+			}
+		}
+		assert.True(t, seen)
+		assert.NotNil(t, events(t, patient.ID)[0].PublishedAt)
+
+		_, err = outbox.Prune(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.NotEmpty(t, events(t, patient.ID), "recently published events are kept")
+	})
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// recordEvent writes a domain event to the outbox in the caller's
+// transaction, so the event exists if and only if the change commits
+func recordEvent(ctx context.Context, tx pgx.Tx, eventType models.EventType, aggregateID uuid.UUID, payload interface{}) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO outbox_events (event_type, aggregate_id, payload)
+		VALUES ($1, $2, $3)`, eventType, aggregateID, payloadJSON)
+	if err != nil {
+		log.Printf("Error recording %s event for %s: %v", eventType, aggregateID, err)
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// recordConsentChange records consent.changed if the patient's consent
+// flags differ from before
+func recordConsentChange(ctx context.Context, tx pgx.Tx, patientID uuid.UUID, before, after map[string]bool) error {
+	changed := models.ChangedConsents(before, after)
+	if changed == nil {
+		return nil
+	}
+	return recordEvent(ctx, tx, models.EventConsentChanged, patientID, &models.ConsentChange{PatientID: patientID, Consents: changed})
+}
+
+// OutboxRepository reads the events other repositories record
+type OutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+const outboxEventColumns = `id, event_type, aggregate_id, payload, occurred_at, published_at`
+
+func scanDomainEvent(row pgx.Row) (*models.DomainEvent, error) {
+	var event models.DomainEvent
+
+	err := row.Scan(
+		&event.ID,
+		&event.Type,
+		&event.AggregateID,
+		&event.Payload,
+		&event.OccurredAt,
+		&event.PublishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// Publish claims up to limit of the oldest unpublished events and passes
+// them to deliver, marking them published only if it succeeds. Claimed
+// events are locked until then, so instances publishing at the same time
+// take different events; if deliver fails, or the instance dies, the events
+// are claimed again later. It returns how many events were published.
+func (r *OutboxRepository) Publish(ctx context.Context, limit int, deliver func(ctx context.Context, events []*models.DomainEvent) error) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT `+outboxEventColumns+`
+		FROM outbox_events
+		WHERE published_at IS NULL
+		ORDER BY occurred_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim events: %w", err)
+	}
+	events := []*models.DomainEvent{}
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		event, err := scanDomainEvent(rows)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+		ids = append(ids, event.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to claim events: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := deliver(ctx, events); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE outbox_events SET published_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`, ids); err != nil {
+		return 0, fmt.Errorf("failed to mark events published: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit published events: %w", err)
+	}
+
+	return len(events), nil
+}
+
+// ListForAggregate returns the events recorded about a triage session,
+// referral, appointment or patient, oldest first
+func (r *OutboxRepository) ListForAggregate(ctx context.Context, aggregateID uuid.UUID) ([]*models.DomainEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+outboxEventColumns+`
+		FROM outbox_events
+		WHERE aggregate_id = $1
+		ORDER BY occurred_at`, aggregateID)
+	if err != nil {
+		log.Printf("Error listing events for %s: %v", aggregateID, err)
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*models.DomainEvent{}
+	for rows.Next() {
+		event, err := scanDomainEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Prune deletes events published before the given time. Unpublished events
+// are kept however old they are.
+func (r *OutboxRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM outbox_events WHERE published_at < $1`, before)
+	if err != nil {
+		log.Printf("Error pruning events: %v", err)
+		return 0, fmt.Errorf("failed to prune events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,110 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestPatientMerge(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	patients := NewPatientRepository(pool)
+	triage := NewTriageRepository(pool)
+	merges := NewPatientMergeRepository(pool)
+
+	create := func(name string, gender *string) *models.Patient {
+		patient, err := patients.Create(ctx, &models.CreatePatientRequest{
+			Phone:  fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)),
+			Name:   &name,
+			Gender: gender,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			pool.Exec(context.Background(), `DELETE FROM patient_merges WHERE survivor_id = $1 OR retired_id = $1`, patient.ID)
+			pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+		})
+		return patient
+	}
+
+	female := "female"
+	survivor := create("Wanjiku Kamau", nil)
+	retired := create("Wanjiku Kamau", &female)
+
+	session, err := triage.Create(ctx, &models.CreateTriageRequest{
+		PatientID: &retired.ID,
+		Symptoms:  map[string]interface{}{"fever": true},
+		Channel:   "sms",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM triage_sessions WHERE id = $1`, session.ID)
+	})
+
+	merge, err := merges.Merge(ctx, survivor.ID, retired.ID, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gender"}, merge.FilledFields)
+	assert.Contains(t, merge.Moved["triage_sessions"], session.ID)
+
+	t.Run("History moves to the survivor", func(t *testing.T) {
+		sessions, err := triage.GetByPatientID(ctx, survivor.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, session.ID, sessions[0].ID)
+
+		// Lookups through the retired ID resolve to the survivor
+		sessions, err = triage.GetByPatientID(ctx, retired.ID, 10)
+		require.NoError(t, err)
+		assert.Len(t, sessions, 1)
+	})
+
+	t.Run("Retired record points at the survivor", func(t *testing.T) {
+		got, err := patients.GetByID(ctx, retired.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.MergedInto)
+		assert.Equal(t, survivor.ID, *got.MergedInto)
+
+		got, err = patients.GetByID(ctx, survivor.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "female", *got.Gender)
+	})
+
+	t.Run("Merging twice is refused", func(t *testing.T) {
+		_, err := merges.Merge(ctx, survivor.ID, retired.ID, nil, nil)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("Undo restores both patients", func(t *testing.T) {
+		undone, err := merges.Undo(ctx, merge.ID, nil)
+		require.NoError(t, err)
+		assert.NotNil(t, undone.UndoneAt)
+
+		sessions, err := triage.GetByPatientID(ctx, retired.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, session.ID, sessions[0].ID)
+
+		got, err := patients.GetByID(ctx, survivor.ID)
+		require.NoError(t, err)
+		assert.Nil(t, got.Gender)
+
+		got, err = patients.GetByID(ctx, retired.ID)
+		require.NoError(t, err)
+		assert.Nil(t, got.MergedInto)
+
+		_, err = merges.Undo(ctx, merge.ID, nil)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("Unknown patient", func(t *testing.T) {
+		_, err := merges.Merge(ctx, survivor.ID, session.ID, nil, nil)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type PatientMergeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPatientMergeRepository(db *pgxpool.Pool) *PatientMergeRepository {
+	return &PatientMergeRepository{db: db}
+}
+
+// patientHistoryTables hold patient_id references that move to the survivor on merge
+var patientHistoryTables = []string{"triage_sessions", "referrals", "appointments", "consent_logs", "users", "household_members"}
+
+// patientHistoryConditions limit which rows move for tables where the
+// survivor can only have one row. A household membership moves only if the
+// survivor has none; otherwise it stays with the retired patient.
+var patientHistoryConditions = map[string]string{
+	"household_members": " AND NOT EXISTS (SELECT 1 FROM household_members WHERE patient_id = $1)",
+}
+
+// patientsMovedKey is the Moved entry for earlier merges redirected to the survivor
+const patientsMovedKey = "patients"
+
+// mergeFillableFields are survivor columns copied from the retired patient when empty
+var mergeFillableFields = []string{"name", "date_of_birth", "gender"}
+
+const patientMergeColumns = `id, survivor_id, retired_id, moved, filled_fields, reason, merged_by, created_at, undone_at, undone_by`
+
+func scanPatientMerge(row pgx.Row) (*models.PatientMerge, error) {
+	var merge models.PatientMerge
+	var movedRaw []byte
+
+	err := row.Scan(
+		&merge.ID,
+		&merge.SurvivorID,
+		&merge.RetiredID,
+		&movedRaw,
+		&merge.FilledFields,
+		&merge.Reason,
+		&merge.MergedBy,
+		&merge.CreatedAt,
+		&merge.UndoneAt,
+		&merge.UndoneBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(movedRaw, &merge.Moved); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal moved records: %w", err)
+	}
+
+	return &merge, nil
+}
+
+// mergeParty is the state of a patient locked for a merge or undo
+type mergeParty struct {
+	mergedInto  *uuid.UUID
+	name        *string
+	dateOfBirth *time.Time
+	gender      *string
+}
+
+// lockPatients locks both patients in ID order, so concurrent merges touching
+// the same pair cannot deadlock, and returns their current state
+func lockPatients(ctx context.Context, tx pgx.Tx, a, b uuid.UUID) (map[uuid.UUID]*mergeParty, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, merged_into, name, date_of_birth, gender
+		FROM patients
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE`, []uuid.UUID{a, b})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock patients: %w", err)
+	}
+	defer rows.Close()
+
+	parties := make(map[uuid.UUID]*mergeParty, 2)
+	for rows.Next() {
+		var id uuid.UUID
+		var party mergeParty
+		if err := rows.Scan(&id, &party.mergedInto, &party.name, &party.dateOfBirth, &party.gender); err != nil {
+			return nil, fmt.Errorf("failed to scan patient: %w", err)
+		}
+		parties[id] = &party
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating patients: %w", err)
+	}
+
+	for _, id := range []uuid.UUID{a, b} {
+		if parties[id] == nil {
+			return nil, fmt.Errorf("patient %s: %w", id, ErrNotFound)
+		}
+	}
+
+	return parties, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func collectIDs(rows pgx.Rows) ([]uuid.UUID, error) {
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+	return ids, err
+}
+
+// Merge folds retiredID into survivorID in one transaction: triage sessions,
+// referrals, appointments, consent logs, user accounts and household
+// membership are re-pointed, empty survivor details are filled in, and the
+// retired record is marked as merged so lookups of its ID resolve to the
+// survivor.
+func (r *PatientMergeRepository) Merge(ctx context.Context, survivorID, retiredID uuid.UUID, reason *string, mergedBy *uuid.UUID) (*models.PatientMerge, error) {
+	if survivorID == retiredID {
+		return nil, fmt.Errorf("%w: a patient cannot be merged into itself", ErrValidation)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	parties, err := lockPatients(ctx, tx, survivorID, retiredID)
+	if err != nil {
+		return nil, err
+	}
+	survivor, retired := parties[survivorID], parties[retiredID]
+	if survivor.mergedInto != nil {
+		return nil, fmt.Errorf("%w: patient %s has already been merged into %s", ErrValidation, survivorID, *survivor.mergedInto)
+	}
+	if retired.mergedInto != nil {
+		return nil, fmt.Errorf("%w: patient %s has already been merged into %s", ErrValidation, retiredID, *retired.mergedInto)
+	}
+
+	moved := make(map[string][]uuid.UUID, len(patientHistoryTables)+1)
+	for _, table := range patientHistoryTables {
+		rows, err := tx.Query(ctx, `UPDATE `+table+` SET patient_id = $1 WHERE patient_id = $2`+patientHistoryConditions[table]+` RETURNING id`, survivorID, retiredID)
+		if err != nil {
+			log.Printf("Error moving %s to patient %s: %v", table, survivorID, err)
+			return nil, fmt.Errorf("failed to move %s: %w", table, err)
+		}
+		if moved[table], err = collectIDs(rows); err != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", table, err)
+		}
+	}
+
+	// Patients merged into the retired record earlier now redirect to the survivor
+	rows, err := tx.Query(ctx, `UPDATE patients SET merged_into = $1 WHERE merged_into = $2 RETURNING id`, survivorID, retiredID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redirect earlier merges: %w", err)
+	}
+	if moved[patientsMovedKey], err = collectIDs(rows); err != nil {
+		return nil, fmt.Errorf("failed to redirect earlier merges: %w", err)
+	}
+
+	filled := []string{}
+	if survivor.name == nil && retired.name != nil {
+		filled = append(filled, "name")
+	}
+	if survivor.dateOfBirth == nil && retired.dateOfBirth != nil {
+		filled = append(filled, "date_of_birth")
+	}
+	if survivor.gender == nil && retired.gender != nil {
+		filled = append(filled, "gender")
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE patients s
+		SET name = COALESCE(s.name, r.name),
+			date_of_birth = COALESCE(s.date_of_birth, r.date_of_birth),
+			gender = COALESCE(s.gender, r.gender),
+			updated_at = CURRENT_TIMESTAMP
+		FROM patients r
+		WHERE s.id = $1 AND r.id = $2`, survivorID, retiredID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update survivor: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE patients
+		SET merged_into = $1, merged_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`, survivorID, retiredID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retire patient: %w", err)
+	}
+
+	movedJSON, err := json.Marshal(moved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal moved records: %w", err)
+	}
+
+	merge, err := scanPatientMerge(tx.QueryRow(ctx, `
+		INSERT INTO patient_merges (survivor_id, retired_id, moved, filled_fields, reason, merged_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+patientMergeColumns,
+		survivorID, retiredID, movedJSON, filled, reason, mergedBy,
+	))
+	if err != nil {
+		log.Printf("Error recording patient merge: %v", err)
+		return nil, fmt.Errorf("failed to record patient merge: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit patient merge: %w", err)
+	}
+
+	return merge, nil
+}
+
+// Undo reverses a merge: the rows it moved go back to the retired patient,
+// fields it filled in are cleared and the retired record is restored. Records
+// created for the survivor since the merge stay with the survivor. A merge
+// whose survivor has itself been merged since must wait for that merge to be
+// undone first.
+func (r *PatientMergeRepository) Undo(ctx context.Context, id uuid.UUID, undoneBy *uuid.UUID) (*models.PatientMerge, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	merge, err := scanPatientMerge(tx.QueryRow(ctx, `SELECT `+patientMergeColumns+` FROM patient_merges WHERE id = $1 FOR UPDATE`, id))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("patient merge %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patient merge: %w", err)
+	}
+	if merge.UndoneAt != nil {
+		return nil, fmt.Errorf("%w: merge has already been undone", ErrValidation)
+	}
+
+	parties, err := lockPatients(ctx, tx, merge.SurvivorID, merge.RetiredID)
+	if err != nil {
+		return nil, err
+	}
+	if into := parties[merge.SurvivorID].mergedInto; into != nil {
+		return nil, fmt.Errorf("%w: patient %s has since been merged into %s; undo that merge first", ErrValidation, merge.SurvivorID, *into)
+	}
+
+	// Only tables known to this version are touched; Moved comes from the database
+	for _, table := range patientHistoryTables {
+		if len(merge.Moved[table]) == 0 {
+			continue
+		}
+		_, err := tx.Exec(ctx, `UPDATE `+table+` SET patient_id = $1 WHERE id = ANY($2) AND patient_id = $3`,
+			merge.RetiredID, merge.Moved[table], merge.SurvivorID)
+		if err != nil {
+			log.Printf("Error restoring %s to patient %s: %v", table, merge.RetiredID, err)
+			return nil, fmt.Errorf("failed to restore %s: %w", table, err)
+		}
+	}
+
+	if ids := merge.Moved[patientsMovedKey]; len(ids) > 0 {
+		_, err := tx.Exec(ctx, `UPDATE patients SET merged_into = $1 WHERE id = ANY($2) AND merged_into = $3`,
+			merge.RetiredID, ids, merge.SurvivorID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore earlier merges: %w", err)
+		}
+	}
+
+	// Filled-in fields are cleared unless they have been edited since the merge
+	for _, field := range mergeFillableFields {
+		if !containsString(merge.FilledFields, field) {
+			continue
+		}
+		_, err := tx.Exec(ctx, `
+			UPDATE patients s SET `+field+` = NULL
+			FROM patients r
+			WHERE s.id = $1 AND r.id = $2 AND s.`+field+` IS NOT DISTINCT FROM r.`+field,
+			merge.SurvivorID, merge.RetiredID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to clear %s: %w", field, err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE patients
+		SET merged_into = NULL, merged_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, merge.RetiredID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore patient: %w", err)
+	}
+
+	merge, err = scanPatientMerge(tx.QueryRow(ctx, `
+		UPDATE patient_merges
+		SET undone_at = CURRENT_TIMESTAMP, undone_by = $2
+		WHERE id = $1
+		RETURNING `+patientMergeColumns, id, undoneBy))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record undo: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit merge undo: %w", err)
+	}
+
+	return merge, nil
+}
+
+// ListForPatient returns the merges a patient took part in as survivor or
+// retired record, newest first
+func (r *PatientMergeRepository) ListForPatient(ctx context.Context, patientID uuid.UUID) ([]*models.PatientMerge, error) {
+	query := `SELECT ` + patientMergeColumns + ` FROM patient_merges
+		WHERE survivor_id = $1 OR retired_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list patient merges: %w", err)
+	}
+	defer rows.Close()
+
+	merges := []*models.PatientMerge{}
+	for rows.Next() {
+		merge, err := scanPatientMerge(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan patient merge: %w", err)
+		}
+		merges = append(merges, merge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating patient merges: %w", err)
+	}
+
+	return merges, nil
+}
@@ -0,0 +1,58 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestPatientPatch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPatientRepository(testPool(t))
+
+	name := "Wanjiku Patch"
+	patient, err := repo.Create(ctx, &models.CreatePatientRequest{
+		Phone:        fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)),
+		Name:         &name,
+		ConsentFlags: map[string]bool{"sms": true},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		repo.db.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+	})
+
+	patch := func(body, ifMatch string) (*models.Patient, error) {
+		req, err := models.DecodePatchPatientRequest([]byte(body))
+		require.NoError(t, err)
+		return repo.Patch(ctx, patient.ID, req, ifMatch)
+	}
+
+	patched, err := patch(`{"preferred_language": "sw", "consent_flags": {"research": true}}`, patient.ETag())
+	require.NoError(t, err)
+	assert.Equal(t, "sw", patched.PreferredLanguage)
+	assert.Equal(t, "Wanjiku Patch", *patched.Name)
+	assert.Equal(t, map[string]bool{"sms": true, "research": true}, patched.ConsentFlags)
+	assert.NotEqual(t, patient.ETag(), patched.ETag())
+
+	// The original ETag is now stale
+	_, err = patch(`{"name": null}`, patient.ETag())
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	cleared, err := patch(`{"name": null}`, patched.ETag())
+	require.NoError(t, err)
+	assert.Nil(t, cleared.Name)
+
+	// A full update needs the current ETag too
+	_, err = repo.Update(ctx, patient.ID, &models.CreatePatientRequest{Name: &name}, patched.ETag())
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	updated, err := repo.Update(ctx, patient.ID, &models.CreatePatientRequest{Name: &name}, cleared.ETag())
+	require.NoError(t, err)
+	assert.Equal(t, "Wanjiku Patch", *updated.Name)
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"maps"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/phone"
 )
 
 type PatientRepository struct {
@@ -19,29 +24,60 @@ func NewPatientRepository(db *pgxpool.Pool) *PatientRepository {
 	return &PatientRepository{db: db}
 }
 
-func (r *PatientRepository) Create(ctx context.Context, req *models.CreatePatientRequest) (*models.Patient, error) {
-	consentFlagsJSON, err := json.Marshal(req.ConsentFlags)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal consent flags: %w", err)
-	}
+const patientColumns = `id, phone, name, date_of_birth, gender, preferred_language, consent_flags, merged_into, merged_at, created_at, updated_at`
 
-	query := `
-		INSERT INTO patients (phone, name, date_of_birth, gender, preferred_language, consent_flags)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, phone, name, date_of_birth, gender, preferred_language, consent_flags, created_at, updated_at
-	`
+// currentPatientID returns SQL that resolves the patient ID placeholder p to
+// the surviving record when that patient has been merged into another
+func currentPatientID(p string) string {
+	return fmt.Sprintf("COALESCE((SELECT merged_into FROM patients WHERE id = %[1]s), %[1]s)", p)
+}
+
+// smsConsentRefused returns SQL that is true when the latest
+// sms_notifications consent of the patient in column patient was refused.
+// Patients who never answered can be texted.
+func smsConsentRefused(patient string) string {
+	return fmt.Sprintf(`COALESCE((
+				SELECT NOT c.granted FROM consent_logs c
+				WHERE c.patient_id = %s AND c.consent_type = 'sms_notifications'
+				ORDER BY c.granted_at DESC
+				LIMIT 1
+			), false)`, patient)
+}
+
+// seenAtFacility returns SQL that is true when the patient in column
+// patient, or a record merged into it, has a referral to or an appointment
+// at the facility placeholder f
+func seenAtFacility(patient, f string) string {
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM patients seen
+		WHERE (seen.id = %[1]s OR seen.merged_into = %[1]s)
+		  AND (EXISTS (SELECT 1 FROM referrals WHERE patient_id = seen.id AND facility_id = %[2]s)
+		       OR EXISTS (SELECT 1 FROM appointments WHERE patient_id = seen.id AND facility_id = %[2]s)))`, patient, f)
+}
+
+// lookedAfterBy returns SQL that is true when the patient in column patient,
+// or a record merged into it, was registered, triaged or referred by the CHV
+// placeholder chv, or belongs to a household on the CHV's caseload
+func lookedAfterBy(patient, chv string) string {
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM patients seen
+		WHERE (seen.id = %[1]s OR seen.merged_into = %[1]s)
+		  AND (seen.registered_by = %[2]s
+		       OR EXISTS (SELECT 1 FROM triage_sessions WHERE patient_id = seen.id AND created_by = %[2]s)
+		       OR EXISTS (SELECT 1 FROM referrals WHERE patient_id = seen.id AND created_by_chv = %[2]s)
+		       OR EXISTS (
+		           SELECT 1 FROM household_members hm
+		           JOIN chv_assignments ca ON ca.household_id = hm.household_id AND ca.ended_at IS NULL
+		           WHERE hm.patient_id = seen.id AND ca.chv_user_id = %[2]s)))`, patient, chv)
+}
 
+// scanPatient scans the patientColumns into a patient. Any extra
+// destinations are scanned after them.
+func scanPatient(row pgx.Row, extra ...interface{}) (*models.Patient, error) {
 	var patient models.Patient
 	var consentFlagsRaw []byte
 
-	err = r.db.QueryRow(ctx, query,
-		req.Phone,
-		req.Name,
-		req.DateOfBirth,
-		req.Gender,
-		req.PreferredLanguage,
-		consentFlagsJSON,
-	).Scan(
+	dest := []interface{}{
 		&patient.ID,
 		&patient.Phone,
 		&patient.Name,
@@ -49,43 +85,99 @@ func (r *PatientRepository) Create(ctx context.Context, req *models.CreatePatien
 		&patient.Gender,
 		&patient.PreferredLanguage,
 		&consentFlagsRaw,
+		&patient.MergedInto,
+		&patient.MergedAt,
 		&patient.CreatedAt,
 		&patient.UpdatedAt,
-	)
-
+	}
+	err := row.Scan(append(dest, extra...)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create patient: %w", err)
+		return nil, err
 	}
 
 	if err := json.Unmarshal(consentFlagsRaw, &patient.ConsentFlags); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal consent flags: %w", err)
 	}
+	patient.PhoneCarrier = phone.CarrierOf(patient.Phone)
 
 	return &patient, nil
 }
 
-func (r *PatientRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Patient, error) {
+func collectPatients(rows pgx.Rows) ([]*models.Patient, error) {
+	defer rows.Close()
+
+	patients := []*models.Patient{}
+	for rows.Next() {
+		patient, err := scanPatient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan patient: %w", err)
+		}
+		patients = append(patients, patient)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating patients: %w", err)
+	}
+
+	return patients, nil
+}
+
+// Create inserts a patient under the E.164 form of req.Phone, recording any
+// consents given as consent.changed. A phone number that is already
+// registered returns ErrDuplicate
+func (r *PatientRepository) Create(ctx context.Context, req *models.CreatePatientRequest) (*models.Patient, error) {
+	normalized, err := normalizePhone(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	consentFlagsJSON, err := json.Marshal(req.ConsentFlags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal consent flags: %w", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		SELECT id, phone, name, date_of_birth, gender, preferred_language, consent_flags, created_at, updated_at
-		FROM patients
-		WHERE id = $1
-	`
+		INSERT INTO patients (phone, name, date_of_birth, gender, preferred_language, consent_flags, registered_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + patientColumns
 
-	var patient models.Patient
-	var consentFlagsRaw []byte
+	patient, err := scanPatient(tx.QueryRow(ctx, query,
+		normalized,
+		req.Name,
+		req.DateOfBirth,
+		req.Gender,
+		req.PreferredLanguage,
+		consentFlagsJSON,
+		req.RegisteredBy,
+	))
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create patient: %w", err)
+	}
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&patient.ID,
-		&patient.Phone,
-		&patient.Name,
-		&patient.DateOfBirth,
-		&patient.Gender,
-		&patient.PreferredLanguage,
-		&consentFlagsRaw,
-		&patient.CreatedAt,
-		&patient.UpdatedAt,
-	)
+	if err := recordConsentChange(ctx, tx, patient.ID, nil, patient.ConsentFlags); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit patient: %w", err)
+	}
+
+	return patient, nil
+}
 
+func (r *PatientRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Patient, error) {
+	query := `SELECT ` + patientColumns + ` FROM patients WHERE id = $1`
+
+	patient, err := scanPatient(r.db.QueryRow(ctx, query, id))
 	if err == pgx.ErrNoRows {
 		return nil, fmt.Errorf("patient not found")
 	}
@@ -93,94 +185,340 @@ func (r *PatientRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.
 		return nil, fmt.Errorf("failed to get patient: %w", err)
 	}
 
-	if err := json.Unmarshal(consentFlagsRaw, &patient.ConsentFlags); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal consent flags: %w", err)
+	return patient, nil
+}
+
+// GetByPhone finds a patient by phone number written in any accepted format
+func (r *PatientRepository) GetByPhone(ctx context.Context, number string) (*models.Patient, error) {
+	keys := phoneLookupKeys(number)
+	query := `SELECT ` + patientColumns + ` FROM patients WHERE phone = ANY($1) ORDER BY phone = $2 DESC LIMIT 1`
+
+	patient, err := scanPatient(r.db.QueryRow(ctx, query, keys, keys[0]))
+	if err == pgx.ErrNoRows {
+		return nil, nil // Return nil, nil for not found (not an error)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patient by phone: %w", err)
 	}
 
-	return &patient, nil
+	return patient, nil
 }
 
-func (r *PatientRepository) GetByPhone(ctx context.Context, phone string) (*models.Patient, error) {
-	query := `
-		SELECT id, phone, name, date_of_birth, gender, preferred_language, consent_flags, created_at, updated_at
-		FROM patients
-		WHERE phone = $1
-	`
+// Search returns patients whose phone is one of phones, whose name fuzzily
+// matches name, and who were born on dob; empty filters are ignored. Name
+// searches are ordered by similarity, others by most recently registered.
+// Merged patients are left out, but their phone numbers find the survivor.
+// A non-nil facilityID limits results to patients seen at that facility,
+// and a non-nil chvUserID to the patients that CHV looks after.
+func (r *PatientRepository) Search(ctx context.Context, phones []string, name string, dob *time.Time, facilityID, chvUserID *uuid.UUID, limit int) ([]*models.Patient, error) {
+	where := []string{"merged_into IS NULL"}
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
 
-	var patient models.Patient
-	var consentFlagsRaw []byte
+	orderBy := "created_at DESC, id"
+	if name != "" {
+		n := arg(name)
+		where = append(where, fmt.Sprintf("(name ILIKE '%%' || %[1]s || '%%' OR %[1]s <%% name)", n))
+		orderBy = fmt.Sprintf("word_similarity(%s, name) DESC, %s", n, orderBy)
+	}
+	if len(phones) > 0 {
+		p := arg(phones)
+		where = append(where, fmt.Sprintf("(phone = ANY(%[1]s) OR id IN (SELECT merged_into FROM patients WHERE phone = ANY(%[1]s)))", p))
+	}
+	if dob != nil {
+		where = append(where, "date_of_birth = "+arg(*dob))
+	}
+	if len(where) == 1 {
+		return nil, fmt.Errorf("%w: at least one search filter is required", ErrValidation)
+	}
+	if facilityID != nil {
+		where = append(where, seenAtFacility("patients.id", arg(*facilityID)))
+	}
+	if chvUserID != nil {
+		where = append(where, lookedAfterBy("patients.id", arg(*chvUserID)))
+	}
 
-	err := r.db.QueryRow(ctx, query, phone).Scan(
-		&patient.ID,
-		&patient.Phone,
-		&patient.Name,
-		&patient.DateOfBirth,
-		&patient.Gender,
-		&patient.PreferredLanguage,
-		&consentFlagsRaw,
-		&patient.CreatedAt,
-		&patient.UpdatedAt,
-	)
+	query := `SELECT ` + patientColumns + ` FROM patients
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY ` + orderBy + `
+		LIMIT ` + arg(limit)
 
-	if err == pgx.ErrNoRows {
-		return nil, nil // Return nil, nil for not found (not an error)
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error searching patients: %v", err)
+		return nil, fmt.Errorf("failed to search patients: %w", err)
 	}
+
+	return collectPatients(rows)
+}
+
+// SeenAtFacility reports whether a patient, or a record merged into it, has
+// a referral to or an appointment at the facility
+func (r *PatientRepository) SeenAtFacility(ctx context.Context, patientID, facilityID uuid.UUID) (bool, error) {
+	var seen bool
+	err := r.db.QueryRow(ctx, `SELECT `+seenAtFacility("$1::uuid", "$2"), patientID, facilityID).Scan(&seen)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get patient by phone: %w", err)
+		log.Printf("Error checking patient %s at facility %s: %v", patientID, facilityID, err)
+		return false, fmt.Errorf("failed to check patient facility: %w", err)
 	}
+	return seen, nil
+}
 
-	if err := json.Unmarshal(consentFlagsRaw, &patient.ConsentFlags); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal consent flags: %w", err)
+// FindDuplicateCandidates returns patients registered with one of phones
+// (merged or not, since the number stays taken), or unmerged patients whose
+// name is similar to name and whose date of birth is dob or unrecorded.
+// Candidates are only a shortlist; services.ScoreDuplicate decides which match.
+func (r *PatientRepository) FindDuplicateCandidates(ctx context.Context, phones []string, name string, dob *time.Time, limit int) ([]*models.Patient, error) {
+	query := `SELECT ` + patientColumns + ` FROM patients
+		WHERE phone = ANY($1)
+		   OR (merged_into IS NULL AND $2 <> '' AND name % $2 AND ($3::date IS NULL OR date_of_birth IS NULL OR date_of_birth = $3))
+		ORDER BY (phone = ANY($1)) DESC, similarity(name, $2) DESC, created_at
+		LIMIT $4`
+
+	rows, err := r.db.Query(ctx, query, phones, name, dob, limit)
+	if err != nil {
+		log.Printf("Error finding duplicate patients: %v", err)
+		return nil, fmt.Errorf("failed to find duplicate patients: %w", err)
 	}
 
-	return &patient, nil
+	return collectPatients(rows)
 }
 
-func (r *PatientRepository) Update(ctx context.Context, id uuid.UUID, req *models.CreatePatientRequest) (*models.Patient, error) {
+// Update replaces a patient's details, recording consent.changed if the
+// consent flags differ. As with Patch, ifMatch is the ETag the client last
+// saw and ErrVersionConflict is returned if the record has changed since.
+// Updates to a merged patient apply to the survivor.
+func (r *PatientRepository) Update(ctx context.Context, id uuid.UUID, req *models.CreatePatientRequest, ifMatch string) (*models.Patient, error) {
 	consentFlagsJSON, err := json.Marshal(req.ConsentFlags)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal consent flags: %w", err)
 	}
 
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := scanPatient(tx.QueryRow(ctx, `SELECT `+patientColumns+` FROM patients WHERE id = `+currentPatientID("$1")+` FOR UPDATE`, id))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("patient %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patient: %w", err)
+	}
+	if ifMatch != "*" && strings.TrimPrefix(ifMatch, "W/") != current.ETag() {
+		return nil, ErrVersionConflict
+	}
+
 	query := `
 		UPDATE patients
 		SET name = $1, date_of_birth = $2, gender = $3, preferred_language = $4, consent_flags = $5, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $6
-		RETURNING id, phone, name, date_of_birth, gender, preferred_language, consent_flags, created_at, updated_at
-	`
-
-	var patient models.Patient
-	var consentFlagsRaw []byte
+		WHERE id = ` + currentPatientID("$6") + `
+		RETURNING ` + patientColumns
 
-	err = r.db.QueryRow(ctx, query,
+	patient, err := scanPatient(tx.QueryRow(ctx, query,
 		req.Name,
 		req.DateOfBirth,
 		req.Gender,
 		req.PreferredLanguage,
 		consentFlagsJSON,
 		id,
-	).Scan(
-		&patient.ID,
-		&patient.Phone,
-		&patient.Name,
-		&patient.DateOfBirth,
-		&patient.Gender,
-		&patient.PreferredLanguage,
-		&consentFlagsRaw,
-		&patient.CreatedAt,
-		&patient.UpdatedAt,
-	)
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update patient: %w", err)
+	}
+
+	if err := recordConsentChange(ctx, tx, patient.ID, current.ConsentFlags, patient.ConsentFlags); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit patient update: %w", err)
+	}
 
+	return patient, nil
+}
+
+// Patch applies a validated merge patch. ifMatch is the ETag the client last
+// saw; if the record has changed since, nothing is written and
+// ErrVersionConflict is returned. "*" matches any version. Patches to a
+// merged patient apply to the survivor.
+func (r *PatientRepository) Patch(ctx context.Context, id uuid.UUID, req *models.PatchPatientRequest, ifMatch string) (*models.Patient, error) {
+	if req.Phone.Set {
+		normalized, err := normalizePhone(req.Phone.Value)
+		if err != nil {
+			return nil, err
+		}
+		req.Phone.Value = normalized
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT ` + patientColumns + ` FROM patients WHERE id = ` + currentPatientID("$1") + ` FOR UPDATE`
+	patient, err := scanPatient(tx.QueryRow(ctx, query, id))
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("patient not found")
+		return nil, fmt.Errorf("patient %s: %w", id, ErrNotFound)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to update patient: %w", err)
+		return nil, fmt.Errorf("failed to get patient: %w", err)
+	}
+	if ifMatch != "*" && strings.TrimPrefix(ifMatch, "W/") != patient.ETag() {
+		return nil, ErrVersionConflict
 	}
 
-	if err := json.Unmarshal(consentFlagsRaw, &patient.ConsentFlags); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal consent flags: %w", err)
+	before := maps.Clone(patient.ConsentFlags)
+	req.Apply(patient)
+
+	consentFlagsJSON, err := json.Marshal(patient.ConsentFlags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal consent flags: %w", err)
 	}
 
-	return &patient, nil
-}
\ No newline at end of file
+	query = `
+		UPDATE patients
+		SET phone = $1, name = $2, date_of_birth = $3, gender = $4, preferred_language = $5, consent_flags = $6
+		WHERE id = $7
+		RETURNING ` + patientColumns
+
+	patient, err = scanPatient(tx.QueryRow(ctx, query,
+		patient.Phone,
+		patient.Name,
+		patient.DateOfBirth,
+		patient.Gender,
+		patient.PreferredLanguage,
+		consentFlagsJSON,
+		patient.ID,
+	))
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		log.Printf("Error patching patient %s: %v", id, err)
+		return nil, fmt.Errorf("failed to patch patient: %w", err)
+	}
+
+	if err := recordConsentChange(ctx, tx, patient.ID, before, patient.ConsentFlags); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit patient patch: %w", err)
+	}
+
+	return patient, nil
+}
+
+// Columns selected into the JSON sections of summaries. The names match the
+// JSON tags of the corresponding models.
+const (
+	triageSummaryColumns      = `id, triage_level, triage_code, recommended_action, summary_text, symptoms, channel, created_at`
+	referralSummaryColumns    = `r.id, r.facility_id, f.name AS facility_name, r.referral_token, r.status, r.priority, r.notes, r.accepted_at, r.completed_at, r.created_at,
+		(SELECT row_to_json(ro) FROM referral_outcomes ro WHERE ro.referral_id = r.id) AS outcome`
+	appointmentSummaryColumns = `a.id, a.referral_id, a.facility_id, f.name AS facility_name, a.scheduled_time, a.status, a.notes`
+)
+
+// patientSummaryQuery loads a patient and their history in one round trip;
+// each section is aggregated to JSON by a correlated subquery
+var patientSummaryQuery = `
+	SELECT ` + patientColumns + `,
+		(SELECT COALESCE(json_agg(t ORDER BY t.created_at DESC), '[]') FROM (
+			SELECT ` + triageSummaryColumns + `
+			FROM triage_sessions
+			WHERE patient_id = p.id
+			ORDER BY created_at DESC
+			LIMIT $3
+		) t),
+		(SELECT COALESCE(json_agg(o ORDER BY o.created_at DESC), '[]') FROM (
+			SELECT ` + referralSummaryColumns + `
+			FROM referrals r
+			LEFT JOIN facilities f ON f.id = r.facility_id
+			WHERE r.patient_id = p.id AND r.status IN ('pending', 'accepted')
+			ORDER BY r.created_at DESC
+		) o),
+		(SELECT COALESCE(json_agg(h ORDER BY h.created_at DESC), '[]') FROM (
+			SELECT ` + referralSummaryColumns + `
+			FROM referrals r
+			LEFT JOIN facilities f ON f.id = r.facility_id
+			WHERE r.patient_id = p.id AND r.status NOT IN ('pending', 'accepted')
+			ORDER BY r.created_at DESC
+			LIMIT $4
+		) h),
+		(SELECT COALESCE(json_agg(a ORDER BY a.scheduled_time), '[]') FROM (
+			SELECT ` + appointmentSummaryColumns + `
+			FROM appointments a
+			LEFT JOIN facilities f ON f.id = a.facility_id
+			WHERE a.patient_id = p.id AND a.scheduled_time >= $2 AND a.status IN ('scheduled', 'confirmed')
+			ORDER BY a.scheduled_time
+			LIMIT $5
+		) a),
+		(SELECT COALESCE(json_agg(c ORDER BY c.consent_type), '[]') FROM (
+			SELECT DISTINCT ON (consent_type) consent_type, granted, granted_at
+			FROM consent_logs
+			WHERE patient_id = p.id
+			ORDER BY consent_type, granted_at DESC
+		) c)
+	FROM patients p
+	WHERE p.id = ` + currentPatientID("$1")
+
+// GetSummary returns the patient's longitudinal summary as of now. A merged
+// patient resolves to the survivor. Returns nil if the patient does not exist.
+func (r *PatientRepository) GetSummary(ctx context.Context, id uuid.UUID, now time.Time) (*models.PatientSummary, error) {
+	var triageJSON, openJSON, pastJSON, appointmentsJSON, consentsJSON []byte
+	row := r.db.QueryRow(ctx, patientSummaryQuery, id, now,
+		models.SummaryRecentTriageLimit, models.SummaryPastReferralLimit, models.SummaryUpcomingAppointments)
+
+	patient, err := scanPatient(row, &triageJSON, &openJSON, &pastJSON, &appointmentsJSON, &consentsJSON)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error getting summary for patient %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get patient summary: %w", err)
+	}
+
+	summary := &models.PatientSummary{Patient: patient, GeneratedAt: now}
+	sections := []struct {
+		raw  []byte
+		dest interface{}
+	}{
+		{triageJSON, &summary.RecentTriage},
+		{openJSON, &summary.OpenReferrals},
+		{pastJSON, &summary.PastReferrals},
+		{appointmentsJSON, &summary.UpcomingAppointments},
+		{consentsJSON, &summary.Consents},
+	}
+	for _, section := range sections {
+		if err := json.Unmarshal(section.raw, section.dest); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal patient summary: %w", err)
+		}
+	}
+	summary.SetAge(now)
+
+	return summary, nil
+}
+
+// GetContact returns the phone, language and SMS consent of a patient
+// (following merges), for texting them about their care
+func (r *PatientRepository) GetContact(ctx context.Context, id uuid.UUID) (*models.PatientContact, error) {
+	var contact models.PatientContact
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(p.phone, ''), COALESCE(p.preferred_language, $2), `+smsConsentRefused("p.id")+`
+		FROM patients p
+		WHERE p.id = `+currentPatientID("$1"), id, models.DefaultLanguage).Scan(&contact.Phone, &contact.PreferredLanguage, &contact.SMSConsentRefused)
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patient contact: %w", err)
+	}
+
+	return &contact, nil
+}
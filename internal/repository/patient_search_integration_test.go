@@ -0,0 +1,153 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestPatientSearch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPatientRepository(testPool(t))
+
+	// A surname unique to this run keeps the assertions independent of other data
+	surname := fmt.Sprintf("Testkamau%d", rand.Intn(1_000_000))
+	dob := time.Date(1990, 5, 14, 0, 0, 0, 0, time.UTC)
+
+	create := func(phone, name string, dob *time.Time) *models.Patient {
+		patient, err := repo.Create(ctx, &models.CreatePatientRequest{Phone: phone, Name: &name, DateOfBirth: dob})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repo.db.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+		})
+		return patient
+	}
+
+	phone := fmt.Sprintf("+2547%08d", rand.Intn(100_000_000))
+	wanjiku := create(phone, "Wanjiku "+surname, &dob)
+	create(fmt.Sprintf("+2541%08d", rand.Intn(100_000_000)), "Achieng "+surname, nil)
+
+	t.Run("Phone", func(t *testing.T) {
+		patients, err := repo.Search(ctx, []string{phone}, "", nil, nil, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, patients, 1)
+		assert.Equal(t, wanjiku.ID, patients[0].ID)
+	})
+
+	t.Run("Fuzzy name, closest first", func(t *testing.T) {
+		patients, err := repo.Search(ctx, nil, "Wanjiku "+surname[:len(surname)-1], nil, nil, nil, 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, patients)
+		assert.Equal(t, wanjiku.ID, patients[0].ID)
+	})
+
+	t.Run("Name and date of birth", func(t *testing.T) {
+		patients, err := repo.Search(ctx, nil, surname, &dob, nil, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, patients, 1)
+		assert.Equal(t, wanjiku.ID, patients[0].ID)
+	})
+
+	t.Run("No filters", func(t *testing.T) {
+		_, err := repo.Search(ctx, nil, "", nil, nil, nil, 10)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("Facility-bound callers only find patients seen there", func(t *testing.T) {
+		var facilityID, otherFacilityID uuid.UUID
+		for _, id := range []*uuid.UUID{&facilityID, &otherFacilityID} {
+			err := repo.db.QueryRow(ctx, `
+				INSERT INTO facilities (name, type, latitude, longitude)
+				VALUES ('Search Test Dispensary', 'dispensary', -0.1022, 34.7617)
+				RETURNING id`).Scan(id)
+			require.NoError(t, err)
+			facility := *id
+			t.Cleanup(func() {
+				repo.db.Exec(context.Background(), `DELETE FROM facilities WHERE id = $1`, facility)
+			})
+		}
+		referrals := NewReferralRepository(repo.db)
+		referral, err := referrals.Create(ctx, &models.CreateReferralRequest{PatientID: wanjiku.ID, FacilityID: facilityID}, fmt.Sprintf("REF-S%07d", rand.Intn(10_000_000)), nil)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repo.db.Exec(context.Background(), `DELETE FROM outbox_events WHERE aggregate_id = $1`, referral.ID)
+			repo.db.Exec(context.Background(), `DELETE FROM referrals WHERE id = $1`, referral.ID)
+		})
+
+		patients, err := repo.Search(ctx, nil, surname, nil, &facilityID, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, patients, 1)
+		assert.Equal(t, wanjiku.ID, patients[0].ID)
+
+		patients, err = repo.Search(ctx, nil, surname, nil, &otherFacilityID, nil, 10)
+		require.NoError(t, err)
+		assert.Empty(t, patients)
+
+		seen, err := repo.SeenAtFacility(ctx, wanjiku.ID, facilityID)
+		require.NoError(t, err)
+		assert.True(t, seen)
+		seen, err = repo.SeenAtFacility(ctx, wanjiku.ID, otherFacilityID)
+		require.NoError(t, err)
+		assert.False(t, seen, "an unrelated facility cannot read the patient")
+	})
+
+	t.Run("CHVs only find the patients they look after", func(t *testing.T) {
+		users := NewUserRepository(repo.db)
+		households := NewHouseholdRepository(repo.db)
+		chv, err := users.Create(ctx, fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)), models.UserRoleCHV)
+		require.NoError(t, err)
+		otherCHV, err := users.Create(ctx, fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)), models.UserRoleCHV)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repo.db.Exec(context.Background(), `DELETE FROM users WHERE id = ANY($1)`, []uuid.UUID{chv.ID, otherCHV.ID})
+		})
+
+		name := "Njeri " + surname
+		registered, err := repo.Create(ctx, &models.CreatePatientRequest{
+			Phone: fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)), Name: &name, RegisteredBy: &chv.ID,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repo.db.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, registered.ID)
+		})
+
+		household, err := households.Create(ctx, &models.CreateHouseholdRequest{Name: "Search household"}, &chv.ID, &chv.ID)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repo.db.Exec(context.Background(), `DELETE FROM households WHERE id = $1`, household.ID)
+		})
+		_, err = households.AddMember(ctx, household.ID, &models.AddHouseholdMemberRequest{PatientID: wanjiku.ID}, &chv.ID)
+		require.NoError(t, err)
+
+		patients, err := repo.Search(ctx, nil, surname, nil, nil, &chv.ID, 10)
+		require.NoError(t, err)
+		var found []uuid.UUID
+		for _, patient := range patients {
+			found = append(found, patient.ID)
+		}
+		assert.ElementsMatch(t, []uuid.UUID{wanjiku.ID, registered.ID}, found, "the caseload member and the patient the CHV registered")
+
+		patients, err = repo.Search(ctx, nil, surname, nil, nil, &otherCHV.ID, 10)
+		require.NoError(t, err)
+		assert.Empty(t, patients)
+	})
+
+	t.Run("Duplicate candidates", func(t *testing.T) {
+		candidates, err := repo.FindDuplicateCandidates(ctx, []string{"+254700000000"}, "Wanjiku "+surname, &dob, 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, candidates)
+		assert.Equal(t, wanjiku.ID, candidates[0].ID)
+
+		_, err = repo.Create(ctx, &models.CreatePatientRequest{Phone: phone})
+		assert.ErrorIs(t, err, ErrDuplicate)
+	})
+}
@@ -0,0 +1,68 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestPatientSummary(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	patients := NewPatientRepository(pool)
+	triage := NewTriageRepository(pool)
+
+	name := "Achieng Summary"
+	dob := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	patient, err := patients.Create(ctx, &models.CreatePatientRequest{
+		Phone:       fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)),
+		Name:        &name,
+		DateOfBirth: &dob,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+	})
+
+	for _, symptom := range []string{"fever", "cough"} {
+		_, err := triage.Create(ctx, &models.CreateTriageRequest{
+			PatientID: &patient.ID,
+			Symptoms:  map[string]interface{}{symptom: true},
+			Channel:   "sms",
+		})
+		require.NoError(t, err)
+	}
+	_, err = pool.Exec(ctx, `
+		INSERT INTO consent_logs (patient_id, consent_type, granted, granted_at)
+		VALUES ($1, 'sms_notifications', true, NOW() - INTERVAL '1 day'), ($1, 'sms_notifications', false, NOW())`,
+		patient.ID)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	summary, err := patients.GetSummary(ctx, patient.ID, now)
+	require.NoError(t, err)
+	require.NotNil(t, summary)
+
+	assert.Equal(t, patient.ID, summary.Patient.ID)
+	assert.Equal(t, 3, *summary.AgeYears)
+	assert.Equal(t, 39, *summary.AgeMonths)
+	require.Len(t, summary.RecentTriage, 2)
+	assert.Contains(t, summary.RecentTriage[0].Symptoms, "cough", "most recent first")
+	assert.Empty(t, summary.OpenReferrals)
+	assert.Empty(t, summary.UpcomingAppointments)
+	require.Len(t, summary.Consents, 1, "only the latest decision per consent type")
+	assert.False(t, summary.Consents[0].Granted)
+
+	missing, err := patients.GetSummary(ctx, uuid.Nil, now)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// PhoneIssueRepository reads the issues recorded by the phone normalization migration
+type PhoneIssueRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPhoneIssueRepository(db *pgxpool.Pool) *PhoneIssueRepository {
+	return &PhoneIssueRepository{db: db}
+}
+
+const phoneIssueColumns = `id, table_name, record_id, original_phone, normalized_phone, conflicting_record_id, resolved_at, created_at`
+
+func scanPhoneIssue(row pgx.Row) (*models.PhoneNormalizationIssue, error) {
+	var issue models.PhoneNormalizationIssue
+	err := row.Scan(
+		&issue.ID,
+		&issue.TableName,
+		&issue.RecordID,
+		&issue.OriginalPhone,
+		&issue.NormalizedPhone,
+		&issue.ConflictingRecordID,
+		&issue.ResolvedAt,
+		&issue.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+func (r *PhoneIssueRepository) List(ctx context.Context, req *models.ListPhoneIssuesRequest) ([]*models.PhoneNormalizationIssue, error) {
+	query := `SELECT ` + phoneIssueColumns + ` FROM phone_normalization_issues WHERE 1=1`
+	args := []interface{}{}
+
+	if req.Table != "" {
+		args = append(args, req.Table)
+		query += fmt.Sprintf(" AND table_name = $%d", len(args))
+	}
+	if !req.IncludeResolved {
+		query += " AND resolved_at IS NULL"
+	}
+	query += " ORDER BY table_name, created_at, id"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list phone issues: %w", err)
+	}
+	defer rows.Close()
+
+	issues := []*models.PhoneNormalizationIssue{}
+	for rows.Next() {
+		issue, err := scanPhoneIssue(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan phone issue: %w", err)
+		}
+		issues = append(issues, issue)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating phone issues: %w", err)
+	}
+
+	return issues, nil
+}
+
+// Resolve marks an issue as handled, e.g. after the duplicate was merged or corrected
+func (r *PhoneIssueRepository) Resolve(ctx context.Context, id uuid.UUID) (*models.PhoneNormalizationIssue, error) {
+	query := `
+		UPDATE phone_normalization_issues SET resolved_at = COALESCE(resolved_at, CURRENT_TIMESTAMP)
+		WHERE id = $1
+		RETURNING ` + phoneIssueColumns
+
+	issue, err := scanPhoneIssue(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("phone issue not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve phone issue: %w", err)
+	}
+
+	return issue, nil
+}
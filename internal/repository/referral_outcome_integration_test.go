@@ -0,0 +1,126 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestReferralOutcomeFeedback(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	referrals := NewReferralRepository(pool)
+	households := NewHouseholdRepository(pool)
+	patients := NewPatientRepository(pool)
+	users := NewUserRepository(pool)
+	notifications := NewNotificationRepository(pool)
+
+	randomPhone := func() string { return fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)) }
+
+	chv, err := users.Create(ctx, randomPhone(), models.UserRoleCHV)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, chv.ID)
+	})
+
+	household, err := households.Create(ctx, &models.CreateHouseholdRequest{Name: "Otieno household"}, &chv.ID, &chv.ID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM households WHERE id = $1`, household.ID)
+	})
+
+	patient, err := patients.Create(ctx, &models.CreatePatientRequest{Phone: randomPhone()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+	})
+	_, err = households.AddMember(ctx, household.ID, &models.AddHouseholdMemberRequest{PatientID: patient.ID}, &chv.ID)
+	require.NoError(t, err)
+
+	var facilityID uuid.UUID
+	err = pool.QueryRow(ctx, `
+		INSERT INTO facilities (name, type, latitude, longitude)
+		VALUES ('Outcome Test Health Centre', 'health_center', -0.1022, 34.7617)
+		RETURNING id`).Scan(&facilityID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM facilities WHERE id = $1`, facilityID)
+	})
+
+	referral, err := referrals.Create(ctx, &models.CreateReferralRequest{PatientID: patient.ID, FacilityID: facilityID}, fmt.Sprintf("REF-T%07d", rand.Intn(10_000_000)), &chv.ID)
+	require.NoError(t, err)
+
+	appropriate := true
+	outcomeReq := &models.RecordReferralOutcomeRequest{
+		DiagnosisCategory: models.DiagnosisMalaria,
+		Disposition:       models.DispositionDischarged,
+		TriageAppropriate: &appropriate,
+	}
+
+	_, err = referrals.RecordOutcome(ctx, referral.ID, outcomeReq, &chv.ID)
+	assert.ErrorIs(t, err, ErrValidation, "outcome before completion")
+
+	accepted, err := referrals.UpdateStatus(ctx, referral.ID, models.ReferralStatusAccepted, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, accepted.AcceptedAt)
+
+	_, err = referrals.UpdateStatus(ctx, referral.ID, models.ReferralStatusAccepted, nil)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	completed, err := referrals.UpdateStatus(ctx, referral.ID, models.ReferralStatusCompleted, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, completed.CompletedAt)
+
+	outcome, err := referrals.RecordOutcome(ctx, referral.ID, outcomeReq, &chv.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DiagnosisMalaria, outcome.DiagnosisCategory)
+
+	outcomeReq.Disposition = models.DispositionAdmitted
+	outcome, err = referrals.RecordOutcome(ctx, referral.ID, outcomeReq, &chv.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DispositionAdmitted, outcome.Disposition, "recording again replaces")
+
+	t.Run("Outcome on caseload", func(t *testing.T) {
+		caseload, err := households.Caseload(ctx, chv.ID, time.Now())
+		require.NoError(t, err)
+		require.Len(t, caseload.Households, 1)
+		require.Len(t, caseload.Households[0].Patients, 1)
+
+		member := caseload.Households[0].Patients[0]
+		assert.Empty(t, member.OpenReferrals)
+		require.Len(t, member.RecentOutcomes, 1)
+		require.NotNil(t, member.RecentOutcomes[0].Outcome)
+		assert.Equal(t, models.DispositionAdmitted, member.RecentOutcomes[0].Outcome.Disposition)
+	})
+
+	t.Run("Inbox", func(t *testing.T) {
+		notification, err := notifications.Create(ctx, chv.ID, models.NotificationReferralCompleted, &referral.ID, "Referral completed")
+		require.NoError(t, err)
+		require.NoError(t, notifications.SetSMSStatus(ctx, notification.ID, models.SMSStatusSent))
+
+		unread, err := notifications.ListForUser(ctx, chv.ID, &models.ListNotificationsRequest{UnreadOnly: true})
+		require.NoError(t, err)
+		require.Len(t, unread, 1)
+		assert.Equal(t, models.SMSStatusSent, unread[0].SMSStatus)
+
+		_, err = notifications.MarkRead(ctx, notification.ID, uuid.New())
+		assert.ErrorIs(t, err, ErrNotFound, "another user's notification")
+
+		read, err := notifications.MarkRead(ctx, notification.ID, chv.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, read.ReadAt)
+
+		unread, err = notifications.ListForUser(ctx, chv.ID, &models.ListNotificationsRequest{UnreadOnly: true})
+		require.NoError(t, err)
+		assert.Empty(t, unread)
+	})
+}
@@ -0,0 +1,99 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestReferralReminders(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	reminders := NewReferralReminderRepository(pool)
+	referrals := NewReferralRepository(pool)
+	patients := NewPatientRepository(pool)
+
+	county := fmt.Sprintf("Reminder County %d", rand.Intn(1_000_000))
+	var facilityID uuid.UUID
+	err := pool.QueryRow(ctx, `
+		INSERT INTO facilities (name, type, county, latitude, longitude)
+		VALUES ('Reminder Test Dispensary', 'dispensary', $1, -0.1022, 34.7617)
+		RETURNING id`, county).Scan(&facilityID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM facilities WHERE id = $1`, facilityID)
+		pool.Exec(context.Background(), `DELETE FROM referral_reminder_schedules WHERE county = $1`, county)
+	})
+
+	patient, err := patients.Create(ctx, &models.CreatePatientRequest{Phone: fmt.Sprintf("+2547%08d", rand.Intn(100_000_000))})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+	})
+
+	red := models.TriageLevelRed
+	referral, err := referrals.Create(ctx, &models.CreateReferralRequest{PatientID: patient.ID, FacilityID: facilityID, Priority: &red}, fmt.Sprintf("REF-R%07d", rand.Intn(10_000_000)), nil)
+	require.NoError(t, err)
+
+	findPending := func() *models.PendingReferral {
+		pending, err := reminders.ListPending(ctx)
+		require.NoError(t, err)
+		for _, p := range pending {
+			if p.Referral.ID == referral.ID {
+				return p
+			}
+		}
+		return nil
+	}
+
+	entry := findPending()
+	require.NotNil(t, entry)
+	assert.Nil(t, entry.Schedule.County, "default schedule")
+	assert.Equal(t, patient.Phone, entry.PatientPhone)
+
+	override, err := reminders.PutSchedule(ctx, &models.PutReminderScheduleRequest{
+		County: &county, Priority: models.TriageLevelRed, PatientReminderMinutes: []int{60}, ExpireAfterMinutes: 1440,
+	}, nil)
+	require.NoError(t, err)
+
+	entry = findPending()
+	require.NotNil(t, entry)
+	assert.Equal(t, override.ID, entry.Schedule.ID, "county schedule overrides the default")
+
+	step := models.ReminderStep{Kind: models.ReminderKindPatient, Step: 0}
+	claimed, err := reminders.Claim(ctx, referral.ID, step, &patient.Phone)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	require.NoError(t, reminders.SetSMSStatus(ctx, claimed.ID, models.SMSStatusSent))
+
+	again, err := reminders.Claim(ctx, referral.ID, step, &patient.Phone)
+	require.NoError(t, err)
+	assert.Nil(t, again, "each step is taken once")
+
+	entry = findPending()
+	require.NotNil(t, entry)
+	assert.Equal(t, []models.ReminderStep{step}, entry.Done)
+
+	expired, err := reminders.Expire(ctx, referral.ID)
+	require.NoError(t, err)
+	assert.True(t, expired)
+	assert.Nil(t, findPending())
+
+	expired, err = reminders.Expire(ctx, referral.ID)
+	require.NoError(t, err)
+	assert.False(t, expired)
+
+	log, err := reminders.ListForReferral(ctx, referral.ID)
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	assert.Equal(t, models.SMSStatusSent, log[0].SMSStatus)
+	assert.Equal(t, models.ReminderKindExpired, log[1].Kind)
+}
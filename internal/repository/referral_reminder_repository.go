@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type ReferralReminderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReferralReminderRepository(db *pgxpool.Pool) *ReferralReminderRepository {
+	return &ReferralReminderRepository{db: db}
+}
+
+const reminderScheduleColumns = `
+			id, county, priority, patient_reminder_minutes, chv_alert_minutes,
+			expire_after_minutes, updated_by, created_at, updated_at`
+
+// scanReminderSchedule scans the reminderScheduleColumns. Any extra
+// destinations are scanned after them.
+func scanReminderSchedule(row pgx.Row, extra ...interface{}) (*models.ReminderSchedule, error) {
+	var schedule models.ReminderSchedule
+
+	dest := []interface{}{
+		&schedule.ID,
+		&schedule.County,
+		&schedule.Priority,
+		&schedule.PatientReminderMinutes,
+		&schedule.CHVAlertMinutes,
+		&schedule.ExpireAfterMinutes,
+		&schedule.UpdatedBy,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+// ListSchedules returns the default schedules followed by county overrides
+func (r *ReferralReminderRepository) ListSchedules(ctx context.Context) ([]*models.ReminderSchedule, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+reminderScheduleColumns+`
+		FROM referral_reminder_schedules
+		ORDER BY county NULLS FIRST, priority`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminder schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []*models.ReminderSchedule{}
+	for rows.Next() {
+		schedule, err := scanReminderSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reminder schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// PutSchedule creates or replaces the schedule for a county and priority
+func (r *ReferralReminderRepository) PutSchedule(ctx context.Context, req *models.PutReminderScheduleRequest, updatedBy *uuid.UUID) (*models.ReminderSchedule, error) {
+	query := `
+		INSERT INTO referral_reminder_schedules (county, priority, patient_reminder_minutes, chv_alert_minutes, expire_after_minutes, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (COALESCE(LOWER(county), ''), priority) DO UPDATE SET
+			patient_reminder_minutes = EXCLUDED.patient_reminder_minutes,
+			chv_alert_minutes = EXCLUDED.chv_alert_minutes,
+			expire_after_minutes = EXCLUDED.expire_after_minutes,
+			updated_by = EXCLUDED.updated_by
+		RETURNING ` + reminderScheduleColumns
+
+	schedule, err := scanReminderSchedule(r.db.QueryRow(ctx, query,
+		req.County,
+		req.Priority,
+		req.PatientReminderMinutes,
+		req.CHVAlertMinutes,
+		req.ExpireAfterMinutes,
+		updatedBy,
+	))
+	if err != nil {
+		log.Printf("Error saving %s reminder schedule: %v", req.Priority, err)
+		return nil, fmt.Errorf("failed to save reminder schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// ListPending loads every pending referral with its patient, the schedule
+// that applies to it (its facility's county, else the default) and the
+// steps already taken
+func (r *ReferralReminderRepository) ListPending(ctx context.Context) ([]*models.PendingReferral, error) {
+	query := `
+		SELECT r.*, COALESCE(f.name, ''), p.name, COALESCE(p.phone, ''), COALESCE(p.preferred_language, $1),
+			` + smsConsentRefused("p.id") + `,
+			s.*,
+			(SELECT COALESCE(json_agg(json_build_object('kind', rr.kind, 'step', rr.step)), '[]')
+				FROM referral_reminders rr WHERE rr.referral_id = r.id)
+		FROM (
+			SELECT ` + referralColumns + ` FROM referrals WHERE status = 'pending'
+		) r
+		LEFT JOIN facilities f ON f.id = r.facility_id
+		LEFT JOIN patients p ON p.id = r.patient_id
+		JOIN LATERAL (
+			SELECT ` + reminderScheduleColumns + `
+			FROM referral_reminder_schedules
+			WHERE priority = COALESCE(r.priority, 'green')
+				AND (county IS NULL OR LOWER(county) = LOWER(f.county))
+			ORDER BY county NULLS LAST
+			LIMIT 1
+		) s ON true
+		ORDER BY r.created_at`
+
+	rows, err := r.db.Query(ctx, query, models.DefaultLanguage)
+	if err != nil {
+		log.Printf("Error listing pending referrals for reminders: %v", err)
+		return nil, fmt.Errorf("failed to list pending referrals: %w", err)
+	}
+	defer rows.Close()
+
+	pending := []*models.PendingReferral{}
+	for rows.Next() {
+		var entry models.PendingReferral
+		var doneJSON []byte
+
+		// The schedule's columns follow the patient's, then the steps taken
+		var schedule models.ReminderSchedule
+		entry.Referral, err = scanReferral(rows,
+			&entry.FacilityName, &entry.PatientName, &entry.PatientPhone, &entry.PatientLanguage, &entry.SMSConsentRefused,
+			&schedule.ID, &schedule.County, &schedule.Priority, &schedule.PatientReminderMinutes, &schedule.CHVAlertMinutes,
+			&schedule.ExpireAfterMinutes, &schedule.UpdatedBy, &schedule.CreatedAt, &schedule.UpdatedAt,
+			&doneJSON,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pending referral: %w", err)
+		}
+		entry.Schedule = &schedule
+		if err := json.Unmarshal(doneJSON, &entry.Done); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal reminder steps: %w", err)
+		}
+
+		pending = append(pending, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending referrals: %w", err)
+	}
+
+	return pending, nil
+}
+
+const referralReminderColumns = `id, referral_id, kind, step, recipient, sms_status, created_at`
+
+func scanReferralReminder(row pgx.Row) (*models.ReferralReminder, error) {
+	var reminder models.ReferralReminder
+
+	err := row.Scan(
+		&reminder.ID,
+		&reminder.ReferralID,
+		&reminder.Kind,
+		&reminder.Step,
+		&reminder.Recipient,
+		&reminder.SMSStatus,
+		&reminder.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reminder, nil
+}
+
+// Claim logs a reminder step before it is sent. It returns nil when the step
+// was already taken, by this or another server, or the referral is no longer
+// pending.
+func (r *ReferralReminderRepository) Claim(ctx context.Context, referralID uuid.UUID, step models.ReminderStep, recipient *string) (*models.ReferralReminder, error) {
+	query := `
+		INSERT INTO referral_reminders (referral_id, kind, step, recipient)
+		SELECT $1, $2, $3, $4
+		WHERE EXISTS (SELECT 1 FROM referrals WHERE id = $1 AND status = 'pending')
+		ON CONFLICT (referral_id, kind, step) DO NOTHING
+		RETURNING ` + referralReminderColumns
+
+	reminder, err := scanReferralReminder(r.db.QueryRow(ctx, query, referralID, step.Kind, step.Step, recipient))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminder: %w", err)
+	}
+
+	return reminder, nil
+}
+
+func (r *ReferralReminderRepository) SetSMSStatus(ctx context.Context, id uuid.UUID, status models.SMSStatus) error {
+	_, err := r.db.Exec(ctx, `UPDATE referral_reminders SET sms_status = $2 WHERE id = $1`, id, status)
+	if err != nil {
+		return fmt.Errorf("failed to update reminder SMS status: %w", err)
+	}
+	return nil
+}
+
+// Expire closes a referral that is still pending and logs the expiry. It
+// returns false if the referral had moved on in the meantime.
+func (r *ReferralReminderRepository) Expire(ctx context.Context, referralID uuid.UUID) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE referrals SET status = 'expired' WHERE id = $1 AND status = 'pending'`, referralID)
+	if err != nil {
+		return false, fmt.Errorf("failed to expire referral: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO referral_reminders (referral_id, kind, sms_status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (referral_id, kind, step) DO NOTHING`,
+		referralID, models.ReminderKindExpired, models.SMSStatusSkipped)
+	if err != nil {
+		return false, fmt.Errorf("failed to log referral expiry: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit referral expiry: %w", err)
+	}
+
+	return true, nil
+}
+
+// ListForReferral returns the reminder log of a referral, oldest first
+func (r *ReferralReminderRepository) ListForReferral(ctx context.Context, referralID uuid.UUID) ([]*models.ReferralReminder, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+referralReminderColumns+`
+		FROM referral_reminders
+		WHERE referral_id = $1
+		ORDER BY created_at, kind, step`, referralID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referral reminders: %w", err)
+	}
+	defer rows.Close()
+
+	reminders := []*models.ReferralReminder{}
+	for rows.Next() {
+		reminder, err := scanReferralReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan referral reminder: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating referral reminders: %w", err)
+	}
+
+	return reminders, nil
+}
@@ -0,0 +1,544 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// ReferralRepositoryInterface defines the interface for referral operations
+type ReferralRepositoryInterface interface {
+	Create(ctx context.Context, req *models.CreateReferralRequest, token string, createdBy *uuid.UUID) (*models.Referral, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Referral, error)
+	List(ctx context.Context, req *models.ListReferralsRequest) ([]*models.Referral, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status models.ReferralStatus, clinicianID *uuid.UUID) (*models.Referral, error)
+	RecordOutcome(ctx context.Context, id uuid.UUID, req *models.RecordReferralOutcomeRequest, recordedBy *uuid.UUID) (*models.ReferralOutcome, error)
+	GetOutcome(ctx context.Context, id uuid.UUID) (*models.ReferralOutcome, error)
+	Transfer(ctx context.Context, id uuid.UUID, req *models.TransferReferralRequest, token string, transferredBy *uuid.UUID) (*models.Referral, *models.ReferralTransfer, error)
+	GetTransferChain(ctx context.Context, id uuid.UUID) ([]*models.Referral, []*models.ReferralTransfer, error)
+	TransferStats(ctx context.Context, from, to time.Time) ([]*models.TransferStats, error)
+}
+
+type ReferralRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReferralRepository(db *pgxpool.Pool) *ReferralRepository {
+	return &ReferralRepository{db: db}
+}
+
+const referralColumns = `
+			id, patient_id, triage_session_id, facility_id, referral_token, status,
+			priority, notes, created_by_chv, accepted_by_clinician, accepted_at,
+			completed_at, parent_referral_id, created_at, updated_at`
+
+// scanReferral scans the referralColumns into a referral. Any extra
+// destinations are scanned after them.
+func scanReferral(row pgx.Row, extra ...interface{}) (*models.Referral, error) {
+	var referral models.Referral
+
+	dest := []interface{}{
+		&referral.ID,
+		&referral.PatientID,
+		&referral.TriageSessionID,
+		&referral.FacilityID,
+		&referral.ReferralToken,
+		&referral.Status,
+		&referral.Priority,
+		&referral.Notes,
+		&referral.CreatedByCHV,
+		&referral.AcceptedByClinician,
+		&referral.AcceptedAt,
+		&referral.CompletedAt,
+		&referral.ParentReferralID,
+		&referral.CreatedAt,
+		&referral.UpdatedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &referral, nil
+}
+
+// Create inserts a pending referral and records referral.created. A token
+// collision returns ErrDuplicate so the caller can retry with a fresh token.
+func (r *ReferralRepository) Create(ctx context.Context, req *models.CreateReferralRequest, token string, createdBy *uuid.UUID) (*models.Referral, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO referrals (patient_id, triage_session_id, facility_id, referral_token, priority, notes, created_by_chv)
+		VALUES (` + currentPatientID("$1") + `, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + referralColumns
+
+	referral, err := scanReferral(tx.QueryRow(ctx, query,
+		req.PatientID,
+		req.TriageSessionID,
+		req.FacilityID,
+		token,
+		req.Priority,
+		req.Notes,
+		createdBy,
+	))
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		log.Printf("Error creating referral: %v", err)
+		return nil, fmt.Errorf("failed to create referral: %w", err)
+	}
+
+	if err := recordEvent(ctx, tx, models.EventReferralCreated, referral.ID, referral); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit referral: %w", err)
+	}
+
+	return referral, nil
+}
+
+func (r *ReferralRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Referral, error) {
+	query := `SELECT ` + referralColumns + ` FROM referrals WHERE id = $1`
+
+	referral, err := scanReferral(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("referral not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral: %w", err)
+	}
+
+	return referral, nil
+}
+
+func (r *ReferralRepository) List(ctx context.Context, req *models.ListReferralsRequest) ([]*models.Referral, error) {
+	query := `SELECT ` + referralColumns + ` FROM referrals WHERE 1=1`
+	args := []interface{}{}
+
+	if req.FacilityID != nil {
+		args = append(args, *req.FacilityID)
+		query += fmt.Sprintf(" AND facility_id = $%d", len(args))
+	}
+	if req.PatientID != nil {
+		args = append(args, *req.PatientID)
+		query += " AND patient_id = " + currentPatientID(fmt.Sprintf("$%d", len(args)))
+	}
+	if req.Status != nil {
+		args = append(args, *req.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if req.CreatedByCHV != nil {
+		args = append(args, *req.CreatedByCHV)
+		query += fmt.Sprintf(" AND created_by_chv = $%d", len(args))
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = 50
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrals: %w", err)
+	}
+	defer rows.Close()
+
+	referrals := []*models.Referral{}
+	for rows.Next() {
+		referral, err := scanReferral(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan referral: %w", err)
+		}
+		referrals = append(referrals, referral)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating referrals: %w", err)
+	}
+
+	return referrals, nil
+}
+
+// UpdateStatus moves a referral on as reported by the receiving facility.
+// Accepting records the clinician and time, completing records the time.
+// Accepting records referral.accepted. An illegal transition, such as
+// accepting a completed referral, returns ErrValidation; a missing referral
+// returns ErrNotFound.
+func (r *ReferralRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.ReferralStatus, clinicianID *uuid.UUID) (*models.Referral, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var current models.ReferralStatus
+	err = tx.QueryRow(ctx, `SELECT status FROM referrals WHERE id = $1 FOR UPDATE`, id).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock referral: %w", err)
+	}
+	if err := current.CheckTransition(status); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	query := `
+		UPDATE referrals
+		SET status = $2,
+			accepted_by_clinician = CASE WHEN $2 = 'accepted' THEN $3 ELSE accepted_by_clinician END,
+			accepted_at = CASE WHEN $2 = 'accepted' THEN CURRENT_TIMESTAMP ELSE accepted_at END,
+			completed_at = CASE WHEN $2 = 'completed' THEN CURRENT_TIMESTAMP ELSE completed_at END
+		WHERE id = $1
+		RETURNING ` + referralColumns
+
+	referral, err := scanReferral(tx.QueryRow(ctx, query, id, status, clinicianID))
+	if err != nil {
+		log.Printf("Error updating referral %s to %s: %v", id, status, err)
+		return nil, fmt.Errorf("failed to update referral status: %w", err)
+	}
+
+	if status == models.ReferralStatusAccepted {
+		if err := recordEvent(ctx, tx, models.EventReferralAccepted, referral.ID, referral); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit referral status: %w", err)
+	}
+
+	return referral, nil
+}
+
+const referralOutcomeColumns = `
+			referral_id, diagnosis_category, treatment_given, disposition,
+			triage_appropriate, notes, recorded_by, recorded_at, updated_at`
+
+func scanReferralOutcome(row pgx.Row) (*models.ReferralOutcome, error) {
+	var outcome models.ReferralOutcome
+
+	err := row.Scan(
+		&outcome.ReferralID,
+		&outcome.DiagnosisCategory,
+		&outcome.TreatmentGiven,
+		&outcome.Disposition,
+		&outcome.TriageAppropriate,
+		&outcome.Notes,
+		&outcome.RecordedBy,
+		&outcome.RecordedAt,
+		&outcome.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &outcome, nil
+}
+
+// RecordOutcome stores the outcome of a completed referral, replacing any
+// earlier one. Referrals that are not completed return ErrValidation.
+func (r *ReferralRepository) RecordOutcome(ctx context.Context, id uuid.UUID, req *models.RecordReferralOutcomeRequest, recordedBy *uuid.UUID) (*models.ReferralOutcome, error) {
+	var status models.ReferralStatus
+	err := r.db.QueryRow(ctx, `SELECT status FROM referrals WHERE id = $1`, id).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral: %w", err)
+	}
+	if status != models.ReferralStatusCompleted {
+		return nil, fmt.Errorf("%w: outcomes can only be recorded for completed referrals, this one is %s", ErrValidation, status)
+	}
+
+	query := `
+		INSERT INTO referral_outcomes (referral_id, diagnosis_category, treatment_given, disposition, triage_appropriate, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (referral_id) DO UPDATE SET
+			diagnosis_category = EXCLUDED.diagnosis_category,
+			treatment_given = EXCLUDED.treatment_given,
+			disposition = EXCLUDED.disposition,
+			triage_appropriate = EXCLUDED.triage_appropriate,
+			notes = EXCLUDED.notes,
+			recorded_by = EXCLUDED.recorded_by
+		RETURNING ` + referralOutcomeColumns
+
+	outcome, err := scanReferralOutcome(r.db.QueryRow(ctx, query,
+		id,
+		req.DiagnosisCategory,
+		req.TreatmentGiven,
+		req.Disposition,
+		*req.TriageAppropriate,
+		req.Notes,
+		recordedBy,
+	))
+	if err != nil {
+		log.Printf("Error recording outcome for referral %s: %v", id, err)
+		return nil, fmt.Errorf("failed to record referral outcome: %w", err)
+	}
+
+	return outcome, nil
+}
+
+// GetOutcome returns ErrNotFound until an outcome has been recorded
+func (r *ReferralRepository) GetOutcome(ctx context.Context, id uuid.UUID) (*models.ReferralOutcome, error) {
+	query := `SELECT ` + referralOutcomeColumns + ` FROM referral_outcomes WHERE referral_id = $1`
+
+	outcome, err := scanReferralOutcome(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral outcome: %w", err)
+	}
+
+	return outcome, nil
+}
+
+const referralTransferColumns = `
+			id, from_referral_id, to_referral_id, from_facility_id, to_facility_id,
+			reason, notes, transferred_by, created_at`
+
+func scanReferralTransfer(row pgx.Row) (*models.ReferralTransfer, error) {
+	var transfer models.ReferralTransfer
+
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.FromReferralID,
+		&transfer.ToReferralID,
+		&transfer.FromFacilityID,
+		&transfer.ToFacilityID,
+		&transfer.Reason,
+		&transfer.Notes,
+		&transfer.TransferredBy,
+		&transfer.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transfer, nil
+}
+
+// Transfer forwards a pending or accepted referral to another facility. The
+// referral is closed as transferred and a new pending referral, with the
+// given token and the original as its parent, takes its place; the new
+// referral is recorded as referral.created. Live appointments made for the
+// original, at the facility the patient is no longer going to, are
+// cancelled. A referral that is closed or already at that facility returns
+// ErrValidation; a token collision returns ErrDuplicate so the caller can
+// retry.
+func (r *ReferralRepository) Transfer(ctx context.Context, id uuid.UUID, req *models.TransferReferralRequest, token string, transferredBy *uuid.UUID) (*models.Referral, *models.ReferralTransfer, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	parent, err := scanReferral(tx.QueryRow(ctx, `SELECT `+referralColumns+` FROM referrals WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to lock referral: %w", err)
+	}
+	if !parent.Status.CanTransfer() {
+		return nil, nil, fmt.Errorf("%w: a %s referral cannot be transferred", ErrValidation, parent.Status)
+	}
+	if parent.FacilityID != nil && *parent.FacilityID == req.FacilityID {
+		return nil, nil, fmt.Errorf("%w: the referral is already at that facility", ErrValidation)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE referrals SET status = 'transferred' WHERE id = $1`, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to close transferred referral: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE appointments SET status = 'cancelled'
+		WHERE referral_id = $1 AND status IN ('scheduled', 'confirmed')`, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to cancel appointments of transferred referral: %w", err)
+	}
+
+	query := `
+		INSERT INTO referrals (patient_id, triage_session_id, facility_id, referral_token, priority, notes, created_by_chv, parent_referral_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + referralColumns
+
+	referral, err := scanReferral(tx.QueryRow(ctx, query,
+		parent.PatientID,
+		parent.TriageSessionID,
+		req.FacilityID,
+		token,
+		parent.Priority,
+		parent.Notes,
+		parent.CreatedByCHV,
+		parent.ID,
+	))
+	if isUniqueViolation(err) {
+		return nil, nil, ErrDuplicate
+	}
+	if isForeignKeyViolation(err) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error creating transfer of referral %s: %v", id, err)
+		return nil, nil, fmt.Errorf("failed to create transferred referral: %w", err)
+	}
+
+	transfer, err := scanReferralTransfer(tx.QueryRow(ctx, `
+		INSERT INTO referral_transfers (from_referral_id, to_referral_id, from_facility_id, to_facility_id, reason, notes, transferred_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+referralTransferColumns,
+		parent.ID, referral.ID, parent.FacilityID, referral.FacilityID, req.Reason, req.Notes, transferredBy))
+	if err != nil {
+		log.Printf("Error recording transfer of referral %s: %v", id, err)
+		return nil, nil, fmt.Errorf("failed to record referral transfer: %w", err)
+	}
+
+	if err := recordEvent(ctx, tx, models.EventReferralCreated, referral.ID, referral); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit referral transfer: %w", err)
+	}
+
+	return referral, transfer, nil
+}
+
+// GetTransferChain returns every referral in the chain the given one belongs
+// to, from the original to the latest, and the transfers between them
+func (r *ReferralRepository) GetTransferChain(ctx context.Context, id uuid.UUID) ([]*models.Referral, []*models.ReferralTransfer, error) {
+	query := `
+		WITH RECURSIVE up AS (
+			SELECT id, parent_referral_id FROM referrals WHERE id = $1
+			UNION ALL
+			SELECT p.id, p.parent_referral_id FROM referrals p JOIN up ON p.id = up.parent_referral_id
+		), down AS (
+			SELECT id, 0 AS depth FROM up WHERE parent_referral_id IS NULL
+			UNION ALL
+			SELECT c.id, down.depth + 1 FROM referrals c JOIN down ON c.parent_referral_id = down.id
+		)
+		SELECT ` + referralColumns + `
+		FROM referrals JOIN down USING (id)
+		ORDER BY down.depth`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get referral chain: %w", err)
+	}
+	defer rows.Close()
+
+	chain := []*models.Referral{}
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		referral, err := scanReferral(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan referral: %w", err)
+		}
+		chain = append(chain, referral)
+		ids = append(ids, referral.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating referral chain: %w", err)
+	}
+	if len(chain) == 0 {
+		return nil, nil, ErrNotFound
+	}
+
+	rows, err = r.db.Query(ctx, `
+		SELECT `+referralTransferColumns+` FROM referral_transfers
+		WHERE from_referral_id = ANY($1)
+		ORDER BY created_at`, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list referral transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := []*models.ReferralTransfer{}
+	for rows.Next() {
+		transfer, err := scanReferralTransfer(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan referral transfer: %w", err)
+		}
+		transfers = append(transfers, transfer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating referral transfers: %w", err)
+	}
+
+	return chain, transfers, nil
+}
+
+// TransferStats reports, for each facility that received or forwarded a
+// referral in [from, to), how many it received, forwarded and took over
+// from elsewhere, and why it forwarded them. Busiest facilities come first.
+func (r *ReferralRepository) TransferStats(ctx context.Context, from, to time.Time) ([]*models.TransferStats, error) {
+	query := `
+		WITH received AS (
+			SELECT facility_id, COUNT(*) AS received,
+				COUNT(*) FILTER (WHERE parent_referral_id IS NOT NULL) AS transferred_in
+			FROM referrals
+			WHERE facility_id IS NOT NULL AND created_at >= $1 AND created_at < $2
+			GROUP BY facility_id
+		), forwarded AS (
+			SELECT from_facility_id AS facility_id, reason, COUNT(*) AS n
+			FROM referral_transfers
+			WHERE from_facility_id IS NOT NULL AND created_at >= $1 AND created_at < $2
+			GROUP BY from_facility_id, reason
+		)
+		SELECT f.id, f.name, COALESCE(rc.received, 0), COALESCE(rc.transferred_in, 0),
+			COALESCE((SELECT json_object_agg(fw.reason, fw.n) FROM forwarded fw WHERE fw.facility_id = f.id), '{}')
+		FROM facilities f
+		LEFT JOIN received rc ON rc.facility_id = f.id
+		WHERE rc.facility_id IS NOT NULL OR EXISTS (SELECT 1 FROM forwarded fw WHERE fw.facility_id = f.id)
+		ORDER BY COALESCE(rc.received, 0) DESC, f.name`
+
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		log.Printf("Error computing transfer stats: %v", err)
+		return nil, fmt.Errorf("failed to compute transfer stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []*models.TransferStats{}
+	for rows.Next() {
+		var entry models.TransferStats
+		var reasonsJSON []byte
+		if err := rows.Scan(&entry.FacilityID, &entry.FacilityName, &entry.Received, &entry.TransferredIn, &reasonsJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer stats: %w", err)
+		}
+		if err := json.Unmarshal(reasonsJSON, &entry.Reasons); err != nil {
+			return nil, fmt.Errorf("failed to decode transfer reasons: %w", err)
+		}
+		for _, n := range entry.Reasons {
+			entry.TransferredOut += n
+		}
+		entry.SetRate()
+		stats = append(stats, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transfer stats: %w", err)
+	}
+
+	return stats, nil
+}
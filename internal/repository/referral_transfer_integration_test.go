@@ -0,0 +1,106 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestReferralTransfer(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	referrals := NewReferralRepository(pool)
+	patients := NewPatientRepository(pool)
+
+	facilityIDs := make([]uuid.UUID, 3)
+	for i := range facilityIDs {
+		err := pool.QueryRow(ctx, `
+			INSERT INTO facilities (name, type, latitude, longitude)
+			VALUES ($1, 'hospital', -0.1022, 34.7617)
+			RETURNING id`, fmt.Sprintf("Transfer Test Hospital %d", i)).Scan(&facilityIDs[i])
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM facilities WHERE id = ANY($1)`, facilityIDs)
+	})
+
+	patient, err := patients.Create(ctx, &models.CreatePatientRequest{Phone: fmt.Sprintf("+2547%08d", rand.Intn(100_000_000))})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+	})
+
+	token := func() string { return fmt.Sprintf("REF-T%07d", rand.Intn(10_000_000)) }
+	red := models.TriageLevelRed
+	original, err := referrals.Create(ctx, &models.CreateReferralRequest{PatientID: patient.ID, FacilityID: facilityIDs[0], Priority: &red}, token(), nil)
+	require.NoError(t, err)
+	appointment, err := NewAppointmentRepository(pool).Create(ctx, &models.CreateAppointmentRequest{
+		ReferralID: &original.ID, PatientID: patient.ID, FacilityID: facilityIDs[0], ScheduledTime: time.Now().Add(24 * time.Hour).Truncate(time.Minute),
+	})
+	require.NoError(t, err)
+
+	first, transfer, err := referrals.Transfer(ctx, original.ID, &models.TransferReferralRequest{FacilityID: facilityIDs[1], Reason: models.TransferNoTheatre}, token(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, original.ID, *first.ParentReferralID)
+	assert.Equal(t, models.ReferralStatusPending, first.Status)
+	assert.Equal(t, red, *first.Priority)
+	assert.Equal(t, facilityIDs[0], *transfer.FromFacilityID)
+
+	closed, err := referrals.GetByID(ctx, original.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReferralStatusTransferred, closed.Status)
+
+	t.Run("Appointments at the old facility are cancelled", func(t *testing.T) {
+		var status models.AppointmentStatus
+		require.NoError(t, pool.QueryRow(ctx, `SELECT status FROM appointments WHERE id = $1`, appointment.ID).Scan(&status))
+		assert.Equal(t, models.AppointmentStatusCancelled, status)
+	})
+
+	t.Run("A referral is forwarded once", func(t *testing.T) {
+		_, _, err := referrals.Transfer(ctx, original.ID, &models.TransferReferralRequest{FacilityID: facilityIDs[2], Reason: models.TransferNoBeds}, token(), nil)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("Not to the same facility", func(t *testing.T) {
+		_, _, err := referrals.Transfer(ctx, first.ID, &models.TransferReferralRequest{FacilityID: facilityIDs[1], Reason: models.TransferNoBeds}, token(), nil)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	second, _, err := referrals.Transfer(ctx, first.ID, &models.TransferReferralRequest{FacilityID: facilityIDs[2], Reason: models.TransferPowerOutage}, token(), nil)
+	require.NoError(t, err)
+
+	t.Run("Chain from any link", func(t *testing.T) {
+		chain, transfers, err := referrals.GetTransferChain(ctx, first.ID)
+		require.NoError(t, err)
+		require.Len(t, chain, 3)
+		assert.Equal(t, []uuid.UUID{original.ID, first.ID, second.ID}, []uuid.UUID{chain[0].ID, chain[1].ID, chain[2].ID})
+		require.Len(t, transfers, 2)
+		assert.Equal(t, models.TransferPowerOutage, transfers[1].Reason)
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		stats, err := referrals.TransferStats(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		byFacility := map[uuid.UUID]*models.TransferStats{}
+		for _, entry := range stats {
+			byFacility[entry.FacilityID] = entry
+		}
+		require.Contains(t, byFacility, facilityIDs[0])
+		assert.Equal(t, 1, byFacility[facilityIDs[0]].Received)
+		assert.Equal(t, 1, byFacility[facilityIDs[0]].TransferredOut)
+		assert.Equal(t, 1.0, byFacility[facilityIDs[0]].TransferRate)
+		assert.Equal(t, 1, byFacility[facilityIDs[0]].Reasons[models.TransferNoTheatre])
+		assert.Equal(t, 1, byFacility[facilityIDs[2]].TransferredIn)
+		assert.Equal(t, 0.0, byFacility[facilityIDs[2]].TransferRate)
+	})
+}
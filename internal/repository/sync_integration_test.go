@@ -0,0 +1,160 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestSyncApply(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	repo := NewSyncRepository(pool)
+	users := NewUserRepository(pool)
+
+	chv, err := users.Create(ctx, fmt.Sprintf("+2547%08d", rand.Intn(100_000_000)), models.UserRoleCHV)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, chv.ID)
+	})
+
+	now := time.Now()
+	apply := func(entity models.SyncEntity, op models.SyncOperation, entityID uuid.UUID, clientTimestamp time.Time, data interface{}) *models.SyncResult {
+		t.Helper()
+		raw, err := json.Marshal(data)
+		require.NoError(t, err)
+		m := &models.SyncMutation{ID: uuid.New(), Entity: entity, Operation: op, EntityID: entityID, ClientTimestamp: clientTimestamp, Data: raw}
+		require.NoError(t, m.Decode(now))
+
+		result, err := repo.Apply(ctx, chv.ID, "device-1", m)
+		require.NoError(t, err)
+
+		replay, err := repo.Apply(ctx, chv.ID, "device-1", m)
+		require.NoError(t, err)
+		assert.True(t, replay.Replayed)
+		assert.Equal(t, result.Status, replay.Status)
+		return result
+	}
+
+	phone := fmt.Sprintf("+2547%08d", rand.Intn(100_000_000))
+	patientID := uuid.New()
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patientID)
+	})
+
+	t.Run("Patient create is idempotent", func(t *testing.T) {
+		result := apply(models.SyncEntityPatient, models.SyncOperationCreate, patientID, now.Add(-2*time.Hour), map[string]interface{}{"phone": phone, "name": "Wanjiku Sync"})
+		assert.Equal(t, models.SyncStatusApplied, result.Status)
+
+		again := apply(models.SyncEntityPatient, models.SyncOperationCreate, patientID, now.Add(-2*time.Hour), map[string]interface{}{"phone": phone})
+		assert.Equal(t, models.SyncStatusApplied, again.Status)
+		assert.Equal(t, patientID, again.EntityID)
+	})
+
+	t.Run("Phone taken", func(t *testing.T) {
+		result := apply(models.SyncEntityPatient, models.SyncOperationCreate, uuid.New(), now.Add(-time.Hour), map[string]interface{}{"phone": phone})
+		assert.Equal(t, models.SyncStatusConflict, result.Status)
+		assert.Equal(t, "PHONE_TAKEN", result.Code)
+		assert.Equal(t, patientID, result.EntityID)
+	})
+
+	t.Run("Own earlier writes are not a conflict", func(t *testing.T) {
+		result := apply(models.SyncEntityPatient, models.SyncOperationUpdate, patientID, now.Add(-90*time.Minute), map[string]interface{}{"gender": "female"})
+		assert.Equal(t, models.SyncStatusApplied, result.Status)
+	})
+
+	t.Run("Server wins over an older update", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `UPDATE patients SET name = 'Wanjiku Online' WHERE id = $1`, patientID)
+		require.NoError(t, err)
+
+		result := apply(models.SyncEntityPatient, models.SyncOperationUpdate, patientID, now.Add(-time.Hour), map[string]interface{}{"name": "Wanjiku Offline"})
+		assert.Equal(t, models.SyncStatusConflict, result.Status)
+		assert.Equal(t, "VERSION_CONFLICT", result.Code)
+	})
+
+	t.Run("Triage keeps the client timestamp", func(t *testing.T) {
+		happened := now.Add(-3 * time.Hour).Truncate(time.Microsecond)
+		result := apply(models.SyncEntityTriageSession, models.SyncOperationCreate, uuid.New(), happened,
+			map[string]interface{}{"patient_id": patientID, "symptoms": map[string]interface{}{"fever": true}})
+		require.Equal(t, models.SyncStatusApplied, result.Status)
+
+		session := result.Record.(*models.TriageSession)
+		assert.True(t, happened.Equal(session.CreatedAt))
+		assert.Equal(t, models.TriageChannelCHVApp, session.Channel)
+	})
+
+	t.Run("Consent is recorded as an event once", func(t *testing.T) {
+		t.Cleanup(func() {
+			pool.Exec(context.Background(), `DELETE FROM outbox_events WHERE aggregate_id = $1`, patientID)
+		})
+		consentID := uuid.New()
+		for i := 0; i < 2; i++ {
+			result := apply(models.SyncEntityConsent, models.SyncOperationCreate, consentID, now,
+				map[string]interface{}{"patient_id": patientID, "consent_type": "research", "granted": true})
+			require.Equal(t, models.SyncStatusApplied, result.Status)
+		}
+
+		var count int
+		err := pool.QueryRow(ctx, `
+			SELECT COUNT(*) FROM outbox_events
+			WHERE aggregate_id = $1 AND event_type = $2 AND payload->'consents' ? 'research'`,
+			patientID, models.EventConsentChanged).Scan(&count)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Unknown patient", func(t *testing.T) {
+		result := apply(models.SyncEntityConsent, models.SyncOperationCreate, uuid.New(), now,
+			map[string]interface{}{"patient_id": uuid.New(), "consent_type": "data_collection", "granted": true})
+		assert.Equal(t, models.SyncStatusRejected, result.Status)
+	})
+}
+
+func TestSyncChanges(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	repo := NewSyncRepository(pool)
+
+	var start models.SyncCursor
+	err := pool.QueryRow(ctx, `SELECT COALESCE(MAX(txid), 0), COALESCE(MAX(seq), 0) FROM sync_changes`).Scan(&start.TxID, &start.Seq)
+	require.NoError(t, err)
+
+	var facilityID uuid.UUID
+	err = pool.QueryRow(ctx, `
+		INSERT INTO facilities (name, type, latitude, longitude)
+		VALUES ('Sync Test Dispensary', 'dispensary', -1.2921, 36.8219)
+		RETURNING id`).Scan(&facilityID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM facilities WHERE id = $1`, facilityID)
+	})
+
+	changes, err := repo.Changes(ctx, uuid.New(), start, 100)
+	require.NoError(t, err)
+	ids := []uuid.UUID{}
+	for _, facility := range changes.Facilities {
+		ids = append(ids, facility.ID)
+	}
+	assert.Contains(t, ids, facilityID)
+
+	after, err := models.DecodeSyncCursor(changes.Cursor)
+	require.NoError(t, err)
+
+	_, err = pool.Exec(ctx, `DELETE FROM facilities WHERE id = $1`, facilityID)
+	require.NoError(t, err)
+
+	changes, err = repo.Changes(ctx, uuid.New(), after, 100)
+	require.NoError(t, err)
+	assert.Contains(t, changes.DeletedFacilityIDs, facilityID)
+	assert.Empty(t, changes.Facilities)
+}
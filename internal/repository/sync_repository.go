@@ -0,0 +1,596 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/phone"
+)
+
+// SyncRepository applies offline mutations from the CHV app and serves the
+// change feed. The conflict rules are documented in models/sync.go.
+type SyncRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSyncRepository(db *pgxpool.Pool) *SyncRepository {
+	return &SyncRepository{db: db}
+}
+
+const consentColumns = `id, patient_id, consent_type, granted, details, granted_at`
+
+func scanConsent(row pgx.Row) (*models.Consent, error) {
+	var consent models.Consent
+	var detailsRaw []byte
+
+	err := row.Scan(
+		&consent.ID,
+		&consent.PatientID,
+		&consent.ConsentType,
+		&consent.Granted,
+		&detailsRaw,
+		&consent.GrantedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(detailsRaw, &consent.Details); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal consent details: %w", err)
+	}
+
+	return &consent, nil
+}
+
+// Apply applies one decoded mutation from a user's device in its own
+// transaction and records the result under the mutation ID. A mutation that
+// has already been applied returns the recorded result without changing
+// anything. A referral token collision returns ErrDuplicate so the caller can
+// retry with a fresh token.
+func (r *SyncRepository) Apply(ctx context.Context, userID uuid.UUID, deviceID string, m *models.SyncMutation) (*models.SyncResult, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Serializes concurrent deliveries of the same mutation, e.g. a retry
+	// sent while the first request is still running
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))`, m.ID); err != nil {
+		return nil, fmt.Errorf("failed to lock sync mutation: %w", err)
+	}
+
+	var owner uuid.UUID
+	var resultRaw []byte
+	err = tx.QueryRow(ctx, `SELECT user_id, result FROM sync_mutations WHERE id = $1`, m.ID).Scan(&owner, &resultRaw)
+	if err == nil {
+		result := &models.SyncResult{MutationID: m.ID, Entity: m.Entity, EntityID: m.EntityID}
+		if owner != userID {
+			result.Reject("MUTATION_ID_TAKEN", "mutation id was already used by another user")
+			return result, nil
+		}
+		if err := json.Unmarshal(resultRaw, result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sync result: %w", err)
+		}
+		result.Replayed = true
+		return result, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to look up sync mutation: %w", err)
+	}
+
+	result := &models.SyncResult{MutationID: m.ID, Entity: m.Entity, EntityID: m.EntityID, Status: models.SyncStatusApplied}
+	switch payload := m.Payload.(type) {
+	case *models.CreatePatientRequest:
+		err = syncCreatePatient(ctx, tx, userID, m, payload, result)
+	case *models.PatchPatientRequest:
+		err = syncPatchPatient(ctx, tx, userID, deviceID, m, payload, result)
+	case *models.SyncTriageCreate:
+		err = syncCreateTriage(ctx, tx, userID, m, payload, result)
+	case *models.SyncReferralCreate:
+		err = syncCreateReferral(ctx, tx, userID, m, payload, result)
+	case *models.SyncReferralUpdate:
+		err = syncCancelReferral(ctx, tx, userID, m, result)
+	case *models.SyncConsentCreate:
+		err = syncCreateConsent(ctx, tx, m, payload, result)
+	default:
+		return nil, fmt.Errorf("sync mutation %s has not been decoded", m.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sync result: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO sync_mutations (id, user_id, device_id, entity, entity_id, operation, client_timestamp, status, result)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		m.ID, userID, deviceID, m.Entity, result.EntityID, m.Operation, m.ClientTimestamp, result.Status, resultJSON)
+	if err != nil {
+		log.Printf("Error recording sync mutation %s: %v", m.ID, err)
+		return nil, fmt.Errorf("failed to record sync mutation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit sync mutation: %w", err)
+	}
+
+	return result, nil
+}
+
+// syncExistingPatient finds the patient a create collides with: the same ID,
+// which is a replay, or the same phone number, which is a PHONE_TAKEN
+// conflict. A merged patient resolves to the survivor. It reports whether a
+// collision was found.
+func syncExistingPatient(ctx context.Context, tx pgx.Tx, id uuid.UUID, number string, result *models.SyncResult) (bool, error) {
+	query := `SELECT ` + patientColumns + ` FROM patients WHERE id = $1`
+	patient, err := scanPatient(tx.QueryRow(ctx, query, id))
+	if err == nil {
+		result.Record = patient
+		return true, nil
+	}
+	if err != pgx.ErrNoRows {
+		return false, fmt.Errorf("failed to get patient: %w", err)
+	}
+
+	keys := phoneLookupKeys(number)
+	query = `SELECT ` + patientColumns + ` FROM patients WHERE id = (
+		SELECT COALESCE(merged_into, id) FROM patients WHERE phone = ANY($1) ORDER BY phone = $2 DESC LIMIT 1)`
+	patient, err = scanPatient(tx.QueryRow(ctx, query, keys, keys[0]))
+	if err == nil {
+		result.EntityID = patient.ID
+		result.Conflict("PHONE_TAKEN", "phone number is already registered; use the existing patient's id", patient)
+		return true, nil
+	}
+	if err != pgx.ErrNoRows {
+		return false, fmt.Errorf("failed to get patient by phone: %w", err)
+	}
+
+	return false, nil
+}
+
+func syncCreatePatient(ctx context.Context, tx pgx.Tx, userID uuid.UUID, m *models.SyncMutation, req *models.CreatePatientRequest, result *models.SyncResult) error {
+	normalized, err := phone.Normalize(req.Phone)
+	if err != nil {
+		result.Reject("INVALID_PHONE", err.Error())
+		return nil
+	}
+
+	language := req.PreferredLanguage
+	if language == "" {
+		language = models.DefaultLanguage
+	}
+	consentFlagsJSON, err := json.Marshal(req.ConsentFlags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal consent flags: %w", err)
+	}
+
+	query := `
+		INSERT INTO patients (id, phone, name, date_of_birth, gender, preferred_language, consent_flags, registered_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT DO NOTHING
+		RETURNING ` + patientColumns
+
+	// A second pass finds a record written concurrently between the lookup
+	// and the insert
+	for attempt := 0; attempt < 2; attempt++ {
+		found, err := syncExistingPatient(ctx, tx, m.EntityID, normalized, result)
+		if err != nil || found {
+			return err
+		}
+
+		patient, err := scanPatient(tx.QueryRow(ctx, query,
+			m.EntityID, normalized, req.Name, req.DateOfBirth, req.Gender, language, consentFlagsJSON, userID))
+		if err == pgx.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			log.Printf("Error creating synced patient %s: %v", m.EntityID, err)
+			return fmt.Errorf("failed to create patient: %w", err)
+		}
+		if err := recordConsentChange(ctx, tx, patient.ID, nil, patient.ConsentFlags); err != nil {
+			return err
+		}
+
+		result.Record = patient
+		return nil
+	}
+
+	return fmt.Errorf("failed to create patient %s: record changed concurrently", m.EntityID)
+}
+
+func syncPatchPatient(ctx context.Context, tx pgx.Tx, userID uuid.UUID, deviceID string, m *models.SyncMutation, req *models.PatchPatientRequest, result *models.SyncResult) error {
+	query := `SELECT ` + patientColumns + ` FROM patients WHERE id = ` + currentPatientID("$1") + ` FOR UPDATE`
+	patient, err := scanPatient(tx.QueryRow(ctx, query, m.EntityID))
+	if err == pgx.ErrNoRows {
+		result.Reject("PATIENT_NOT_FOUND", "patient not found")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get patient: %w", err)
+	}
+	result.EntityID = patient.ID
+
+	if patient.UpdatedAt.After(m.ClientTimestamp) {
+		// Writes by this device's earlier mutations are not a conflict; they
+		// are stamped with the server time they were applied at
+		var ownWrite *time.Time
+		err := tx.QueryRow(ctx, `
+			SELECT MAX(received_at) FROM sync_mutations
+			WHERE user_id = $1 AND device_id = $2 AND entity = $3 AND entity_id = $4 AND status = $5`,
+			userID, deviceID, models.SyncEntityPatient, patient.ID, models.SyncStatusApplied).Scan(&ownWrite)
+		if err != nil {
+			return fmt.Errorf("failed to look up earlier sync mutations: %w", err)
+		}
+		if ownWrite == nil || patient.UpdatedAt.After(*ownWrite) {
+			result.Conflict("VERSION_CONFLICT", "patient was changed on the server after this update was made", patient)
+			return nil
+		}
+	}
+
+	if req.Phone.Set {
+		normalized, err := phone.Normalize(req.Phone.Value)
+		if err != nil {
+			result.Reject("INVALID_PHONE", err.Error())
+			return nil
+		}
+		req.Phone.Value = normalized
+
+		var taken bool
+		err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM patients WHERE phone = ANY($1) AND id <> $2)`,
+			phoneLookupKeys(normalized), patient.ID).Scan(&taken)
+		if err != nil {
+			return fmt.Errorf("failed to check phone number: %w", err)
+		}
+		if taken {
+			result.Conflict("PHONE_TAKEN", "phone number is already registered to another patient", patient)
+			return nil
+		}
+	}
+
+	before := maps.Clone(patient.ConsentFlags)
+	req.Apply(patient)
+
+	consentFlagsJSON, err := json.Marshal(patient.ConsentFlags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal consent flags: %w", err)
+	}
+
+	query = `
+		UPDATE patients
+		SET phone = $1, name = $2, date_of_birth = $3, gender = $4, preferred_language = $5, consent_flags = $6
+		WHERE id = $7
+		RETURNING ` + patientColumns
+
+	patient, err = scanPatient(tx.QueryRow(ctx, query,
+		patient.Phone,
+		patient.Name,
+		patient.DateOfBirth,
+		patient.Gender,
+		patient.PreferredLanguage,
+		consentFlagsJSON,
+		patient.ID,
+	))
+	if err != nil {
+		log.Printf("Error patching synced patient %s: %v", m.EntityID, err)
+		return fmt.Errorf("failed to patch patient: %w", err)
+	}
+	if err := recordConsentChange(ctx, tx, patient.ID, before, patient.ConsentFlags); err != nil {
+		return err
+	}
+
+	result.Record = patient
+	return nil
+}
+
+func syncCreateTriage(ctx context.Context, tx pgx.Tx, userID uuid.UUID, m *models.SyncMutation, req *models.SyncTriageCreate, result *models.SyncResult) error {
+	symptomsJSON, err := json.Marshal(req.Symptoms)
+	if err != nil {
+		return fmt.Errorf("failed to marshal symptoms: %w", err)
+	}
+
+	var deviceVerdictJSON []byte
+	if req.DeviceVerdict != nil {
+		if deviceVerdictJSON, err = json.Marshal(req.DeviceVerdict); err != nil {
+			return fmt.Errorf("failed to marshal device verdict: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO triage_sessions (id, patient_id, symptoms, channel, bundle_version, device_verdict, created_by, created_at, updated_at)
+		SELECT $1, p.id, $3, $4, $5, $6, $8, $7, $7
+		FROM patients p
+		WHERE p.id = ` + currentPatientID("$2") + `
+		ON CONFLICT (id) DO NOTHING
+		RETURNING ` + triageSessionColumns
+
+	session, err := scanTriageSession(tx.QueryRow(ctx, query,
+		m.EntityID, req.PatientID, symptomsJSON, models.TriageChannelCHVApp, req.BundleVersion, deviceVerdictJSON, m.ClientTimestamp, userID))
+	created := err == nil
+	if err == pgx.ErrNoRows {
+		// Either the session already exists or the patient does not
+		session, err = scanTriageSession(tx.QueryRow(ctx, `SELECT `+triageSessionColumns+` FROM triage_sessions WHERE id = $1`, m.EntityID))
+		if err == pgx.ErrNoRows {
+			result.Reject("PATIENT_NOT_FOUND", "patient not found")
+			return nil
+		}
+	}
+	if err != nil {
+		log.Printf("Error creating synced triage session %s: %v", m.EntityID, err)
+		return fmt.Errorf("failed to create triage session: %w", err)
+	}
+
+	// The device triaged the patient offline, so its verdict completes the session
+	if created && session.DeviceVerdict != nil {
+		completed := *session
+		completed.Status = models.TriageStatusCompleted
+		if err := recordEvent(ctx, tx, models.EventTriageCompleted, session.ID, &completed); err != nil {
+			return err
+		}
+	}
+
+	result.Record = session
+	return nil
+}
+
+func syncCreateReferral(ctx context.Context, tx pgx.Tx, userID uuid.UUID, m *models.SyncMutation, req *models.SyncReferralCreate, result *models.SyncResult) error {
+	existing, err := scanReferral(tx.QueryRow(ctx, `SELECT `+referralColumns+` FROM referrals WHERE id = $1`, m.EntityID))
+	if err == nil {
+		result.Record = existing
+		return nil
+	}
+	if err != pgx.ErrNoRows {
+		return fmt.Errorf("failed to get referral: %w", err)
+	}
+
+	query := `
+		INSERT INTO referrals (id, patient_id, triage_session_id, facility_id, referral_token, priority, notes, created_by_chv, created_at, updated_at)
+		SELECT $1, p.id, $3, f.id, $5, $6, $7, $8, $9, $9
+		FROM patients p, facilities f
+		WHERE p.id = ` + currentPatientID("$2") + ` AND f.id = $4
+			AND ($3::uuid IS NULL OR EXISTS (SELECT 1 FROM triage_sessions WHERE id = $3))
+		ON CONFLICT DO NOTHING
+		RETURNING ` + referralColumns
+
+	referral, err := scanReferral(tx.QueryRow(ctx, query,
+		m.EntityID, req.PatientID, req.TriageSessionID, req.FacilityID, req.Token,
+		req.Priority, req.Notes, userID, m.ClientTimestamp))
+	if err == pgx.ErrNoRows {
+		var missing bool
+		err := tx.QueryRow(ctx, `
+			SELECT NOT EXISTS (SELECT 1 FROM patients WHERE id = $1)
+				OR NOT EXISTS (SELECT 1 FROM facilities WHERE id = $2)
+				OR ($3::uuid IS NOT NULL AND NOT EXISTS (SELECT 1 FROM triage_sessions WHERE id = $3))`,
+			req.PatientID, req.FacilityID, req.TriageSessionID).Scan(&missing)
+		if err != nil {
+			return fmt.Errorf("failed to check referral references: %w", err)
+		}
+		if missing {
+			result.Reject("NOT_FOUND", "patient, facility or triage session not found")
+			return nil
+		}
+		// The ID was checked above, so the token collided
+		return ErrDuplicate
+	}
+	if err != nil {
+		log.Printf("Error creating synced referral %s: %v", m.EntityID, err)
+		return fmt.Errorf("failed to create referral: %w", err)
+	}
+	if err := recordEvent(ctx, tx, models.EventReferralCreated, referral.ID, referral); err != nil {
+		return err
+	}
+
+	result.Record = referral
+	result.Warnings = req.Warnings
+	return nil
+}
+
+func syncCancelReferral(ctx context.Context, tx pgx.Tx, userID uuid.UUID, m *models.SyncMutation, result *models.SyncResult) error {
+	referral, err := scanReferral(tx.QueryRow(ctx, `SELECT `+referralColumns+` FROM referrals WHERE id = $1 FOR UPDATE`, m.EntityID))
+	if err == pgx.ErrNoRows {
+		result.Reject("NOT_FOUND", "referral not found")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get referral: %w", err)
+	}
+	if referral.CreatedByCHV == nil || *referral.CreatedByCHV != userID {
+		result.Reject("FORBIDDEN", "only the CHV who sent a referral can cancel it")
+		return nil
+	}
+
+	switch referral.Status {
+	case models.ReferralStatusCancelled:
+		result.Record = referral
+		return nil
+	case models.ReferralStatusPending:
+	default:
+		result.Conflict("REFERRAL_CLOSED", fmt.Sprintf("referral is already %s", referral.Status), referral)
+		return nil
+	}
+
+	query := `UPDATE referrals SET status = $1 WHERE id = $2 RETURNING ` + referralColumns
+	referral, err = scanReferral(tx.QueryRow(ctx, query, models.ReferralStatusCancelled, referral.ID))
+	if err != nil {
+		log.Printf("Error cancelling synced referral %s: %v", m.EntityID, err)
+		return fmt.Errorf("failed to cancel referral: %w", err)
+	}
+
+	result.Record = referral
+	return nil
+}
+
+func syncCreateConsent(ctx context.Context, tx pgx.Tx, m *models.SyncMutation, req *models.SyncConsentCreate, result *models.SyncResult) error {
+	details := req.Details
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal consent details: %w", err)
+	}
+
+	query := `
+		INSERT INTO consent_logs (id, patient_id, consent_type, granted, details, granted_at)
+		SELECT $1, p.id, $3, $4, $5, $6
+		FROM patients p
+		WHERE p.id = ` + currentPatientID("$2") + `
+		ON CONFLICT (id) DO NOTHING
+		RETURNING ` + consentColumns
+
+	consent, err := scanConsent(tx.QueryRow(ctx, query,
+		m.EntityID, req.PatientID, req.ConsentType, req.Granted, detailsJSON, m.ClientTimestamp))
+	created := err == nil
+	if err == pgx.ErrNoRows {
+		// Either the consent already exists or the patient does not
+		consent, err = scanConsent(tx.QueryRow(ctx, `SELECT `+consentColumns+` FROM consent_logs WHERE id = $1`, m.EntityID))
+		if err == pgx.ErrNoRows {
+			result.Reject("PATIENT_NOT_FOUND", "patient not found")
+			return nil
+		}
+	}
+	if err != nil {
+		log.Printf("Error creating synced consent %s: %v", m.EntityID, err)
+		return fmt.Errorf("failed to record consent: %w", err)
+	}
+
+	if created {
+		change := &models.ConsentChange{
+			PatientID: consent.PatientID,
+			Consents:  map[string]bool{string(consent.ConsentType): consent.Granted},
+		}
+		if err := recordEvent(ctx, tx, models.EventConsentChanged, consent.PatientID, change); err != nil {
+			return err
+		}
+	}
+
+	result.Record = consent
+	return nil
+}
+
+// Changes returns up to limit changes after the cursor. Only transactions
+// older than every one still running are included, so a change is never
+// handed out ahead of an earlier one that has yet to commit. Referrals are
+// limited to those the user sent.
+func (r *SyncRepository) Changes(ctx context.Context, userID uuid.UUID, after models.SyncCursor, limit int) (*models.SyncChanges, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT entity, entity_id, deleted, txid, seq
+		FROM sync_changes
+		WHERE (txid, seq) > ($1, $2)
+			AND txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+		ORDER BY txid, seq
+		LIMIT $3`, after.TxID, after.Seq, limit+1)
+	if err != nil {
+		log.Printf("Error reading sync changes: %v", err)
+		return nil, fmt.Errorf("failed to read sync changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := &models.SyncChanges{
+		Facilities:         []*models.Facility{},
+		FacilityStatuses:   []*models.FacilityStatus{},
+		Referrals:          []*models.Referral{},
+		DeletedFacilityIDs: []uuid.UUID{},
+	}
+	cursor := after
+	var facilityIDs, statusIDs, referralIDs []uuid.UUID
+	for count := 0; rows.Next(); count++ {
+		if count == limit {
+			changes.HasMore = true
+			break
+		}
+
+		var entity models.SyncEntity
+		var id uuid.UUID
+		var deleted bool
+		if err := rows.Scan(&entity, &id, &deleted, &cursor.TxID, &cursor.Seq); err != nil {
+			return nil, fmt.Errorf("failed to scan sync change: %w", err)
+		}
+
+		switch {
+		case entity == models.SyncEntityFacility && deleted:
+			changes.DeletedFacilityIDs = append(changes.DeletedFacilityIDs, id)
+		case entity == models.SyncEntityFacility:
+			facilityIDs = append(facilityIDs, id)
+		case entity == models.SyncEntityFacilityStatus && !deleted:
+			statusIDs = append(statusIDs, id)
+		case entity == models.SyncEntityReferral && !deleted:
+			referralIDs = append(referralIDs, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sync changes: %w", err)
+	}
+	rows.Close()
+	changes.Cursor = cursor.Encode()
+
+	if len(facilityIDs) > 0 {
+		rows, err := r.db.Query(ctx, `SELECT `+facilityColumns+` FROM facilities WHERE id = ANY($1)`, facilityIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load changed facilities: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			facility, err := scanFacility(rows)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan facility: %w", err)
+			}
+			changes.Facilities = append(changes.Facilities, facility)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating facilities: %w", err)
+		}
+	}
+
+	if len(statusIDs) > 0 {
+		rows, err := r.db.Query(ctx, `SELECT `+facilityStatusColumns+`, updated_at FROM facility_status WHERE facility_id = ANY($1)`, statusIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load changed facility status: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var status models.FacilityStatus
+			if err := scanFacilityStatus(rows, &status, &status.UpdatedAt); err != nil {
+				return nil, fmt.Errorf("failed to scan facility status: %w", err)
+			}
+			changes.FacilityStatuses = append(changes.FacilityStatuses, &status)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating facility status: %w", err)
+		}
+	}
+
+	if len(referralIDs) > 0 {
+		rows, err := r.db.Query(ctx, `SELECT `+referralColumns+` FROM referrals WHERE id = ANY($1) AND created_by_chv = $2`, referralIDs, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load changed referrals: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			referral, err := scanReferral(rows)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan referral: %w", err)
+			}
+			changes.Referrals = append(changes.Referrals, referral)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating referrals: %w", err)
+		}
+	}
+
+	return changes, nil
+}
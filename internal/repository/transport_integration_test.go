@@ -0,0 +1,132 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestTransportDispatch(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	referrals := NewReferralRepository(pool)
+	patients := NewPatientRepository(pool)
+	transport := NewTransportRepository(pool)
+
+	var facilityID uuid.UUID
+	err := pool.QueryRow(ctx, `
+		INSERT INTO facilities (name, type, latitude, longitude)
+		VALUES ('Transport Test Hospital', 'hospital', -0.1022, 34.7617)
+		RETURNING id`).Scan(&facilityID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM facilities WHERE id = $1`, facilityID)
+	})
+
+	phone := fmt.Sprintf("+2547%08d", rand.Intn(100_000_000))
+	patient, err := patients.Create(ctx, &models.CreatePatientRequest{Phone: phone})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM patients WHERE id = $1`, patient.ID)
+	})
+
+	red := models.TriageLevelRed
+	referral, err := referrals.Create(ctx, &models.CreateReferralRequest{PatientID: patient.ID, FacilityID: facilityID, Priority: &red}, fmt.Sprintf("REF-D%07d", rand.Intn(10_000_000)), nil)
+	require.NoError(t, err)
+
+	req, err := transport.GetRequest(ctx, referral.ID)
+	require.NoError(t, err)
+	assert.Equal(t, referral.ReferralToken, req.ReferralToken)
+	assert.Equal(t, phone, req.PatientPhone)
+	assert.Equal(t, "Transport Test Hospital", req.FacilityName)
+	assert.Nil(t, req.PickupLatitude, "patient has no household")
+
+	_, err = transport.GetByReferral(ctx, referral.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	dispatch, err := transport.Create(ctx, referral.ID, "simulator", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, dispatch)
+	assert.Equal(t, models.DispatchStatusRequested, dispatch.Status)
+
+	again, err := transport.Create(ctx, referral.ID, "simulator", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, again, "transport is requested once per referral")
+
+	// A redelivery after the provider call was cut short claims the request again
+	reclaimed, err := transport.Create(ctx, referral.ID, "simulator", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, reclaimed)
+	assert.Equal(t, dispatch.ID, reclaimed.ID)
+	again, err = transport.Create(ctx, referral.ID, "simulator", reclaimed.RequestedAt)
+	require.NoError(t, err)
+	assert.Nil(t, again, "claiming refreshes the request time")
+
+	ref := fmt.Sprintf("SIM-%08d", rand.Intn(100_000_000))
+	ambulance := models.VehicleAmbulance
+	_, err = transport.SetBooking(ctx, dispatch.ID, &models.TransportBooking{ProviderRef: ref, VehicleType: &ambulance})
+	require.NoError(t, err)
+
+	t.Run("Unknown reference", func(t *testing.T) {
+		_, err := transport.ApplyUpdate(ctx, "simulator", &models.TransportUpdate{ProviderRef: "SIM-UNKNOWN", Status: models.DispatchStatusAssigned})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = transport.ApplyUpdate(ctx, "other", &models.TransportUpdate{ProviderRef: ref, Status: models.DispatchStatusAssigned})
+		assert.ErrorIs(t, err, ErrNotFound, "references belong to one provider")
+	})
+
+	t.Run("Assigned then arrived", func(t *testing.T) {
+		driver, eta := "Otieno", 12
+		assigned, err := transport.ApplyUpdate(ctx, "simulator", &models.TransportUpdate{ProviderRef: ref, Status: models.DispatchStatusAssigned, DriverName: &driver, ETAMinutes: &eta})
+		require.NoError(t, err)
+		assert.Equal(t, models.DispatchStatusAssigned, assigned.Status)
+		assert.Equal(t, driver, *assigned.DriverName)
+		assert.Equal(t, eta, *assigned.ETAMinutes)
+		assert.Equal(t, ambulance, *assigned.VehicleType)
+		require.NotNil(t, assigned.AssignedAt)
+
+		arrived, err := transport.ApplyUpdate(ctx, "simulator", &models.TransportUpdate{ProviderRef: ref, Status: models.DispatchStatusArrived})
+		require.NoError(t, err)
+		assert.Equal(t, models.DispatchStatusArrived, arrived.Status)
+		assert.Equal(t, driver, *arrived.DriverName)
+		assert.Nil(t, arrived.ETAMinutes)
+		assert.NotNil(t, arrived.ArrivedAt)
+
+		_, err = transport.ApplyUpdate(ctx, "simulator", &models.TransportUpdate{ProviderRef: ref, Status: models.DispatchStatusFailed})
+		assert.ErrorIs(t, err, ErrValidation)
+
+		stored, err := transport.GetByReferral(ctx, referral.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.DispatchStatusArrived, stored.Status)
+	})
+
+	t.Run("Only failed dispatches are reopened", func(t *testing.T) {
+		_, err := transport.Reopen(ctx, referral.ID, "simulator", time.Now())
+		assert.ErrorIs(t, err, ErrValidation)
+
+		other, err := referrals.Create(ctx, &models.CreateReferralRequest{PatientID: patient.ID, FacilityID: facilityID, Priority: &red}, fmt.Sprintf("REF-D%07d", rand.Intn(10_000_000)), nil)
+		require.NoError(t, err)
+		failed, err := transport.Create(ctx, other.ID, "simulator", time.Now())
+		require.NoError(t, err)
+		_, err = transport.SetFailed(ctx, failed.ID, "no riders available")
+		require.NoError(t, err)
+
+		reopened, err := transport.Reopen(ctx, other.ID, "simulator", time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, failed.ID, reopened.ID)
+		assert.Equal(t, models.DispatchStatusRequested, reopened.Status)
+		assert.Nil(t, reopened.FailureReason)
+
+		_, err = transport.Reopen(ctx, uuid.New(), "simulator", time.Now())
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
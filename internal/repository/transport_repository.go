@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type TransportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTransportRepository(db *pgxpool.Pool) *TransportRepository {
+	return &TransportRepository{db: db}
+}
+
+const transportDispatchColumns = `
+			id, referral_id, provider, provider_ref, status, vehicle_type,
+			driver_name, driver_phone, eta_minutes, failure_reason,
+			requested_at, assigned_at, arrived_at, updated_at`
+
+func scanTransportDispatch(row pgx.Row) (*models.TransportDispatch, error) {
+	var dispatch models.TransportDispatch
+
+	err := row.Scan(
+		&dispatch.ID,
+		&dispatch.ReferralID,
+		&dispatch.Provider,
+		&dispatch.ProviderRef,
+		&dispatch.Status,
+		&dispatch.VehicleType,
+		&dispatch.DriverName,
+		&dispatch.DriverPhone,
+		&dispatch.ETAMinutes,
+		&dispatch.FailureReason,
+		&dispatch.RequestedAt,
+		&dispatch.AssignedAt,
+		&dispatch.ArrivedAt,
+		&dispatch.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dispatch, nil
+}
+
+// Create logs a transport request before the provider is called. It
+// returns nil if transport was already requested for the referral, unless
+// that request was logged before staleBefore and never got a provider
+// reference: the call that logged it then failed or was cut short, so the
+// request is claimed again. Claiming refreshes requested_at, so concurrent
+// redeliveries claim it once.
+func (r *TransportRepository) Create(ctx context.Context, referralID uuid.UUID, provider string, staleBefore time.Time) (*models.TransportDispatch, error) {
+	query := `
+		INSERT INTO transport_dispatches (referral_id, provider)
+		VALUES ($1, $2)
+		ON CONFLICT (referral_id) DO UPDATE
+		SET provider = EXCLUDED.provider, requested_at = CURRENT_TIMESTAMP
+		WHERE transport_dispatches.status = 'requested' AND transport_dispatches.provider_ref IS NULL
+			AND transport_dispatches.requested_at < $3
+		RETURNING ` + transportDispatchColumns
+
+	dispatch, err := scanTransportDispatch(r.db.QueryRow(ctx, query, referralID, provider, staleBefore))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error creating transport dispatch for referral %s: %v", referralID, err)
+		return nil, fmt.Errorf("failed to create transport dispatch: %w", err)
+	}
+
+	return dispatch, nil
+}
+
+// SetBooking records the provider's reference for a requested dispatch
+func (r *TransportRepository) SetBooking(ctx context.Context, id uuid.UUID, booking *models.TransportBooking) (*models.TransportDispatch, error) {
+	query := `
+		UPDATE transport_dispatches SET provider_ref = $2, vehicle_type = $3
+		WHERE id = $1
+		RETURNING ` + transportDispatchColumns
+
+	dispatch, err := scanTransportDispatch(r.db.QueryRow(ctx, query, id, booking.ProviderRef, booking.VehicleType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record transport booking: %w", err)
+	}
+	return dispatch, nil
+}
+
+// SetFailed marks a dispatch the provider refused
+func (r *TransportRepository) SetFailed(ctx context.Context, id uuid.UUID, reason string) (*models.TransportDispatch, error) {
+	query := `
+		UPDATE transport_dispatches SET status = 'failed', failure_reason = $2
+		WHERE id = $1
+		RETURNING ` + transportDispatchColumns
+
+	dispatch, err := scanTransportDispatch(r.db.QueryRow(ctx, query, id, reason))
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark transport dispatch failed: %w", err)
+	}
+	return dispatch, nil
+}
+
+// Reopen clears a failed dispatch so transport can be requested again, or
+// logs one for a referral that never had transport requested. A request
+// logged before staleBefore that never got a provider reference is reopened
+// too, since the call that made it has given up. A dispatch still in
+// progress returns ErrValidation and an unknown referral ErrNotFound.
+func (r *TransportRepository) Reopen(ctx context.Context, referralID uuid.UUID, provider string, staleBefore time.Time) (*models.TransportDispatch, error) {
+	query := `
+		INSERT INTO transport_dispatches (referral_id, provider)
+		VALUES ($1, $2)
+		ON CONFLICT (referral_id) DO UPDATE
+		SET provider = EXCLUDED.provider, status = 'requested', provider_ref = NULL,
+			vehicle_type = NULL, driver_name = NULL, driver_phone = NULL, eta_minutes = NULL,
+			failure_reason = NULL, requested_at = CURRENT_TIMESTAMP, assigned_at = NULL, arrived_at = NULL
+		WHERE transport_dispatches.status = 'failed'
+			OR (transport_dispatches.status = 'requested' AND transport_dispatches.provider_ref IS NULL
+				AND transport_dispatches.requested_at < $3)
+		RETURNING ` + transportDispatchColumns
+
+	dispatch, err := scanTransportDispatch(r.db.QueryRow(ctx, query, referralID, provider, staleBefore))
+	if isForeignKeyViolation(err) {
+		return nil, ErrNotFound
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		existing, getErr := r.GetByReferral(ctx, referralID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return nil, fmt.Errorf("%w: transport is already %s", ErrValidation, existing.Status)
+	}
+	if err != nil {
+		log.Printf("Error reopening transport dispatch for referral %s: %v", referralID, err)
+		return nil, fmt.Errorf("failed to reopen transport dispatch: %w", err)
+	}
+
+	return dispatch, nil
+}
+
+// ApplyUpdate records a status report from a provider. An unknown reference
+// returns ErrNotFound and an out-of-order report returns ErrValidation.
+// Fields the report leaves out keep their values.
+func (r *TransportRepository) ApplyUpdate(ctx context.Context, provider string, update *models.TransportUpdate) (*models.TransportDispatch, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	var current models.DispatchStatus
+	err = tx.QueryRow(ctx, `
+		SELECT id, status FROM transport_dispatches
+		WHERE provider = $1 AND provider_ref = $2
+		FOR UPDATE`, provider, update.ProviderRef).Scan(&id, &current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock transport dispatch: %w", err)
+	}
+	if err := current.CheckTransition(update.Status); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	query := `
+		UPDATE transport_dispatches
+		SET status = $2,
+			vehicle_type = COALESCE($3, vehicle_type),
+			driver_name = COALESCE($4, driver_name),
+			driver_phone = COALESCE($5, driver_phone),
+			eta_minutes = CASE WHEN $2 = 'assigned' THEN COALESCE($6, eta_minutes) ELSE NULL END,
+			failure_reason = CASE WHEN $2 = 'failed' THEN $7 ELSE failure_reason END,
+			assigned_at = CASE WHEN $2 = 'assigned' THEN COALESCE(assigned_at, CURRENT_TIMESTAMP) ELSE assigned_at END,
+			arrived_at = CASE WHEN $2 = 'arrived' THEN CURRENT_TIMESTAMP ELSE arrived_at END
+		WHERE id = $1
+		RETURNING ` + transportDispatchColumns
+
+	dispatch, err := scanTransportDispatch(tx.QueryRow(ctx, query,
+		id,
+		update.Status,
+		update.VehicleType,
+		update.DriverName,
+		update.DriverPhone,
+		update.ETAMinutes,
+		update.Reason,
+	))
+	if err != nil {
+		log.Printf("Error updating transport dispatch %s to %s: %v", id, update.Status, err)
+		return nil, fmt.Errorf("failed to update transport dispatch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transport dispatch: %w", err)
+	}
+
+	return dispatch, nil
+}
+
+// GetByReferral returns ErrNotFound if no transport was requested
+func (r *TransportRepository) GetByReferral(ctx context.Context, referralID uuid.UUID) (*models.TransportDispatch, error) {
+	query := `SELECT ` + transportDispatchColumns + ` FROM transport_dispatches WHERE referral_id = $1`
+
+	dispatch, err := scanTransportDispatch(r.db.QueryRow(ctx, query, referralID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transport dispatch: %w", err)
+	}
+
+	return dispatch, nil
+}
+
+// GetRequest assembles what a provider needs to collect the patient on a
+// referral: their phone, their household's location if known, and the
+// facility to take them to
+func (r *TransportRepository) GetRequest(ctx context.Context, referralID uuid.UUID) (*models.TransportRequest, error) {
+	req := models.TransportRequest{ReferralID: referralID}
+
+	err := r.db.QueryRow(ctx, `
+		SELECT r.referral_token, COALESCE(p.phone, ''), h.latitude::float8, h.longitude::float8,
+			f.name, f.latitude::float8, f.longitude::float8
+		FROM referrals r
+		JOIN facilities f ON f.id = r.facility_id
+		LEFT JOIN patients p ON p.id = r.patient_id
+		LEFT JOIN household_members hm ON hm.patient_id = r.patient_id
+		LEFT JOIN households h ON h.id = hm.household_id
+		WHERE r.id = $1`, referralID).Scan(
+		&req.ReferralToken,
+		&req.PatientPhone,
+		&req.PickupLatitude,
+		&req.PickupLongitude,
+		&req.FacilityName,
+		&req.FacilityLatitude,
+		&req.FacilityLongitude,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transport request: %w", err)
+	}
+
+	return &req, nil
+}
@@ -15,6 +15,7 @@ import (
 // TriageRepositoryInterface defines the interface for triage operations
 type TriageRepositoryInterface interface {
 	Create(ctx context.Context, req *models.CreateTriageRequest) (*models.TriageSession, error)
+	CreateWithVerdict(ctx context.Context, req *models.CreateTriageRequest, verdict *models.TriageVerdict, llmResponse map[string]interface{}) (*models.TriageSession, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.TriageSession, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status models.TriageStatus) error
 	UpdateTriageResult(ctx context.Context, id uuid.UUID, level models.TriageLevel, code string, confidence float64, action string, llmResponse map[string]interface{}) error
@@ -29,24 +30,17 @@ func NewTriageRepository(db *pgxpool.Pool) *TriageRepository {
 	return &TriageRepository{db: db}
 }
 
-func (r *TriageRepository) Create(ctx context.Context, req *models.CreateTriageRequest) (*models.TriageSession, error) {
-	symptomsJSON, err := json.Marshal(req.Symptoms)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal symptoms: %w", err)
-	}
-
-	query := `
-		INSERT INTO triage_sessions (patient_id, symptoms, channel)
-		VALUES ($1, $2, $3)
-		RETURNING id, patient_id, symptoms, summary_text, triage_level, triage_code, 
-				  confidence, recommended_action, llm_response, channel, created_at, updated_at
-	`
+const triageSessionColumns = `
+			id, patient_id, symptoms, summary_text, triage_level, triage_code,
+			confidence, recommended_action, llm_response, channel, bundle_version,
+			device_verdict, created_at, updated_at`
 
+func scanTriageSession(row pgx.Row) (*models.TriageSession, error) {
 	var session models.TriageSession
-	var symptomsRaw, llmResponseRaw []byte
+	var symptomsRaw, llmResponseRaw, deviceVerdictRaw []byte
 	var triageLevelStr *string
 
-	err = r.db.QueryRow(ctx, query, req.PatientID, symptomsJSON, req.Channel).Scan(
+	err := row.Scan(
 		&session.ID,
 		&session.PatientID,
 		&symptomsRaw,
@@ -57,16 +51,15 @@ func (r *TriageRepository) Create(ctx context.Context, req *models.CreateTriageR
 		&session.RecommendedAction,
 		&llmResponseRaw,
 		&session.Channel,
+		&session.BundleVersion,
+		&deviceVerdictRaw,
 		&session.CreatedAt,
 		&session.UpdatedAt,
 	)
-
 	if err != nil {
-		log.Printf("Error creating triage session: %v", err)
-		return nil, fmt.Errorf("failed to create triage session: %w", err)
+		return nil, err
 	}
 
-	// Parse JSON fields
 	if err := json.Unmarshal(symptomsRaw, &session.Symptoms); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal symptoms: %w", err)
 	}
@@ -77,71 +70,92 @@ func (r *TriageRepository) Create(ctx context.Context, req *models.CreateTriageR
 		}
 	}
 
+	if deviceVerdictRaw != nil {
+		if err := json.Unmarshal(deviceVerdictRaw, &session.DeviceVerdict); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal device_verdict: %w", err)
+		}
+	}
+
 	if triageLevelStr != nil {
 		level := models.TriageLevel(*triageLevelStr)
 		session.TriageLevel = &level
 	}
 
-	// Set default status as queued
 	session.Status = models.TriageStatusQueued
 
 	return &session, nil
 }
 
-func (r *TriageRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.TriageSession, error) {
+func (r *TriageRepository) Create(ctx context.Context, req *models.CreateTriageRequest) (*models.TriageSession, error) {
+	symptomsJSON, err := json.Marshal(req.Symptoms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal symptoms: %w", err)
+	}
+
 	query := `
-		SELECT id, patient_id, symptoms, summary_text, triage_level, triage_code,
-			   confidence, recommended_action, llm_response, channel, created_at, updated_at
-		FROM triage_sessions
-		WHERE id = $1
-	`
+		INSERT INTO triage_sessions (patient_id, symptoms, channel)
+		VALUES (` + currentPatientID("$1") + `, $2, $3)
+		RETURNING ` + triageSessionColumns
 
-	var session models.TriageSession
-	var symptomsRaw, llmResponseRaw []byte
-	var triageLevelStr *string
+	session, err := scanTriageSession(r.db.QueryRow(ctx, query, req.PatientID, symptomsJSON, req.Channel))
+	if err != nil {
+		log.Printf("Error creating triage session: %v", err)
+		return nil, fmt.Errorf("failed to create triage session: %w", err)
+	}
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&session.ID,
-		&session.PatientID,
-		&symptomsRaw,
-		&session.SummaryText,
-		&triageLevelStr,
-		&session.TriageCode,
-		&session.Confidence,
-		&session.RecommendedAction,
-		&llmResponseRaw,
-		&session.Channel,
-		&session.CreatedAt,
-		&session.UpdatedAt,
-	)
+	return session, nil
+}
 
-	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("triage session not found")
+// CreateWithVerdict creates a session already settled by the rulebook, so a
+// red flag is never left queued if the result fails to save
+func (r *TriageRepository) CreateWithVerdict(ctx context.Context, req *models.CreateTriageRequest, verdict *models.TriageVerdict, llmResponse map[string]interface{}) (*models.TriageSession, error) {
+	symptomsJSON, err := json.Marshal(req.Symptoms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal symptoms: %w", err)
 	}
+
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		log.Printf("Error getting triage session: %v", err)
-		return nil, fmt.Errorf("failed to get triage session: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	// Parse JSON fields
-	if err := json.Unmarshal(symptomsRaw, &session.Symptoms); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal symptoms: %w", err)
+	query := `
+		INSERT INTO triage_sessions (patient_id, symptoms, channel)
+		VALUES (` + currentPatientID("$1") + `, $2, $3)
+		RETURNING id`
+
+	var id uuid.UUID
+	if err := tx.QueryRow(ctx, query, req.PatientID, symptomsJSON, req.Channel).Scan(&id); err != nil {
+		log.Printf("Error creating triage session: %v", err)
+		return nil, fmt.Errorf("failed to create triage session: %w", err)
 	}
 
-	if llmResponseRaw != nil && string(llmResponseRaw) != "null" {
-		if err := json.Unmarshal(llmResponseRaw, &session.LLMResponse); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal llm_response: %w", err)
-		}
+	session, err := recordTriageResult(ctx, tx, id, verdict.TriageLevel, verdict.TriageCode, 1, verdict.RecommendedAction, llmResponse)
+	if err != nil {
+		return nil, err
 	}
 
-	if triageLevelStr != nil {
-		level := models.TriageLevel(*triageLevelStr)
-		session.TriageLevel = &level
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit triage session: %w", err)
 	}
 
-	session.Status = models.TriageStatusQueued
+	return session, nil
+}
 
-	return &session, nil
+func (r *TriageRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.TriageSession, error) {
+	query := `SELECT ` + triageSessionColumns + ` FROM triage_sessions WHERE id = $1`
+
+	session, err := scanTriageSession(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("triage session not found")
+	}
+	if err != nil {
+		log.Printf("Error getting triage session: %v", err)
+		return nil, fmt.Errorf("failed to get triage session: %w", err)
+	}
+
+	return session, nil
 }
 
 func (r *TriageRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.TriageStatus) error {
@@ -161,9 +175,29 @@ func (r *TriageRepository) UpdateStatus(ctx context.Context, id uuid.UUID, statu
 }
 
 func (r *TriageRepository) UpdateTriageResult(ctx context.Context, id uuid.UUID, level models.TriageLevel, code string, confidence float64, action string, llmResponse map[string]interface{}) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := recordTriageResult(ctx, tx, id, level, code, confidence, action, llmResponse); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit triage result: %w", err)
+	}
+
+	return nil
+}
+
+// recordTriageResult saves the result of a session and records it as
+// completed in the outbox, inside the caller's transaction
+func recordTriageResult(ctx context.Context, tx pgx.Tx, id uuid.UUID, level models.TriageLevel, code string, confidence float64, action string, llmResponse map[string]interface{}) (*models.TriageSession, error) {
 	llmResponseJSON, err := json.Marshal(llmResponse)
 	if err != nil {
-		return fmt.Errorf("failed to marshal llm_response: %w", err)
+		return nil, fmt.Errorf("failed to marshal llm_response: %w", err)
 	}
 
 	query := `
@@ -171,27 +205,31 @@ func (r *TriageRepository) UpdateTriageResult(ctx context.Context, id uuid.UUID,
 		SET triage_level = $1, triage_code = $2, confidence = $3, 
 		    recommended_action = $4, llm_response = $5, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $6
-	`
+		RETURNING ` + triageSessionColumns
 
-	result, err := r.db.Exec(ctx, query, level, code, confidence, action, llmResponseJSON, id)
+	session, err := scanTriageSession(tx.QueryRow(ctx, query, level, code, confidence, action, llmResponseJSON, id))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("triage session not found")
+	}
 	if err != nil {
 		log.Printf("Error updating triage result: %v", err)
-		return fmt.Errorf("failed to update triage result: %w", err)
+		return nil, fmt.Errorf("failed to update triage result: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("triage session not found")
+	// Status is not stored, so scanning reads every session as queued
+	session.Status = models.TriageStatusCompleted
+	if err := recordEvent(ctx, tx, models.EventTriageCompleted, session.ID, session); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return session, nil
 }
 
 func (r *TriageRepository) GetByPatientID(ctx context.Context, patientID uuid.UUID, limit int) ([]*models.TriageSession, error) {
 	query := `
-		SELECT id, patient_id, symptoms, summary_text, triage_level, triage_code,
-			   confidence, recommended_action, llm_response, channel, created_at, updated_at
+		SELECT ` + triageSessionColumns + `
 		FROM triage_sessions
-		WHERE patient_id = $1
+		WHERE patient_id = ` + currentPatientID("$1") + `
 		ORDER BY created_at DESC
 		LIMIT $2
 	`
@@ -206,49 +244,13 @@ func (r *TriageRepository) GetByPatientID(ctx context.Context, patientID uuid.UU
 	var sessions []*models.TriageSession
 
 	for rows.Next() {
-		var session models.TriageSession
-		var symptomsRaw, llmResponseRaw []byte
-		var triageLevelStr *string
-
-		err := rows.Scan(
-			&session.ID,
-			&session.PatientID,
-			&symptomsRaw,
-			&session.SummaryText,
-			&triageLevelStr,
-			&session.TriageCode,
-			&session.Confidence,
-			&session.RecommendedAction,
-			&llmResponseRaw,
-			&session.Channel,
-			&session.CreatedAt,
-			&session.UpdatedAt,
-		)
-
+		session, err := scanTriageSession(rows)
 		if err != nil {
 			log.Printf("Error scanning triage session: %v", err)
 			return nil, fmt.Errorf("failed to scan triage session: %w", err)
 		}
 
-		// Parse JSON fields
-		if err := json.Unmarshal(symptomsRaw, &session.Symptoms); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal symptoms: %w", err)
-		}
-
-		if llmResponseRaw != nil && string(llmResponseRaw) != "null" {
-			if err := json.Unmarshal(llmResponseRaw, &session.LLMResponse); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal llm_response: %w", err)
-			}
-		}
-
-		if triageLevelStr != nil {
-			level := models.TriageLevel(*triageLevelStr)
-			session.TriageLevel = &level
-		}
-
-		session.Status = models.TriageStatusQueued
-
-		sessions = append(sessions, &session)
+		sessions = append(sessions, session)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -256,4 +258,4 @@ func (r *TriageRepository) GetByPatientID(ctx context.Context, patientID uuid.UU
 	}
 
 	return sessions, nil
-}
\ No newline at end of file
+}
@@ -23,7 +23,14 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// Create registers a user under the E.164 form of phone. A number that is
+// already registered returns ErrDuplicate
 func (r *UserRepository) Create(ctx context.Context, phone string, role models.UserRole) (*models.User, error) {
+	phone, err := normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		INSERT INTO users (phone, role)
 		VALUES ($1, $2)
@@ -31,7 +38,7 @@ func (r *UserRepository) Create(ctx context.Context, phone string, role models.U
 	`
 
 	var user models.User
-	err := r.db.QueryRow(ctx, query, phone, role).Scan(
+	err = r.db.QueryRow(ctx, query, phone, role).Scan(
 		&user.ID,
 		&user.Phone,
 		&user.Name,
@@ -45,6 +52,9 @@ func (r *UserRepository) Create(ctx context.Context, phone string, role models.U
 		&user.UpdatedAt,
 	)
 
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicate
+	}
 	if err != nil {
 		log.Printf("Error creating user: %v", err)
 		return nil, fmt.Errorf("failed to create user: %w", err)
@@ -86,15 +96,19 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	return &user, nil
 }
 
+// GetByPhone finds a user by phone number written in any accepted format
 func (r *UserRepository) GetByPhone(ctx context.Context, phone string) (*models.User, error) {
+	keys := phoneLookupKeys(phone)
 	query := `
 		SELECT id, phone, name, email, role, patient_id, clinician_id, is_active, last_login_at, created_at, updated_at
 		FROM users
-		WHERE phone = $1
+		WHERE phone = ANY($1)
+		ORDER BY phone = $2 DESC
+		LIMIT 1
 	`
 
 	var user models.User
-	err := r.db.QueryRow(ctx, query, phone).Scan(
+	err := r.db.QueryRow(ctx, query, keys, keys[0]).Scan(
 		&user.ID,
 		&user.Phone,
 		&user.Name,
@@ -0,0 +1,114 @@
+package rulebook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// Bundle is everything the CHV app needs to triage and refer offline in one
+// county. Version changes whenever any part of the content does, so it
+// doubles as the ETag.
+type Bundle struct {
+	Version         string             `json:"version"`
+	RulebookVersion string             `json:"rulebook_version"`
+	County          string             `json:"county"`
+	Rulebook        *Rulebook          `json:"rulebook"`
+	Facilities      []*models.Facility `json:"facilities"`
+	Templates       Templates          `json:"templates"`
+}
+
+// NewBundle assembles a bundle and derives its version from the content:
+// the rulebook version followed by a content hash, e.g. "2026.10.1-3fa2c1d9e0ab"
+func NewBundle(book *Rulebook, templates Templates, county string, facilities []*models.Facility) (*Bundle, error) {
+	bundle := &Bundle{
+		RulebookVersion: book.Version,
+		County:          county,
+		Rulebook:        book,
+		Facilities:      facilities,
+		Templates:       templates,
+	}
+
+	content, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	bundle.Version = book.Version + "-" + hex.EncodeToString(sum[:6])
+
+	return bundle, nil
+}
+
+// Signer signs bundles with an Ed25519 key. Devices hold the public key and
+// check the signature before unpacking a bundle.
+type Signer struct {
+	key   ed25519.PrivateKey
+	KeyID string
+}
+
+// NewSigner loads a signer from a base64-encoded 32-byte Ed25519 seed
+func NewSigner(seed string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil || len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key must be a base64-encoded %d-byte Ed25519 seed", ed25519.SeedSize)
+	}
+	return newSigner(ed25519.NewKeyFromSeed(raw)), nil
+}
+
+// GenerateSigner returns a signer with a new random key
+func GenerateSigner() (*Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	return newSigner(key), nil
+}
+
+func newSigner(key ed25519.PrivateKey) *Signer {
+	sum := sha256.Sum256(key.Public().(ed25519.PublicKey))
+	return &Signer{key: key, KeyID: hex.EncodeToString(sum[:8])}
+}
+
+// PublicKey returns the base64-encoded public key
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Pack gzips the bundle's JSON and signs the compressed bytes. The signature
+// is returned base64-encoded.
+func (s *Signer) Pack(bundle *Bundle) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(bundle); err != nil {
+		return nil, "", fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to compress bundle: %w", err)
+	}
+
+	body := buf.Bytes()
+	signature := ed25519.Sign(s.key, body)
+
+	return body, base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks a signature from Pack against a base64-encoded public key
+func Verify(publicKey string, body []byte, signature string) bool {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(key, body, sig)
+}
@@ -0,0 +1,216 @@
+// Package rulebook holds the red-flag triage rules and the message templates
+// shared by the server and the CHV app. The server applies the rules when a
+// triage session is created; the app gets the same rules in a signed bundle
+// so it can triage offline.
+package rulebook
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+//go:embed rulebook.json
+var rulebookJSON []byte
+
+//go:embed templates.json
+var templatesJSON []byte
+
+// Condition tests one symptom. With Above or Below the symptom must be a
+// number beyond that bound; otherwise it must be present and truthy.
+type Condition struct {
+	Symptom string   `json:"symptom"`
+	Above   *float64 `json:"above,omitempty"`
+	Below   *float64 `json:"below,omitempty"`
+}
+
+// Rule flags a set of symptoms. It matches when every All condition holds
+// and, if there are any, at least one Any condition does.
+type Rule struct {
+	Code      string             `json:"code"`
+	Condition string             `json:"condition"`
+	Level     models.TriageLevel `json:"level"`
+	All       []Condition        `json:"all,omitempty"`
+	Any       []Condition        `json:"any,omitempty"`
+	Action    string             `json:"action"`
+}
+
+type Rulebook struct {
+	Version string `json:"version"`
+	// Symptoms documents the symptom keys the rules read
+	Symptoms map[string]string `json:"symptoms"`
+	Rules    []Rule            `json:"rules"`
+}
+
+// Templates are message texts by key and then language. Placeholders are
+// written {{name}}.
+type Templates map[string]map[string]string
+
+var (
+	loadOnce         sync.Once
+	defaultRulebook  *Rulebook
+	defaultTemplates Templates
+)
+
+// Default returns the rulebook built into the server
+func Default() *Rulebook {
+	load()
+	return defaultRulebook
+}
+
+// DefaultTemplates returns the message templates built into the server
+func DefaultTemplates() Templates {
+	load()
+	return defaultTemplates
+}
+
+func load() {
+	loadOnce.Do(func() {
+		var err error
+		if defaultRulebook, err = Parse(rulebookJSON); err != nil {
+			panic(fmt.Sprintf("embedded rulebook: %v", err))
+		}
+		if defaultTemplates, err = ParseTemplates(templatesJSON); err != nil {
+			panic(fmt.Sprintf("embedded templates: %v", err))
+		}
+	})
+}
+
+// Parse reads and validates a rulebook
+func Parse(data []byte) (*Rulebook, error) {
+	var book Rulebook
+	if err := json.Unmarshal(data, &book); err != nil {
+		return nil, err
+	}
+	if book.Version == "" {
+		return nil, fmt.Errorf("version is required")
+	}
+
+	codes := map[string]bool{}
+	for _, rule := range book.Rules {
+		if rule.Code == "" || codes[rule.Code] {
+			return nil, fmt.Errorf("rule codes must be set and unique: %q", rule.Code)
+		}
+		codes[rule.Code] = true
+
+		if rule.Level != models.TriageLevelRed && rule.Level != models.TriageLevelYellow {
+			return nil, fmt.Errorf("rule %s: level must be red or yellow", rule.Code)
+		}
+		if len(rule.All)+len(rule.Any) == 0 {
+			return nil, fmt.Errorf("rule %s has no conditions", rule.Code)
+		}
+		for _, condition := range append(append([]Condition{}, rule.All...), rule.Any...) {
+			if _, ok := book.Symptoms[condition.Symptom]; !ok {
+				return nil, fmt.Errorf("rule %s: undocumented symptom %q", rule.Code, condition.Symptom)
+			}
+		}
+	}
+
+	return &book, nil
+}
+
+// ParseTemplates reads templates and checks every one is available in each
+// supported language
+func ParseTemplates(data []byte) (Templates, error) {
+	var templates Templates
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, err
+	}
+
+	for key, texts := range templates {
+		for _, language := range models.SupportedLanguages {
+			if texts[language] == "" {
+				return nil, fmt.Errorf("template %s has no %s text", key, language)
+			}
+		}
+	}
+
+	return templates, nil
+}
+
+// Render fills in a template's placeholders. Languages without a text fall
+// back to the default language; unknown keys render as "".
+func (t Templates) Render(key, language string, values map[string]string) string {
+	text, ok := t[key][language]
+	if !ok {
+		text = t[key][models.DefaultLanguage]
+	}
+	for name, value := range values {
+		text = strings.ReplaceAll(text, "{{"+name+"}}", value)
+	}
+	return text
+}
+
+// Evaluate returns the verdict of the most severe rule the symptoms match,
+// or nil when there are no red flags. Among rules of the same level the
+// first listed wins.
+func (b *Rulebook) Evaluate(symptoms map[string]interface{}) *models.TriageVerdict {
+	var match *Rule
+	for i := range b.Rules {
+		rule := &b.Rules[i]
+		if !rule.matches(symptoms) {
+			continue
+		}
+		if match == nil || (rule.Level == models.TriageLevelRed && match.Level != models.TriageLevelRed) {
+			match = rule
+		}
+	}
+	if match == nil {
+		return nil
+	}
+
+	return &models.TriageVerdict{
+		TriageLevel:       match.Level,
+		TriageCode:        match.Code,
+		RecommendedAction: match.Action,
+	}
+}
+
+func (r *Rule) matches(symptoms map[string]interface{}) bool {
+	for _, condition := range r.All {
+		if !condition.holds(symptoms) {
+			return false
+		}
+	}
+	if len(r.Any) == 0 {
+		return true
+	}
+	for _, condition := range r.Any {
+		if condition.holds(symptoms) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Condition) holds(symptoms map[string]interface{}) bool {
+	value, ok := symptoms[c.Symptom]
+	if !ok {
+		return false
+	}
+
+	if c.Above != nil || c.Below != nil {
+		number, ok := value.(float64)
+		if !ok {
+			return false
+		}
+		return (c.Above == nil || number > *c.Above) && (c.Below == nil || number < *c.Below)
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "yes", "true", "ndiyo":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package rulebook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+func TestEvaluate(t *testing.T) {
+	book := Default()
+
+	t.Run("Red flag", func(t *testing.T) {
+		verdict := book.Evaluate(map[string]interface{}{"fever": true, "temperature_c": 39.6})
+		require.NotNil(t, verdict)
+		assert.Equal(t, models.TriageLevelRed, verdict.TriageLevel)
+		assert.Equal(t, "FEVER_DANGER_SIGNS", verdict.TriageCode)
+		assert.NotEmpty(t, verdict.RecommendedAction)
+	})
+
+	t.Run("Yellow flag", func(t *testing.T) {
+		verdict := book.Evaluate(map[string]interface{}{"muac_cm": 11.2})
+		require.NotNil(t, verdict)
+		assert.Equal(t, models.TriageLevelYellow, verdict.TriageLevel)
+		assert.Equal(t, "ACUTE_MALNUTRITION", verdict.TriageCode)
+	})
+
+	t.Run("Red beats yellow", func(t *testing.T) {
+		verdict := book.Evaluate(map[string]interface{}{"muac_cm": 11.2, "cough": true, "chest_indrawing": "ndiyo"})
+		require.NotNil(t, verdict)
+		assert.Equal(t, "COUGH_DANGER_SIGNS", verdict.TriageCode)
+	})
+
+	t.Run("All conditions must hold", func(t *testing.T) {
+		assert.Nil(t, book.Evaluate(map[string]interface{}{"vaginal_bleeding": true}))
+		assert.NotNil(t, book.Evaluate(map[string]interface{}{"pregnant": true, "vaginal_bleeding": true}))
+	})
+
+	t.Run("No red flags", func(t *testing.T) {
+		assert.Nil(t, book.Evaluate(map[string]interface{}{"fever": true, "temperature_c": 38.2}))
+		assert.Nil(t, book.Evaluate(map[string]interface{}{"fever": true, "convulsions": false}))
+	})
+}
+
+func TestParse(t *testing.T) {
+	_, err := Parse([]byte(`{"version": "1", "symptoms": {}, "rules": [{"code": "X", "level": "red", "any": [{"symptom": "fever"}]}]}`))
+	assert.Error(t, err, "undocumented symptom")
+
+	_, err = Parse([]byte(`{"version": "1", "symptoms": {"fever": "boolean"}, "rules": [{"code": "X", "level": "green", "any": [{"symptom": "fever"}]}]}`))
+	assert.Error(t, err, "green is not a flag")
+
+	_, err = ParseTemplates([]byte(`{"referral_red": {"en": "Go now"}}`))
+	assert.Error(t, err, "missing Swahili text")
+}
+
+func TestBundle(t *testing.T) {
+	county := "Kisumu"
+	facilities := []*models.Facility{{ID: uuid.New(), Name: "Kisumu County Referral Hospital", County: &county}}
+
+	first, err := NewBundle(Default(), DefaultTemplates(), "Kisumu", facilities)
+	require.NoError(t, err)
+	again, err := NewBundle(Default(), DefaultTemplates(), "Kisumu", facilities)
+	require.NoError(t, err)
+	assert.Equal(t, first.Version, again.Version)
+	assert.Contains(t, first.Version, Default().Version+"-")
+
+	facilities = append(facilities, &models.Facility{ID: uuid.New(), Name: "Ahero Sub-County Hospital", County: &county})
+	changed, err := NewBundle(Default(), DefaultTemplates(), "Kisumu", facilities)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Version, changed.Version)
+
+	t.Run("Pack and verify", func(t *testing.T) {
+		signer, err := GenerateSigner()
+		require.NoError(t, err)
+
+		body, signature, err := signer.Pack(first)
+		require.NoError(t, err)
+		assert.True(t, Verify(signer.PublicKey(), body, signature))
+
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		var unpacked Bundle
+		require.NoError(t, json.NewDecoder(reader).Decode(&unpacked))
+		assert.Equal(t, first.Version, unpacked.Version)
+
+		tampered := append([]byte{}, body...)
+		tampered[len(tampered)-1] ^= 0xff
+		assert.False(t, Verify(signer.PublicKey(), tampered, signature))
+
+		other, err := GenerateSigner()
+		require.NoError(t, err)
+		assert.False(t, Verify(other.PublicKey(), body, signature))
+	})
+
+	t.Run("Signer from seed", func(t *testing.T) {
+		seed := "c2VlZHNlZWRzZWVkc2VlZHNlZWRzZWVkc2VlZHNlZWQ="
+		a, err := NewSigner(seed)
+		require.NoError(t, err)
+		b, err := NewSigner(seed)
+		require.NoError(t, err)
+		assert.Equal(t, a.KeyID, b.KeyID)
+
+		_, err = NewSigner("c2hvcnQ=")
+		assert.Error(t, err)
+	})
+}
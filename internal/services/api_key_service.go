@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+)
+
+// API keys look like "dmh_1a2b3c4d.<secret>". The part before the dot is the
+// prefix, stored in clear so keys can be identified in logs and admin lists.
+const apiKeyPrefixTag = "dmh_"
+
+// lastUsedGranularity limits how often last_used_at is written for a busy key
+const lastUsedGranularity = time.Minute
+
+type APIKeyService struct {
+	apiKeyRepo *repository.APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+// Create issues a new API key. The plaintext key is only available in the returned response.
+func (s *APIKeyService) Create(ctx context.Context, req *models.CreateAPIKeyRequest, createdBy *uuid.UUID) (*models.CreateAPIKeyResponse, error) {
+	for _, scope := range req.Scopes {
+		if !scope.IsValid() {
+			return nil, fmt.Errorf("%w: invalid scope: %s", repository.ErrValidation, scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", repository.ErrValidation)
+	}
+
+	prefix, secret, err := GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.apiKeyRepo.Create(ctx, req, prefix, HashAPIKeySecret(secret), createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreateAPIKeyResponse{
+		APIKey: key,
+		Key:    prefix + "." + secret,
+	}, nil
+}
+
+// Validate checks a raw API key and returns the stored key if it is usable
+func (s *APIKeyService) Validate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	prefix, secret, err := ParseAPIKey(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.apiKeyRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api key")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(HashAPIKeySecret(secret))) != 1 {
+		return nil, fmt.Errorf("invalid api key")
+	}
+
+	if key.IsRevoked() {
+		return nil, fmt.Errorf("api key revoked")
+	}
+
+	if key.IsExpired() {
+		return nil, fmt.Errorf("api key expired")
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedGranularity {
+		if err := s.apiKeyRepo.UpdateLastUsed(ctx, key.ID, now); err != nil {
+			// Tracking failures must not lock partners out
+			log.Printf("Warning: failed to track API key usage: %v", err)
+		}
+		key.LastUsedAt = &now
+	}
+
+	return key, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, facilityID *uuid.UUID) ([]*models.APIKey, error) {
+	return s.apiKeyRepo.List(ctx, facilityID)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
+	return s.apiKeyRepo.Revoke(ctx, id)
+}
+
+// GenerateAPIKey returns a new random prefix and secret
+func GenerateAPIKey() (string, string, error) {
+	prefixBytes := make([]byte, 4)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key prefix: %w", err)
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key secret: %w", err)
+	}
+
+	return apiKeyPrefixTag + hex.EncodeToString(prefixBytes), base64.RawURLEncoding.EncodeToString(secretBytes), nil
+}
+
+// ParseAPIKey splits a raw key into its prefix and secret
+func ParseAPIKey(rawKey string) (string, string, error) {
+	prefix, secret, found := strings.Cut(rawKey, ".")
+	if !found || !strings.HasPrefix(prefix, apiKeyPrefixTag) || secret == "" {
+		return "", "", fmt.Errorf("malformed api key")
+	}
+	return prefix, secret, nil
+}
+
+// HashAPIKeySecret returns the hex SHA-256 digest stored for a key secret
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAndParseAPIKey(t *testing.T) {
+	prefix, secret, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(prefix, "dmh_"))
+	assert.Len(t, prefix, 12)
+	assert.NotEmpty(t, secret)
+
+	gotPrefix, gotSecret, err := ParseAPIKey(prefix + "." + secret)
+	assert.NoError(t, err)
+	assert.Equal(t, prefix, gotPrefix)
+	assert.Equal(t, secret, gotSecret)
+}
+
+func TestParseAPIKeyMalformed(t *testing.T) {
+	for _, raw := range []string{"", "dmh_1234", "abc_1234.secret", "dmh_1234."} {
+		_, _, err := ParseAPIKey(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestHashAPIKeySecret(t *testing.T) {
+	hash := HashAPIKeySecret("secret")
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashAPIKeySecret("secret"))
+	assert.NotEqual(t, hash, HashAPIKeySecret("other"))
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/rulebook"
+)
+
+// AppointmentStore finds upcoming appointments and logs reminders and
+// replies; AppointmentRepository satisfies it
+type AppointmentStore interface {
+	ListUpcoming(ctx context.Context, now time.Time) ([]*models.UpcomingAppointment, error)
+	FindForReply(ctx context.Context, phone string, now time.Time) (*models.UpcomingAppointment, error)
+	ClaimReminder(ctx context.Context, appointmentID uuid.UUID, kind models.AppointmentReminderKind, recipient *string) (uuid.UUID, bool, error)
+	SetReminderSMSStatus(ctx context.Context, id uuid.UUID, status models.SMSStatus) error
+	MarkNoShows(ctx context.Context, before time.Time) (int64, error)
+	RecordInbound(ctx context.Context, msg *models.InboundSMS, appointmentID *uuid.UUID, action models.AppointmentReply, status models.AppointmentStatus) (*models.Appointment, bool, error)
+}
+
+// AppointmentService texts patients before their appointments, applies
+// their YES/NO replies, and marks appointments nobody came to as no_show
+type AppointmentService struct {
+	store       AppointmentStore
+	sms         SMSSender
+	templates   rulebook.Templates
+	noShowAfter time.Duration
+	now         func() time.Time
+}
+
+func NewAppointmentService(store AppointmentStore, sms SMSSender, noShowAfter time.Duration) *AppointmentService {
+	if noShowAfter <= 0 {
+		noShowAfter = models.DefaultNoShowAfter
+	}
+	return &AppointmentService{store: store, sms: sms, templates: rulebook.DefaultTemplates(), noShowAfter: noShowAfter, now: time.Now}
+}
+
+// Tick marks missed appointments as no_show, then sends the reminders that
+// are due. The server runs it as a scheduled job. A reminder that fails to
+// send is not retried, since its claim has been logged.
+func (s *AppointmentService) Tick(ctx context.Context) error {
+	now := s.now()
+
+	marked, err := s.store.MarkNoShows(ctx, now.Add(-s.noShowAfter))
+	if err != nil {
+		return err
+	}
+	if marked > 0 {
+		log.Printf("Marked %d appointments as no_show", marked)
+	}
+
+	upcoming, err := s.store.ListUpcoming(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, appointment := range upcoming {
+		kind, ok := appointment.DueReminder(now)
+		if !ok {
+			continue
+		}
+		if err := s.remind(ctx, appointment, kind); err != nil {
+			log.Printf("Error sending %s for appointment %s: %v", kind, appointment.Appointment.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *AppointmentService) remind(ctx context.Context, upcoming *models.UpcomingAppointment, kind models.AppointmentReminderKind) error {
+	var recipient *string
+	if upcoming.PatientPhone != "" {
+		recipient = &upcoming.PatientPhone
+	}
+
+	id, claimed, err := s.store.ClaimReminder(ctx, upcoming.Appointment.ID, kind, recipient)
+	if err != nil || !claimed {
+		return err
+	}
+
+	status := models.SMSStatusSkipped
+	if recipient != nil && !upcoming.SMSConsentRefused {
+		status = s.text(ctx, upcoming, "appointment_"+string(kind), "reminder")
+	}
+
+	return s.store.SetReminderSMSStatus(ctx, id, status)
+}
+
+// HandleReply applies an SMS reply to the sender's next appointment and
+// texts them back. Replies that are not a keyword, or that come from a
+// number with no upcoming appointment, are logged and otherwise ignored.
+func (s *AppointmentService) HandleReply(ctx context.Context, msg *models.InboundSMS) (models.AppointmentReply, error) {
+	reply := models.ParseAppointmentReply(msg.Text)
+
+	var upcoming *models.UpcomingAppointment
+	if reply != models.AppointmentReplyUnknown {
+		var err error
+		upcoming, err = s.store.FindForReply(ctx, msg.From, s.now())
+		if err != nil {
+			return "", err
+		}
+		if upcoming == nil {
+			reply = models.AppointmentReplyUnknown
+		}
+	}
+
+	// The reply is recorded and applied together, so a gateway retry after
+	// a failure is applied rather than dropped as a duplicate
+	var appointmentID *uuid.UUID
+	var status models.AppointmentStatus
+	template := "appointment_cancelled"
+	if upcoming != nil {
+		appointmentID = &upcoming.Appointment.ID
+		status = models.AppointmentStatusCancelled
+		if reply == models.AppointmentReplyConfirm {
+			template = "appointment_confirmed"
+			status = models.AppointmentStatusConfirmed
+		}
+		if upcoming.Appointment.Status == status {
+			status = ""
+		}
+	}
+
+	appointment, recorded, err := s.store.RecordInbound(ctx, msg, appointmentID, reply, status)
+	if err != nil {
+		return "", err
+	}
+	if !recorded || upcoming == nil {
+		return reply, nil
+	}
+	if status != "" {
+		if appointment == nil {
+			// Cancelled or closed since it was found; there is nothing to confirm
+			return reply, nil
+		}
+		upcoming.Appointment = appointment
+	}
+
+	s.text(ctx, upcoming, template, "reply")
+	return reply, nil
+}
+
+func (s *AppointmentService) text(ctx context.Context, upcoming *models.UpcomingAppointment, template, what string) models.SMSStatus {
+	body := s.templates.Render(template, upcoming.PatientLanguage, map[string]string{
+		"facility_name": upcoming.FacilityName,
+		"time":          upcoming.Appointment.ScheduledTime.In(models.FacilityTimezone).Format("2/1/2006 15:04"),
+	})
+
+	err := s.sms.Send(ctx, upcoming.PatientPhone, body)
+	status := smsStatus(err)
+	if status == models.SMSStatusFailed {
+		log.Printf("Error sending appointment %s SMS for %s: %v", what, upcoming.Appointment.ID, err)
+	}
+	return status
+}
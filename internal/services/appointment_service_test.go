@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type fakeAppointmentStore struct {
+	upcoming      []*models.UpcomingAppointment
+	claimed       map[models.AppointmentReminderKind]uuid.UUID
+	smsStatus     map[uuid.UUID]models.SMSStatus
+	noShowsBefore time.Time
+	statuses      map[uuid.UUID]models.AppointmentStatus
+	inbound       map[string]models.AppointmentReply
+}
+
+func (f *fakeAppointmentStore) ListUpcoming(ctx context.Context, now time.Time) ([]*models.UpcomingAppointment, error) {
+	return f.upcoming, nil
+}
+
+func (f *fakeAppointmentStore) FindForReply(ctx context.Context, phone string, now time.Time) (*models.UpcomingAppointment, error) {
+	for _, upcoming := range f.upcoming {
+		if upcoming.PatientPhone == phone {
+			return upcoming, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeAppointmentStore) ClaimReminder(ctx context.Context, appointmentID uuid.UUID, kind models.AppointmentReminderKind, recipient *string) (uuid.UUID, bool, error) {
+	if f.claimed == nil {
+		f.claimed = map[models.AppointmentReminderKind]uuid.UUID{}
+	}
+	if _, taken := f.claimed[kind]; taken {
+		return uuid.Nil, false, nil
+	}
+	f.claimed[kind] = uuid.New()
+	return f.claimed[kind], true, nil
+}
+
+func (f *fakeAppointmentStore) SetReminderSMSStatus(ctx context.Context, id uuid.UUID, status models.SMSStatus) error {
+	if f.smsStatus == nil {
+		f.smsStatus = map[uuid.UUID]models.SMSStatus{}
+	}
+	f.smsStatus[id] = status
+	return nil
+}
+
+func (f *fakeAppointmentStore) MarkNoShows(ctx context.Context, before time.Time) (int64, error) {
+	f.noShowsBefore = before
+	return 0, nil
+}
+
+func (f *fakeAppointmentStore) RecordInbound(ctx context.Context, msg *models.InboundSMS, appointmentID *uuid.UUID, action models.AppointmentReply, status models.AppointmentStatus) (*models.Appointment, bool, error) {
+	if f.inbound == nil {
+		f.inbound = map[string]models.AppointmentReply{}
+	}
+	if _, seen := f.inbound[msg.ProviderID]; seen {
+		return nil, false, nil
+	}
+	f.inbound[msg.ProviderID] = action
+	if appointmentID == nil || status == "" {
+		return nil, true, nil
+	}
+
+	if f.statuses == nil {
+		f.statuses = map[uuid.UUID]models.AppointmentStatus{}
+	}
+	f.statuses[*appointmentID] = status
+	for _, upcoming := range f.upcoming {
+		if upcoming.Appointment.ID == *appointmentID {
+			updated := *upcoming.Appointment
+			updated.Status = status
+			return &updated, true, nil
+		}
+	}
+	return nil, true, nil
+}
+
+func TestAppointmentTick(t *testing.T) {
+	slot := time.Date(2026, 3, 3, 9, 30, 0, 0, models.FacilityTimezone)
+	upcoming := func() *models.UpcomingAppointment {
+		return &models.UpcomingAppointment{
+			Appointment:     &models.Appointment{ID: uuid.New(), ScheduledTime: slot, Status: models.AppointmentStatusScheduled},
+			FacilityName:    "Ahero Sub-County Hospital",
+			PatientPhone:    "+254712345678",
+			PatientLanguage: "sw",
+		}
+	}
+	newService := func(store *fakeAppointmentStore, sms *fakeSMSSender, at time.Time) *AppointmentService {
+		service := NewAppointmentService(store, sms, 90*time.Minute)
+		service.now = func() time.Time { return at }
+		return service
+	}
+
+	t.Run("Day-before reminder in the patient's language", func(t *testing.T) {
+		store, sms := &fakeAppointmentStore{upcoming: []*models.UpcomingAppointment{upcoming()}}, &fakeSMSSender{}
+		now := slot.Add(-20 * time.Hour)
+		service := newService(store, sms, now)
+
+		require.NoError(t, service.Tick(context.Background()))
+		assert.Equal(t, now.Add(-90*time.Minute), store.noShowsBefore)
+		message := sms.sent["+254712345678"]
+		assert.Contains(t, message, "Kumbusho")
+		assert.Contains(t, message, "3/3/2026 09:30")
+		assert.Equal(t, models.SMSStatusSent, store.smsStatus[store.claimed[models.AppointmentReminder24h]])
+
+		// A second tick finds the reminder already claimed
+		sms.sent = nil
+		require.NoError(t, service.Tick(context.Background()))
+		assert.Empty(t, sms.sent)
+	})
+
+	t.Run("Patient who refused SMS is skipped but logged", func(t *testing.T) {
+		refused := upcoming()
+		refused.SMSConsentRefused = true
+		store, sms := &fakeAppointmentStore{upcoming: []*models.UpcomingAppointment{refused}}, &fakeSMSSender{}
+
+		require.NoError(t, newService(store, sms, slot.Add(-time.Hour)).Tick(context.Background()))
+		assert.Empty(t, sms.sent)
+		assert.Equal(t, models.SMSStatusSkipped, store.smsStatus[store.claimed[models.AppointmentReminder2h]])
+	})
+}
+
+func TestAppointmentHandleReply(t *testing.T) {
+	slot := time.Date(2026, 3, 3, 9, 30, 0, 0, models.FacilityTimezone)
+	appointment := &models.UpcomingAppointment{
+		Appointment:     &models.Appointment{ID: uuid.New(), ScheduledTime: slot, Status: models.AppointmentStatusScheduled},
+		FacilityName:    "Ahero Sub-County Hospital",
+		PatientPhone:    "+254712345678",
+		PatientLanguage: "en",
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		store, sms := &fakeAppointmentStore{upcoming: []*models.UpcomingAppointment{appointment}}, &fakeSMSSender{}
+		service := NewAppointmentService(store, sms, 0)
+		msg := &models.InboundSMS{ProviderID: "ATXid_1", From: "+254712345678", Text: "no, sorry"}
+
+		reply, err := service.HandleReply(context.Background(), msg)
+		require.NoError(t, err)
+		assert.Equal(t, models.AppointmentReplyCancel, reply)
+		assert.Equal(t, models.AppointmentStatusCancelled, store.statuses[appointment.Appointment.ID])
+		assert.Contains(t, sms.sent["+254712345678"], "cancelled")
+
+		// The gateway retrying the same message changes nothing
+		sms.sent, store.statuses = nil, nil
+		_, err = service.HandleReply(context.Background(), msg)
+		require.NoError(t, err)
+		assert.Empty(t, sms.sent)
+		assert.Empty(t, store.statuses)
+	})
+
+	t.Run("Unknown text is logged without a reply", func(t *testing.T) {
+		store, sms := &fakeAppointmentStore{upcoming: []*models.UpcomingAppointment{appointment}}, &fakeSMSSender{}
+		service := NewAppointmentService(store, sms, 0)
+
+		reply, err := service.HandleReply(context.Background(), &models.InboundSMS{ProviderID: "ATXid_2", From: "+254712345678", Text: "Habari"})
+		require.NoError(t, err)
+		assert.Equal(t, models.AppointmentReplyUnknown, reply)
+		assert.Equal(t, models.AppointmentReplyUnknown, store.inbound["ATXid_2"])
+		assert.Empty(t, sms.sent)
+	})
+
+	t.Run("No appointment for the number", func(t *testing.T) {
+		store, sms := &fakeAppointmentStore{}, &fakeSMSSender{}
+		service := NewAppointmentService(store, sms, 0)
+
+		reply, err := service.HandleReply(context.Background(), &models.InboundSMS{ProviderID: "ATXid_3", From: "+254700000000", Text: "YES"})
+		require.NoError(t, err)
+		assert.Equal(t, models.AppointmentReplyUnknown, reply)
+		assert.Empty(t, sms.sent)
+	})
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/jobs"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// JobDeliverEvent is the kind of job that delivers one event to one subscriber
+const JobDeliverEvent = "event_delivery"
+
+const (
+	// eventBatchSize is how many events are claimed from the outbox at once
+	eventBatchSize = 100
+	// eventDeliveryAttempts gives a subscriber about five hours of backoff
+	// to recover before its delivery is dead-lettered
+	eventDeliveryAttempts = 12
+)
+
+// EventOutbox hands out recorded events; OutboxRepository satisfies it
+type EventOutbox interface {
+	Publish(ctx context.Context, limit int, deliver func(ctx context.Context, events []*models.DomainEvent) error) (int, error)
+}
+
+// EventHandler receives one event. It may see the same event more than
+// once and should return an error only when a retry could help; wrap it
+// with jobs.Permanent otherwise.
+type EventHandler func(ctx context.Context, event *models.DomainEvent) error
+
+type eventSubscription struct {
+	handler EventHandler
+	types   map[models.EventType]bool
+}
+
+// eventDelivery is the payload of a JobDeliverEvent job
+type eventDelivery struct {
+	Subscriber string              `json:"subscriber"`
+	Event      *models.DomainEvent `json:"event"`
+}
+
+// EventDispatcher moves events from the outbox onto the job queue, one job
+// per event and subscriber, and runs those jobs. Each subscriber retries on
+// its own, so a failing webhook does not hold up transport requests.
+type EventDispatcher struct {
+	outbox        EventOutbox
+	queue         jobs.Queue
+	subscriptions map[string]*eventSubscription
+	names         []string
+}
+
+func NewEventDispatcher(outbox EventOutbox, queue jobs.Queue) *EventDispatcher {
+	return &EventDispatcher{outbox: outbox, queue: queue, subscriptions: map[string]*eventSubscription{}}
+}
+
+// Subscribe registers a handler for the given event types, or for every
+// type if none are given. The name keys queued deliveries, so it must stay
+// the same between releases. Call it before Run.
+func (d *EventDispatcher) Subscribe(name string, handler EventHandler, types ...models.EventType) {
+	sub := &eventSubscription{handler: handler}
+	if len(types) > 0 {
+		sub.types = map[models.EventType]bool{}
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	if _, ok := d.subscriptions[name]; !ok {
+		d.names = append(d.names, name)
+	}
+	d.subscriptions[name] = sub
+}
+
+// Register has the worker run event deliveries
+func (d *EventDispatcher) Register(worker *jobs.Worker) {
+	worker.Handle(JobDeliverEvent, d.Deliver)
+}
+
+// Run calls Tick every interval until the context is cancelled. A full
+// batch is followed at once by the next, so a backlog drains quickly.
+func (d *EventDispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		published, err := d.Tick(ctx)
+		if err != nil {
+			log.Printf("Error publishing events: %v", err)
+		}
+		if err == nil && published == eventBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick publishes a batch of events from the outbox, queueing a delivery
+// for each subscriber that wants them. If queueing fails the batch stays in
+// the outbox and is tried again; deliveries already queued carry a unique
+// key, so on the Postgres queue they are not queued twice.
+func (d *EventDispatcher) Tick(ctx context.Context) (int, error) {
+	return d.outbox.Publish(ctx, eventBatchSize, func(ctx context.Context, events []*models.DomainEvent) error {
+		for _, event := range events {
+			for _, name := range d.names {
+				if types := d.subscriptions[name].types; types != nil && !types[event.Type] {
+					continue
+				}
+
+				_, err := d.queue.Enqueue(ctx, &jobs.NewJob{
+					Kind:        JobDeliverEvent,
+					Payload:     &eventDelivery{Subscriber: name, Event: event},
+					MaxAttempts: eventDeliveryAttempts,
+					UniqueKey:   fmt.Sprintf("event:%s:%s", event.ID, name),
+				})
+				if err != nil {
+					return fmt.Errorf("failed to queue %s for %s: %w", event.Type, name, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Deliver runs a JobDeliverEvent job. Deliveries to a subscriber that is no
+// longer registered are dead-lettered.
+func (d *EventDispatcher) Deliver(ctx context.Context, job *jobs.Job) error {
+	var delivery eventDelivery
+	if err := job.Decode(&delivery); err != nil {
+		return err
+	}
+	if delivery.Event == nil {
+		return jobs.Permanent(fmt.Errorf("event delivery to %s has no event", delivery.Subscriber))
+	}
+
+	sub, ok := d.subscriptions[delivery.Subscriber]
+	if !ok {
+		return jobs.Permanent(fmt.Errorf("no subscriber named %s", delivery.Subscriber))
+	}
+
+	return sub.handler(ctx, delivery.Event)
+}
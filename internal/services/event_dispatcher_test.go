@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/jobs"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type fakeEventOutbox struct {
+	events    []*models.DomainEvent
+	published map[uuid.UUID]bool
+}
+
+func (f *fakeEventOutbox) Publish(ctx context.Context, limit int, deliver func(ctx context.Context, events []*models.DomainEvent) error) (int, error) {
+	var batch []*models.DomainEvent
+	for _, event := range f.events {
+		if !f.published[event.ID] && len(batch) < limit {
+			batch = append(batch, event)
+		}
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := deliver(ctx, batch); err != nil {
+		return 0, err
+	}
+	for _, event := range batch {
+		f.published[event.ID] = true
+	}
+	return len(batch), nil
+}
+
+// fakeJobQueue keeps jobs in memory, honouring unique keys like the
+// Postgres queue
+type fakeJobQueue struct {
+	jobs    []*jobs.Job
+	keys    map[string]bool
+	failing bool
+}
+
+func newFakeJobQueue() *fakeJobQueue {
+	return &fakeJobQueue{keys: map[string]bool{}}
+}
+
+func (f *fakeJobQueue) Enqueue(ctx context.Context, newJob *jobs.NewJob) (*jobs.Job, error) {
+	if f.failing {
+		return nil, errors.New("queue unavailable")
+	}
+	if newJob.UniqueKey != "" {
+		if f.keys[newJob.UniqueKey] {
+			return nil, nil
+		}
+		f.keys[newJob.UniqueKey] = true
+	}
+	payload, err := json.Marshal(newJob.Payload)
+	if err != nil {
+		return nil, err
+	}
+	job := &jobs.Job{ID: uuid.New(), Kind: newJob.Kind, Payload: payload, Status: jobs.StatusQueued, MaxAttempts: newJob.MaxAttempts, RunAt: time.Now()}
+	f.jobs = append(f.jobs, job)
+	return job, nil
+}
+
+func (f *fakeJobQueue) Reserve(ctx context.Context, worker string, kinds []string) (*jobs.Job, error) {
+	for _, job := range f.jobs {
+		if job.Status == jobs.StatusQueued && !job.RunAt.After(time.Now()) {
+			job.Status = jobs.StatusRunning
+			job.Attempts++
+			return job, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeJobQueue) Complete(ctx context.Context, job *jobs.Job) error {
+	job.Status = jobs.StatusSucceeded
+	return nil
+}
+
+func (f *fakeJobQueue) RetryLater(ctx context.Context, job *jobs.Job, cause error, at time.Time) error {
+	message := cause.Error()
+	job.Status, job.LastError, job.RunAt = jobs.StatusQueued, &message, at
+	return nil
+}
+
+func (f *fakeJobQueue) DeadLetter(ctx context.Context, job *jobs.Job, cause error) error {
+	message := cause.Error()
+	job.Status, job.LastError = jobs.StatusDead, &message
+	return nil
+}
+
+func TestEventDispatcher(t *testing.T) {
+	created := &models.DomainEvent{ID: uuid.New(), Type: models.EventReferralCreated, AggregateID: uuid.New(), Payload: json.RawMessage(`{}`)}
+	booked := &models.DomainEvent{ID: uuid.New(), Type: models.EventAppointmentBooked, AggregateID: uuid.New(), Payload: json.RawMessage(`{}`)}
+
+	newDispatcher := func(queue *fakeJobQueue) (*EventDispatcher, *fakeEventOutbox, map[string][]uuid.UUID) {
+		outbox := &fakeEventOutbox{events: []*models.DomainEvent{created, booked}, published: map[uuid.UUID]bool{}}
+		received := map[string][]uuid.UUID{}
+		dispatcher := NewEventDispatcher(outbox, queue)
+		dispatcher.Subscribe("referrals", func(ctx context.Context, event *models.DomainEvent) error {
+			received["referrals"] = append(received["referrals"], event.ID)
+			return nil
+		}, models.EventReferralCreated)
+		dispatcher.Subscribe("everything", func(ctx context.Context, event *models.DomainEvent) error {
+			received["everything"] = append(received["everything"], event.ID)
+			return nil
+		})
+		return dispatcher, outbox, received
+	}
+	work := func(t *testing.T, dispatcher *EventDispatcher, queue *fakeJobQueue) {
+		worker := jobs.NewWorker(queue, "test-1")
+		dispatcher.Register(worker)
+		for {
+			worked, err := worker.Work(context.Background())
+			require.NoError(t, err)
+			if !worked {
+				return
+			}
+		}
+	}
+
+	t.Run("Each subscriber gets the events it asked for", func(t *testing.T) {
+		queue := newFakeJobQueue()
+		dispatcher, outbox, received := newDispatcher(queue)
+
+		published, err := dispatcher.Tick(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, published)
+		assert.True(t, outbox.published[created.ID])
+		assert.True(t, outbox.published[booked.ID])
+		require.Len(t, queue.jobs, 3)
+		for _, job := range queue.jobs {
+			assert.Equal(t, JobDeliverEvent, job.Kind)
+			assert.Equal(t, eventDeliveryAttempts, job.MaxAttempts)
+		}
+
+		work(t, dispatcher, queue)
+		assert.Equal(t, []uuid.UUID{created.ID}, received["referrals"])
+		assert.Equal(t, []uuid.UUID{created.ID, booked.ID}, received["everything"])
+	})
+
+	t.Run("Events stay in the outbox until they are queued", func(t *testing.T) {
+		queue := newFakeJobQueue()
+		dispatcher, outbox, _ := newDispatcher(queue)
+
+		queue.failing = true
+		_, err := dispatcher.Tick(context.Background())
+		assert.Error(t, err)
+		assert.Empty(t, outbox.published)
+
+		queue.failing = false
+		published, err := dispatcher.Tick(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, published)
+		assert.Len(t, queue.jobs, 3)
+	})
+
+	t.Run("Republished events are not queued twice", func(t *testing.T) {
+		queue := newFakeJobQueue()
+		dispatcher, outbox, _ := newDispatcher(queue)
+
+		_, err := dispatcher.Tick(context.Background())
+		require.NoError(t, err)
+		// As if the instance died before marking the batch published
+		outbox.published = map[uuid.UUID]bool{}
+		_, err = dispatcher.Tick(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, queue.jobs, 3)
+	})
+
+	t.Run("A failing subscriber is retried on its own", func(t *testing.T) {
+		queue := newFakeJobQueue()
+		dispatcher, _, received := newDispatcher(queue)
+		dispatcher.Subscribe("referrals", func(ctx context.Context, event *models.DomainEvent) error {
+			return errors.New("facility system down")
+		}, models.EventReferralCreated)
+
+		_, err := dispatcher.Tick(context.Background())
+		require.NoError(t, err)
+		work(t, dispatcher, queue)
+
+		assert.Len(t, received["everything"], 2)
+		assert.Equal(t, jobs.StatusQueued, queue.jobs[0].Status)
+		assert.Equal(t, "facility system down", *queue.jobs[0].LastError)
+	})
+
+	t.Run("Deliveries to a removed subscriber are dead-lettered", func(t *testing.T) {
+		queue := newFakeJobQueue()
+		dispatcher, _, _ := newDispatcher(queue)
+		_, err := dispatcher.Tick(context.Background())
+		require.NoError(t, err)
+
+		work(t, NewEventDispatcher(&fakeEventOutbox{}, queue), queue)
+		for _, job := range queue.jobs {
+			assert.Equal(t, jobs.StatusDead, job.Status)
+		}
+	})
+}
+
+func TestWebhookSink(t *testing.T) {
+	event := &models.DomainEvent{ID: uuid.New(), Type: models.EventConsentChanged, AggregateID: uuid.New(), Payload: json.RawMessage(`{"consents":{"research":false}}`)}
+
+	var status int
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	sink := NewWebhookSink(server.URL, "shared-secret")
+
+	t.Run("Events are posted signed", func(t *testing.T) {
+		status = http.StatusNoContent
+		require.NoError(t, sink.Handle(context.Background(), event))
+
+		var posted models.DomainEvent
+		require.NoError(t, json.Unmarshal(body, &posted))
+		assert.Equal(t, event.ID, posted.ID)
+		assert.Equal(t, "consent.changed", header.Get("X-Event-Type"))
+		assert.Equal(t, event.ID.String(), header.Get("X-Event-Id"))
+		assert.Equal(t, sink.Sign(body), header.Get("X-Signature"))
+		assert.NotEqual(t, NewWebhookSink(server.URL, "other-secret").Sign(body), header.Get("X-Signature"))
+	})
+
+	t.Run("Server errors are retried", func(t *testing.T) {
+		status = http.StatusBadGateway
+		err := sink.Handle(context.Background(), event)
+		require.Error(t, err)
+		assert.False(t, jobs.IsPermanent(err))
+	})
+
+	t.Run("Refusals are not retried", func(t *testing.T) {
+		status = http.StatusUnauthorized
+		err := sink.Handle(context.Background(), event)
+		require.Error(t, err)
+		assert.True(t, jobs.IsPermanent(err))
+	})
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/jobs"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// webhookTimeout bounds one POST to an external sink
+const webhookTimeout = 10 * time.Second
+
+// WebhookSink posts events as JSON to an external URL. Each request carries
+// the event type and ID in X-Event-Type and X-Event-Id, and an
+// X-Signature of "sha256=" and the hex HMAC-SHA256 of the body under the
+// shared secret. Receivers should drop event IDs they have already seen,
+// since an event can arrive more than once.
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{url: url, secret: []byte(secret), client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Sign returns the X-Signature value for a body
+func (s *WebhookSink) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handle delivers one event. Server errors, timeouts and 429 are retried;
+// any other refusal means the receiver will never take it, so the delivery
+// is dead-lettered.
+func (s *WebhookSink) Handle(ctx context.Context, event *models.DomainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("failed to encode event: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("failed to build webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", string(event.Type))
+	req.Header.Set("X-Event-Id", event.ID.String())
+	req.Header.Set("X-Signature", s.Sign(body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post %s event: %w", event.Type, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	default:
+		return jobs.Permanent(fmt.Errorf("webhook refused %s event: %s", event.Type, resp.Status))
+	}
+}
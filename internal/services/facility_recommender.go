@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/pagination"
+)
+
+// FacilityFinder is the facility data the recommender needs; FacilityRepository satisfies it
+type FacilityFinder interface {
+	GetNearby(ctx context.Context, lat, lng, radiusKM float64, limit int, after *pagination.Cursor, keep repository.FacilityFilter) ([]*models.NearbyFacility, *pagination.Cursor, error)
+	GetReferralLoad(ctx context.Context, facilityIDs []uuid.UUID) (map[uuid.UUID]int, error)
+}
+
+// ClosureLister supplies public holidays and closures; FacilityClosureRepository satisfies it
+type ClosureLister interface {
+	List(ctx context.Context, from, to time.Time, facilityID *uuid.UUID) ([]*models.FacilityClosure, error)
+}
+
+const (
+	defaultRecommendRadiusKM = 50
+	defaultRecommendLimit    = 5
+	// Upper bound on facilities scored per request
+	maxRecommendCandidates = 100
+)
+
+// Scoring weights. A facility starts at 100 and loses or gains points for each factor.
+const (
+	scoreBase           = 100.0
+	penaltyPerKM        = 1.5
+	penaltyClosed       = 25.0
+	penaltyClosedUrgent = 40.0
+	penaltyHoursUnknown = 10.0
+	penaltyFull         = 35.0
+	penaltyPerStockout  = 5.0
+	penaltyPerReferral  = 2.0
+	maxReferralPenalty  = 30.0
+	bonusPerExtraLevel  = 2.0
+	bonusMpesaPreferred = 10.0
+)
+
+// serviceKeywords maps symptom keywords onto the facility service they call
+// for. Keywords match whole words, in order for phrases; a word ending in *
+// matches any word that starts with it.
+var serviceKeywords = map[string][]string{
+	"maternity": {"pregnan*", "labour", "labor", "contraction*", "antenatal", "obstetric", "miscarriage", "postpartum", "waters broke"},
+	"icu":       {"unconscious", "unresponsive", "seizure*", "convulsion*", "difficulty breathing", "not breathing", "shock"},
+	"surgery":   {"fracture*", "trauma", "accident", "appendic*", "wound*", "burn", "burns", "burnt", "gunshot", "stab", "stabbed", "hernia"},
+}
+
+type FacilityRecommender struct {
+	facilities FacilityFinder
+	closures   ClosureLister
+	status     FacilityStatusLookup
+	now        func() time.Time
+}
+
+func NewFacilityRecommender(facilities FacilityFinder, closures ClosureLister, status FacilityStatusLookup) *FacilityRecommender {
+	return &FacilityRecommender{facilities: facilities, closures: closures, status: status, now: time.Now}
+}
+
+// MinimumFacilityLevel is the lowest KEPH level that can manage a triage level
+func MinimumFacilityLevel(level models.TriageLevel) int {
+	switch level {
+	case models.TriageLevelRed:
+		return 4
+	case models.TriageLevelYellow:
+		return 3
+	default:
+		return models.MinFacilityLevel
+	}
+}
+
+// RequiredServices infers the facility services a case needs from the
+// symptom keys and free-text values recorded in a triage session
+func RequiredServices(symptoms map[string]interface{}) []string {
+	var fields [][]string
+	for key, value := range symptoms {
+		fields = append(fields, symptomWords(key))
+		if s, ok := value.(string); ok {
+			fields = append(fields, symptomWords(s))
+		}
+	}
+
+	var services []string
+	for service, keywords := range serviceKeywords {
+		if anyKeywordIn(fields, keywords) {
+			services = append(services, service)
+		}
+	}
+	sort.Strings(services)
+
+	return services
+}
+
+// symptomWords splits symptom text into lower-case words. Underscores in
+// keys such as difficulty_breathing separate words too.
+func symptomWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func anyKeywordIn(fields [][]string, keywords []string) bool {
+	for _, keyword := range keywords {
+		for _, words := range fields {
+			if containsKeyword(words, strings.Fields(keyword)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsKeyword reports whether the keyword's words appear in a row in words
+func containsKeyword(words, keyword []string) bool {
+	for i := 0; i+len(keyword) <= len(words); i++ {
+		matched := true
+		for j, want := range keyword {
+			if stem, ok := strings.CutSuffix(want, "*"); ok {
+				matched = strings.HasPrefix(words[i+j], stem)
+			} else {
+				matched = words[i+j] == want
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Criteria derives the hard requirements for a completed triage session
+func Criteria(session *models.TriageSession) (*models.RecommendationCriteria, error) {
+	if session.TriageLevel == nil {
+		return nil, fmt.Errorf("triage session has no triage level yet")
+	}
+
+	return &models.RecommendationCriteria{
+		TriageLevel:      *session.TriageLevel,
+		MinimumLevel:     MinimumFacilityLevel(*session.TriageLevel),
+		RequiredServices: RequiredServices(session.Symptoms),
+	}, nil
+}
+
+// Recommend ranks referral-accepting facilities around (lat, lng) for a
+// triage session, best first. Facilities below the minimum level or missing
+// a required service are excluded.
+func (r *FacilityRecommender) Recommend(ctx context.Context, session *models.TriageSession, req *models.RecommendFacilitiesRequest) ([]*models.FacilityRecommendation, *models.RecommendationCriteria, error) {
+	criteria, err := Criteria(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	radius := req.RadiusKM
+	if radius == 0 {
+		radius = defaultRecommendRadiusKM
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultRecommendLimit
+	}
+
+	// Filtering while reading keeps ineligible facilities from using up the
+	// candidate limit
+	keep := func(facility *models.Facility) bool { return meetsCriteria(facility, criteria) }
+	eligible, _, err := r.facilities.GetNearby(ctx, req.Latitude, req.Longitude, radius, maxRecommendCandidates, nil, keep)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(eligible) == 0 {
+		return []*models.FacilityRecommendation{}, criteria, nil
+	}
+
+	ids := make([]uuid.UUID, len(eligible))
+	for i, facility := range eligible {
+		ids[i] = facility.ID
+	}
+	load, err := r.facilities.GetReferralLoad(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	statuses, err := r.status.GetMany(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := r.now()
+	from, to := models.ClosureWindow(now, now)
+	closures, err := r.closures.List(ctx, from, to, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recommendations := make([]*models.FacilityRecommendation, len(eligible))
+	for i, facility := range eligible {
+		facility.SetOpenStatus(now, closures)
+		if status, ok := statuses[facility.ID]; ok {
+			facility.Capacity = status
+			facility.Warnings = status.Warnings(now)
+		}
+		recommendations[i] = ScoreFacility(facility, criteria, load[facility.ID], req.PreferMpesa, now, closures)
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	return recommendations, criteria, nil
+}
+
+func meetsCriteria(facility *models.Facility, criteria *models.RecommendationCriteria) bool {
+	if !facility.AcceptsReferrals {
+		return false
+	}
+	// Facilities without a recorded level are only trusted for non-urgent cases
+	if facility.Level == nil {
+		if criteria.MinimumLevel > models.MinFacilityLevel {
+			return false
+		}
+	} else if *facility.Level < criteria.MinimumLevel {
+		return false
+	}
+
+	for _, required := range criteria.RequiredServices {
+		if !hasService(facility.Services, required) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasService(services []string, service string) bool {
+	for _, s := range services {
+		if strings.EqualFold(s, service) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScoreFacility scores an eligible facility and explains the score
+func ScoreFacility(facility *models.NearbyFacility, criteria *models.RecommendationCriteria, referralLoad int, preferMpesa bool, now time.Time, closures []*models.FacilityClosure) *models.FacilityRecommendation {
+	score := scoreBase - facility.DistanceKM*penaltyPerKM
+
+	var reasons []string
+	if facility.Level != nil {
+		score += float64(*facility.Level-criteria.MinimumLevel) * bonusPerExtraLevel
+		reasons = append(reasons, fmt.Sprintf("Level %d %s", *facility.Level, strings.ReplaceAll(string(facility.Type), "_", " ")))
+	} else {
+		reasons = append(reasons, strings.ReplaceAll(string(facility.Type), "_", " ")+" (level unknown)")
+	}
+	if len(criteria.RequiredServices) > 0 {
+		reasons[0] += " with " + strings.Join(criteria.RequiredServices, " and ")
+	}
+	reasons = append(reasons, fmt.Sprintf("%.1f km away", facility.DistanceKM))
+
+	recommendation := &models.FacilityRecommendation{
+		Facility:     facility,
+		ReferralLoad: referralLoad,
+	}
+
+	open, known := facility.IsOpenAt(now, closures)
+	switch {
+	case !known:
+		score -= penaltyHoursUnknown
+		reasons = append(reasons, "opening hours unknown")
+	case open:
+		recommendation.OpenNow = &open
+		reasons = append(reasons, "open now")
+	default:
+		recommendation.OpenNow = &open
+		if criteria.TriageLevel == models.TriageLevelRed {
+			score -= penaltyClosedUrgent
+		} else {
+			score -= penaltyClosed
+		}
+		reasons = append(reasons, "currently closed")
+	}
+
+	if referralLoad > 0 {
+		penalty := float64(referralLoad) * penaltyPerReferral
+		if penalty > maxReferralPenalty {
+			penalty = maxReferralPenalty
+		}
+		score -= penalty
+		reasons = append(reasons, fmt.Sprintf("%d open referrals", referralLoad))
+	} else {
+		reasons = append(reasons, "no open referrals")
+	}
+
+	if status := facility.Capacity; status != nil {
+		if status.IsFull() {
+			score -= penaltyFull
+			reasons = append(reasons, "no free beds")
+		} else if available := status.BedsAvailable(); available != nil {
+			reasons = append(reasons, fmt.Sprintf("%d beds free", *available))
+		}
+		if len(status.Stockouts) > 0 {
+			score -= float64(len(status.Stockouts)) * penaltyPerStockout
+			stockouts := make([]string, len(status.Stockouts))
+			for i, stockout := range status.Stockouts {
+				stockouts[i] = strings.ReplaceAll(string(stockout), "_", " ")
+			}
+			reasons = append(reasons, "out of "+strings.Join(stockouts, ", "))
+		}
+	}
+
+	if preferMpesa && facility.AcceptsMpesa {
+		score += bonusMpesaPreferred
+		reasons = append(reasons, "accepts M-Pesa")
+	}
+
+	recommendation.Score = math.Round(score*10) / 10
+	recommendation.Reason = strings.Join(reasons, ", ")
+
+	return recommendation
+}
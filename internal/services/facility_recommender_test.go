@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/pagination"
+)
+
+type fakeFacilityFinder struct {
+	facilities []*models.NearbyFacility
+	load       map[uuid.UUID]int
+}
+
+func (f *fakeFacilityFinder) GetNearby(ctx context.Context, lat, lng, radiusKM float64, limit int, after *pagination.Cursor, keep repository.FacilityFilter) ([]*models.NearbyFacility, *pagination.Cursor, error) {
+	var kept []*models.NearbyFacility
+	for _, facility := range f.facilities {
+		if keep == nil || keep(&facility.Facility) {
+			kept = append(kept, facility)
+		}
+	}
+	return kept, nil, nil
+}
+
+func (f *fakeFacilityFinder) GetReferralLoad(ctx context.Context, facilityIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	return f.load, nil
+}
+
+type fakeClosureLister struct {
+	closures []*models.FacilityClosure
+}
+
+func (f *fakeClosureLister) List(ctx context.Context, from, to time.Time, facilityID *uuid.UUID) ([]*models.FacilityClosure, error) {
+	return f.closures, nil
+}
+
+type fakeStatusLookup struct {
+	statuses map[uuid.UUID]*models.FacilityStatus
+}
+
+func (f *fakeStatusLookup) Get(ctx context.Context, facilityID uuid.UUID) (*models.FacilityStatus, error) {
+	return f.statuses[facilityID], nil
+}
+
+func (f *fakeStatusLookup) GetMany(ctx context.Context, facilityIDs []uuid.UUID) (map[uuid.UUID]*models.FacilityStatus, error) {
+	return f.statuses, nil
+}
+
+func nearbyFacility(name string, level int, distanceKM float64, services ...string) *models.NearbyFacility {
+	return &models.NearbyFacility{
+		Facility: models.Facility{
+			ID:               uuid.New(),
+			Name:             name,
+			Type:             models.FacilityTypeCountyHospital,
+			Level:            &level,
+			Services:         services,
+			OperatingHours:   models.OperatingHours{AlwaysOpen: true},
+			AcceptsReferrals: true,
+		},
+		DistanceKM: distanceKM,
+	}
+}
+
+func TestRequiredServices(t *testing.T) {
+	assert.Equal(t, []string{"maternity"}, RequiredServices(map[string]interface{}{"pregnant": true, "bleeding": "heavy"}))
+	assert.Equal(t, []string{"icu", "surgery"}, RequiredServices(map[string]interface{}{"notes": "Road accident, patient unconscious"}))
+	assert.Empty(t, RequiredServices(map[string]interface{}{"fever": true}))
+	assert.Equal(t, []string{"icu"}, RequiredServices(map[string]interface{}{"difficulty_breathing": true}))
+	assert.Equal(t, []string{"maternity"}, RequiredServices(map[string]interface{}{"notes": "Pregnancy, 34 weeks"}))
+
+	// Keywords match whole words, not parts of longer ones
+	tests := []struct {
+		notes string
+		want  []string
+	}{
+		{"Stab wound to the arm", []string{"surgery"}},
+		{"Stable, walking unaided", nil},
+		{"Burn on the left hand", []string{"surgery"}},
+		{"Heartburn after meals", nil},
+		{"Labor pains since morning", []string{"maternity"}},
+		{"Laboratory results pending", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, RequiredServices(map[string]interface{}{"notes": tt.notes}), tt.notes)
+	}
+}
+
+func TestMinimumFacilityLevel(t *testing.T) {
+	assert.Equal(t, 4, MinimumFacilityLevel(models.TriageLevelRed))
+	assert.Equal(t, 3, MinimumFacilityLevel(models.TriageLevelYellow))
+	assert.Equal(t, 2, MinimumFacilityLevel(models.TriageLevelGreen))
+}
+
+func TestRecommend(t *testing.T) {
+	// Monday 10:00 EAT
+	monday := time.Date(2024, 1, 15, 7, 0, 0, 0, time.UTC)
+	red := models.TriageLevelRed
+
+	healthCenter := nearbyFacility("Kamulu Health Center", 3, 2, "maternity")
+	county := nearbyFacility("Makueni County Hospital", 4, 20, "maternity", "icu", "surgery")
+	busy := nearbyFacility("Machakos Level 5", 5, 15, "maternity")
+	noMaternity := nearbyFacility("Kangundo Hospital", 4, 5, "surgery")
+
+	finder := &fakeFacilityFinder{
+		facilities: []*models.NearbyFacility{healthCenter, noMaternity, busy, county},
+		load:       map[uuid.UUID]int{busy.ID: 12},
+	}
+	recommender := NewFacilityRecommender(finder, &fakeClosureLister{}, &fakeStatusLookup{})
+	recommender.now = func() time.Time { return monday }
+
+	session := &models.TriageSession{
+		ID:          uuid.New(),
+		Symptoms:    map[string]interface{}{"pregnant": true, "severe_bleeding": true},
+		TriageLevel: &red,
+	}
+
+	recommendations, criteria, err := recommender.Recommend(context.Background(), session, &models.RecommendFacilitiesRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, criteria.MinimumLevel)
+	assert.Equal(t, []string{"maternity"}, criteria.RequiredServices)
+
+	// The level 3 health center and the hospital without maternity are excluded
+	require.Len(t, recommendations, 2)
+	assert.Equal(t, county.ID, recommendations[0].Facility.ID)
+	assert.Equal(t, busy.ID, recommendations[1].Facility.ID)
+	assert.Equal(t, 12, recommendations[1].ReferralLoad)
+	assert.Contains(t, recommendations[0].Reason, "Level 4 county hospital with maternity")
+	assert.Contains(t, recommendations[0].Reason, "open now")
+	assert.Contains(t, recommendations[1].Reason, "12 open referrals")
+}
+
+func TestRecommendPendingTriage(t *testing.T) {
+	recommender := NewFacilityRecommender(&fakeFacilityFinder{}, &fakeClosureLister{}, &fakeStatusLookup{})
+	_, _, err := recommender.Recommend(context.Background(), &models.TriageSession{}, &models.RecommendFacilitiesRequest{})
+	assert.Error(t, err)
+}
+
+func TestScoreFacility(t *testing.T) {
+	sunday := time.Date(2024, 1, 14, 7, 0, 0, 0, time.UTC)
+	criteria := &models.RecommendationCriteria{TriageLevel: models.TriageLevelGreen, MinimumLevel: 2}
+
+	facility := nearbyFacility("Kamulu Health Center", 3, 4, "outpatient")
+	facility.OperatingHours = models.OperatingHours{Days: map[string][]models.TimeInterval{
+		"monday": {{Opens: "08:00", Closes: "17:00"}},
+	}}
+
+	closed := ScoreFacility(facility, criteria, 0, false, sunday, nil)
+	require.NotNil(t, closed.OpenNow)
+	assert.False(t, *closed.OpenNow)
+	assert.Contains(t, closed.Reason, "currently closed")
+
+	facility.AcceptsMpesa = true
+	mpesa := ScoreFacility(facility, criteria, 0, true, sunday, nil)
+	assert.Equal(t, closed.Score+bonusMpesaPreferred, mpesa.Score)
+	assert.Contains(t, mpesa.Reason, "accepts M-Pesa")
+
+	// A facility closure on Monday counts as closed even within opening hours
+	monday := sunday.AddDate(0, 0, 1)
+	closure := &models.FacilityClosure{FacilityID: &facility.ID, Kind: models.ClosureKindClosure, StartsOn: monday, EndsOn: monday}
+	shut := ScoreFacility(facility, criteria, 0, false, monday, []*models.FacilityClosure{closure})
+	assert.False(t, *shut.OpenNow)
+
+	total, occupied := 20, 20
+	facility.Capacity = &models.FacilityStatus{BedsTotal: &total, BedsOccupied: &occupied, Stockouts: []models.Stockout{models.StockoutLabReagents}}
+	full := ScoreFacility(facility, criteria, 0, false, sunday, nil)
+	assert.Equal(t, closed.Score-penaltyFull-penaltyPerStockout, full.Score)
+	assert.Contains(t, full.Reason, "no free beds")
+	assert.Contains(t, full.Reason, "out of lab reagents")
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/rulebook"
+)
+
+// NotificationStore keeps the in-app inbox; NotificationRepository satisfies it
+type NotificationStore interface {
+	Create(ctx context.Context, userID uuid.UUID, kind models.NotificationKind, referralID *uuid.UUID, body string) (*models.Notification, error)
+	SetSMSStatus(ctx context.Context, id uuid.UUID, status models.SMSStatus) error
+}
+
+// UserLookup loads a user account; UserRepository satisfies it
+type UserLookup interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+}
+
+// PatientContactLookup loads what is needed to text a patient;
+// PatientRepository satisfies it
+type PatientContactLookup interface {
+	GetContact(ctx context.Context, id uuid.UUID) (*models.PatientContact, error)
+}
+
+type NotificationService struct {
+	store     NotificationStore
+	users     UserLookup
+	patients  PatientContactLookup
+	sms       SMSSender
+	templates rulebook.Templates
+}
+
+func NewNotificationService(store NotificationStore, users UserLookup, patients PatientContactLookup, sms SMSSender) *NotificationService {
+	return &NotificationService{store: store, users: users, patients: patients, sms: sms, templates: rulebook.DefaultTemplates()}
+}
+
+// NotifyReferralStatus tells the CHV who made a referral that the facility
+// accepted it, completed it or saw no patient arrive. The message goes to
+// the CHV's inbox and by SMS. It names the facility and referral token but
+// not the patient, since SMS is not private.
+func (s *NotificationService) NotifyReferralStatus(ctx context.Context, referral *models.Referral, facilityName string) (*models.Notification, error) {
+	kind, ok := models.NotificationKindForStatus(referral.Status)
+	if !ok {
+		return nil, nil
+	}
+	return s.notifyReferrer(ctx, kind, referral, facilityName)
+}
+
+// NotifyReferralUnattended asks the CHV who made a referral to follow up
+// because the patient has not reached the facility
+func (s *NotificationService) NotifyReferralUnattended(ctx context.Context, referral *models.Referral, facilityName string) (*models.Notification, error) {
+	return s.notifyReferrer(ctx, models.NotificationReferralUnattended, referral, facilityName)
+}
+
+// NotifyReferralTransferred gives the patient and the referring CHV the
+// new facility and referral token after a referral was forwarded. The
+// patient is texted in their language unless they refused SMS; a failure
+// to reach them is logged and does not stop the CHV being told.
+func (s *NotificationService) NotifyReferralTransferred(ctx context.Context, referral *models.Referral, facilityName string) (*models.Notification, error) {
+	if referral.PatientID != nil {
+		s.textPatient(ctx, *referral.PatientID, "referral_transferred_patient", map[string]string{
+			"referral_token": referral.ReferralToken,
+			"facility_name":  facilityName,
+		})
+	}
+	return s.notifyReferrer(ctx, models.NotificationReferralTransferred, referral, facilityName)
+}
+
+// textPatient sends a templated SMS to a patient who has not refused them
+func (s *NotificationService) textPatient(ctx context.Context, patientID uuid.UUID, template string, values map[string]string) {
+	contact, err := s.patients.GetContact(ctx, patientID)
+	if err != nil {
+		log.Printf("Error loading contact for patient %s: %v", patientID, err)
+		return
+	}
+	if contact.Phone == "" || contact.SMSConsentRefused {
+		return
+	}
+
+	body := s.templates.Render(template, contact.PreferredLanguage, values)
+	if err := s.sms.Send(ctx, contact.Phone, body); smsStatus(err) == models.SMSStatusFailed {
+		log.Printf("Error sending %s SMS to patient %s: %v", template, patientID, err)
+	}
+}
+
+// notifyReferrer sends a referral notification to the CHV who made it.
+// Referrals made by anyone else return nil.
+func (s *NotificationService) notifyReferrer(ctx context.Context, kind models.NotificationKind, referral *models.Referral, facilityName string) (*models.Notification, error) {
+	if referral.CreatedByCHV == nil {
+		return nil, nil
+	}
+
+	user, err := s.users.GetByID(ctx, *referral.CreatedByCHV)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != models.UserRoleCHV {
+		return nil, nil
+	}
+
+	body := s.templates.Render(string(kind), models.DefaultLanguage, map[string]string{
+		"referral_token": referral.ReferralToken,
+		"facility_name":  facilityName,
+	})
+
+	referralID := referral.ID
+	notification, err := s.store.Create(ctx, user.ID, kind, &referralID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	notification.SMSStatus = models.SMSStatusSkipped
+	if user.Phone != "" {
+		err := s.sms.Send(ctx, user.Phone, body)
+		notification.SMSStatus = smsStatus(err)
+		if notification.SMSStatus == models.SMSStatusFailed {
+			log.Printf("Error sending %s SMS for notification %s: %v", kind, notification.ID, err)
+		}
+	}
+
+	if err := s.store.SetSMSStatus(ctx, notification.ID, notification.SMSStatus); err != nil {
+		log.Printf("Error recording SMS status for notification %s: %v", notification.ID, err)
+	}
+
+	return notification, nil
+}
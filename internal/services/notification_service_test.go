@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type fakeNotificationStore struct {
+	created   []*models.Notification
+	smsStatus map[uuid.UUID]models.SMSStatus
+}
+
+func (f *fakeNotificationStore) Create(ctx context.Context, userID uuid.UUID, kind models.NotificationKind, referralID *uuid.UUID, body string) (*models.Notification, error) {
+	notification := &models.Notification{ID: uuid.New(), UserID: userID, Kind: kind, ReferralID: referralID, Body: body, SMSStatus: models.SMSStatusPending}
+	f.created = append(f.created, notification)
+	return notification, nil
+}
+
+func (f *fakeNotificationStore) SetSMSStatus(ctx context.Context, id uuid.UUID, status models.SMSStatus) error {
+	if f.smsStatus == nil {
+		f.smsStatus = map[uuid.UUID]models.SMSStatus{}
+	}
+	f.smsStatus[id] = status
+	return nil
+}
+
+type fakeUserLookup struct {
+	users map[uuid.UUID]*models.User
+}
+
+func (f *fakeUserLookup) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if user, ok := f.users[id]; ok {
+		return user, nil
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+type fakeSMSSender struct {
+	sent map[string]string
+	err  error
+}
+
+func (f *fakeSMSSender) Send(ctx context.Context, phone, message string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.sent == nil {
+		f.sent = map[string]string{}
+	}
+	f.sent[phone] = message
+	return nil
+}
+
+type fakePatientContacts struct {
+	contacts map[uuid.UUID]*models.PatientContact
+}
+
+func (f *fakePatientContacts) GetContact(ctx context.Context, id uuid.UUID) (*models.PatientContact, error) {
+	if contact, ok := f.contacts[id]; ok {
+		return contact, nil
+	}
+	return nil, fmt.Errorf("patient not found")
+}
+
+func TestNotifyReferralStatus(t *testing.T) {
+	chv := &models.User{ID: uuid.New(), Phone: "+254712345678", Role: models.UserRoleCHV}
+	clinician := &models.User{ID: uuid.New(), Phone: "+254722000111", Role: models.UserRoleClinician}
+	users := &fakeUserLookup{users: map[uuid.UUID]*models.User{chv.ID: chv, clinician.ID: clinician}}
+
+	referral := func(status models.ReferralStatus, createdBy *uuid.UUID) *models.Referral {
+		return &models.Referral{ID: uuid.New(), ReferralToken: "REF-7KQ2M9XD", Status: status, CreatedByCHV: createdBy}
+	}
+
+	t.Run("Inbox and SMS", func(t *testing.T) {
+		store, sms := &fakeNotificationStore{}, &fakeSMSSender{}
+		service := NewNotificationService(store, users, &fakePatientContacts{}, sms)
+
+		notification, err := service.NotifyReferralStatus(context.Background(), referral(models.ReferralStatusNoShow, &chv.ID), "Ahero Sub-County Hospital")
+		require.NoError(t, err)
+		require.NotNil(t, notification)
+		assert.Equal(t, models.NotificationReferralNoShow, notification.Kind)
+		assert.Contains(t, notification.Body, "REF-7KQ2M9XD")
+		assert.Contains(t, notification.Body, "Ahero Sub-County Hospital")
+		assert.NotContains(t, notification.Body, "{{")
+		assert.Equal(t, notification.Body, sms.sent[chv.Phone])
+		assert.Equal(t, models.SMSStatusSent, store.smsStatus[notification.ID])
+	})
+
+	t.Run("Failed SMS is recorded", func(t *testing.T) {
+		store := &fakeNotificationStore{}
+		service := NewNotificationService(store, users, &fakePatientContacts{}, &fakeSMSSender{err: errors.New("gateway timeout")})
+
+		notification, err := service.NotifyReferralStatus(context.Background(), referral(models.ReferralStatusAccepted, &chv.ID), "Ahero Sub-County Hospital")
+		require.NoError(t, err)
+		assert.Equal(t, models.SMSStatusFailed, store.smsStatus[notification.ID])
+		assert.Len(t, store.created, 1)
+	})
+
+	t.Run("Logged SMS is not recorded as sent", func(t *testing.T) {
+		store := &fakeNotificationStore{}
+		service := NewNotificationService(store, users, &fakePatientContacts{}, LogSMSSender{})
+
+		notification, err := service.NotifyReferralStatus(context.Background(), referral(models.ReferralStatusAccepted, &chv.ID), "Ahero Sub-County Hospital")
+		require.NoError(t, err)
+		assert.Equal(t, models.SMSStatusLogged, store.smsStatus[notification.ID])
+	})
+
+	t.Run("Only CHV referrers are notified", func(t *testing.T) {
+		store := &fakeNotificationStore{}
+		service := NewNotificationService(store, users, &fakePatientContacts{}, &fakeSMSSender{})
+
+		for _, r := range []*models.Referral{
+			referral(models.ReferralStatusCompleted, &clinician.ID),
+			referral(models.ReferralStatusCompleted, nil),
+			referral(models.ReferralStatusCancelled, &chv.ID),
+		} {
+			notification, err := service.NotifyReferralStatus(context.Background(), r, "Ahero Sub-County Hospital")
+			require.NoError(t, err)
+			assert.Nil(t, notification)
+		}
+		assert.Empty(t, store.created)
+	})
+}
+
+func TestNotifyReferralTransferred(t *testing.T) {
+	chv := &models.User{ID: uuid.New(), Phone: "+254712345678", Role: models.UserRoleCHV}
+	users := &fakeUserLookup{users: map[uuid.UUID]*models.User{chv.ID: chv}}
+	patientID, refusedID := uuid.New(), uuid.New()
+	patients := &fakePatientContacts{contacts: map[uuid.UUID]*models.PatientContact{
+		patientID: {Phone: "+254733444555", PreferredLanguage: "sw"},
+		refusedID: {Phone: "+254733000999", PreferredLanguage: "en", SMSConsentRefused: true},
+	}}
+
+	t.Run("Patient and CHV get the new destination", func(t *testing.T) {
+		store, sms := &fakeNotificationStore{}, &fakeSMSSender{}
+		service := NewNotificationService(store, users, patients, sms)
+		referral := &models.Referral{ID: uuid.New(), PatientID: &patientID, ReferralToken: "REF-9HQ4TZ2K", CreatedByCHV: &chv.ID}
+
+		notification, err := service.NotifyReferralTransferred(context.Background(), referral, "Kisumu County Referral Hospital")
+		require.NoError(t, err)
+		require.NotNil(t, notification)
+		assert.Equal(t, models.NotificationReferralTransferred, notification.Kind)
+		assert.Contains(t, sms.sent[chv.Phone], "Kisumu County Referral Hospital")
+		assert.Contains(t, sms.sent["+254733444555"], "Rufaa yako imehamishiwa Kisumu County Referral Hospital")
+		assert.Contains(t, sms.sent["+254733444555"], "REF-9HQ4TZ2K")
+	})
+
+	t.Run("Patient who refused SMS is not texted", func(t *testing.T) {
+		store, sms := &fakeNotificationStore{}, &fakeSMSSender{}
+		service := NewNotificationService(store, users, patients, sms)
+		referral := &models.Referral{ID: uuid.New(), PatientID: &refusedID, ReferralToken: "REF-9HQ4TZ2K", CreatedByCHV: &chv.ID}
+
+		_, err := service.NotifyReferralTransferred(context.Background(), referral, "Kisumu County Referral Hospital")
+		require.NoError(t, err)
+		assert.NotContains(t, sms.sent, "+254733000999")
+		assert.Contains(t, sms.sent, chv.Phone)
+	})
+}
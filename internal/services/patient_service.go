@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/pkg/phone"
+)
+
+// PatientStore is the patient data the service needs; PatientRepository satisfies it
+type PatientStore interface {
+	Create(ctx context.Context, req *models.CreatePatientRequest) (*models.Patient, error)
+	Search(ctx context.Context, phones []string, name string, dob *time.Time, facilityID, chvUserID *uuid.UUID, limit int) ([]*models.Patient, error)
+	FindDuplicateCandidates(ctx context.Context, phones []string, name string, dob *time.Time, limit int) ([]*models.Patient, error)
+}
+
+var (
+	ErrInvalidPhone         = errors.New("invalid phone number")
+	ErrSearchFilterRequired = errors.New("at least one of phone, name or dob is required")
+	ErrFutureDateOfBirth    = errors.New("date_of_birth cannot be in the future")
+)
+
+// DuplicatePatientError is returned instead of creating a patient that may
+// already be registered. PhoneTaken is set when one of the matches has the
+// same phone number, which ConfirmNew cannot override.
+type DuplicatePatientError struct {
+	Matches    []*models.PatientMatch
+	PhoneTaken bool
+}
+
+func (e *DuplicatePatientError) Error() string {
+	return fmt.Sprintf("%d possible duplicate patients", len(e.Matches))
+}
+
+// Duplicate detection thresholds
+const (
+	// DuplicateScoreThreshold is the lowest score reported as a possible duplicate
+	DuplicateScoreThreshold = 0.6
+	// Name similarity needed alongside a matching date of birth
+	minNameSimilarityWithDOB = 0.5
+	// Name similarity needed when either date of birth is unknown
+	minNameSimilarityWithoutDOB = 0.8
+	maxDuplicateCandidates      = 10
+)
+
+type PatientService struct {
+	patients PatientStore
+}
+
+func NewPatientService(patients PatientStore) *PatientService {
+	return &PatientService{patients: patients}
+}
+
+// Search finds patients by normalized phone, fuzzy name and date of birth
+func (s *PatientService) Search(ctx context.Context, req *models.SearchPatientsRequest) ([]*models.Patient, error) {
+	dob, err := req.ParseDateOfBirth()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrValidation, err)
+	}
+
+	var phones []string
+	if req.Phone != "" {
+		normalized, err := phone.Normalize(req.Phone)
+		if err != nil {
+			return nil, ErrInvalidPhone
+		}
+		phones = phone.Variants(normalized)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if phones == nil && name == "" && dob == nil {
+		return nil, ErrSearchFilterRequired
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = models.DefaultPatientSearchLimit
+	}
+
+	return s.patients.Search(ctx, phones, name, dob, req.FacilityID, req.CHVUserID, limit)
+}
+
+// FindDuplicates scores existing patients against a registration, best match first
+func (s *PatientService) FindDuplicates(ctx context.Context, req *models.CreatePatientRequest) ([]*models.PatientMatch, error) {
+	name := ""
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+	}
+
+	candidates, err := s.patients.FindDuplicateCandidates(ctx, phone.Variants(req.Phone), name, req.DateOfBirth, maxDuplicateCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []*models.PatientMatch{}
+	for _, candidate := range candidates {
+		if match := ScoreDuplicate(req, candidate); match != nil {
+			matches = append(matches, match)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+
+	return matches, nil
+}
+
+// Create registers a patient with a normalized phone number. Possible
+// duplicates are returned as a DuplicatePatientError unless the caller has
+// confirmed the patient is new.
+func (s *PatientService) Create(ctx context.Context, req *models.CreatePatientRequest) (*models.Patient, error) {
+	normalized, err := phone.Normalize(req.Phone)
+	if err != nil {
+		return nil, ErrInvalidPhone
+	}
+	req.Phone = normalized
+	if req.DateOfBirth != nil && req.DateOfBirth.After(time.Now()) {
+		return nil, ErrFutureDateOfBirth
+	}
+
+	matches, err := s.FindDuplicates(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > 0 {
+		phoneTaken := samePhone(req.Phone, matches[0].Patient.Phone)
+		if phoneTaken || !req.ConfirmNew {
+			return nil, &DuplicatePatientError{Matches: matches, PhoneTaken: phoneTaken}
+		}
+	}
+
+	patient, err := s.patients.Create(ctx, req)
+	if errors.Is(err, repository.ErrDuplicate) {
+		// Registered concurrently with the duplicate check
+		return nil, &DuplicatePatientError{Matches: []*models.PatientMatch{}, PhoneTaken: true}
+	}
+
+	return patient, err
+}
+
+// ScoreDuplicate returns how likely candidate is the patient being
+// registered, or nil below DuplicateScoreThreshold. A shared phone number is
+// a certain match; otherwise the name must be similar and the dates of birth
+// must agree, with less confidence when either is unknown.
+func ScoreDuplicate(req *models.CreatePatientRequest, candidate *models.Patient) *models.PatientMatch {
+	if samePhone(req.Phone, candidate.Phone) {
+		return &models.PatientMatch{Patient: candidate, Score: 1, Reasons: []string{"same phone number"}}
+	}
+
+	if req.Name == nil || candidate.Name == nil {
+		return nil
+	}
+	similarity := NameSimilarity(*req.Name, *candidate.Name)
+	reasons := []string{fmt.Sprintf("name %.0f%% similar", similarity*100)}
+
+	var score float64
+	switch {
+	case req.DateOfBirth != nil && candidate.DateOfBirth != nil:
+		if !sameDate(*req.DateOfBirth, *candidate.DateOfBirth) || similarity < minNameSimilarityWithDOB {
+			return nil
+		}
+		score = 0.6 + 0.35*similarity
+		reasons = append(reasons, "same date of birth")
+	default:
+		if similarity < minNameSimilarityWithoutDOB {
+			return nil
+		}
+		score = 0.3 + 0.4*similarity
+		reasons = append(reasons, "date of birth not recorded")
+	}
+
+	score = math.Round(score*100) / 100
+	if score < DuplicateScoreThreshold {
+		return nil
+	}
+
+	return &models.PatientMatch{Patient: candidate, Score: score, Reasons: reasons}
+}
+
+func samePhone(normalized, stored string) bool {
+	if stored == normalized {
+		return true
+	}
+	// Older records hold numbers as typed
+	other, err := phone.Normalize(stored)
+	return err == nil && other == normalized
+}
+
+func sameDate(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
+
+// NameSimilarity compares two names by trigram overlap, like pg_trgm's
+// similarity(), so word order and small spelling differences matter little
+func NameSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
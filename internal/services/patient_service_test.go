@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type fakePatientStore struct {
+	candidates []*models.Patient
+	created    *models.CreatePatientRequest
+	searched   []string
+}
+
+func (f *fakePatientStore) Create(ctx context.Context, req *models.CreatePatientRequest) (*models.Patient, error) {
+	f.created = req
+	return &models.Patient{ID: uuid.New(), Phone: req.Phone, Name: req.Name}, nil
+}
+
+func (f *fakePatientStore) Search(ctx context.Context, phones []string, name string, dob *time.Time, facilityID, chvUserID *uuid.UUID, limit int) ([]*models.Patient, error) {
+	f.searched = phones
+	return []*models.Patient{}, nil
+}
+
+func (f *fakePatientStore) FindDuplicateCandidates(ctx context.Context, phones []string, name string, dob *time.Time, limit int) ([]*models.Patient, error) {
+	return f.candidates, nil
+}
+
+func strPtr(v string) *string { return &v }
+
+func datePtr(year int, month time.Month, day int) *time.Time {
+	d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &d
+}
+
+func TestNameSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, NameSimilarity("Wanjiku Kamau", "wanjiku  KAMAU"))
+	assert.Equal(t, 1.0, NameSimilarity("Kamau Wanjiku", "Wanjiku Kamau"), "word order does not matter")
+	assert.Greater(t, NameSimilarity("Wanjiku Kamau", "Wanjiku Kamua"), 0.5)
+	assert.Less(t, NameSimilarity("Wanjiku Kamau", "Otieno Odhiambo"), 0.1)
+	assert.Equal(t, 0.0, NameSimilarity("", "Wanjiku"))
+}
+
+func TestScoreDuplicate(t *testing.T) {
+	req := &models.CreatePatientRequest{
+		Phone:       "+254712345678",
+		Name:        strPtr("Wanjiku Kamau"),
+		DateOfBirth: datePtr(1990, 5, 14),
+	}
+
+	tests := []struct {
+		name      string
+		candidate *models.Patient
+		wantMatch bool
+		wantScore float64
+	}{
+		{
+			name:      "Same phone stored as typed",
+			candidate: &models.Patient{Phone: "0712345678", Name: strPtr("Someone Else")},
+			wantMatch: true,
+			wantScore: 1,
+		},
+		{
+			name:      "Same name and date of birth",
+			candidate: &models.Patient{Phone: "+254700000001", Name: strPtr("wanjiku kamau"), DateOfBirth: datePtr(1990, 5, 14)},
+			wantMatch: true,
+			wantScore: 0.95,
+		},
+		{
+			name:      "Misspelt name and same date of birth",
+			candidate: &models.Patient{Phone: "+254700000001", Name: strPtr("Wanjiku Kamua"), DateOfBirth: datePtr(1990, 5, 14)},
+			wantMatch: true,
+		},
+		{
+			name:      "Same name, different date of birth",
+			candidate: &models.Patient{Phone: "+254700000001", Name: strPtr("Wanjiku Kamau"), DateOfBirth: datePtr(2012, 1, 3)},
+		},
+		{
+			name:      "Same name, date of birth not recorded",
+			candidate: &models.Patient{Phone: "+254700000001", Name: strPtr("Wanjiku Kamau")},
+			wantMatch: true,
+			wantScore: 0.7,
+		},
+		{
+			name:      "Different name, same date of birth",
+			candidate: &models.Patient{Phone: "+254700000001", Name: strPtr("Achieng Otieno"), DateOfBirth: datePtr(1990, 5, 14)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := ScoreDuplicate(req, tt.candidate)
+			if !tt.wantMatch {
+				assert.Nil(t, match)
+				return
+			}
+			require.NotNil(t, match)
+			assert.GreaterOrEqual(t, match.Score, DuplicateScoreThreshold)
+			if tt.wantScore != 0 {
+				assert.Equal(t, tt.wantScore, match.Score)
+			}
+			assert.NotEmpty(t, match.Reasons)
+		})
+	}
+}
+
+func TestPatientServiceCreate(t *testing.T) {
+	ctx := context.Background()
+	similar := &models.Patient{ID: uuid.New(), Phone: "+254700000001", Name: strPtr("Wanjiku Kamau"), DateOfBirth: datePtr(1990, 5, 14)}
+	samePhone := &models.Patient{ID: uuid.New(), Phone: "0712345678"}
+
+	newRequest := func() *models.CreatePatientRequest {
+		return &models.CreatePatientRequest{Phone: "0712 345 678", Name: strPtr("Wanjiku Kamau"), DateOfBirth: datePtr(1990, 5, 14)}
+	}
+
+	t.Run("Stores normalized phone", func(t *testing.T) {
+		store := &fakePatientStore{}
+		patient, err := NewPatientService(store).Create(ctx, newRequest())
+		require.NoError(t, err)
+		assert.Equal(t, "+254712345678", patient.Phone)
+	})
+
+	t.Run("Invalid phone", func(t *testing.T) {
+		req := newRequest()
+		req.Phone = "12345"
+		_, err := NewPatientService(&fakePatientStore{}).Create(ctx, req)
+		assert.ErrorIs(t, err, ErrInvalidPhone)
+	})
+
+	t.Run("Date of birth in the future", func(t *testing.T) {
+		req := newRequest()
+		tomorrow := time.Now().AddDate(0, 0, 1)
+		req.DateOfBirth = &tomorrow
+		_, err := NewPatientService(&fakePatientStore{}).Create(ctx, req)
+		assert.ErrorIs(t, err, ErrFutureDateOfBirth)
+	})
+
+	t.Run("Similar patient needs confirmation", func(t *testing.T) {
+		store := &fakePatientStore{candidates: []*models.Patient{similar}}
+		_, err := NewPatientService(store).Create(ctx, newRequest())
+
+		var duplicateErr *DuplicatePatientError
+		require.True(t, errors.As(err, &duplicateErr))
+		assert.False(t, duplicateErr.PhoneTaken)
+		require.Len(t, duplicateErr.Matches, 1)
+		assert.Equal(t, similar.ID, duplicateErr.Matches[0].Patient.ID)
+		assert.Nil(t, store.created)
+
+		req := newRequest()
+		req.ConfirmNew = true
+		patient, err := NewPatientService(store).Create(ctx, req)
+		require.NoError(t, err)
+		assert.NotNil(t, patient)
+	})
+
+	t.Run("Registered phone cannot be confirmed", func(t *testing.T) {
+		store := &fakePatientStore{candidates: []*models.Patient{similar, samePhone}}
+		req := newRequest()
+		req.ConfirmNew = true
+		_, err := NewPatientService(store).Create(ctx, req)
+
+		var duplicateErr *DuplicatePatientError
+		require.True(t, errors.As(err, &duplicateErr))
+		assert.True(t, duplicateErr.PhoneTaken)
+		assert.Equal(t, samePhone.ID, duplicateErr.Matches[0].Patient.ID, "phone match ranks first")
+	})
+}
+
+func TestPatientServiceSearch(t *testing.T) {
+	ctx := context.Background()
+	store := &fakePatientStore{}
+	service := NewPatientService(store)
+
+	_, err := service.Search(ctx, &models.SearchPatientsRequest{Phone: "254712345678"})
+	require.NoError(t, err)
+	assert.Contains(t, store.searched, "0712345678", "matches numbers stored as typed")
+
+	_, err = service.Search(ctx, &models.SearchPatientsRequest{})
+	assert.ErrorIs(t, err, ErrSearchFilterRequired)
+
+	_, err = service.Search(ctx, &models.SearchPatientsRequest{Name: "Wanjiku", DateOfBirth: "14/05/1990"})
+	assert.Error(t, err)
+
+	_, err = service.Search(ctx, &models.SearchPatientsRequest{Phone: "not a phone"})
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/rulebook"
+)
+
+// ReminderStore finds pending referrals and logs what was done about them;
+// ReferralReminderRepository satisfies it
+type ReminderStore interface {
+	ListPending(ctx context.Context) ([]*models.PendingReferral, error)
+	Claim(ctx context.Context, referralID uuid.UUID, step models.ReminderStep, recipient *string) (*models.ReferralReminder, error)
+	SetSMSStatus(ctx context.Context, id uuid.UUID, status models.SMSStatus) error
+	Expire(ctx context.Context, referralID uuid.UUID) (bool, error)
+}
+
+// UnattendedNotifier alerts the referring CHV; NotificationService satisfies it
+type UnattendedNotifier interface {
+	NotifyReferralUnattended(ctx context.Context, referral *models.Referral, facilityName string) (*models.Notification, error)
+}
+
+// ReferralReminderService chases referrals the patient has not acted on:
+// it texts the patient, then alerts the CHV, then expires the referral, on
+// the schedule for the referral's priority and county
+type ReferralReminderService struct {
+	store     ReminderStore
+	notifier  UnattendedNotifier
+	sms       SMSSender
+	templates rulebook.Templates
+	now       func() time.Time
+}
+
+func NewReferralReminderService(store ReminderStore, notifier UnattendedNotifier, sms SMSSender) *ReferralReminderService {
+	return &ReferralReminderService{store: store, notifier: notifier, sms: sms, templates: rulebook.DefaultTemplates(), now: time.Now}
+}
+
+// Tick takes every step that is due on the pending referrals. The server
+// runs it as a scheduled job. A step that fails is logged and the rest
+// carry on; it is not retried, since its claim has been logged.
+func (s *ReferralReminderService) Tick(ctx context.Context) error {
+	pending, err := s.store.ListPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	for _, referral := range pending {
+		for _, step := range referral.DueSteps(now) {
+			if err := s.take(ctx, referral, step); err != nil {
+				log.Printf("Error taking %s step %d on referral %s: %v", step.Kind, step.Step, referral.Referral.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *ReferralReminderService) take(ctx context.Context, pending *models.PendingReferral, step models.ReminderStep) error {
+	referral := pending.Referral
+
+	if step.Kind == models.ReminderKindExpired {
+		_, err := s.store.Expire(ctx, referral.ID)
+		return err
+	}
+
+	var recipient *string
+	if step.Kind == models.ReminderKindPatient && pending.PatientPhone != "" {
+		recipient = &pending.PatientPhone
+	}
+
+	reminder, err := s.store.Claim(ctx, referral.ID, step, recipient)
+	if err != nil || reminder == nil {
+		return err
+	}
+
+	status := models.SMSStatusSkipped
+	switch step.Kind {
+	case models.ReminderKindPatient:
+		if recipient != nil && !pending.SMSConsentRefused {
+			status = s.remindPatient(ctx, pending, *recipient)
+		}
+	case models.ReminderKindCHV:
+		notification, err := s.notifier.NotifyReferralUnattended(ctx, referral, pending.FacilityName)
+		if err != nil {
+			status = models.SMSStatusFailed
+			log.Printf("Error alerting CHV about referral %s: %v", referral.ID, err)
+		} else if notification != nil {
+			status = notification.SMSStatus
+		}
+	}
+
+	return s.store.SetSMSStatus(ctx, reminder.ID, status)
+}
+
+func (s *ReferralReminderService) remindPatient(ctx context.Context, pending *models.PendingReferral, phone string) models.SMSStatus {
+	body := s.templates.Render("referral_reminder", pending.PatientLanguage, map[string]string{
+		"referral_token": pending.Referral.ReferralToken,
+		"facility_name":  pending.FacilityName,
+	})
+
+	err := s.sms.Send(ctx, phone, body)
+	status := smsStatus(err)
+	if status == models.SMSStatusFailed {
+		log.Printf("Error sending reminder SMS for referral %s: %v", pending.Referral.ID, err)
+	}
+	return status
+}
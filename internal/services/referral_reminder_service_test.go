@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+type fakeReminderStore struct {
+	pending   []*models.PendingReferral
+	claimed   map[models.ReminderStep]*models.ReferralReminder
+	smsStatus map[uuid.UUID]models.SMSStatus
+	expired   []uuid.UUID
+}
+
+func (f *fakeReminderStore) ListPending(ctx context.Context) ([]*models.PendingReferral, error) {
+	return f.pending, nil
+}
+
+func (f *fakeReminderStore) Claim(ctx context.Context, referralID uuid.UUID, step models.ReminderStep, recipient *string) (*models.ReferralReminder, error) {
+	if f.claimed == nil {
+		f.claimed = map[models.ReminderStep]*models.ReferralReminder{}
+	}
+	if _, taken := f.claimed[step]; taken {
+		return nil, nil
+	}
+	reminder := &models.ReferralReminder{ID: uuid.New(), ReferralID: referralID, Kind: step.Kind, Step: step.Step, Recipient: recipient}
+	f.claimed[step] = reminder
+	return reminder, nil
+}
+
+func (f *fakeReminderStore) SetSMSStatus(ctx context.Context, id uuid.UUID, status models.SMSStatus) error {
+	if f.smsStatus == nil {
+		f.smsStatus = map[uuid.UUID]models.SMSStatus{}
+	}
+	f.smsStatus[id] = status
+	return nil
+}
+
+func (f *fakeReminderStore) Expire(ctx context.Context, referralID uuid.UUID) (bool, error) {
+	f.expired = append(f.expired, referralID)
+	return true, nil
+}
+
+type fakeUnattendedNotifier struct {
+	alerted []uuid.UUID
+}
+
+func (f *fakeUnattendedNotifier) NotifyReferralUnattended(ctx context.Context, referral *models.Referral, facilityName string) (*models.Notification, error) {
+	f.alerted = append(f.alerted, referral.ID)
+	return &models.Notification{SMSStatus: models.SMSStatusSent}, nil
+}
+
+func TestReferralReminderTick(t *testing.T) {
+	created := time.Date(2026, 3, 2, 9, 0, 0, 0, models.FacilityTimezone)
+	alert := 480
+	schedule := &models.ReminderSchedule{PatientReminderMinutes: []int{120, 360}, CHVAlertMinutes: &alert, ExpireAfterMinutes: 2880}
+	pending := func() *models.PendingReferral {
+		return &models.PendingReferral{
+			Referral:        &models.Referral{ID: uuid.New(), ReferralToken: "REF-7KQ2M9XD", CreatedAt: created},
+			FacilityName:    "Ahero Sub-County Hospital",
+			PatientPhone:    "+254712345678",
+			PatientLanguage: "sw",
+			Schedule:        schedule,
+		}
+	}
+	newService := func(store *fakeReminderStore, sms *fakeSMSSender, at time.Time) (*ReferralReminderService, *fakeUnattendedNotifier) {
+		notifier := &fakeUnattendedNotifier{}
+		service := NewReferralReminderService(store, notifier, sms)
+		service.now = func() time.Time { return at }
+		return service, notifier
+	}
+
+	t.Run("Patient reminder in their language", func(t *testing.T) {
+		store, sms := &fakeReminderStore{pending: []*models.PendingReferral{pending()}}, &fakeSMSSender{}
+		service, _ := newService(store, sms, created.Add(2*time.Hour))
+
+		require.NoError(t, service.Tick(context.Background()))
+		message := sms.sent["+254712345678"]
+		assert.Contains(t, message, "Kumbusho")
+		assert.Contains(t, message, "REF-7KQ2M9XD")
+
+		reminder := store.claimed[models.ReminderStep{Kind: models.ReminderKindPatient, Step: 0}]
+		require.NotNil(t, reminder)
+		assert.Equal(t, models.SMSStatusSent, store.smsStatus[reminder.ID])
+
+		// A second tick finds the step already claimed
+		sms.sent = nil
+		require.NoError(t, service.Tick(context.Background()))
+		assert.Empty(t, sms.sent)
+	})
+
+	t.Run("Patient who refused SMS is skipped but logged", func(t *testing.T) {
+		refused := pending()
+		refused.SMSConsentRefused = true
+		store, sms := &fakeReminderStore{pending: []*models.PendingReferral{refused}}, &fakeSMSSender{}
+		service, _ := newService(store, sms, created.Add(2*time.Hour))
+
+		require.NoError(t, service.Tick(context.Background()))
+		assert.Empty(t, sms.sent)
+		reminder := store.claimed[models.ReminderStep{Kind: models.ReminderKindPatient, Step: 0}]
+		require.NotNil(t, reminder)
+		assert.Equal(t, models.SMSStatusSkipped, store.smsStatus[reminder.ID])
+	})
+
+	t.Run("CHV alert", func(t *testing.T) {
+		referral := pending()
+		referral.Done = []models.ReminderStep{{Kind: models.ReminderKindPatient, Step: 1}}
+		store := &fakeReminderStore{pending: []*models.PendingReferral{referral}}
+		service, notifier := newService(store, &fakeSMSSender{}, created.Add(8*time.Hour))
+
+		require.NoError(t, service.Tick(context.Background()))
+		assert.Equal(t, []uuid.UUID{referral.Referral.ID}, notifier.alerted)
+		reminder := store.claimed[models.ReminderStep{Kind: models.ReminderKindCHV}]
+		require.NotNil(t, reminder)
+		assert.Equal(t, models.SMSStatusSent, store.smsStatus[reminder.ID])
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		referral := pending()
+		store, sms := &fakeReminderStore{pending: []*models.PendingReferral{referral}}, &fakeSMSSender{}
+		service, notifier := newService(store, sms, created.Add(48*time.Hour))
+
+		require.NoError(t, service.Tick(context.Background()))
+		assert.Equal(t, []uuid.UUID{referral.Referral.ID}, store.expired)
+		assert.Empty(t, sms.sent)
+		assert.Empty(t, notifier.alerted)
+	})
+}
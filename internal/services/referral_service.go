@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+)
+
+// FacilityLookup loads a single facility; FacilityRepository satisfies it
+type FacilityLookup interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Facility, error)
+}
+
+// FacilityStatusLookup loads live capacity; FacilityStatusRepository satisfies it
+type FacilityStatusLookup interface {
+	Get(ctx context.Context, facilityID uuid.UUID) (*models.FacilityStatus, error)
+	GetMany(ctx context.Context, facilityIDs []uuid.UUID) (map[uuid.UUID]*models.FacilityStatus, error)
+}
+
+var (
+	ErrFacilityNotFound     = errors.New("facility not found")
+	ErrFacilityNotAccepting = errors.New("facility is not accepting referrals")
+)
+
+// CapacityWarningError is returned when the destination has reported it is
+// full or out of stock and the caller has not acknowledged the warnings
+type CapacityWarningError struct {
+	Warnings []string
+}
+
+func (e *CapacityWarningError) Error() string {
+	return "facility capacity warnings: " + strings.Join(e.Warnings, "; ")
+}
+
+// Referral tokens are read out over the phone and typed into USSD, so the
+// alphabet leaves out characters that are easy to confuse (0/O, 1/I/L)
+const (
+	referralTokenAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	referralTokenLength   = 8
+	referralTokenAttempts = 3
+)
+
+// ReferralNotifier tells the referring CHV, and on a transfer the patient,
+// about progress on a referral; NotificationService satisfies it
+type ReferralNotifier interface {
+	NotifyReferralStatus(ctx context.Context, referral *models.Referral, facilityName string) (*models.Notification, error)
+	NotifyReferralTransferred(ctx context.Context, referral *models.Referral, facilityName string) (*models.Notification, error)
+}
+
+type ReferralService struct {
+	referrals repository.ReferralRepositoryInterface
+	facility  FacilityLookup
+	status    FacilityStatusLookup
+	notifier  ReferralNotifier
+	now       func() time.Time
+}
+
+func NewReferralService(referrals repository.ReferralRepositoryInterface, facility FacilityLookup, status FacilityStatusLookup, notifier ReferralNotifier) *ReferralService {
+	return &ReferralService{referrals: referrals, facility: facility, status: status, notifier: notifier, now: time.Now}
+}
+
+// GenerateReferralToken returns a random token such as "REF-7KQ2M9XD"
+func GenerateReferralToken() (string, error) {
+	b := make([]byte, referralTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate referral token: %w", err)
+	}
+
+	token := make([]byte, referralTokenLength)
+	for i, v := range b {
+		token[i] = referralTokenAlphabet[int(v)%len(referralTokenAlphabet)]
+	}
+
+	return "REF-" + string(token), nil
+}
+
+// CheckFacility returns the capacity warnings for sending a patient to a
+// facility, or ErrFacilityNotAccepting if it cannot take referrals at all
+func (s *ReferralService) CheckFacility(ctx context.Context, facilityID uuid.UUID) ([]string, error) {
+	facility, err := s.facility.GetByID(ctx, facilityID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrFacilityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !facility.IsActive || !facility.AcceptsReferrals {
+		return nil, ErrFacilityNotAccepting
+	}
+
+	status, err := s.status.Get(ctx, facilityID)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return []string{}, nil
+	}
+
+	now := s.now()
+	if status.IsPaused(now) {
+		return nil, fmt.Errorf("%w: %s", ErrFacilityNotAccepting, strings.Join(status.Warnings(now), "; "))
+	}
+
+	return status.Warnings(now), nil
+}
+
+// Create sends a referral. Paused or inactive facilities are refused outright;
+// a full or under-stocked facility needs AcknowledgeWarnings. The warnings are
+// returned alongside the referral so they can be shown to the sender.
+func (s *ReferralService) Create(ctx context.Context, req *models.CreateReferralRequest, createdBy *uuid.UUID) (*models.Referral, []string, error) {
+	warnings, err := s.CheckFacility(ctx, req.FacilityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(warnings) > 0 && !req.AcknowledgeWarnings {
+		return nil, nil, &CapacityWarningError{Warnings: warnings}
+	}
+
+	referral, err := withReferralToken(func(token string) (*models.Referral, error) {
+		return s.referrals.Create(ctx, req, token, createdBy)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return referral, warnings, nil
+}
+
+// withReferralToken calls create with fresh tokens until one is not taken
+func withReferralToken(create func(token string) (*models.Referral, error)) (*models.Referral, error) {
+	for attempt := 0; attempt < referralTokenAttempts; attempt++ {
+		token, err := GenerateReferralToken()
+		if err != nil {
+			return nil, err
+		}
+
+		referral, err := create(token)
+		if errors.Is(err, repository.ErrDuplicate) {
+			continue
+		}
+		return referral, err
+	}
+
+	return nil, fmt.Errorf("failed to allocate a unique referral token")
+}
+
+// UpdateStatus records the receiving facility's progress on a referral and
+// notifies the CHV who made it. The status change stands even if the
+// notification fails; the failure is logged.
+func (s *ReferralService) UpdateStatus(ctx context.Context, id uuid.UUID, status models.ReferralStatus, clinicianID *uuid.UUID) (*models.Referral, error) {
+	referral, err := s.referrals.UpdateStatus(ctx, id, status, clinicianID)
+	if err != nil {
+		return nil, err
+	}
+
+	facilityName := ""
+	if referral.FacilityID != nil {
+		if facility, err := s.facility.GetByID(ctx, *referral.FacilityID); err == nil {
+			facilityName = facility.Name
+		}
+	}
+
+	if _, err := s.notifier.NotifyReferralStatus(ctx, referral, facilityName); err != nil {
+		log.Printf("Error notifying CHV of referral %s %s: %v", referral.ID, referral.Status, err)
+	}
+
+	return referral, nil
+}
+
+// Transfer forwards a referral the receiving facility cannot handle to
+// another facility, which is checked as for a new referral. The new
+// referral carries a fresh token, and the patient and referring CHV are
+// told where to go; a failed notification is logged.
+func (s *ReferralService) Transfer(ctx context.Context, id uuid.UUID, req *models.TransferReferralRequest, transferredBy *uuid.UUID) (*models.Referral, *models.ReferralTransfer, []string, error) {
+	warnings, err := s.CheckFacility(ctx, req.FacilityID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(warnings) > 0 && !req.AcknowledgeWarnings {
+		return nil, nil, nil, &CapacityWarningError{Warnings: warnings}
+	}
+
+	var transfer *models.ReferralTransfer
+	referral, err := withReferralToken(func(token string) (*models.Referral, error) {
+		var referral *models.Referral
+		var err error
+		referral, transfer, err = s.referrals.Transfer(ctx, id, req, token, transferredBy)
+		return referral, err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	facilityName := ""
+	if facility, err := s.facility.GetByID(ctx, req.FacilityID); err == nil {
+		facilityName = facility.Name
+	}
+
+	if _, err := s.notifier.NotifyReferralTransferred(ctx, referral, facilityName); err != nil {
+		log.Printf("Error notifying transfer of referral %s to %s: %v", id, referral.ID, err)
+	}
+
+	return referral, transfer, warnings, nil
+}
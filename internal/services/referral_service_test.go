@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+)
+
+type fakeReferralRepository struct {
+	collisions  int
+	tokens      []string
+	updated     *models.Referral
+	updateErr   error
+	transferErr error
+}
+
+func (f *fakeReferralRepository) Create(ctx context.Context, req *models.CreateReferralRequest, token string, createdBy *uuid.UUID) (*models.Referral, error) {
+	f.tokens = append(f.tokens, token)
+	if len(f.tokens) <= f.collisions {
+		return nil, repository.ErrDuplicate
+	}
+	return &models.Referral{ID: uuid.New(), FacilityID: &req.FacilityID, ReferralToken: token, Status: models.ReferralStatusPending}, nil
+}
+
+func (f *fakeReferralRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Referral, error) {
+	return nil, fmt.Errorf("referral not found")
+}
+
+func (f *fakeReferralRepository) List(ctx context.Context, req *models.ListReferralsRequest) ([]*models.Referral, error) {
+	return nil, nil
+}
+
+func (f *fakeReferralRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.ReferralStatus, clinicianID *uuid.UUID) (*models.Referral, error) {
+	if f.updateErr != nil {
+		return nil, f.updateErr
+	}
+	f.updated.Status = status
+	return f.updated, nil
+}
+
+func (f *fakeReferralRepository) RecordOutcome(ctx context.Context, id uuid.UUID, req *models.RecordReferralOutcomeRequest, recordedBy *uuid.UUID) (*models.ReferralOutcome, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeReferralRepository) GetOutcome(ctx context.Context, id uuid.UUID) (*models.ReferralOutcome, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeReferralRepository) Transfer(ctx context.Context, id uuid.UUID, req *models.TransferReferralRequest, token string, transferredBy *uuid.UUID) (*models.Referral, *models.ReferralTransfer, error) {
+	f.tokens = append(f.tokens, token)
+	if f.transferErr != nil {
+		return nil, nil, f.transferErr
+	}
+	if len(f.tokens) <= f.collisions {
+		return nil, nil, repository.ErrDuplicate
+	}
+	referral := &models.Referral{ID: uuid.New(), FacilityID: &req.FacilityID, ReferralToken: token, Status: models.ReferralStatusPending, ParentReferralID: &id}
+	return referral, &models.ReferralTransfer{ID: uuid.New(), FromReferralID: id, ToReferralID: referral.ID, Reason: req.Reason}, nil
+}
+
+func (f *fakeReferralRepository) GetTransferChain(ctx context.Context, id uuid.UUID) ([]*models.Referral, []*models.ReferralTransfer, error) {
+	return nil, nil, repository.ErrNotFound
+}
+
+func (f *fakeReferralRepository) TransferStats(ctx context.Context, from, to time.Time) ([]*models.TransferStats, error) {
+	return nil, nil
+}
+
+type fakeReferralNotifier struct {
+	referrals    []*models.Referral
+	transferred  []*models.Referral
+	facilityName string
+	err          error
+}
+
+func (f *fakeReferralNotifier) NotifyReferralStatus(ctx context.Context, referral *models.Referral, facilityName string) (*models.Notification, error) {
+	f.referrals = append(f.referrals, referral)
+	f.facilityName = facilityName
+	return nil, f.err
+}
+
+func (f *fakeReferralNotifier) NotifyReferralTransferred(ctx context.Context, referral *models.Referral, facilityName string) (*models.Notification, error) {
+	f.transferred = append(f.transferred, referral)
+	f.facilityName = facilityName
+	return nil, f.err
+}
+
+type fakeFacilityLookup struct {
+	facility *models.Facility
+	err      error
+}
+
+func (f *fakeFacilityLookup) GetByID(ctx context.Context, id uuid.UUID) (*models.Facility, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.facility == nil || f.facility.ID != id {
+		return nil, repository.ErrNotFound
+	}
+	return f.facility, nil
+}
+
+func newTestReferralService(facility *models.Facility, status *models.FacilityStatus, referrals *fakeReferralRepository, now time.Time) *ReferralService {
+	statuses := map[uuid.UUID]*models.FacilityStatus{}
+	if status != nil {
+		statuses[facility.ID] = status
+	}
+	service := NewReferralService(referrals, &fakeFacilityLookup{facility: facility}, &fakeStatusLookup{statuses: statuses}, &fakeReferralNotifier{})
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestGenerateReferralToken(t *testing.T) {
+	token, err := GenerateReferralToken()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, "REF-"))
+	assert.Len(t, token, 12)
+	assert.NotContains(t, token[4:], "0")
+	assert.NotContains(t, token[4:], "O")
+}
+
+func TestReferralServiceCreate(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, models.FacilityTimezone)
+	facility := &models.Facility{ID: uuid.New(), IsActive: true, AcceptsReferrals: true}
+	total, occupied := 10, 10
+	until := now.Add(12 * time.Hour)
+	reason := "Flooded wards"
+	full := &models.FacilityStatus{FacilityID: facility.ID, BedsTotal: &total, BedsOccupied: &occupied}
+	paused := &models.FacilityStatus{FacilityID: facility.ID, ReferralsPaused: true, PauseReason: &reason, PausedUntil: &until}
+
+	t.Run("No status reported", func(t *testing.T) {
+		service := newTestReferralService(facility, nil, &fakeReferralRepository{}, now)
+		referral, warnings, err := service.Create(context.Background(), &models.CreateReferralRequest{FacilityID: facility.ID}, nil)
+		require.NoError(t, err)
+		assert.NotEmpty(t, referral.ReferralToken)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("Unknown facility", func(t *testing.T) {
+		service := newTestReferralService(facility, nil, &fakeReferralRepository{}, now)
+		_, _, err := service.Create(context.Background(), &models.CreateReferralRequest{FacilityID: uuid.New()}, nil)
+		assert.ErrorIs(t, err, ErrFacilityNotFound)
+	})
+
+	t.Run("Facility lookup failure is not reported as not found", func(t *testing.T) {
+		service := NewReferralService(&fakeReferralRepository{}, &fakeFacilityLookup{err: assert.AnError}, &fakeStatusLookup{}, &fakeReferralNotifier{})
+		_, _, err := service.Create(context.Background(), &models.CreateReferralRequest{FacilityID: facility.ID}, nil)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NotErrorIs(t, err, ErrFacilityNotFound)
+	})
+
+	t.Run("Paused facility is refused", func(t *testing.T) {
+		service := newTestReferralService(facility, paused, &fakeReferralRepository{}, now)
+		_, _, err := service.Create(context.Background(), &models.CreateReferralRequest{FacilityID: facility.ID, AcknowledgeWarnings: true}, nil)
+		assert.ErrorIs(t, err, ErrFacilityNotAccepting)
+		assert.Contains(t, err.Error(), reason)
+	})
+
+	t.Run("Expired pause is ignored", func(t *testing.T) {
+		service := newTestReferralService(facility, paused, &fakeReferralRepository{}, until.Add(time.Minute))
+		_, _, err := service.Create(context.Background(), &models.CreateReferralRequest{FacilityID: facility.ID}, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Full facility needs acknowledgement", func(t *testing.T) {
+		service := newTestReferralService(facility, full, &fakeReferralRepository{}, now)
+		_, _, err := service.Create(context.Background(), &models.CreateReferralRequest{FacilityID: facility.ID}, nil)
+
+		var warningErr *CapacityWarningError
+		require.True(t, errors.As(err, &warningErr))
+		assert.Equal(t, []string{"no free beds"}, warningErr.Warnings)
+
+		referral, warnings, err := service.Create(context.Background(), &models.CreateReferralRequest{FacilityID: facility.ID, AcknowledgeWarnings: true}, nil)
+		require.NoError(t, err)
+		assert.NotNil(t, referral)
+		assert.Equal(t, []string{"no free beds"}, warnings)
+	})
+
+	t.Run("Token collision is retried", func(t *testing.T) {
+		referrals := &fakeReferralRepository{collisions: 2}
+		service := newTestReferralService(facility, nil, referrals, now)
+		referral, _, err := service.Create(context.Background(), &models.CreateReferralRequest{FacilityID: facility.ID}, nil)
+		require.NoError(t, err)
+		assert.Len(t, referrals.tokens, 3)
+		assert.Equal(t, referrals.tokens[2], referral.ReferralToken)
+	})
+
+	t.Run("Gives up after repeated collisions", func(t *testing.T) {
+		service := newTestReferralService(facility, nil, &fakeReferralRepository{collisions: referralTokenAttempts}, now)
+		_, _, err := service.Create(context.Background(), &models.CreateReferralRequest{FacilityID: facility.ID}, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestReferralServiceUpdateStatus(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, models.FacilityTimezone)
+	facility := &models.Facility{ID: uuid.New(), Name: "Ahero Sub-County Hospital", IsActive: true, AcceptsReferrals: true}
+
+	t.Run("Referring CHV is notified", func(t *testing.T) {
+		referrals := &fakeReferralRepository{updated: &models.Referral{ID: uuid.New(), FacilityID: &facility.ID, Status: models.ReferralStatusPending}}
+		service := newTestReferralService(facility, nil, referrals, now)
+		notifier := &fakeReferralNotifier{}
+		service.notifier = notifier
+
+		referral, err := service.UpdateStatus(context.Background(), referrals.updated.ID, models.ReferralStatusAccepted, nil)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReferralStatusAccepted, referral.Status)
+		require.Len(t, notifier.referrals, 1)
+		assert.Equal(t, facility.Name, notifier.facilityName)
+	})
+
+	t.Run("Notification failure does not undo the update", func(t *testing.T) {
+		referrals := &fakeReferralRepository{updated: &models.Referral{ID: uuid.New(), FacilityID: &facility.ID, Status: models.ReferralStatusAccepted}}
+		service := newTestReferralService(facility, nil, referrals, now)
+		service.notifier = &fakeReferralNotifier{err: errors.New("inbox unavailable")}
+
+		referral, err := service.UpdateStatus(context.Background(), referrals.updated.ID, models.ReferralStatusNoShow, nil)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReferralStatusNoShow, referral.Status)
+	})
+
+	t.Run("Illegal transition is not notified", func(t *testing.T) {
+		referrals := &fakeReferralRepository{updateErr: repository.ErrValidation}
+		service := newTestReferralService(facility, nil, referrals, now)
+		notifier := &fakeReferralNotifier{}
+		service.notifier = notifier
+
+		_, err := service.UpdateStatus(context.Background(), uuid.New(), models.ReferralStatusAccepted, nil)
+		assert.ErrorIs(t, err, repository.ErrValidation)
+		assert.Empty(t, notifier.referrals)
+	})
+}
+
+func TestReferralServiceTransfer(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, models.FacilityTimezone)
+	facility := &models.Facility{ID: uuid.New(), Name: "Kisumu County Referral Hospital", IsActive: true, AcceptsReferrals: true}
+	req := &models.TransferReferralRequest{FacilityID: facility.ID, Reason: models.TransferNoTheatre}
+
+	t.Run("New referral is made and notified", func(t *testing.T) {
+		referrals := &fakeReferralRepository{collisions: 1}
+		service := newTestReferralService(facility, nil, referrals, now)
+		notifier := &fakeReferralNotifier{}
+		service.notifier = notifier
+
+		parentID := uuid.New()
+		referral, transfer, _, err := service.Transfer(context.Background(), parentID, req, nil)
+		require.NoError(t, err)
+		assert.Equal(t, parentID, *referral.ParentReferralID)
+		assert.Equal(t, referrals.tokens[1], referral.ReferralToken, "token collision is retried")
+		assert.Equal(t, models.TransferNoTheatre, transfer.Reason)
+		require.Len(t, notifier.transferred, 1)
+		assert.Equal(t, facility.Name, notifier.facilityName)
+	})
+
+	t.Run("Destination is checked like a new referral", func(t *testing.T) {
+		closed := *facility
+		closed.AcceptsReferrals = false
+		referrals := &fakeReferralRepository{}
+		service := newTestReferralService(&closed, nil, referrals, now)
+
+		_, _, _, err := service.Transfer(context.Background(), uuid.New(), req, nil)
+		assert.ErrorIs(t, err, ErrFacilityNotAccepting)
+		assert.Empty(t, referrals.tokens)
+	})
+
+	t.Run("Closed referral is not notified", func(t *testing.T) {
+		service := newTestReferralService(facility, nil, &fakeReferralRepository{transferErr: repository.ErrValidation}, now)
+		notifier := &fakeReferralNotifier{}
+		service.notifier = notifier
+
+		_, _, _, err := service.Transfer(context.Background(), uuid.New(), req, nil)
+		assert.ErrorIs(t, err, repository.ErrValidation)
+		assert.Empty(t, notifier.transferred)
+	})
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// smsTimeout bounds one request to the SMS gateway
+const smsTimeout = 10 * time.Second
+
+// ErrSMSNotSent is returned by senders that accept a message without
+// delivering it, such as LogSMSSender
+var ErrSMSNotSent = errors.New("sms logged, not sent")
+
+// SMSSender delivers a text message to a phone number in E.164 form
+type SMSSender interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+// smsStatus is the status to record for the result of SMSSender.Send
+func smsStatus(err error) models.SMSStatus {
+	switch {
+	case err == nil:
+		return models.SMSStatusSent
+	case errors.Is(err, ErrSMSNotSent):
+		return models.SMSStatusLogged
+	default:
+		return models.SMSStatusFailed
+	}
+}
+
+// LogSMSSender stands in for the gateway in development. It logs that a
+// message would have been sent, without the number or text, and returns
+// ErrSMSNotSent so it is not recorded as delivered.
+type LogSMSSender struct{}
+
+func (LogSMSSender) Send(ctx context.Context, phone, message string) error {
+	log.Printf("SMS to %s not sent (%d characters)", maskPhone(phone), len([]rune(message)))
+	return ErrSMSNotSent
+}
+
+// maskPhone keeps only the last three digits of a phone number
+func maskPhone(phone string) string {
+	if len(phone) <= 3 {
+		return "***"
+	}
+	return "***" + phone[len(phone)-3:]
+}
+
+// AfricasTalkingSender sends messages through the Africa's Talking bulk SMS
+// API. The "sandbox" username uses the sandbox endpoint.
+type AfricasTalkingSender struct {
+	username string
+	apiKey   string
+	url      string
+	client   *http.Client
+}
+
+func NewAfricasTalkingSender(username, apiKey string) *AfricasTalkingSender {
+	endpoint := "https://api.africastalking.com/version1/messaging"
+	if username == "sandbox" {
+		endpoint = "https://api.sandbox.africastalking.com/version1/messaging"
+	}
+	return &AfricasTalkingSender{username: username, apiKey: apiKey, url: endpoint, client: &http.Client{Timeout: smsTimeout}}
+}
+
+func (s *AfricasTalkingSender) Send(ctx context.Context, phone, message string) error {
+	form := url.Values{"username": {s.username}, "to": {phone}, "message": {message}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("apiKey", s.apiKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return fmt.Errorf("SMS gateway answered %s", resp.Status)
+	}
+
+	var result struct {
+		SMSMessageData struct {
+			Message    string `json:"Message"`
+			Recipients []struct {
+				Status string `json:"status"`
+			} `json:"Recipients"`
+		} `json:"SMSMessageData"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode SMS gateway response: %w", err)
+	}
+
+	recipients := result.SMSMessageData.Recipients
+	if len(recipients) == 0 {
+		return fmt.Errorf("SMS gateway refused message: %s", result.SMSMessageData.Message)
+	}
+	if recipients[0].Status != "Success" {
+		return fmt.Errorf("SMS gateway refused message: %s", recipients[0].Status)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAfricasTalkingSender(t *testing.T) {
+	gateway := func(status int, body string) *AfricasTalkingSender {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "test-key", r.Header.Get("apiKey"))
+			assert.Equal(t, "sandbox", r.PostForm.Get("username"))
+			assert.Equal(t, "+254712345678", r.PostForm.Get("to"))
+			assert.Equal(t, "Habari", r.PostForm.Get("message"))
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+
+		sender := NewAfricasTalkingSender("sandbox", "test-key")
+		sender.url = server.URL
+		return sender
+	}
+
+	t.Run("Accepted", func(t *testing.T) {
+		sender := gateway(http.StatusCreated, `{"SMSMessageData":{"Message":"Sent to 1/1","Recipients":[{"statusCode":101,"status":"Success"}]}}`)
+		assert.NoError(t, sender.Send(context.Background(), "+254712345678", "Habari"))
+	})
+
+	t.Run("Recipient refused", func(t *testing.T) {
+		sender := gateway(http.StatusCreated, `{"SMSMessageData":{"Message":"Sent to 0/1","Recipients":[{"statusCode":403,"status":"InvalidPhoneNumber"}]}}`)
+		err := sender.Send(context.Background(), "+254712345678", "Habari")
+		assert.ErrorContains(t, err, "InvalidPhoneNumber")
+	})
+
+	t.Run("Gateway error", func(t *testing.T) {
+		sender := gateway(http.StatusUnauthorized, `The supplied authentication is invalid`)
+		assert.Error(t, sender.Send(context.Background(), "+254712345678", "Habari"))
+	})
+}
+
+func TestLogSMSSender(t *testing.T) {
+	err := LogSMSSender{}.Send(context.Background(), "+254712345678", "Habari")
+	assert.ErrorIs(t, err, ErrSMSNotSent)
+	assert.Equal(t, "***678", maskPhone("+254712345678"))
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+)
+
+// SyncStore applies mutations and reads the change feed; SyncRepository satisfies it
+type SyncStore interface {
+	Apply(ctx context.Context, userID uuid.UUID, deviceID string, m *models.SyncMutation) (*models.SyncResult, error)
+	Changes(ctx context.Context, userID uuid.UUID, after models.SyncCursor, limit int) (*models.SyncChanges, error)
+}
+
+type SyncService struct {
+	store    SyncStore
+	facility FacilityLookup
+	status   FacilityStatusLookup
+	now      func() time.Time
+}
+
+func NewSyncService(store SyncStore, facility FacilityLookup, status FacilityStatusLookup) *SyncService {
+	return &SyncService{store: store, facility: facility, status: status, now: time.Now}
+}
+
+// Sync applies a device's mutations in order, then returns the changes since
+// its cursor. An invalid mutation is rejected in its result without stopping
+// the batch; a server error stops it, and the device retries the whole batch.
+func (s *SyncService) Sync(ctx context.Context, userID uuid.UUID, req *models.SyncRequest) (*models.SyncResponse, error) {
+	after, err := models.DecodeSyncCursor(req.Cursor)
+	if err != nil {
+		return nil, repository.ErrInvalidCursor
+	}
+
+	now := s.now()
+	results := make([]*models.SyncResult, 0, len(req.Mutations))
+	for i, m := range req.Mutations {
+		if m == nil {
+			return nil, fmt.Errorf("%w: mutation %d is null", repository.ErrValidation, i)
+		}
+
+		result, err := s.apply(ctx, userID, req.DeviceID, m, now)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	limit := req.FeedLimit
+	if limit == 0 {
+		limit = models.DefaultSyncFeedLimit
+	}
+	changes, err := s.store.Changes(ctx, userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SyncResponse{Results: results, Changes: changes}, nil
+}
+
+func (s *SyncService) apply(ctx context.Context, userID uuid.UUID, deviceID string, m *models.SyncMutation, now time.Time) (*models.SyncResult, error) {
+	if err := m.Decode(now); err != nil {
+		result := &models.SyncResult{MutationID: m.ID, Entity: m.Entity, EntityID: m.EntityID}
+		result.Reject("VALIDATION_FAILED", err.Error())
+		return result, nil
+	}
+
+	referral, ok := m.Payload.(*models.SyncReferralCreate)
+	if !ok {
+		return s.store.Apply(ctx, userID, deviceID, m)
+	}
+
+	warnings, err := s.referralWarnings(ctx, referral.FacilityID, now)
+	if err != nil {
+		return nil, err
+	}
+	referral.Warnings = warnings
+
+	for attempt := 0; attempt < referralTokenAttempts; attempt++ {
+		token, err := GenerateReferralToken()
+		if err != nil {
+			return nil, err
+		}
+		referral.Token = token
+
+		result, err := s.store.Apply(ctx, userID, deviceID, m)
+		if errors.Is(err, repository.ErrDuplicate) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("failed to allocate a unique referral token")
+}
+
+// referralWarnings lists why the facility may not be able to take a referral
+// that was made offline. Unlike CheckFacility nothing is refused, since the
+// patient has already been sent. An unknown facility has no warnings; the
+// mutation is rejected when it is applied.
+func (s *SyncService) referralWarnings(ctx context.Context, facilityID uuid.UUID, now time.Time) ([]string, error) {
+	facility, err := s.facility.GetByID(ctx, facilityID)
+	if err != nil {
+		return nil, nil
+	}
+
+	warnings := []string{}
+	if !facility.IsActive || !facility.AcceptsReferrals {
+		warnings = append(warnings, ErrFacilityNotAccepting.Error())
+	}
+
+	status, err := s.status.Get(ctx, facilityID)
+	if err != nil {
+		return nil, err
+	}
+	if status != nil {
+		warnings = append(warnings, status.Warnings(now)...)
+	}
+
+	return warnings, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+)
+
+type fakeSyncStore struct {
+	collisions int
+	applied    []*models.SyncMutation
+	tokens     []string
+	feedLimit  int
+}
+
+func (f *fakeSyncStore) Apply(ctx context.Context, userID uuid.UUID, deviceID string, m *models.SyncMutation) (*models.SyncResult, error) {
+	result := &models.SyncResult{MutationID: m.ID, Entity: m.Entity, EntityID: m.EntityID, Status: models.SyncStatusApplied}
+	if referral, ok := m.Payload.(*models.SyncReferralCreate); ok {
+		f.tokens = append(f.tokens, referral.Token)
+		if len(f.tokens) <= f.collisions {
+			return nil, repository.ErrDuplicate
+		}
+		result.Warnings = referral.Warnings
+	}
+	f.applied = append(f.applied, m)
+	return result, nil
+}
+
+func (f *fakeSyncStore) Changes(ctx context.Context, userID uuid.UUID, after models.SyncCursor, limit int) (*models.SyncChanges, error) {
+	f.feedLimit = limit
+	return &models.SyncChanges{Cursor: after.Encode()}, nil
+}
+
+func TestSyncService(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, models.FacilityTimezone)
+	facility := &models.Facility{ID: uuid.New(), IsActive: true, AcceptsReferrals: true}
+	until := now.Add(12 * time.Hour)
+	reason := "Flooded wards"
+	paused := &models.FacilityStatus{FacilityID: facility.ID, ReferralsPaused: true, PauseReason: &reason, PausedUntil: &until}
+
+	newService := func(store *fakeSyncStore) *SyncService {
+		statuses := map[uuid.UUID]*models.FacilityStatus{facility.ID: paused}
+		service := NewSyncService(store, &fakeFacilityLookup{facility: facility}, &fakeStatusLookup{statuses: statuses})
+		service.now = func() time.Time { return now }
+		return service
+	}
+	mutation := func(entity models.SyncEntity, data interface{}) *models.SyncMutation {
+		raw, err := json.Marshal(data)
+		require.NoError(t, err)
+		return &models.SyncMutation{
+			ID:              uuid.New(),
+			Entity:          entity,
+			Operation:       models.SyncOperationCreate,
+			EntityID:        uuid.New(),
+			ClientTimestamp: now.Add(-time.Hour),
+			Data:            raw,
+		}
+	}
+
+	t.Run("Invalid mutations are rejected without stopping the batch", func(t *testing.T) {
+		store := &fakeSyncStore{}
+		invalid := mutation(models.SyncEntityPatient, map[string]interface{}{"phone": "0712345678", "gender": "unknown"})
+		valid := mutation(models.SyncEntityPatient, map[string]interface{}{"phone": "0712345678"})
+
+		resp, err := newService(store).Sync(context.Background(), uuid.New(), &models.SyncRequest{
+			DeviceID:  "device-1",
+			Mutations: []*models.SyncMutation{invalid, valid},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 2)
+		assert.Equal(t, models.SyncStatusRejected, resp.Results[0].Status)
+		assert.Equal(t, "VALIDATION_FAILED", resp.Results[0].Code)
+		assert.Equal(t, models.SyncStatusApplied, resp.Results[1].Status)
+		require.Len(t, store.applied, 1)
+		assert.Equal(t, valid.ID, store.applied[0].ID)
+		assert.Equal(t, models.DefaultSyncFeedLimit, store.feedLimit)
+	})
+
+	t.Run("Referrals to a paused facility are recorded with warnings", func(t *testing.T) {
+		store := &fakeSyncStore{collisions: 1}
+		referral := mutation(models.SyncEntityReferral, map[string]interface{}{"patient_id": uuid.New(), "facility_id": facility.ID})
+
+		resp, err := newService(store).Sync(context.Background(), uuid.New(), &models.SyncRequest{
+			DeviceID:  "device-1",
+			FeedLimit: 50,
+			Mutations: []*models.SyncMutation{referral},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 1)
+		assert.Equal(t, models.SyncStatusApplied, resp.Results[0].Status)
+		require.Len(t, resp.Results[0].Warnings, 1)
+		assert.Contains(t, resp.Results[0].Warnings[0], "not accepting referrals")
+		require.Len(t, store.tokens, 2)
+		assert.NotEqual(t, store.tokens[0], store.tokens[1])
+		assert.Equal(t, 50, store.feedLimit)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		_, err := newService(&fakeSyncStore{}).Sync(context.Background(), uuid.New(), &models.SyncRequest{DeviceID: "device-1", Cursor: "%%"})
+		assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+	})
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+)
+
+// TransportProvider books an ambulance or boda-boda rider to bring a patient
+// to a facility. Providers report progress later through the transport
+// webhook, quoting the reference returned here.
+type TransportProvider interface {
+	// Name identifies the provider in stored dispatches
+	Name() string
+	// Request wraps its error with jobs.Permanent when the provider refuses
+	// the request outright; any other error is retried
+	Request(ctx context.Context, req *models.TransportRequest) (*models.TransportBooking, error)
+}
+
+// SimulatedTransportProvider stands in for a real provider in development
+// and tests. It accepts every request and, in the background, reports a
+// rider assigned after AssignAfter and arrived after a further ArriveAfter.
+type SimulatedTransportProvider struct {
+	AssignAfter time.Duration
+	ArriveAfter time.Duration
+	// Report receives the simulated updates, as the webhook would;
+	// TransportService.HandleUpdate is the usual target
+	Report func(ctx context.Context, update *models.TransportUpdate) (*models.TransportDispatch, error)
+}
+
+func (p *SimulatedTransportProvider) Name() string {
+	return "simulator"
+}
+
+func (p *SimulatedTransportProvider) Request(ctx context.Context, req *models.TransportRequest) (*models.TransportBooking, error) {
+	ref := "SIM-" + strings.ToUpper(uuid.NewString()[:8])
+	vehicle := models.VehicleBodaBoda
+	if req.PickupLatitude == nil {
+		// Without a location the rider needs directions by phone; send
+		// the ambulance, whose crew can take the call
+		vehicle = models.VehicleAmbulance
+	}
+
+	if p.Report != nil {
+		go p.simulate(ref, vehicle)
+	}
+
+	return &models.TransportBooking{ProviderRef: ref, VehicleType: &vehicle}, nil
+}
+
+func (p *SimulatedTransportProvider) simulate(ref string, vehicle models.VehicleType) {
+	driver, phone, eta := "Simulated Rider", "+254700000000", 15
+	updates := []struct {
+		after  time.Duration
+		update *models.TransportUpdate
+	}{
+		{p.AssignAfter, &models.TransportUpdate{ProviderRef: ref, Status: models.DispatchStatusAssigned, VehicleType: &vehicle, DriverName: &driver, DriverPhone: &phone, ETAMinutes: &eta}},
+		{p.ArriveAfter, &models.TransportUpdate{ProviderRef: ref, Status: models.DispatchStatusArrived}},
+	}
+
+	for _, step := range updates {
+		time.Sleep(step.after)
+		if _, err := p.Report(context.Background(), step.update); err != nil {
+			log.Printf("Error reporting simulated transport %s %s: %v", ref, step.update.Status, err)
+			return
+		}
+	}
+}
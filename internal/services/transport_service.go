@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/jobs"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+)
+
+// dispatchTimeout bounds the provider call made for a new referral
+const dispatchTimeout = 10 * time.Second
+
+// ErrTransportDisabled is returned when transport is requested by hand but
+// no provider is configured
+var ErrTransportDisabled = errors.New("transport dispatch is not configured")
+
+// TransportStore keeps transport dispatches; TransportRepository satisfies it
+type TransportStore interface {
+	Create(ctx context.Context, referralID uuid.UUID, provider string, staleBefore time.Time) (*models.TransportDispatch, error)
+	SetBooking(ctx context.Context, id uuid.UUID, booking *models.TransportBooking) (*models.TransportDispatch, error)
+	SetFailed(ctx context.Context, id uuid.UUID, reason string) (*models.TransportDispatch, error)
+	Reopen(ctx context.Context, referralID uuid.UUID, provider string, staleBefore time.Time) (*models.TransportDispatch, error)
+	ApplyUpdate(ctx context.Context, provider string, update *models.TransportUpdate) (*models.TransportDispatch, error)
+	GetRequest(ctx context.Context, referralID uuid.UUID) (*models.TransportRequest, error)
+}
+
+// TransportService requests emergency transport for red referrals and
+// records the provider's progress
+type TransportService struct {
+	store    TransportStore
+	provider TransportProvider
+	now      func() time.Time
+}
+
+// NewTransportService takes a nil provider when no transport provider is
+// configured; nothing is then dispatched
+func NewTransportService(store TransportStore, provider TransportProvider) *TransportService {
+	return &TransportService{store: store, provider: provider, now: time.Now}
+}
+
+// DispatchForReferral requests transport if the referral is red. It returns
+// nil when no transport is needed or it was already requested. A provider
+// that refuses the request leaves the dispatch failed, with the reason; an
+// error reaching it is returned so the caller retries, and the retry asks
+// again since the dispatch never got a provider reference.
+func (s *TransportService) DispatchForReferral(ctx context.Context, referral *models.Referral) (*models.TransportDispatch, error) {
+	if s.provider == nil || referral.Priority == nil || *referral.Priority != models.TriageLevelRed {
+		return nil, nil
+	}
+
+	dispatch, err := s.store.Create(ctx, referral.ID, s.provider.Name(), s.now().Add(-dispatchTimeout))
+	if err != nil || dispatch == nil {
+		return nil, err
+	}
+
+	return s.request(ctx, dispatch)
+}
+
+// Redispatch requests transport again for a referral whose dispatch failed,
+// whose request was never answered, or that never had one. A dispatch still
+// in progress returns repository.ErrValidation.
+func (s *TransportService) Redispatch(ctx context.Context, referral *models.Referral) (*models.TransportDispatch, error) {
+	if s.provider == nil {
+		return nil, ErrTransportDisabled
+	}
+
+	dispatch, err := s.store.Reopen(ctx, referral.ID, s.provider.Name(), s.now().Add(-dispatchTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.request(ctx, dispatch)
+}
+
+// request asks the provider for transport for a logged dispatch
+func (s *TransportService) request(ctx context.Context, dispatch *models.TransportDispatch) (*models.TransportDispatch, error) {
+	req, err := s.store.GetRequest(ctx, dispatch.ReferralID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.store.SetFailed(ctx, dispatch.ID, "could not load pickup details")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, dispatchTimeout)
+	defer cancel()
+
+	booking, err := s.provider.Request(requestCtx, req)
+	if jobs.IsPermanent(err) {
+		log.Printf("Transport for referral %s refused by %s: %v", dispatch.ReferralID, s.provider.Name(), err)
+		return s.store.SetFailed(ctx, dispatch.ID, err.Error())
+	}
+	if err != nil {
+		log.Printf("Error requesting transport for referral %s from %s: %v", dispatch.ReferralID, s.provider.Name(), err)
+		return nil, err
+	}
+
+	return s.store.SetBooking(ctx, dispatch.ID, booking)
+}
+
+// HandleReferralCreated is the referral.created subscriber. A transfer
+// moves a patient who is already at a facility, so only referrals from the
+// community have transport requested.
+func (s *TransportService) HandleReferralCreated(ctx context.Context, event *models.DomainEvent) error {
+	var referral models.Referral
+	if err := event.DecodePayload(&referral); err != nil {
+		return jobs.Permanent(err)
+	}
+	if referral.ParentReferralID != nil {
+		return nil
+	}
+
+	_, err := s.DispatchForReferral(ctx, &referral)
+	return err
+}
+
+// HandleUpdate records a status report from the configured provider
+func (s *TransportService) HandleUpdate(ctx context.Context, update *models.TransportUpdate) (*models.TransportDispatch, error) {
+	if s.provider == nil {
+		return nil, repository.ErrNotFound
+	}
+	return s.store.ApplyUpdate(ctx, s.provider.Name(), update)
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/jobs"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/models"
+	"github.com/MarkAndrewKamau/Digital-Micro-Health-Assistant-Referral/internal/repository"
+)
+
+type fakeTransportStore struct {
+	mu         sync.Mutex
+	dispatches map[uuid.UUID]*models.TransportDispatch
+	updates    []*models.TransportUpdate
+	request    *models.TransportRequest
+}
+
+func newFakeTransportStore() *fakeTransportStore {
+	return &fakeTransportStore{dispatches: map[uuid.UUID]*models.TransportDispatch{}}
+}
+
+func (f *fakeTransportStore) Create(ctx context.Context, referralID uuid.UUID, provider string, staleBefore time.Time) (*models.TransportDispatch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, dispatch := range f.dispatches {
+		if dispatch.ReferralID == referralID {
+			if dispatch.Status != models.DispatchStatusRequested || dispatch.ProviderRef != nil || !dispatch.RequestedAt.Before(staleBefore) {
+				return nil, nil
+			}
+			dispatch.RequestedAt = time.Now()
+			return dispatch, nil
+		}
+	}
+	dispatch := &models.TransportDispatch{ID: uuid.New(), ReferralID: referralID, Provider: provider, Status: models.DispatchStatusRequested, RequestedAt: time.Now()}
+	f.dispatches[dispatch.ID] = dispatch
+	return dispatch, nil
+}
+
+func (f *fakeTransportStore) SetBooking(ctx context.Context, id uuid.UUID, booking *models.TransportBooking) (*models.TransportDispatch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dispatch := f.dispatches[id]
+	dispatch.ProviderRef = &booking.ProviderRef
+	dispatch.VehicleType = booking.VehicleType
+	return dispatch, nil
+}
+
+func (f *fakeTransportStore) SetFailed(ctx context.Context, id uuid.UUID, reason string) (*models.TransportDispatch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dispatch := f.dispatches[id]
+	dispatch.Status = models.DispatchStatusFailed
+	dispatch.FailureReason = &reason
+	return dispatch, nil
+}
+
+func (f *fakeTransportStore) Reopen(ctx context.Context, referralID uuid.UUID, provider string, staleBefore time.Time) (*models.TransportDispatch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, dispatch := range f.dispatches {
+		if dispatch.ReferralID == referralID {
+			if dispatch.Status != models.DispatchStatusFailed && (dispatch.ProviderRef != nil || !dispatch.RequestedAt.Before(staleBefore)) {
+				return nil, repository.ErrValidation
+			}
+			*dispatch = models.TransportDispatch{ID: dispatch.ID, ReferralID: referralID, Provider: provider, Status: models.DispatchStatusRequested, RequestedAt: time.Now()}
+			return dispatch, nil
+		}
+	}
+	dispatch := &models.TransportDispatch{ID: uuid.New(), ReferralID: referralID, Provider: provider, Status: models.DispatchStatusRequested}
+	f.dispatches[dispatch.ID] = dispatch
+	return dispatch, nil
+}
+
+func (f *fakeTransportStore) ApplyUpdate(ctx context.Context, provider string, update *models.TransportUpdate) (*models.TransportDispatch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, dispatch := range f.dispatches {
+		if dispatch.Provider == provider && dispatch.ProviderRef != nil && *dispatch.ProviderRef == update.ProviderRef {
+			if err := dispatch.Status.CheckTransition(update.Status); err != nil {
+				return nil, repository.ErrValidation
+			}
+			dispatch.Status = update.Status
+			f.updates = append(f.updates, update)
+			return dispatch, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeTransportStore) GetRequest(ctx context.Context, referralID uuid.UUID) (*models.TransportRequest, error) {
+	if f.request == nil {
+		return nil, repository.ErrNotFound
+	}
+	return f.request, nil
+}
+
+func (f *fakeTransportStore) statuses() []models.DispatchStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var statuses []models.DispatchStatus
+	for _, update := range f.updates {
+		statuses = append(statuses, update.Status)
+	}
+	return statuses
+}
+
+type failingTransportProvider struct {
+	err error
+}
+
+func (failingTransportProvider) Name() string {
+	return "failing"
+}
+
+func (p failingTransportProvider) Request(ctx context.Context, req *models.TransportRequest) (*models.TransportBooking, error) {
+	return nil, p.err
+}
+
+func TestTransportServiceDispatch(t *testing.T) {
+	red, yellow := models.TriageLevelRed, models.TriageLevelYellow
+	lat, lng := -0.1022, 34.7617
+
+	t.Run("Only red referrals get transport", func(t *testing.T) {
+		store := newFakeTransportStore()
+		service := NewTransportService(store, &SimulatedTransportProvider{})
+
+		dispatch, err := service.DispatchForReferral(context.Background(), &models.Referral{ID: uuid.New(), Priority: &yellow})
+		require.NoError(t, err)
+		assert.Nil(t, dispatch)
+		assert.Empty(t, store.dispatches)
+	})
+
+	t.Run("Nothing is dispatched without a provider", func(t *testing.T) {
+		store := newFakeTransportStore()
+		service := NewTransportService(store, nil)
+
+		dispatch, err := service.DispatchForReferral(context.Background(), &models.Referral{ID: uuid.New(), Priority: &red})
+		require.NoError(t, err)
+		assert.Nil(t, dispatch)
+
+		_, err = service.HandleUpdate(context.Background(), &models.TransportUpdate{ProviderRef: "SIM-1", Status: models.DispatchStatusAssigned})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Located patients get a boda-boda, and only once", func(t *testing.T) {
+		store := newFakeTransportStore()
+		store.request = &models.TransportRequest{PickupLatitude: &lat, PickupLongitude: &lng}
+		service := NewTransportService(store, &SimulatedTransportProvider{})
+		referral := &models.Referral{ID: uuid.New(), Priority: &red}
+
+		dispatch, err := service.DispatchForReferral(context.Background(), referral)
+		require.NoError(t, err)
+		require.NotNil(t, dispatch)
+		assert.Equal(t, "simulator", dispatch.Provider)
+		assert.Equal(t, models.DispatchStatusRequested, dispatch.Status)
+		assert.Contains(t, *dispatch.ProviderRef, "SIM-")
+		assert.Equal(t, models.VehicleBodaBoda, *dispatch.VehicleType)
+
+		again, err := service.DispatchForReferral(context.Background(), referral)
+		require.NoError(t, err)
+		assert.Nil(t, again)
+	})
+
+	t.Run("Provider refusal is recorded on the dispatch", func(t *testing.T) {
+		store := newFakeTransportStore()
+		store.request = &models.TransportRequest{}
+		service := NewTransportService(store, failingTransportProvider{err: jobs.Permanent(errors.New("no riders available"))})
+
+		dispatch, err := service.DispatchForReferral(context.Background(), &models.Referral{ID: uuid.New(), Priority: &red})
+		require.NoError(t, err)
+		assert.Equal(t, models.DispatchStatusFailed, dispatch.Status)
+		assert.Equal(t, "no riders available", *dispatch.FailureReason)
+	})
+
+	t.Run("Unreachable provider is retried", func(t *testing.T) {
+		store := newFakeTransportStore()
+		store.request = &models.TransportRequest{}
+		service := NewTransportService(store, failingTransportProvider{err: errors.New("connection refused")})
+
+		_, err := service.DispatchForReferral(context.Background(), &models.Referral{ID: uuid.New(), Priority: &red})
+		assert.Error(t, err)
+		assert.False(t, jobs.IsPermanent(err))
+		for _, dispatch := range store.dispatches {
+			assert.Equal(t, models.DispatchStatusRequested, dispatch.Status)
+		}
+	})
+
+	t.Run("Redelivery after a half-finished dispatch requests it again", func(t *testing.T) {
+		store := newFakeTransportStore()
+		store.request = &models.TransportRequest{}
+		referral := &models.Referral{ID: uuid.New(), Priority: &red}
+		payload, err := json.Marshal(referral)
+		require.NoError(t, err)
+		event := &models.DomainEvent{ID: uuid.New(), Type: models.EventReferralCreated, AggregateID: referral.ID, Payload: payload}
+
+		only := func() *models.TransportDispatch {
+			require.Len(t, store.dispatches, 1)
+			for _, dispatch := range store.dispatches {
+				return dispatch
+			}
+			return nil
+		}
+
+		// The first delivery logged the dispatch, then the provider call failed
+		unreachable := NewTransportService(store, failingTransportProvider{err: errors.New("connection refused")})
+		require.Error(t, unreachable.HandleReferralCreated(context.Background(), event))
+		assert.Nil(t, only().ProviderRef)
+
+		service := NewTransportService(store, &SimulatedTransportProvider{})
+		require.NoError(t, service.HandleReferralCreated(context.Background(), event))
+		assert.Nil(t, only().ProviderRef, "a request still within its timeout is left to the first call")
+
+		service.now = func() time.Time { return time.Now().Add(time.Minute) }
+		require.NoError(t, service.HandleReferralCreated(context.Background(), event))
+		require.NotNil(t, only().ProviderRef)
+		ref := *only().ProviderRef
+		assert.Contains(t, ref, "SIM-")
+
+		// Once booked, further redeliveries change nothing
+		require.NoError(t, service.HandleReferralCreated(context.Background(), event))
+		assert.Equal(t, ref, *only().ProviderRef)
+	})
+
+	t.Run("Refused transport can be requested again", func(t *testing.T) {
+		store := newFakeTransportStore()
+		store.request = &models.TransportRequest{}
+		referral := &models.Referral{ID: uuid.New(), Priority: &red}
+		_, err := NewTransportService(store, failingTransportProvider{err: jobs.Permanent(errors.New("no riders available"))}).DispatchForReferral(context.Background(), referral)
+		require.NoError(t, err)
+
+		service := NewTransportService(store, &SimulatedTransportProvider{})
+		dispatch, err := service.Redispatch(context.Background(), referral)
+		require.NoError(t, err)
+		assert.Equal(t, models.DispatchStatusRequested, dispatch.Status)
+		assert.Nil(t, dispatch.FailureReason)
+		require.NotNil(t, dispatch.ProviderRef)
+
+		_, err = service.Redispatch(context.Background(), referral)
+		assert.ErrorIs(t, err, repository.ErrValidation, "a booked dispatch is not requested twice")
+
+		_, err = NewTransportService(store, nil).Redispatch(context.Background(), referral)
+		assert.ErrorIs(t, err, ErrTransportDisabled)
+	})
+
+	t.Run("New referrals are dispatched from their referral.created event", func(t *testing.T) {
+		store := newFakeTransportStore()
+		store.request = &models.TransportRequest{}
+		service := NewTransportService(store, &SimulatedTransportProvider{})
+
+		referral := &models.Referral{ID: uuid.New(), Priority: &red}
+		parentID := uuid.New()
+		transferred := &models.Referral{ID: uuid.New(), Priority: &red, ParentReferralID: &parentID}
+		for _, r := range []*models.Referral{referral, transferred} {
+			payload, err := json.Marshal(r)
+			require.NoError(t, err)
+			event := &models.DomainEvent{ID: uuid.New(), Type: models.EventReferralCreated, AggregateID: r.ID, Payload: payload}
+			require.NoError(t, service.HandleReferralCreated(context.Background(), event))
+		}
+
+		require.Len(t, store.dispatches, 1)
+		for _, dispatch := range store.dispatches {
+			assert.Equal(t, referral.ID, dispatch.ReferralID)
+		}
+
+		bad := &models.DomainEvent{ID: uuid.New(), Type: models.EventReferralCreated, Payload: json.RawMessage(`[]`)}
+		assert.True(t, jobs.IsPermanent(service.HandleReferralCreated(context.Background(), bad)))
+	})
+}
+
+func TestSimulatedTransportProvider(t *testing.T) {
+	red := models.TriageLevelRed
+	store := newFakeTransportStore()
+	store.request = &models.TransportRequest{}
+	simulator := &SimulatedTransportProvider{AssignAfter: 10 * time.Millisecond, ArriveAfter: 10 * time.Millisecond}
+	service := NewTransportService(store, simulator)
+	simulator.Report = service.HandleUpdate
+
+	dispatch, err := service.DispatchForReferral(context.Background(), &models.Referral{ID: uuid.New(), Priority: &red})
+	require.NoError(t, err)
+	assert.Equal(t, models.VehicleAmbulance, *dispatch.VehicleType)
+
+	assert.Eventually(t, func() bool {
+		return len(store.statuses()) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []models.DispatchStatus{models.DispatchStatusAssigned, models.DispatchStatusArrived}, store.statuses())
+}
@@ -1,7 +1,7 @@
 -- Seed test facilities
-INSERT INTO facilities (id, name, type, level, county, sub_county, location, phone, services, operating_hours, accepts_referrals) VALUES
-('550e8400-e29b-41d4-a716-446655440001', 'Kamulu Health Center', 'health_center', 3, 'Machakos', 'Kangundo', ST_GeogFromText('POINT(37.1234 -1.2345)'), '+254712345678', '["outpatient", "maternity", "lab", "pharmacy"]', '{"monday": "08:00-17:00", "tuesday": "08:00-17:00", "wednesday": "08:00-17:00", "thursday": "08:00-17:00", "friday": "08:00-17:00"}', true),
-('550e8400-e29b-41d4-a716-446655440002', 'Makueni County Hospital', 'county_hospital', 4, 'Makueni', 'Makueni', ST_GeogFromText('POINT(37.6234 -1.8345)'), '+254722345678', '["outpatient", "inpatient", "maternity", "lab", "pharmacy", "surgery", "icu"]', '{"monday": "24/7", "tuesday": "24/7", "wednesday": "24/7", "thursday": "24/7", "friday": "24/7", "saturday": "24/7", "sunday": "24/7"}', true);
+INSERT INTO facilities (id, name, type, level, county, sub_county, latitude, longitude, phone, services, operating_hours, accepts_referrals) VALUES
+('550e8400-e29b-41d4-a716-446655440001', 'Kamulu Health Center', 'health_center', 3, 'Machakos', 'Kangundo', -1.2345, 37.1234, '+254712345678', '["outpatient", "maternity", "lab", "pharmacy"]', '{"monday": "08:00-17:00", "tuesday": "08:00-17:00", "wednesday": "08:00-17:00", "thursday": "08:00-17:00", "friday": "08:00-17:00"}', true),
+('550e8400-e29b-41d4-a716-446655440002', 'Makueni County Hospital', 'county_hospital', 4, 'Makueni', 'Makueni', -1.8345, 37.6234, '+254722345678', '["outpatient", "inpatient", "maternity", "lab", "pharmacy", "surgery", "icu"]', '{"monday": "24/7", "tuesday": "24/7", "wednesday": "24/7", "thursday": "24/7", "friday": "24/7", "saturday": "24/7", "sunday": "24/7"}', true);
 
 -- Seed test clinicians
 INSERT INTO clinicians (id, name, phone, email, facility_id, specialization) VALUES
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_facilities_active;
+ALTER TABLE facilities DROP COLUMN IF EXISTS is_active;
@@ -0,0 +1,4 @@
+-- Soft-deactivation for facilities managed through the admin API
+ALTER TABLE facilities ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT true;
+
+CREATE INDEX idx_facilities_active ON facilities(is_active);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_facilities_mfl_code;
+ALTER TABLE facilities DROP COLUMN IF EXISTS mfl_code;
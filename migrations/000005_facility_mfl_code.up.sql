@@ -0,0 +1,4 @@
+-- Official Kenya Master Health Facility List code, used as the import key
+ALTER TABLE facilities ADD COLUMN mfl_code VARCHAR(20);
+
+CREATE UNIQUE INDEX idx_facilities_mfl_code ON facilities(mfl_code);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS sync_facilities_location ON facilities;
+DROP FUNCTION IF EXISTS sync_facility_location();
+DROP INDEX IF EXISTS idx_facilities_location;
+ALTER TABLE facilities DROP COLUMN IF EXISTS location;
@@ -0,0 +1,36 @@
+-- PostGIS is optional. latitude/longitude stay the source of truth; when the
+-- extension is available we also maintain a geography column so nearby
+-- searches can use a GiST index. Without it, the Haversine backend uses
+-- idx_facilities_lat_lng instead.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'postgis') THEN
+        CREATE EXTENSION IF NOT EXISTS postgis;
+
+        ALTER TABLE facilities ADD COLUMN IF NOT EXISTS location GEOGRAPHY(POINT, 4326);
+
+        UPDATE facilities
+        SET location = ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography
+        WHERE latitude IS NOT NULL AND longitude IS NOT NULL;
+
+        CREATE INDEX IF NOT EXISTS idx_facilities_location ON facilities USING GIST(location);
+
+        CREATE OR REPLACE FUNCTION sync_facility_location()
+        RETURNS TRIGGER AS $fn$
+        BEGIN
+            IF NEW.latitude IS NULL OR NEW.longitude IS NULL THEN
+                NEW.location = NULL;
+            ELSE
+                NEW.location = ST_SetSRID(ST_MakePoint(NEW.longitude, NEW.latitude), 4326)::geography;
+            END IF;
+            RETURN NEW;
+        END;
+        $fn$ LANGUAGE plpgsql;
+
+        DROP TRIGGER IF EXISTS sync_facilities_location ON facilities;
+        CREATE TRIGGER sync_facilities_location
+            BEFORE INSERT OR UPDATE OF latitude, longitude ON facilities
+            FOR EACH ROW EXECUTE FUNCTION sync_facility_location();
+    END IF;
+END
+$$;
@@ -0,0 +1,18 @@
+-- Public holidays and one-off closures. A NULL facility_id applies to every facility.
+CREATE TABLE facility_closures (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    facility_id UUID REFERENCES facilities(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('public_holiday', 'closure')),
+    name VARCHAR(255) NOT NULL,
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX idx_facility_closures_dates ON facility_closures(starts_on, ends_on);
+CREATE INDEX idx_facility_closures_facility ON facility_closures(facility_id);
+
+-- Public holidays are data, not schema: load them with
+-- scripts/seed/public-holidays-2026.sql or POST /v1/admin/closures
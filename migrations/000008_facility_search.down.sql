@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_facilities_level;
+DROP INDEX IF EXISTS idx_facilities_sub_county;
+DROP INDEX IF EXISTS idx_facilities_name_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
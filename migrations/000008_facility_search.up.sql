@@ -0,0 +1,6 @@
+-- Fuzzy facility name search ("Kamulu Helth Centre" should still find "Kamulu Health Center")
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_facilities_name_trgm ON facilities USING GIN(name gin_trgm_ops);
+CREATE INDEX idx_facilities_sub_county ON facilities(sub_county);
+CREATE INDEX idx_facilities_level ON facilities(level);
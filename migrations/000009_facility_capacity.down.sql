@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS facility_status_history;
+DROP TABLE IF EXISTS facility_status;
@@ -0,0 +1,32 @@
+-- Live capacity reported by facility staff. facility_status holds the latest
+-- report per facility; every report is also appended to the history table.
+CREATE TABLE facility_status (
+    facility_id UUID PRIMARY KEY REFERENCES facilities(id) ON DELETE CASCADE,
+    beds_total INTEGER CHECK (beds_total >= 0),
+    beds_occupied INTEGER CHECK (beds_occupied >= 0),
+    stockouts JSONB NOT NULL DEFAULT '[]',
+    referrals_paused BOOLEAN NOT NULL DEFAULT false,
+    pause_reason TEXT,
+    paused_until TIMESTAMP WITH TIME ZONE,
+    reported_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reported_by_api_key UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE facility_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    facility_id UUID NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    beds_total INTEGER,
+    beds_occupied INTEGER,
+    stockouts JSONB NOT NULL DEFAULT '[]',
+    referrals_paused BOOLEAN NOT NULL DEFAULT false,
+    pause_reason TEXT,
+    paused_until TIMESTAMP WITH TIME ZONE,
+    reported_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reported_by_api_key UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_facility_status_history_facility ON facility_status_history(facility_id, created_at DESC);
+
+CREATE TRIGGER update_facility_status_updated_at BEFORE UPDATE ON facility_status FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_patients_date_of_birth;
+DROP INDEX IF EXISTS idx_patients_name_trgm;
@@ -0,0 +1,3 @@
+-- Fuzzy patient name search and duplicate detection (pg_trgm is installed by 000008)
+CREATE INDEX idx_patients_name_trgm ON patients USING GIN(name gin_trgm_ops);
+CREATE INDEX idx_patients_date_of_birth ON patients(date_of_birth);
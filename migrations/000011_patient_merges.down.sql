@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS patient_merges;
+DROP INDEX IF EXISTS idx_patients_merged_into;
+ALTER TABLE patients DROP COLUMN IF EXISTS merged_at;
+ALTER TABLE patients DROP COLUMN IF EXISTS merged_into;
@@ -0,0 +1,24 @@
+-- A merged patient keeps its row (and phone number) but points at the survivor
+ALTER TABLE patients ADD COLUMN merged_into UUID REFERENCES patients(id);
+ALTER TABLE patients ADD COLUMN merged_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_patients_merged_into ON patients(merged_into) WHERE merged_into IS NOT NULL;
+
+-- Audit trail of merges. moved lists the rows re-pointed per table so a merge
+-- can be undone without touching records created for the survivor afterwards.
+CREATE TABLE patient_merges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    survivor_id UUID NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    retired_id UUID NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    moved JSONB NOT NULL DEFAULT '{}',
+    filled_fields TEXT[] NOT NULL DEFAULT '{}',
+    reason TEXT,
+    merged_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    undone_at TIMESTAMP WITH TIME ZONE,
+    undone_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (survivor_id <> retired_id)
+);
+
+CREATE INDEX idx_patient_merges_survivor ON patient_merges(survivor_id);
+CREATE INDEX idx_patient_merges_retired ON patient_merges(retired_id);
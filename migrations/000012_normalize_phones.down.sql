@@ -0,0 +1,3 @@
+-- Normalized numbers are kept; their original form is not recoverable
+DROP TABLE IF EXISTS phone_normalization_issues;
+DROP FUNCTION IF EXISTS normalize_ke_phone(TEXT);
@@ -0,0 +1,91 @@
+-- Store phone numbers in E.164 form (+2547XXXXXXXX). Mirrors pkg/phone.Normalize;
+-- returns NULL for anything that is not a Kenyan mobile number.
+CREATE OR REPLACE FUNCTION normalize_ke_phone(raw TEXT) RETURNS TEXT AS $$
+DECLARE
+    digits TEXT;
+BEGIN
+    IF raw IS NULL OR raw !~ '^\s*\+?[0-9 ().-]+\s*$' THEN
+        RETURN NULL;
+    END IF;
+
+    digits := regexp_replace(raw, '[^0-9]', '', 'g');
+    IF digits LIKE '00%' THEN
+        digits := substr(digits, 3);
+    END IF;
+
+    IF length(digits) = 12 AND digits LIKE '254%' THEN
+        digits := substr(digits, 4);
+    ELSIF length(digits) = 10 AND digits LIKE '0%' THEN
+        digits := substr(digits, 2);
+    ELSIF length(digits) <> 9 THEN
+        RETURN NULL;
+    END IF;
+
+    IF left(digits, 1) NOT IN ('7', '1') THEN
+        RETURN NULL;
+    END IF;
+
+    RETURN '+254' || digits;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Rows the normalization left alone. normalized_phone is NULL when the number
+-- could not be parsed; otherwise conflicting_record_id holds the row that kept
+-- the number and the two records need reviewing (e.g. with a patient merge).
+CREATE TABLE phone_normalization_issues (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    table_name VARCHAR(50) NOT NULL,
+    record_id UUID NOT NULL,
+    original_phone VARCHAR(20) NOT NULL,
+    normalized_phone VARCHAR(20),
+    conflicting_record_id UUID,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_phone_normalization_issues_open ON phone_normalization_issues(table_name) WHERE resolved_at IS NULL;
+
+DO $$
+DECLARE
+    t TEXT;
+    updated INTEGER;
+    collisions INTEGER;
+    invalid INTEGER;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['patients', 'users', 'clinicians'] LOOP
+        EXECUTE format($q$
+            INSERT INTO phone_normalization_issues (table_name, record_id, original_phone)
+            SELECT %1$L, id, phone FROM %1$I WHERE normalize_ke_phone(phone) IS NULL
+        $q$, t);
+        GET DIAGNOSTICS invalid = ROW_COUNT;
+
+        -- Per normalized number, the row already in E.164 form keeps it, else the oldest row
+        EXECUTE format($q$
+            CREATE TEMP TABLE phone_groups ON COMMIT DROP AS
+            SELECT id, phone, normalize_ke_phone(phone) AS normalized,
+                   first_value(id) OVER (
+                       PARTITION BY normalize_ke_phone(phone)
+                       ORDER BY phone = normalize_ke_phone(phone) DESC, created_at, id
+                   ) AS keeper_id
+            FROM %I
+            WHERE normalize_ke_phone(phone) IS NOT NULL
+        $q$, t);
+
+        INSERT INTO phone_normalization_issues (table_name, record_id, original_phone, normalized_phone, conflicting_record_id)
+        SELECT t, id, phone, normalized, keeper_id FROM phone_groups WHERE id <> keeper_id;
+        GET DIAGNOSTICS collisions = ROW_COUNT;
+
+        EXECUTE format($q$
+            UPDATE %I target SET phone = g.normalized
+            FROM phone_groups g
+            WHERE target.id = g.id AND g.id = g.keeper_id AND target.phone <> g.normalized
+        $q$, t);
+        GET DIAGNOSTICS updated = ROW_COUNT;
+
+        DROP TABLE phone_groups;
+
+        RAISE NOTICE '%: % phone numbers normalized, % collisions and % unparseable numbers recorded in phone_normalization_issues',
+            t, updated, collisions, invalid;
+    END LOOP;
+END;
+$$;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_consent_patient_type_granted;
+DROP INDEX IF EXISTS idx_appointments_patient_scheduled;
+DROP INDEX IF EXISTS idx_referrals_patient_created;
+DROP INDEX IF EXISTS idx_triage_patient_created;
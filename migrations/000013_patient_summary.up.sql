@@ -0,0 +1,6 @@
+-- Per-patient history in date order, so each section of the patient summary
+-- is an index range scan rather than a sort of every row for the patient
+CREATE INDEX idx_triage_patient_created ON triage_sessions(patient_id, created_at DESC);
+CREATE INDEX idx_referrals_patient_created ON referrals(patient_id, created_at DESC);
+CREATE INDEX idx_appointments_patient_scheduled ON appointments(patient_id, scheduled_time);
+CREATE INDEX idx_consent_patient_type_granted ON consent_logs(patient_id, consent_type, granted_at DESC);
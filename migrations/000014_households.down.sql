@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS chv_assignments;
+DROP TABLE IF EXISTS household_members;
+DROP TABLE IF EXISTS households;
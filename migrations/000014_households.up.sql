@@ -0,0 +1,43 @@
+-- Households are the unit a CHV serves, mirroring the paper household register
+CREATE TABLE households (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    village VARCHAR(255),
+    landmark TEXT,
+    -- Approximate location, typically captured on the CHV's phone at the first visit
+    latitude DECIMAL(10, 8),
+    longitude DECIMAL(11, 8),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((latitude IS NULL) = (longitude IS NULL))
+);
+
+CREATE INDEX idx_households_village ON households(village);
+CREATE TRIGGER update_households_updated_at BEFORE UPDATE ON households FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- A patient belongs to at most one household
+CREATE TABLE household_members (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    household_id UUID NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    patient_id UUID NOT NULL UNIQUE REFERENCES patients(id) ON DELETE CASCADE,
+    relationship VARCHAR(50),
+    is_head BOOLEAN NOT NULL DEFAULT false,
+    added_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    added_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_household_members_household ON household_members(household_id);
+
+-- Assignment history; ended_at is set when a household moves to another CHV
+CREATE TABLE chv_assignments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    household_id UUID NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    chv_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    assigned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_chv_assignments_active ON chv_assignments(household_id) WHERE ended_at IS NULL;
+CREATE INDEX idx_chv_assignments_chv ON chv_assignments(chv_user_id) WHERE ended_at IS NULL;
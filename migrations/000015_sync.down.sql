@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS sync_referrals ON referrals;
+DROP TRIGGER IF EXISTS sync_facility_status ON facility_status;
+DROP TRIGGER IF EXISTS sync_facilities ON facilities;
+DROP FUNCTION IF EXISTS record_sync_change();
+DROP TABLE IF EXISTS sync_changes;
+DROP SEQUENCE IF EXISTS sync_changes_seq;
+DROP TABLE IF EXISTS sync_mutations;
@@ -0,0 +1,68 @@
+-- Mutations applied through POST /v1/sync, keyed by the client-generated
+-- mutation ID. A replayed mutation returns the stored result.
+CREATE TABLE sync_mutations (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_id VARCHAR(100) NOT NULL,
+    entity VARCHAR(30) NOT NULL,
+    entity_id UUID NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    client_timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    result JSONB NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sync_mutations_user ON sync_mutations(user_id, received_at DESC);
+
+-- Change feed for devices: the latest change per record. txid is the writing
+-- transaction, so readers can hold back changes from transactions that may
+-- still be in flight (see SyncRepository.Changes).
+CREATE SEQUENCE sync_changes_seq;
+
+CREATE TABLE sync_changes (
+    entity VARCHAR(30) NOT NULL,
+    entity_id UUID NOT NULL,
+    txid BIGINT NOT NULL,
+    seq BIGINT NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT false,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (entity, entity_id)
+);
+
+CREATE INDEX idx_sync_changes_position ON sync_changes(txid, seq);
+
+CREATE OR REPLACE FUNCTION record_sync_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    changed_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        EXECUTE format('SELECT ($1).%I', TG_ARGV[1]) INTO changed_id USING OLD;
+    ELSE
+        EXECUTE format('SELECT ($1).%I', TG_ARGV[1]) INTO changed_id USING NEW;
+    END IF;
+
+    INSERT INTO sync_changes (entity, entity_id, txid, seq, deleted, changed_at)
+    VALUES (TG_ARGV[0], changed_id, pg_current_xact_id()::text::bigint, nextval('sync_changes_seq'), TG_OP = 'DELETE', CURRENT_TIMESTAMP)
+    ON CONFLICT (entity, entity_id) DO UPDATE
+    SET txid = EXCLUDED.txid, seq = EXCLUDED.seq, deleted = EXCLUDED.deleted, changed_at = EXCLUDED.changed_at;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER sync_facilities AFTER INSERT OR UPDATE OR DELETE ON facilities
+    FOR EACH ROW EXECUTE FUNCTION record_sync_change('facility', 'id');
+CREATE TRIGGER sync_facility_status AFTER INSERT OR UPDATE OR DELETE ON facility_status
+    FOR EACH ROW EXECUTE FUNCTION record_sync_change('facility_status', 'facility_id');
+CREATE TRIGGER sync_referrals AFTER INSERT OR UPDATE OR DELETE ON referrals
+    FOR EACH ROW EXECUTE FUNCTION record_sync_change('referral', 'id');
+
+-- Existing records start in the feed so a first sync pulls everything
+INSERT INTO sync_changes (entity, entity_id, txid, seq)
+SELECT 'facility', id, pg_current_xact_id()::text::bigint, nextval('sync_changes_seq') FROM facilities;
+INSERT INTO sync_changes (entity, entity_id, txid, seq)
+SELECT 'facility_status', facility_id, pg_current_xact_id()::text::bigint, nextval('sync_changes_seq') FROM facility_status;
+INSERT INTO sync_changes (entity, entity_id, txid, seq)
+SELECT 'referral', id, pg_current_xact_id()::text::bigint, nextval('sync_changes_seq') FROM referrals;
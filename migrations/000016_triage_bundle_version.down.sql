@@ -0,0 +1,2 @@
+ALTER TABLE triage_sessions DROP COLUMN IF EXISTS device_verdict;
+ALTER TABLE triage_sessions DROP COLUMN IF EXISTS bundle_version;
@@ -0,0 +1,4 @@
+-- Sessions triaged offline in the CHV app record the rule bundle that
+-- produced the on-device verdict
+ALTER TABLE triage_sessions ADD COLUMN bundle_version VARCHAR(64);
+ALTER TABLE triage_sessions ADD COLUMN device_verdict JSONB;
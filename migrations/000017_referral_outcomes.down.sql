@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS referral_outcomes;
+
+-- Postgres cannot drop an enum value, so rebuild the type without no_show
+UPDATE referrals SET status = 'cancelled' WHERE status = 'no_show';
+ALTER TYPE referral_status RENAME TO referral_status_old;
+CREATE TYPE referral_status AS ENUM ('pending', 'accepted', 'completed', 'cancelled');
+ALTER TABLE referrals ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE referrals ALTER COLUMN status TYPE referral_status USING status::text::referral_status;
+ALTER TABLE referrals ALTER COLUMN status SET DEFAULT 'pending';
+DROP TYPE referral_status_old;
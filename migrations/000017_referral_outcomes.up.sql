@@ -0,0 +1,34 @@
+-- Facilities report patients who never arrived
+ALTER TYPE referral_status ADD VALUE IF NOT EXISTS 'no_show';
+
+-- What happened to a referred patient, recorded by a clinician once the
+-- referral is completed. One outcome per referral; corrections overwrite it.
+CREATE TABLE referral_outcomes (
+    referral_id UUID PRIMARY KEY REFERENCES referrals(id) ON DELETE CASCADE,
+    diagnosis_category VARCHAR(40) NOT NULL,
+    treatment_given TEXT,
+    disposition VARCHAR(20) NOT NULL CHECK (disposition IN ('admitted', 'discharged')),
+    -- Whether the CHV's triage level matched the clinician's assessment
+    triage_appropriate BOOLEAN NOT NULL,
+    notes TEXT,
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_referral_outcomes_updated_at BEFORE UPDATE ON referral_outcomes FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- In-app inbox. sms_status tracks the SMS copy of the notification.
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(40) NOT NULL,
+    referral_id UUID REFERENCES referrals(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    sms_status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (sms_status IN ('pending', 'sent', 'failed', 'skipped')),
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_referrals_pending_created;
+DROP TABLE IF EXISTS referral_reminders;
+DROP TABLE IF EXISTS referral_reminder_schedules;
+
+-- Postgres cannot drop an enum value, so rebuild the type without expired
+UPDATE referrals SET status = 'cancelled' WHERE status = 'expired';
+ALTER TYPE referral_status RENAME TO referral_status_old;
+CREATE TYPE referral_status AS ENUM ('pending', 'accepted', 'completed', 'cancelled', 'no_show');
+ALTER TABLE referrals ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE referrals ALTER COLUMN status TYPE referral_status USING status::text::referral_status;
+ALTER TABLE referrals ALTER COLUMN status SET DEFAULT 'pending';
+DROP TYPE referral_status_old;
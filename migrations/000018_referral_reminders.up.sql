@@ -0,0 +1,41 @@
+-- Pending referrals nobody acted on are closed by the reminder scheduler
+ALTER TYPE referral_status ADD VALUE IF NOT EXISTS 'expired';
+
+-- When to remind the patient, alert the CHV and give up on a pending
+-- referral, in minutes after it was made. A row with no county is the
+-- default for that priority; a county row overrides it. Referrals without
+-- a priority follow the green schedule.
+CREATE TABLE referral_reminder_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    county VARCHAR(100),
+    priority triage_level NOT NULL,
+    patient_reminder_minutes INTEGER[] NOT NULL DEFAULT '{}',
+    chv_alert_minutes INTEGER CHECK (chv_alert_minutes > 0),
+    expire_after_minutes INTEGER NOT NULL CHECK (expire_after_minutes > 0),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_reminder_schedules_county_priority ON referral_reminder_schedules (COALESCE(LOWER(county), ''), priority);
+CREATE TRIGGER update_referral_reminder_schedules_updated_at BEFORE UPDATE ON referral_reminder_schedules FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO referral_reminder_schedules (priority, patient_reminder_minutes, chv_alert_minutes, expire_after_minutes) VALUES
+    ('red', '{120, 360}', 480, 2880),
+    ('yellow', '{720, 2160}', 2880, 10080),
+    ('green', '{4320}', 7200, 20160);
+
+-- Every reminder, alert and expiry the scheduler acted on. The unique key
+-- stops two server instances sending the same reminder.
+CREATE TABLE referral_reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    referral_id UUID NOT NULL REFERENCES referrals(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('patient_reminder', 'chv_alert', 'expired')),
+    step INTEGER NOT NULL DEFAULT 0,
+    recipient VARCHAR(20),
+    sms_status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (sms_status IN ('pending', 'sent', 'failed', 'skipped')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (referral_id, kind, step)
+);
+
+CREATE INDEX idx_referrals_pending_created ON referrals(created_at) WHERE status = 'pending';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS inbound_sms;
+DROP TABLE IF EXISTS appointment_reminders;
+DROP INDEX IF EXISTS idx_appointments_slot;
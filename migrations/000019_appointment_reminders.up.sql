@@ -0,0 +1,32 @@
+-- A slot is a facility (and clinician, when one is named) at a time. Only
+-- live appointments hold a slot, so cancelling frees it for rebooking.
+CREATE UNIQUE INDEX idx_appointments_slot ON appointments (
+    facility_id,
+    COALESCE(clinician_id, '00000000-0000-0000-0000-000000000000'::uuid),
+    scheduled_time
+) WHERE status IN ('scheduled', 'confirmed');
+
+-- Reminders sent before an appointment and the patient's reply to them.
+-- The unique key stops two server instances sending the same reminder.
+CREATE TABLE appointment_reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    appointment_id UUID NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('reminder_24h', 'reminder_2h')),
+    recipient VARCHAR(20),
+    sms_status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (sms_status IN ('pending', 'sent', 'failed', 'skipped')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (appointment_id, kind)
+);
+
+-- Inbound SMS replies, kept whether or not they matched an appointment
+CREATE TABLE inbound_sms (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    provider_id VARCHAR(100) UNIQUE,
+    phone VARCHAR(20) NOT NULL,
+    body TEXT NOT NULL,
+    appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL,
+    action VARCHAR(20) NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_inbound_sms_phone ON inbound_sms(phone, received_at DESC);
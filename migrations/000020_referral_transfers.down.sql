@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS referral_transfers;
+DROP INDEX IF EXISTS idx_referrals_parent;
+ALTER TABLE referrals DROP COLUMN IF EXISTS parent_referral_id;
+
+-- Postgres cannot drop an enum value, so rebuild the type without transferred.
+-- The pending index names the type in its predicate and is rebuilt with it.
+UPDATE referrals SET status = 'cancelled' WHERE status = 'transferred';
+DROP INDEX IF EXISTS idx_referrals_pending_created;
+ALTER TYPE referral_status RENAME TO referral_status_old;
+CREATE TYPE referral_status AS ENUM ('pending', 'accepted', 'completed', 'cancelled', 'no_show', 'expired');
+ALTER TABLE referrals ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE referrals ALTER COLUMN status TYPE referral_status USING status::text::referral_status;
+ALTER TABLE referrals ALTER COLUMN status SET DEFAULT 'pending';
+DROP TYPE referral_status_old;
+CREATE INDEX idx_referrals_pending_created ON referrals(created_at) WHERE status = 'pending';
@@ -0,0 +1,25 @@
+-- A referral the receiving facility cannot handle is forwarded: it is
+-- closed as transferred and a new referral to the next facility points
+-- back at it, so the chain of custody can be followed
+ALTER TYPE referral_status ADD VALUE IF NOT EXISTS 'transferred';
+
+ALTER TABLE referrals ADD COLUMN parent_referral_id UUID REFERENCES referrals(id) ON DELETE SET NULL;
+
+-- A referral is forwarded at most once
+CREATE UNIQUE INDEX idx_referrals_parent ON referrals(parent_referral_id) WHERE parent_referral_id IS NOT NULL;
+
+-- Why and by whom each referral was forwarded, for transfer-rate reporting
+CREATE TABLE referral_transfers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    from_referral_id UUID NOT NULL UNIQUE REFERENCES referrals(id) ON DELETE CASCADE,
+    to_referral_id UUID NOT NULL UNIQUE REFERENCES referrals(id) ON DELETE CASCADE,
+    from_facility_id UUID REFERENCES facilities(id) ON DELETE SET NULL,
+    to_facility_id UUID REFERENCES facilities(id) ON DELETE SET NULL,
+    reason VARCHAR(30) NOT NULL CHECK (reason IN ('no_theatre', 'power_outage', 'no_specialist', 'no_beds', 'equipment_failure', 'other')),
+    notes TEXT,
+    transferred_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_referral_transfers_from_facility ON referral_transfers(from_facility_id, created_at);
+CREATE INDEX idx_referral_transfers_to_facility ON referral_transfers(to_facility_id, created_at);
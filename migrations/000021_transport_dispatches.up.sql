@@ -0,0 +1,23 @@
+-- Transport requested for a red referral from an ambulance or boda-boda
+-- provider, and how far the provider has got. One dispatch per referral.
+CREATE TABLE transport_dispatches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    referral_id UUID NOT NULL UNIQUE REFERENCES referrals(id) ON DELETE CASCADE,
+    provider VARCHAR(40) NOT NULL,
+    provider_ref VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'requested' CHECK (status IN ('requested', 'assigned', 'arrived', 'failed')),
+    vehicle_type VARCHAR(20) CHECK (vehicle_type IN ('ambulance', 'boda_boda')),
+    driver_name VARCHAR(255),
+    driver_phone VARCHAR(20),
+    eta_minutes INTEGER CHECK (eta_minutes >= 0),
+    failure_reason TEXT,
+    requested_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    assigned_at TIMESTAMP WITH TIME ZONE,
+    arrived_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Provider status updates find the dispatch by the provider's reference
+CREATE UNIQUE INDEX idx_transport_dispatches_provider_ref ON transport_dispatches(provider, provider_ref) WHERE provider_ref IS NOT NULL;
+
+CREATE TRIGGER update_transport_dispatches_updated_at BEFORE UPDATE ON transport_dispatches FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,31 @@
+-- Background jobs for the Postgres queue. Workers take due jobs with
+-- FOR UPDATE SKIP LOCKED; a running job is taken again once the lease on
+-- locked_at runs out, on the assumption that its worker died, and the first
+-- worker's outcome is then discarded. Leases are not renewed, so a job must
+-- finish within one. Dead jobs have used up their attempts and wait for an
+-- admin to retry them.
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5 CHECK (max_attempts > 0),
+    unique_key VARCHAR(255),
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_by VARCHAR(100),
+    locked_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A unique key is enqueued once, e.g. one run of a schedule per slot
+CREATE UNIQUE INDEX idx_jobs_unique_key ON jobs(unique_key) WHERE unique_key IS NOT NULL;
+
+CREATE INDEX idx_jobs_due ON jobs(run_at) WHERE status = 'queued';
+CREATE INDEX idx_jobs_leased ON jobs(locked_at) WHERE status = 'running';
+CREATE INDEX idx_jobs_status ON jobs(status, created_at DESC);
+
+CREATE TRIGGER update_jobs_updated_at BEFORE UPDATE ON jobs FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,16 @@
+-- Domain events written in the same transaction as the change they
+-- describe. The dispatcher claims unpublished events with FOR UPDATE SKIP
+-- LOCKED, hands them to the job queue for delivery to each subscriber and
+-- marks them published; published events are pruned after a week.
+CREATE TABLE outbox_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_outbox_events_unpublished ON outbox_events(occurred_at) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published ON outbox_events(published_at) WHERE published_at IS NOT NULL;
+CREATE INDEX idx_outbox_events_aggregate ON outbox_events(aggregate_id, occurred_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_facilities_sub_county;
+DROP INDEX IF EXISTS idx_facilities_county;
+CREATE INDEX idx_facilities_county ON facilities(county);
+CREATE INDEX idx_facilities_sub_county ON facilities(sub_county);
@@ -0,0 +1,6 @@
+-- County filters compare case-insensitively with lower(), so index the
+-- lowered names instead
+DROP INDEX IF EXISTS idx_facilities_county;
+DROP INDEX IF EXISTS idx_facilities_sub_county;
+CREATE INDEX idx_facilities_county ON facilities (lower(county));
+CREATE INDEX idx_facilities_sub_county ON facilities (lower(sub_county));
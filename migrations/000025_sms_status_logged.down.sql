@@ -0,0 +1,9 @@
+UPDATE notifications SET sms_status = 'skipped' WHERE sms_status = 'logged';
+ALTER TABLE notifications DROP CONSTRAINT notifications_sms_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_sms_status_check CHECK (sms_status IN ('pending', 'sent', 'failed', 'skipped'));
+UPDATE referral_reminders SET sms_status = 'skipped' WHERE sms_status = 'logged';
+ALTER TABLE referral_reminders DROP CONSTRAINT referral_reminders_sms_status_check;
+ALTER TABLE referral_reminders ADD CONSTRAINT referral_reminders_sms_status_check CHECK (sms_status IN ('pending', 'sent', 'failed', 'skipped'));
+UPDATE appointment_reminders SET sms_status = 'skipped' WHERE sms_status = 'logged';
+ALTER TABLE appointment_reminders DROP CONSTRAINT appointment_reminders_sms_status_check;
+ALTER TABLE appointment_reminders ADD CONSTRAINT appointment_reminders_sms_status_check CHECK (sms_status IN ('pending', 'sent', 'failed', 'skipped'));
@@ -0,0 +1,8 @@
+-- LogSMSSender stands in for the gateway in development; what it "sends" is
+-- recorded as logged so it is never mistaken for a delivered SMS
+ALTER TABLE notifications DROP CONSTRAINT notifications_sms_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_sms_status_check CHECK (sms_status IN ('pending', 'sent', 'failed', 'skipped', 'logged'));
+ALTER TABLE referral_reminders DROP CONSTRAINT referral_reminders_sms_status_check;
+ALTER TABLE referral_reminders ADD CONSTRAINT referral_reminders_sms_status_check CHECK (sms_status IN ('pending', 'sent', 'failed', 'skipped', 'logged'));
+ALTER TABLE appointment_reminders DROP CONSTRAINT appointment_reminders_sms_status_check;
+ALTER TABLE appointment_reminders ADD CONSTRAINT appointment_reminders_sms_status_check CHECK (sms_status IN ('pending', 'sent', 'failed', 'skipped', 'logged'));
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_triage_created_by;
+DROP INDEX IF EXISTS idx_patients_registered_by;
+ALTER TABLE triage_sessions DROP COLUMN IF EXISTS created_by;
+ALTER TABLE patients DROP COLUMN IF EXISTS registered_by;
@@ -0,0 +1,6 @@
+-- Who registered a patient and who ran a triage session, so a CHV's patient
+-- search can be limited to the patients they look after
+ALTER TABLE patients ADD COLUMN registered_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE triage_sessions ADD COLUMN created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX idx_patients_registered_by ON patients(registered_by) WHERE registered_by IS NOT NULL;
+CREATE INDEX idx_triage_created_by ON triage_sessions(created_by, patient_id) WHERE created_by IS NOT NULL;
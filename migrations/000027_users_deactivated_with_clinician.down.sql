@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS deactivated_with_clinician_at;
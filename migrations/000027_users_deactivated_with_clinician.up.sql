@@ -0,0 +1,4 @@
+-- Set on the users that deactivating their clinician turned off, so
+-- reactivating the clinician restores only those and not users an admin
+-- disabled for another reason
+ALTER TABLE users ADD COLUMN deactivated_with_clinician_at TIMESTAMP WITH TIME ZONE;
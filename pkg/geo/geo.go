@@ -0,0 +1,80 @@
+package geo
+
+import "math"
+
+// EarthRadiusKM is the mean Earth radius used for great-circle distances
+const EarthRadiusKM = 6371.0088
+
+// kmPerDegreeLat is the length of one degree of latitude
+const kmPerDegreeLat = 111.045
+
+// BoundingBox is a latitude/longitude rectangle in degrees
+type BoundingBox struct {
+	MinLat float64
+	MaxLat float64
+	MinLng float64
+	MaxLng float64
+}
+
+// HaversineKM returns the great-circle distance between two points in kilometres
+func HaversineKM(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * EarthRadiusKM * math.Asin(math.Sqrt(a))
+}
+
+// Bounds returns a box that contains every point within radiusKM of (lat, lng).
+// It is used to pre-filter rows with an ordinary index before computing exact distances.
+func Bounds(lat, lng, radiusKM float64) BoundingBox {
+	dLat := radiusKM / kmPerDegreeLat
+
+	// Degrees of longitude shrink towards the poles; Kenya straddles the
+	// equator so the correction is small, but keep it exact
+	cosLat := math.Cos(toRadians(lat))
+	dLng := 180.0
+	if cosLat > 1e-9 {
+		dLng = math.Min(radiusKM/(kmPerDegreeLat*cosLat), 180.0)
+	}
+
+	return BoundingBox{
+		MinLat: lat - dLat,
+		MaxLat: lat + dLat,
+		MinLng: lng - dLng,
+		MaxLng: lng + dLng,
+	}
+}
+
+// Contains reports whether a point lies inside the box
+func (b BoundingBox) Contains(lat, lng float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lng >= b.MinLng && lng <= b.MaxLng
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// BearingDegrees returns the initial compass bearing from the first point to
+// the second, in degrees clockwise from north (0-360)
+func BearingDegrees(lat1, lng1, lat2, lng2 float64) float64 {
+	phi1 := toRadians(lat1)
+	phi2 := toRadians(lat2)
+	dLng := toRadians(lng2 - lng1)
+
+	y := math.Sin(dLng) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLng)
+
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+	return math.Mod(bearing+360, 360)
+}
+
+var compassPoints = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// CompassPoint turns a bearing into one of the eight compass directions
+func CompassPoint(bearing float64) string {
+	idx := int(math.Floor(math.Mod(bearing+22.5, 360) / 45))
+	return compassPoints[idx]
+}
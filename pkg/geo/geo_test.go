@@ -0,0 +1,65 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHaversineKM(t *testing.T) {
+	// Nairobi CBD to Machakos town is roughly 56 km as the crow flies
+	d := HaversineKM(-1.2864, 36.8172, -1.5177, 37.2634)
+	assert.InDelta(t, 55.8, d, 1.0)
+
+	assert.Equal(t, 0.0, HaversineKM(-1.2345, 37.1234, -1.2345, 37.1234))
+
+	// Symmetric
+	assert.InDelta(t, HaversineKM(0, 34, 1, 35), HaversineKM(1, 35, 0, 34), 1e-9)
+}
+
+func TestBounds(t *testing.T) {
+	lat, lng, radius := -1.2345, 37.1234, 10.0
+	box := Bounds(lat, lng, radius)
+
+	assert.True(t, box.Contains(lat, lng))
+
+	// Points just inside the radius in each direction must be inside the box
+	for _, p := range [][2]float64{
+		{lat + 0.089, lng},
+		{lat - 0.089, lng},
+		{lat, lng + 0.089},
+		{lat, lng - 0.089},
+	} {
+		assert.Less(t, HaversineKM(lat, lng, p[0], p[1]), radius)
+		assert.True(t, box.Contains(p[0], p[1]))
+	}
+
+	// A point 20 km north is outside
+	assert.False(t, box.Contains(lat+0.18, lng))
+}
+
+func TestBearingDegrees(t *testing.T) {
+	assert.InDelta(t, 0, BearingDegrees(0, 37, 1, 37), 0.01)
+	assert.InDelta(t, 90, BearingDegrees(0, 37, 0, 38), 0.01)
+	assert.InDelta(t, 180, BearingDegrees(1, 37, 0, 37), 0.01)
+	assert.InDelta(t, 270, BearingDegrees(0, 38, 0, 37), 0.01)
+}
+
+func TestCompassPoint(t *testing.T) {
+	assert.Equal(t, "N", CompassPoint(0))
+	assert.Equal(t, "N", CompassPoint(350))
+	assert.Equal(t, "NE", CompassPoint(40))
+	assert.Equal(t, "E", CompassPoint(90))
+	assert.Equal(t, "SW", CompassPoint(225))
+	assert.Equal(t, "NW", CompassPoint(320))
+}
+
+func TestTravelSpeedsEstimate(t *testing.T) {
+	speeds := TravelSpeeds{WalkKMH: 5, BodaKMH: 30, MatatuKMH: 0}
+
+	estimates := speeds.Estimate(2)
+	assert.Equal(t, []TravelEstimate{
+		{Mode: TravelModeWalk, Minutes: 34},
+		{Mode: TravelModeBoda, Minutes: 6},
+	}, estimates)
+}
@@ -0,0 +1,51 @@
+package geo
+
+import "math"
+
+type TravelMode string
+
+const (
+	TravelModeWalk   TravelMode = "walk"
+	TravelModeBoda   TravelMode = "boda"
+	TravelModeMatatu TravelMode = "matatu"
+)
+
+// roadFactor converts straight-line distance into an approximate road
+// distance. Rural roads rarely run straight to the facility.
+const roadFactor = 1.4
+
+// TravelSpeeds holds average speeds in km/h for each travel mode
+type TravelSpeeds struct {
+	WalkKMH   float64
+	BodaKMH   float64
+	MatatuKMH float64
+}
+
+type TravelEstimate struct {
+	Mode    TravelMode `json:"mode"`
+	Minutes int        `json:"minutes"`
+}
+
+// Estimate returns rough travel times for a straight-line distance. Modes
+// with a non-positive speed are left out.
+func (s TravelSpeeds) Estimate(distanceKM float64) []TravelEstimate {
+	modes := []struct {
+		mode  TravelMode
+		speed float64
+	}{
+		{TravelModeWalk, s.WalkKMH},
+		{TravelModeBoda, s.BodaKMH},
+		{TravelModeMatatu, s.MatatuKMH},
+	}
+
+	estimates := make([]TravelEstimate, 0, len(modes))
+	for _, m := range modes {
+		if m.speed <= 0 {
+			continue
+		}
+		minutes := int(math.Ceil(distanceKM * roadFactor / m.speed * 60))
+		estimates = append(estimates, TravelEstimate{Mode: m.mode, Minutes: minutes})
+	}
+
+	return estimates
+}
@@ -0,0 +1,39 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Cursor marks a position in a keyset-paginated list: the sort value of the
+// last row returned and its ID as a tie-breaker. Sort names the ordering the
+// cursor belongs to, so a cursor cannot be replayed against another one.
+type Cursor struct {
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+	Sort  string    `json:"s,omitempty"`
+}
+
+// Encode returns an opaque, URL-safe token for the cursor
+func Encode(c Cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode parses a token produced by Encode
+func Decode(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == uuid.Nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &c, nil
+}
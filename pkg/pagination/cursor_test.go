@@ -0,0 +1,27 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{Value: "2.4871653", ID: uuid.New(), Sort: "-level"}
+
+	token := Encode(c)
+	assert.NotContains(t, token, "=")
+
+	got, err := Decode(token)
+	require.NoError(t, err)
+	assert.Equal(t, c, *got)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "e30"} {
+		_, err := Decode(token)
+		assert.Error(t, err, token)
+	}
+}
@@ -0,0 +1,129 @@
+// Package phone parses Kenyan mobile numbers into canonical E.164 form and
+// identifies the network they were issued on
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned for input that is not a Kenyan mobile number
+var ErrInvalid = errors.New("invalid phone number")
+
+// CountryCode is the Kenyan international dialling code
+const CountryCode = "254"
+
+// subscriberLength is the number of digits after the country code
+const subscriberLength = 9
+
+// Normalize converts a Kenyan mobile number written in any of the usual ways
+// ("0712 345 678", "254712345678", "+254-712-345678", "712345678") into
+// E.164 form, e.g. "+254712345678"
+func Normalize(raw string) (string, error) {
+	var digits strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", ErrInvalid
+		}
+	}
+
+	number := digits.String()
+	number = strings.TrimPrefix(number, "00")
+	switch {
+	case len(number) == len(CountryCode)+subscriberLength && strings.HasPrefix(number, CountryCode):
+		number = number[len(CountryCode):]
+	case len(number) == subscriberLength+1 && number[0] == '0':
+		number = number[1:]
+	case len(number) == subscriberLength:
+	default:
+		return "", ErrInvalid
+	}
+
+	// Kenyan mobile ranges start with 7 (07xx) or 1 (01xx)
+	if number[0] != '7' && number[0] != '1' {
+		return "", ErrInvalid
+	}
+
+	return "+" + CountryCode + number, nil
+}
+
+// Variants returns the forms a normalized number may have been stored in by
+// clients that saved phone numbers as typed
+func Variants(e164 string) []string {
+	subscriber := strings.TrimPrefix(e164, "+"+CountryCode)
+	if subscriber == e164 {
+		return []string{e164}
+	}
+	return []string{e164, CountryCode + subscriber, "0" + subscriber, subscriber}
+}
+
+// Carrier is the mobile network a number range was allocated to
+type Carrier string
+
+const (
+	CarrierSafaricom Carrier = "safaricom"
+	CarrierAirtel    Carrier = "airtel"
+	CarrierTelkom    Carrier = "telkom"
+	CarrierUnknown   Carrier = "unknown"
+)
+
+// carrierPrefixes maps the leading digits of the subscriber number (after
+// +254) to the network the range is allocated to. Longer prefixes win. The
+// ranges follow the Communications Authority numbering plan; numbers that
+// were ported keep their original prefix, so this is a best guess.
+var carrierPrefixes = map[string]Carrier{
+	"70": CarrierSafaricom, "71": CarrierSafaricom, "72": CarrierSafaricom, "79": CarrierSafaricom,
+	"740": CarrierSafaricom, "741": CarrierSafaricom, "742": CarrierSafaricom, "743": CarrierSafaricom,
+	"745": CarrierSafaricom, "746": CarrierSafaricom, "748": CarrierSafaricom,
+	"757": CarrierSafaricom, "758": CarrierSafaricom, "759": CarrierSafaricom,
+	"768": CarrierSafaricom, "769": CarrierSafaricom,
+	"110": CarrierSafaricom, "111": CarrierSafaricom, "112": CarrierSafaricom, "113": CarrierSafaricom,
+	"114": CarrierSafaricom, "115": CarrierSafaricom,
+
+	"73": CarrierAirtel, "78": CarrierAirtel,
+	"750": CarrierAirtel, "751": CarrierAirtel, "752": CarrierAirtel, "753": CarrierAirtel,
+	"754": CarrierAirtel, "755": CarrierAirtel, "756": CarrierAirtel, "762": CarrierAirtel,
+	"100": CarrierAirtel, "101": CarrierAirtel, "102": CarrierAirtel,
+
+	"77": CarrierTelkom,
+}
+
+// CarrierOf returns the network a normalized number belongs to
+func CarrierOf(e164 string) Carrier {
+	subscriber := strings.TrimPrefix(e164, "+"+CountryCode)
+	if subscriber == e164 || len(subscriber) != subscriberLength {
+		return CarrierUnknown
+	}
+	for length := 3; length >= 2; length-- {
+		if carrier, ok := carrierPrefixes[subscriber[:length]]; ok {
+			return carrier
+		}
+	}
+	return CarrierUnknown
+}
+
+// Number is a parsed phone number
+type Number struct {
+	E164    string  `json:"e164"`
+	Carrier Carrier `json:"carrier"`
+}
+
+// Parse normalizes raw and identifies its carrier
+func Parse(raw string) (Number, error) {
+	e164, err := Normalize(raw)
+	if err != nil {
+		return Number{}, err
+	}
+	return Number{E164: e164, Carrier: CarrierOf(e164)}, nil
+}
+
+// IsValid reports whether raw can be normalized
+func IsValid(raw string) bool {
+	_, err := Normalize(raw)
+	return err == nil
+}
@@ -0,0 +1,78 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "0712345678", want: "+254712345678"},
+		{raw: "0712 345 678", want: "+254712345678"},
+		{raw: "254712345678", want: "+254712345678"},
+		{raw: "+254-712-345678", want: "+254712345678"},
+		{raw: "00254712345678", want: "+254712345678"},
+		{raw: "712345678", want: "+254712345678"},
+		{raw: "0110123456", want: "+254110123456"},
+		{raw: "", wantErr: true},
+		{raw: "071234567", wantErr: true},
+		{raw: "0201234567", wantErr: true},
+		{raw: "+255712345678", wantErr: true},
+		{raw: "07123456a8", wantErr: true},
+		{raw: "07+12345678", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := Normalize(tt.raw)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVariants(t *testing.T) {
+	assert.Equal(t,
+		[]string{"+254712345678", "254712345678", "0712345678", "712345678"},
+		Variants("+254712345678"))
+	assert.Equal(t, []string{"12345"}, Variants("12345"))
+}
+
+func TestCarrierOf(t *testing.T) {
+	tests := map[string]Carrier{
+		"+254712345678": CarrierSafaricom,
+		"+254790123456": CarrierSafaricom,
+		"+254748123456": CarrierSafaricom,
+		"+254110123456": CarrierSafaricom,
+		"+254733345678": CarrierAirtel,
+		"+254755345678": CarrierAirtel,
+		"+254101234567": CarrierAirtel,
+		"+254771234567": CarrierTelkom,
+		"+254747123456": CarrierUnknown,
+		"0712345678":    CarrierUnknown,
+	}
+
+	for number, want := range tests {
+		assert.Equal(t, want, CarrierOf(number), number)
+	}
+}
+
+func TestParse(t *testing.T) {
+	number, err := Parse("0733 345 678")
+	assert.NoError(t, err)
+	assert.Equal(t, Number{E164: "+254733345678", Carrier: CarrierAirtel}, number)
+
+	_, err = Parse("12345")
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.False(t, IsValid("12345"))
+	assert.True(t, IsValid("+254712345678"))
+}
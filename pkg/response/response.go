@@ -40,4 +40,17 @@ func ErrorWithDetails(c *gin.Context, statusCode int, code, message, details str
 			Details: details,
 		},
 	})
-}
\ No newline at end of file
+}
+
+// ErrorWithData returns an error alongside data the client needs to resolve
+// it, e.g. the existing records that caused a conflict
+func ErrorWithData(c *gin.Context, statusCode int, code, message string, data interface{}) {
+	c.JSON(statusCode, APIResponse{
+		Success: false,
+		Data:    data,
+		Error: &ErrorInfo{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
@@ -67,4 +67,22 @@ func TestErrorWithDetails(t *testing.T) {
 	assert.Equal(t, "SERVER_ERROR", response.Error.Code)
 	assert.Equal(t, "Internal error", response.Error.Message)
 	assert.Equal(t, "Stack trace here", response.Error.Details)
-}
\ No newline at end of file
+}
+
+func TestErrorWithData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	ErrorWithData(c, http.StatusConflict, "CONFLICT", "Already exists", map[string]string{"id": "123"})
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response APIResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.Equal(t, map[string]interface{}{"id": "123"}, response.Data)
+	assert.Equal(t, "CONFLICT", response.Error.Code)
+}
@@ -0,0 +1,21 @@
+-- Kenyan public holidays for 2026. Idd ul-Fitr and Idd ul-Adha are gazetted
+-- each year once the dates are confirmed; add them, and later years, through
+-- POST /v1/admin/closures. Safe to run more than once.
+INSERT INTO facility_closures (kind, name, starts_on, ends_on)
+SELECT 'public_holiday', holiday.name, holiday.day, holiday.day
+FROM (VALUES
+    ('New Year''s Day', DATE '2026-01-01'),
+    ('Good Friday', DATE '2026-04-03'),
+    ('Easter Monday', DATE '2026-04-06'),
+    ('Labour Day', DATE '2026-05-01'),
+    ('Madaraka Day', DATE '2026-06-01'),
+    ('Mazingira Day', DATE '2026-10-10'),
+    ('Mashujaa Day', DATE '2026-10-20'),
+    ('Jamhuri Day', DATE '2026-12-12'),
+    ('Christmas Day', DATE '2026-12-25'),
+    ('Boxing Day', DATE '2026-12-26')
+) AS holiday(name, day)
+WHERE NOT EXISTS (
+    SELECT 1 FROM facility_closures c
+    WHERE c.kind = 'public_holiday' AND c.facility_id IS NULL AND c.starts_on = holiday.day
+);